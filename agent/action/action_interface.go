@@ -22,3 +22,9 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// ProgressReporter is implemented by asynchronous actions
+// that can report progress of their running task
+type ProgressReporter interface {
+	Progress() interface{}
+}
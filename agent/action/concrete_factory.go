@@ -1,6 +1,8 @@
 package action
 
 import (
+	"github.com/pivotal-golang/clock"
+
 	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
@@ -14,6 +16,7 @@ import (
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 type concreteFactory struct {
@@ -40,6 +43,8 @@ func NewFactory(
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
 	ntpService := boshntp.NewConcreteService(platform.GetFs(), dirProvider)
+	uuidGenerator := boshuuid.NewGenerator()
+	timeService := clock.NewClock()
 
 	factory = concreteFactory{
 		availableActions: map[string]Action{
@@ -49,9 +54,11 @@ func NewFactory(
 			"cancel_task": NewCancelTask(taskService),
 
 			// VM admin
-			"ssh":             NewSSH(settingsService, platform, dirProvider, logger),
-			"fetch_logs":      NewFetchLogs(compressor, copier, blobstore, dirProvider),
-			"update_settings": NewUpdateSettings(settingsService, platform, certManager, logger),
+			"ssh":               NewSSH(settingsService, platform, dirProvider, logger),
+			"fetch_logs":        NewFetchLogs(compressor, copier, blobstore, dirProvider),
+			"update_settings":   NewUpdateSettings(settingsService, platform, certManager, logger),
+			"reload_settings":   NewReloadSettings(settingsService),
+			"get_settings_info": NewGetSettingsInfo(settingsService),
 
 			// Job management
 			"prepare":    NewPrepare(applier),
@@ -72,8 +79,9 @@ func NewFactory(
 
 			// Disk management
 			"list_disk":    NewListDisk(settingsService, platform, logger),
+			"disk_info":    NewDiskInfo(settingsService, platform, logger),
 			"migrate_disk": NewMigrateDisk(platform, dirProvider),
-			"mount_disk":   NewMountDisk(settingsService, platform, dirProvider, logger),
+			"mount_disk":   NewMountDisk(settingsService, platform, dirProvider, notifier, uuidGenerator, timeService, logger),
 			"unmount_disk": NewUnmountDisk(settingsService, platform),
 
 			// ARP cache management
@@ -83,6 +91,7 @@ func NewFactory(
 			"prepare_network_change":     NewPrepareNetworkChange(platform.GetFs(), settingsService, NewAgentKiller()),
 			"prepare_configure_networks": NewPrepareConfigureNetworks(platform, settingsService),
 			"configure_networks":         NewConfigureNetworks(NewAgentKiller()),
+			"plan_network":               NewPlanNetwork(platform),
 
 			// DNS
 			"sync_dns": NewSyncDNS(blobstore, settingsService, platform, logger),
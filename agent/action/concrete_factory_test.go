@@ -3,6 +3,7 @@ package action_test
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 
@@ -10,6 +11,7 @@ import (
 	boshntp "github.com/cloudfoundry/bosh-agent/platform/ntp"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
@@ -122,6 +124,12 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewListDisk(settingsService, platform, logger)))
 	})
 
+	It("disk_info", func() {
+		action, err := factory.Create("disk_info")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewDiskInfo(settingsService, platform, logger)))
+	})
+
 	It("migrate_disk", func() {
 		action, err := factory.Create("migrate_disk")
 		Expect(err).ToNot(HaveOccurred())
@@ -131,7 +139,15 @@ var _ = Describe("concreteFactory", func() {
 	It("mount_disk", func() {
 		action, err := factory.Create("mount_disk")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewMountDisk(settingsService, platform, platform.GetDirProvider(), logger)))
+		Expect(action).To(Equal(NewMountDisk(
+			settingsService,
+			platform,
+			platform.GetDirProvider(),
+			notifier,
+			boshuuid.NewGenerator(),
+			clock.NewClock(),
+			logger,
+		)))
 	})
 
 	It("ping", func() {
@@ -158,6 +174,12 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewConfigureNetworks(NewAgentKiller())))
 	})
 
+	It("plan_network", func() {
+		action, err := factory.Create("plan_network")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewPlanNetwork(platform)))
+	})
+
 	It("ssh", func() {
 		action, err := factory.Create("ssh")
 		Expect(err).ToNot(HaveOccurred())
@@ -214,6 +236,18 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewDeleteARPEntries(platform)))
 	})
 
+	It("reload_settings", func() {
+		action, err := factory.Create("reload_settings")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewReloadSettings(settingsService)))
+	})
+
+	It("get_settings_info", func() {
+		action, err := factory.Create("get_settings_info")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewGetSettingsInfo(settingsService)))
+	})
+
 	It("sync_dns", func() {
 		action, err := factory.Create("sync_dns")
 		Expect(err).ToNot(HaveOccurred())
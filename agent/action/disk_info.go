@@ -0,0 +1,89 @@
+package action
+
+import (
+	"errors"
+	"sort"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type DiskInfoAction struct {
+	settingsService boshsettings.Service
+	platform        boshplatform.Platform
+	logger          boshlog.Logger
+}
+
+func NewDiskInfo(
+	settingsService boshsettings.Service,
+	platform boshplatform.Platform,
+	logger boshlog.Logger,
+) (action DiskInfoAction) {
+	action.settingsService = settingsService
+	action.platform = platform
+	action.logger = logger
+	return
+}
+
+func (a DiskInfoAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a DiskInfoAction) IsPersistent() bool {
+	return false
+}
+
+func (a DiskInfoAction) IsLoggable() bool {
+	return true
+}
+
+func (a DiskInfoAction) Run() ([]boshdisk.DiskInfo, error) {
+	settings := a.settingsService.GetSettings()
+
+	disksInfo := []boshdisk.DiskInfo{
+		a.diskInfo(boshdisk.DiskTypeSystem, boshsettings.DiskSettings{}),
+	}
+
+	if settings.Disks.Ephemeral != nil {
+		disksInfo = append(disksInfo, a.diskInfo(boshdisk.DiskTypeEphemeral, settings.EphemeralDiskSettings()))
+	}
+
+	for _, diskSettings := range settings.RawEphemeralDiskSettings() {
+		disksInfo = append(disksInfo, a.diskInfo(boshdisk.DiskTypeRawEphemeral, diskSettings))
+	}
+
+	var diskIDs []string
+	for diskID := range settings.Disks.Persistent {
+		diskIDs = append(diskIDs, diskID)
+	}
+	sort.Strings(diskIDs)
+
+	for _, diskID := range diskIDs {
+		diskSettings, _ := settings.PersistentDiskSettings(diskID)
+		disksInfo = append(disksInfo, a.diskInfo(boshdisk.DiskTypePersistent, diskSettings))
+	}
+
+	return disksInfo, nil
+}
+
+// diskInfo records failures on the disk itself so that
+// details of other disks are still returned
+func (a DiskInfoAction) diskInfo(diskType boshdisk.DiskType, diskSettings boshsettings.DiskSettings) boshdisk.DiskInfo {
+	info, err := a.platform.GetDiskInfo(diskType, diskSettings)
+	if err != nil {
+		a.logger.Warn("disk-info-action", "Failed to get info of %s disk %+v: %s", diskType, diskSettings, err)
+		info.Error = err.Error()
+	}
+
+	return info
+}
+
+func (a DiskInfoAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a DiskInfoAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,84 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("DiskInfoAction", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		platform        *fakeplatform.FakePlatform
+		action          DiskInfoAction
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		platform = fakeplatform.NewFakePlatform()
+		action = NewDiskInfo(settingsService, platform, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("returns info of system disk when no other disks are known", func() {
+		disksInfo, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(disksInfo).To(Equal([]boshdisk.DiskInfo{{Type: boshdisk.DiskTypeSystem}}))
+	})
+
+	It("returns info of all known disks", func() {
+		settingsService.Settings.Disks = boshsettings.Disks{
+			Ephemeral:    "/dev/sdb",
+			RawEphemeral: []boshsettings.DiskSettings{{Path: "/dev/xvdb"}, {Path: "/dev/xvdc"}},
+			Persistent: map[string]interface{}{
+				"vol-2": "/dev/sdd",
+				"vol-1": "/dev/sdc",
+			},
+		}
+
+		disksInfo, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(disksInfo).To(Equal([]boshdisk.DiskInfo{
+			{Type: boshdisk.DiskTypeSystem},
+			{Type: boshdisk.DiskTypeEphemeral, DevicePath: "/dev/sdb"},
+			{Type: boshdisk.DiskTypeRawEphemeral, DevicePath: "/dev/xvdb"},
+			{Type: boshdisk.DiskTypeRawEphemeral, DevicePath: "/dev/xvdc"},
+			{Type: boshdisk.DiskTypePersistent, CID: "vol-1", DevicePath: "/dev/sdc"},
+			{Type: boshdisk.DiskTypePersistent, CID: "vol-2", DevicePath: "/dev/sdd"},
+		}))
+	})
+
+	It("reports errors per disk and keeps going", func() {
+		settingsService.Settings.Disks = boshsettings.Disks{
+			Persistent: map[string]interface{}{
+				"vol-1": "/dev/sdc",
+				"vol-2": "/dev/sdd",
+			},
+		}
+		platform.GetDiskInfoErrs = map[string]error{"/dev/sdc": errors.New("fake-disk-info-err")}
+
+		disksInfo, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(disksInfo[1]).To(Equal(boshdisk.DiskInfo{
+			Type:       boshdisk.DiskTypePersistent,
+			CID:        "vol-1",
+			DevicePath: "/dev/sdc",
+			Error:      "fake-disk-info-err",
+		}))
+		Expect(disksInfo[2].Error).To(BeEmpty())
+	})
+})
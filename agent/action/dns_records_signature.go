@@ -0,0 +1,71 @@
+package action
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ecdsaSignature is ASN.1 structure of ECDSA signature (RFC 3279)
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// verifyDNSRecordsSignature verifies base64 encoded detached signature
+// of DNS records blob contents; RSA (PKCS #1 v1.5) and ASN.1 encoded
+// ECDSA signatures are made over SHA-256 digest of contents
+func verifyDNSRecordsSignature(publicKeyPEM string, contents []byte, signature string) error {
+	if signature == "" {
+		return bosherr.Error("DNS records are not signed")
+	}
+
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return bosherr.Error("Decoding DNS records public key PEM")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing DNS records public key")
+	}
+
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return bosherr.WrapError(err, "Decoding DNS records signature")
+	}
+
+	digest := sha256.Sum256(contents)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signatureBytes)
+		if err != nil {
+			return bosherr.WrapError(err, "Verifying DNS records signature")
+		}
+
+	case *ecdsa.PublicKey:
+		var ecdsaSignature ecdsaSignature
+
+		rest, err := asn1.Unmarshal(signatureBytes, &ecdsaSignature)
+		if err != nil || len(rest) > 0 || ecdsaSignature.R == nil || ecdsaSignature.S == nil {
+			return bosherr.Error("Verifying DNS records signature: malformed signature")
+		}
+
+		if !ecdsa.Verify(key, digest[:], ecdsaSignature.R, ecdsaSignature.S) {
+			return bosherr.Error("Verifying DNS records signature: invalid signature")
+		}
+
+	default:
+		return bosherr.Errorf("Unsupported DNS records public key type '%T'", publicKey)
+	}
+
+	return nil
+}
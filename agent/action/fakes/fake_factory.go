@@ -54,6 +54,8 @@ type TestAction struct {
 
 	Canceled  bool
 	CancelErr error
+
+	ProgressValue interface{}
 }
 
 func (a *TestAction) IsAsynchronous() bool {
@@ -81,3 +83,7 @@ func (a *TestAction) Cancel() error {
 	a.Canceled = true
 	return a.CancelErr
 }
+
+func (a *TestAction) Progress() interface{} {
+	return a.ProgressValue
+}
@@ -0,0 +1,40 @@
+package action
+
+import (
+	"errors"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type GetSettingsInfoAction struct {
+	settingsService boshsettings.Service
+}
+
+func NewGetSettingsInfo(settingsService boshsettings.Service) GetSettingsInfoAction {
+	return GetSettingsInfoAction{settingsService: settingsService}
+}
+
+func (a GetSettingsInfoAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a GetSettingsInfoAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetSettingsInfoAction) IsLoggable() bool {
+	return true
+}
+
+// Run returns where current settings came from and outcome of last settings load
+func (a GetSettingsInfoAction) Run() (boshsettings.Info, error) {
+	return a.settingsService.GetSettingsInfo(), nil
+}
+
+func (a GetSettingsInfoAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetSettingsInfoAction) Cancel() error {
+	return errors.New("not supported")
+}
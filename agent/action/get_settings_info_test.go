@@ -0,0 +1,47 @@
+package action_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+)
+
+func init() {
+	Describe("GetSettingsInfo", func() {
+		var (
+			settingsService *fakesettings.FakeSettingsService
+			action          GetSettingsInfoAction
+		)
+
+		BeforeEach(func() {
+			settingsService = &fakesettings.FakeSettingsService{}
+			action = NewGetSettingsInfo(settingsService)
+		})
+
+		AssertActionIsNotAsynchronous(action)
+		AssertActionIsNotPersistent(action)
+		AssertActionIsLoggable(action)
+
+		AssertActionIsNotResumable(action)
+		AssertActionIsNotCancelable(action)
+
+		It("returns settings info", func() {
+			info := boshsettings.Info{
+				Source:         boshsettings.SourceInfo{Type: "File", Endpoint: "/fake-settings-path"},
+				FetchedAt:      time.Date(2016, time.March, 1, 10, 0, 0, 0, time.UTC),
+				FromCachedFile: true,
+				LastError:      "fake-fetch-error",
+			}
+			settingsService.SettingsInfo = info
+
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(info))
+		})
+	})
+}
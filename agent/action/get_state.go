@@ -56,6 +56,7 @@ type GetStateV1ApplySpec struct {
 	Processes    []boshjobsuper.Process `json:"processes,omitempty"`
 	VM           boshsettings.VM        `json:"vm"`
 	Ntp          boshntp.Info           `json:"ntp"`
+	SettingsInfo *boshsettings.Info     `json:"settings_info,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -66,6 +67,7 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 
 	var vitals boshvitals.Vitals
 	var vitalsReference *boshvitals.Vitals
+	var settingsInfoReference *boshsettings.Info
 
 	if len(filters) > 0 && filters[0] == "full" {
 		vitals, err = a.vitalsService.Get()
@@ -73,6 +75,9 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
 		}
 		vitalsReference = &vitals
+
+		settingsInfo := a.settingsService.GetSettingsInfo()
+		settingsInfoReference = &settingsInfo
 	}
 
 	processes, err := a.jobSupervisor.Processes()
@@ -91,6 +96,7 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		processes,
 		settings.VM,
 		a.ntpService.GetInfo(),
+		settingsInfoReference,
 	}
 
 	if value.NetworkSpecs == nil {
@@ -87,6 +87,7 @@ var _ = Describe("GetState", func() {
 					Expect(state.JobState).To(Equal(expectedSpec.JobState))
 					Expect(state.Deployment).To(Equal(expectedSpec.Deployment))
 					boshassert.LacksJSONKey(GinkgoT(), state, "vitals")
+					boshassert.LacksJSONKey(GinkgoT(), state, "settings_info")
 
 					Expect(state).To(Equal(expectedSpec))
 				})
@@ -118,6 +119,12 @@ var _ = Describe("GetState", func() {
 					vitalsService.GetVitals = expectedVitals
 					expectedVM := map[string]interface{}{"name": "vm-abc-def"}
 
+					expectedSettingsInfo := boshsettings.Info{
+						Source:         boshsettings.SourceInfo{Type: "File", Endpoint: "/fake-settings-path"},
+						FromCachedFile: true,
+					}
+					settingsService.SettingsInfo = expectedSettingsInfo
+
 					expectedProcesses := []boshjobsuper.Process{
 						boshjobsuper.Process{
 							Name:  "fake-process-name-1",
@@ -136,6 +143,7 @@ var _ = Describe("GetState", func() {
 					boshassert.MatchesJSONString(GinkgoT(), state.JobState, `"running"`)
 					boshassert.MatchesJSONString(GinkgoT(), state.Deployment, `"fake-deployment"`)
 					Expect(*state.Vitals).To(Equal(expectedVitals))
+					Expect(*state.SettingsInfo).To(Equal(expectedSettingsInfo))
 					Expect(state.Processes).To(Equal(expectedProcesses))
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
 				})
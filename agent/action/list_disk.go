@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -15,6 +16,13 @@ type ListDiskAction struct {
 	logger          boshlog.Logger
 }
 
+// ListDiskDetails is returned for each mounted disk when full listing is requested
+type ListDiskDetails struct {
+	ID           string                    `json:"id"`
+	MountOptions []string                  `json:"mount_options"`
+	Quotas       []boshdisk.DirectoryQuota `json:"quotas"`
+}
+
 func NewListDisk(
 	settingsService boshsettings.Service,
 	platform boshplatform.Platform,
@@ -38,9 +46,10 @@ func (a ListDiskAction) IsLoggable() bool {
 	return true
 }
 
-func (a ListDiskAction) Run() (interface{}, error) {
+func (a ListDiskAction) Run(filters ...string) (interface{}, error) {
 	settings := a.settingsService.GetSettings()
 	diskIDs := []string{}
+	disksDetails := []ListDiskDetails{}
 
 	for diskID := range settings.Disks.Persistent {
 		var isMounted bool
@@ -53,11 +62,20 @@ func (a ListDiskAction) Run() (interface{}, error) {
 
 		if isMounted {
 			diskIDs = append(diskIDs, diskID)
+			disksDetails = append(disksDetails, ListDiskDetails{
+				ID:           diskID,
+				MountOptions: diskSettings.MountOptions,
+				Quotas:       diskSettings.Quotas,
+			})
 		} else {
 			a.logger.Debug("list-disk-action", "Volume '%s' not mounted", diskID)
 		}
 	}
 
+	if len(filters) > 0 && filters[0] == "full" {
+		return disksDetails, nil
+	}
+
 	return diskIDs, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
@@ -53,5 +54,33 @@ func init() {
 			Expect(values).To(ContainElement("volume-3"))
 			Expect(len(values)).To(Equal(2))
 		})
+
+		It("lists mount options and quotas of mounted disks when full listing is requested", func() {
+			platform.MountedDevicePaths = []string{"/dev/sdb"}
+
+			settingsService.Settings.Disks = boshsettings.Disks{
+				Persistent: map[string]interface{}{
+					"volume-1": "/dev/sda",
+					"volume-2": map[string]interface{}{
+						"path":          "/dev/sdb",
+						"mount_options": []interface{}{"discard"},
+					},
+				},
+			}
+			settingsService.Settings.Env = boshsettings.Env{
+				PersistentDiskMountOptions: []string{"noatime"},
+				PersistentDiskQuotas:       []boshdisk.DirectoryQuota{{Path: "fake-job", SizeInMB: 100}},
+			}
+
+			value, err := action.Run("full")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal([]ListDiskDetails{
+				{
+					ID:           "volume-2",
+					MountOptions: []string{"noatime", "discard"},
+					Quotas:       []boshdisk.DirectoryQuota{{Path: "fake-job", SizeInMB: 100}},
+				},
+			}))
+		})
 	})
 }
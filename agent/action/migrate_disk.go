@@ -1,8 +1,6 @@
 package action
 
 import (
-	"errors"
-
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -27,7 +25,7 @@ func (a MigrateDiskAction) IsAsynchronous() bool {
 }
 
 func (a MigrateDiskAction) IsPersistent() bool {
-	return false
+	return true
 }
 
 func (a MigrateDiskAction) IsLoggable() bool {
@@ -45,10 +43,22 @@ func (a MigrateDiskAction) Run() (value interface{}, err error) {
 	return
 }
 
+// Resume continues migration from the last persisted step
 func (a MigrateDiskAction) Resume() (interface{}, error) {
-	return nil, errors.New("not supported")
+	return a.Run()
 }
 
+// Cancel leaves old disk mounted and writable
+// unless mount points are already being switched
 func (a MigrateDiskAction) Cancel() error {
-	return errors.New("not supported")
+	return a.platform.CancelPersistentDiskMigration()
+}
+
+func (a MigrateDiskAction) Progress() interface{} {
+	progress, found := a.platform.GetPersistentDiskMigrationProgress()
+	if !found {
+		return nil
+	}
+
+	return progress
 }
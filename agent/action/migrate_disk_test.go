@@ -1,10 +1,13 @@
 package action_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
@@ -28,12 +31,9 @@ func init() {
 		})
 
 		AssertActionIsAsynchronous(action)
-		AssertActionIsNotPersistent(action)
+		AssertActionIsPersistent(action)
 		AssertActionIsLoggable(action)
 
-		AssertActionIsNotResumable(action)
-		AssertActionIsNotCancelable(action)
-
 		It("migrate disk action run", func() {
 			value, err := action.Run()
 			Expect(err).ToNot(HaveOccurred())
@@ -42,5 +42,54 @@ func init() {
 			Expect(platform.MigratePersistentDiskFromMountPoint).To(boshassert.MatchPath("/foo/store"))
 			Expect(platform.MigratePersistentDiskToMountPoint).To(boshassert.MatchPath("/foo/store_migration_target"))
 		})
+
+		It("returns error when migration fails", func() {
+			platform.MigratePersistentDiskErr = errors.New("fake-migrate-err")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-migrate-err"))
+		})
+
+		It("resumes migration", func() {
+			value, err := action.Resume()
+			Expect(err).ToNot(HaveOccurred())
+			boshassert.MatchesJSONString(GinkgoT(), value, "{}")
+
+			Expect(platform.MigratePersistentDiskFromMountPoint).To(boshassert.MatchPath("/foo/store"))
+			Expect(platform.MigratePersistentDiskToMountPoint).To(boshassert.MatchPath("/foo/store_migration_target"))
+		})
+
+		It("cancels migration", func() {
+			err := action.Cancel()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(platform.CancelPersistentDiskMigrationCalled).To(BeTrue())
+		})
+
+		It("returns error when migration cannot be canceled", func() {
+			platform.CancelPersistentDiskMigrationErr = errors.New("fake-cancel-err")
+
+			err := action.Cancel()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-cancel-err"))
+		})
+
+		It("reports migration progress", func() {
+			platform.GetPersistentDiskMigrationProgressFound = true
+			platform.GetPersistentDiskMigrationProgressProgress = boshdisk.MigrationProgress{
+				FromMountPoint: "/foo/store",
+				ToMountPoint:   "/foo/store_migration_target",
+				Phase:          boshdisk.MigrationPhaseCopying,
+				TotalEntries:   4,
+				CopiedEntries:  1,
+			}
+
+			boshassert.MatchesJSONString(GinkgoT(), action.Progress(),
+				`{"from_mount_point":"/foo/store","to_mount_point":"/foo/store_migration_target","phase":"copying","total_entries":4,"copied_entries":1}`)
+		})
+
+		It("reports no progress when migration is not in progress", func() {
+			Expect(action.Progress()).To(BeNil())
+		})
 	})
 }
@@ -3,15 +3,24 @@ package action
 import (
 	"errors"
 
+	"github.com/pivotal-golang/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
+	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+const mountDiskLogTag = "MountDiskAction"
+
 type diskMounter interface {
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
+	GetPersistentDiskFileSystemCheck(diskSettings boshsettings.DiskSettings) (boshdisk.FileSystemCheckResult, bool, error)
 }
 
 type MountDiskAction struct {
@@ -19,6 +28,9 @@ type MountDiskAction struct {
 	diskMounter        diskMounter
 	devicePathResolver boshdpresolv.DevicePathResolver
 	dirProvider        boshdirs.Provider
+	notifier           boshnotif.Notifier
+	uuidGenerator      boshuuid.Generator
+	timeService        clock.Clock
 	logger             boshlog.Logger
 }
 
@@ -26,11 +38,17 @@ func NewMountDisk(
 	settingsService boshsettings.Service,
 	diskMounter diskMounter,
 	dirProvider boshdirs.Provider,
+	notifier boshnotif.Notifier,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) (mountDisk MountDiskAction) {
 	mountDisk.settingsService = settingsService
 	mountDisk.diskMounter = diskMounter
 	mountDisk.dirProvider = dirProvider
+	mountDisk.notifier = notifier
+	mountDisk.uuidGenerator = uuidGenerator
+	mountDisk.timeService = timeService
 	mountDisk.logger = logger
 	return
 }
@@ -61,13 +79,49 @@ func (a MountDiskAction) Run(diskCid string) (interface{}, error) {
 	}
 
 	mountPoint := a.dirProvider.StoreDir()
+	mountStartedAt := a.timeService.Now()
 
 	err = a.diskMounter.MountPersistentDisk(diskSettings, mountPoint)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Mounting persistent disk")
 	}
 
-	return map[string]string{}, nil
+	value := map[string]interface{}{}
+
+	checkResult, found, err := a.diskMounter.GetPersistentDiskFileSystemCheck(diskSettings)
+	if err != nil {
+		a.logger.Warn(mountDiskLogTag, "Failed to get file system check result: %s", err.Error())
+		return value, nil
+	}
+
+	// Check result is only relevant if disk was checked while being mounted
+	if !found || checkResult.CheckedAt.Before(mountStartedAt) {
+		return value, nil
+	}
+
+	value["filesystem_check"] = checkResult
+
+	a.notifyFileSystemCheck(diskCid, checkResult)
+
+	return value, nil
+}
+
+func (a MountDiskAction) notifyFileSystemCheck(diskCid string, checkResult boshdisk.FileSystemCheckResult) {
+	alertAdapter := boshalert.NewDiskCheckAdapter(diskCid, checkResult, a.uuidGenerator, a.timeService)
+	if alertAdapter.IsIgnorable() {
+		return
+	}
+
+	alert, err := alertAdapter.Alert()
+	if err != nil {
+		a.logger.Warn(mountDiskLogTag, "Failed to build file system check alert: %s", err.Error())
+		return
+	}
+
+	err = a.notifier.NotifyAlert(alert)
+	if err != nil {
+		a.logger.Warn(mountDiskLogTag, "Failed to send file system check alert: %s", err.Error())
+	}
 }
 
 func (a MountDiskAction) Resume() (interface{}, error) {
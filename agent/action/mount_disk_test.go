@@ -2,23 +2,31 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	fakenotif "github.com/cloudfoundry/bosh-agent/notification/fakes"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
 
 var _ = Describe("MountDiskAction", func() {
 	var (
 		settingsService *fakesettings.FakeSettingsService
 		platform        *fakeplatform.FakePlatform
+		notifier        *fakenotif.FakeNotifier
+		timeService     *fakeclock.FakeClock
 		action          MountDiskAction
 		logger          boshlog.Logger
 	)
@@ -26,9 +34,12 @@ var _ = Describe("MountDiskAction", func() {
 	BeforeEach(func() {
 		settingsService = &fakesettings.FakeSettingsService{}
 		platform = fakeplatform.NewFakePlatform()
+		notifier = fakenotif.NewFakeNotifier()
+		timeService = fakeclock.NewFakeClock(time.Unix(1457000000, 0))
 		dirProvider := boshdirs.NewProvider("/fake-base-dir")
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		action = NewMountDisk(settingsService, platform, dirProvider, logger)
+		uuidGenerator := &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+		action = NewMountDisk(settingsService, platform, dirProvider, notifier, uuidGenerator, timeService, logger)
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -54,7 +65,7 @@ var _ = Describe("MountDiskAction", func() {
 					It("returns without an error after mounting store directory", func() {
 						result, err := action.Run("fake-disk-cid")
 						Expect(err).NotTo(HaveOccurred())
-						Expect(result).To(Equal(map[string]string{}))
+						Expect(result).To(Equal(map[string]interface{}{}))
 
 						Expect(platform.MountPersistentDiskSettings).To(Equal(boshsettings.DiskSettings{
 							ID:       "fake-disk-cid",
@@ -65,6 +76,64 @@ var _ = Describe("MountDiskAction", func() {
 					})
 				})
 
+				Context("when file system was checked while mounting", func() {
+					var checkResult boshdisk.FileSystemCheckResult
+
+					BeforeEach(func() {
+						checkResult = boshdisk.FileSystemCheckResult{
+							PartitionPath:  "fake-device-path1",
+							FileSystemType: boshdisk.FileSystemExt4,
+							Mode:           boshdisk.FileSystemCheckModeRepair,
+							Clean:          true,
+							CheckedAt:      timeService.Now(),
+						}
+						platform.GetPersistentDiskFileSystemCheckFound = true
+					})
+
+					It("includes check result in the response", func() {
+						platform.GetPersistentDiskFileSystemCheckResult = checkResult
+
+						result, err := action.Run("fake-disk-cid")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(map[string]interface{}{"filesystem_check": checkResult}))
+						Expect(notifier.NotifiedAlerts).To(BeEmpty())
+					})
+
+					It("sends an alert when file system was repaired", func() {
+						checkResult.Clean = false
+						checkResult.Repaired = true
+						platform.GetPersistentDiskFileSystemCheckResult = checkResult
+
+						_, err := action.Run("fake-disk-cid")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(notifier.NotifiedAlerts).To(HaveLen(1))
+						Expect(notifier.NotifiedAlerts[0].ID).To(Equal("fake-uuid"))
+						Expect(notifier.NotifiedAlerts[0].Severity).To(Equal(boshalert.SeverityWarning))
+						Expect(notifier.NotifiedAlerts[0].Title).To(Equal("Persistent disk file system was repaired"))
+					})
+
+					It("does not fail when sending alert fails", func() {
+						checkResult.Clean = false
+						platform.GetPersistentDiskFileSystemCheckResult = checkResult
+						notifier.NotifyAlertErr = errors.New("fake-notify-err")
+
+						_, err := action.Run("fake-disk-cid")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(notifier.NotifiedAlerts).To(HaveLen(1))
+					})
+
+					It("ignores check results recorded before disk was mounted", func() {
+						checkResult.Repaired = true
+						checkResult.CheckedAt = timeService.Now().Add(-time.Minute)
+						platform.GetPersistentDiskFileSystemCheckResult = checkResult
+
+						result, err := action.Run("fake-disk-cid")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(Equal(map[string]interface{}{}))
+						Expect(notifier.NotifiedAlerts).To(BeEmpty())
+					})
+				})
+
 				Context("when mounting fails", func() {
 					It("returns error after trying to mount store directory", func() {
 						platform.MountPersistentDiskErr = errors.New("fake-mount-persistent-disk-err")
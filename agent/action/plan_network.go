@@ -0,0 +1,49 @@
+package action
+
+import (
+	"errors"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// PlanNetworkAction previews network configuration changes
+// for proposed network settings without applying them
+type PlanNetworkAction struct {
+	platform boshplatform.Platform
+}
+
+func NewPlanNetwork(platform boshplatform.Platform) PlanNetworkAction {
+	return PlanNetworkAction{platform: platform}
+}
+
+func (a PlanNetworkAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a PlanNetworkAction) IsPersistent() bool {
+	return false
+}
+
+func (a PlanNetworkAction) IsLoggable() bool {
+	return true
+}
+
+func (a PlanNetworkAction) Run(networks boshsettings.Networks) (boshnet.NetworkPlan, error) {
+	plan, err := a.platform.PlanNetworking(networks)
+	if err != nil {
+		return boshnet.NetworkPlan{}, bosherr.WrapError(err, "Planning networking")
+	}
+
+	return plan, nil
+}
+
+func (a PlanNetworkAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a PlanNetworkAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,57 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+var _ = Describe("PlanNetwork", func() {
+	var (
+		platform *fakeplatform.FakePlatform
+		action   PlanNetworkAction
+		networks boshsettings.Networks
+	)
+
+	BeforeEach(func() {
+		platform = fakeplatform.NewFakePlatform()
+		action = NewPlanNetwork(platform)
+		networks = boshsettings.Networks{
+			"net1": boshsettings.Network{IP: "10.0.0.2", Netmask: "255.255.255.0", Mac: "aa:bb:cc:dd:ee:ff"},
+		}
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotCancelable(action)
+	AssertActionIsNotResumable(action)
+
+	It("plans networking for given networks", func() {
+		platform.PlanNetworkingPlan = boshnet.NetworkPlan{
+			Files:               []boshnet.PlannedFile{{Path: "/etc/network/interfaces", Content: "content", Changed: true}},
+			RestartedInterfaces: []string{"eth0"},
+			Errors:              []string{},
+		}
+
+		plan, err := action.Run(networks)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(plan).To(Equal(platform.PlanNetworkingPlan))
+		Expect(platform.PlanNetworkingNetworks).To(Equal(networks))
+	})
+
+	It("returns error if planning fails", func() {
+		platform.PlanNetworkingErr = errors.New("fake-plan-err")
+
+		_, err := action.Run(networks)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-plan-err"))
+	})
+})
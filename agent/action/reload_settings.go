@@ -0,0 +1,49 @@
+package action
+
+import (
+	"errors"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type ReloadSettingsAction struct {
+	settingsService boshsettings.Service
+}
+
+type ReloadSettingsResult struct {
+	Changed []string `json:"changed"`
+}
+
+func NewReloadSettings(settingsService boshsettings.Service) ReloadSettingsAction {
+	return ReloadSettingsAction{settingsService: settingsService}
+}
+
+func (a ReloadSettingsAction) IsAsynchronous() bool {
+	return true
+}
+
+func (a ReloadSettingsAction) IsPersistent() bool {
+	return false
+}
+
+func (a ReloadSettingsAction) IsLoggable() bool {
+	return true
+}
+
+// Run refreshes settings and applies changes; result lists changed settings
+func (a ReloadSettingsAction) Run() (ReloadSettingsResult, error) {
+	diff, err := a.settingsService.RefreshSettings()
+	if err != nil {
+		return ReloadSettingsResult{}, err
+	}
+
+	return ReloadSettingsResult{Changed: diff.ChangedGroups()}, nil
+}
+
+func (a ReloadSettingsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ReloadSettingsAction) Cancel() error {
+	return errors.New("not supported")
+}
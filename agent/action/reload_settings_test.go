@@ -0,0 +1,58 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+)
+
+func init() {
+	Describe("ReloadSettings", func() {
+		var (
+			settingsService *fakesettings.FakeSettingsService
+			action          ReloadSettingsAction
+		)
+
+		BeforeEach(func() {
+			settingsService = &fakesettings.FakeSettingsService{}
+			action = NewReloadSettings(settingsService)
+		})
+
+		AssertActionIsAsynchronous(action)
+		AssertActionIsNotPersistent(action)
+		AssertActionIsLoggable(action)
+
+		AssertActionIsNotResumable(action)
+		AssertActionIsNotCancelable(action)
+
+		It("refreshes settings and returns changed settings", func() {
+			settingsService.RefreshSettingsDiff = boshsettings.NewDiff(
+				boshsettings.Settings{Ntp: []string{"fake-old-ntp"}},
+				boshsettings.Settings{Ntp: []string{"fake-new-ntp"}},
+			)
+
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ReloadSettingsResult{Changed: []string{"ntp"}}))
+			Expect(settingsService.RefreshSettingsCallCount()).To(Equal(1))
+		})
+
+		It("returns empty list when settings did not change", func() {
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ReloadSettingsResult{Changed: []string{}}))
+		})
+
+		It("returns error when settings cannot be refreshed", func() {
+			settingsService.RefreshSettingsErr = errors.New("fake-refresh-err")
+
+			_, err := action.Run()
+			Expect(err).To(MatchError("fake-refresh-err"))
+		})
+	})
+}
@@ -17,7 +17,10 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
-const localDNSStateFilename = "local_dns_state.json"
+const (
+	localDNSStateFilename   = "local_dns_state.json"
+	localDNSRecordsFilename = "local_dns_records.json"
+)
 
 type SyncDNS struct {
 	blobstore       boshblob.Blobstore
@@ -59,7 +62,24 @@ func (a SyncDNS) Cancel() error {
 	return errors.New("not supported")
 }
 
-func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, version uint64) (string, error) {
+// SyncDNSOptions are optional arguments of sync_dns
+type SyncDNSOptions struct {
+	// Base64 encoded detached signature of the blob contents
+	Signature string `json:"signature"`
+
+	// Blob with complete records which is synced instead
+	// when delta records do not apply to local records
+	FullBlobID      string                    `json:"full_blob_id"`
+	FullMultiDigest boshcrypto.MultipleDigest `json:"full_multi_digest"`
+	FullSignature   string                    `json:"full_signature"`
+}
+
+func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, version uint64, options ...SyncDNSOptions) (string, error) {
+	var opts SyncDNSOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
 	requestVersionStale, err := a.isLocalStateGreaterThanOrEqual(version)
 	if err != nil {
 		return "", bosherr.WrapError(err, "reading local DNS state")
@@ -69,13 +89,60 @@ func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, versi
 		return "synced", nil
 	}
 
+	dnsRecords, err := a.fetchDNSRecords(blobID, multiDigest, opts.Signature)
 	if err != nil {
-		return "", bosherr.WrapError(err, "Parsing blob digest")
+		return "", err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	localDNSState := state.LocalDNSState{}
+	syncDNSState := a.createSyncDNSState()
+	if syncDNSState.StateFileExists() {
+		localDNSState, err = syncDNSState.LoadState()
+		if err != nil {
+			return "", bosherr.WrapError(err, "loading local DNS state")
+		}
 	}
 
+	//Checking again since don't want to keep lock during blobstore operations
+	if localDNSState.Version >= version {
+		return "synced", nil
+	}
+
+	if dnsRecords.Delta != nil {
+		dnsRecords, err = a.applyDelta(localDNSState, dnsRecords, opts)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = a.platform.SaveDNSRecords(dnsRecords, a.settingsService.GetSettings().AgentID)
+	if err != nil {
+		return "", bosherr.WrapError(err, "saving DNS records")
+	}
+
+	err = a.saveLocalDNSRecords(dnsRecords)
+	if err != nil {
+		return "", bosherr.WrapError(err, "saving local DNS records")
+	}
+
+	// State is saved last so that version is not reported as synced
+	// unless records of the version were saved
+	localDNSState.Version = version
+	err = syncDNSState.SaveState(localDNSState)
+	if err != nil {
+		return "", bosherr.WrapError(err, "saving local DNS state")
+	}
+
+	return "synced", nil
+}
+
+func (a SyncDNS) fetchDNSRecords(blobID string, multiDigest boshcrypto.MultipleDigest, signature string) (boshsettings.DNSRecords, error) {
 	filePath, err := a.blobstore.Get(blobID, multiDigest)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "getting %s from blobstore", blobID)
+		return boshsettings.DNSRecords{}, bosherr.WrapErrorf(err, "getting %s from blobstore", blobID)
 	}
 
 	fs := a.platform.GetFs()
@@ -89,44 +156,100 @@ func (a SyncDNS) Run(blobID string, multiDigest boshcrypto.MultipleDigest, versi
 
 	contents, err := fs.ReadFile(filePath)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "reading %s from blobstore", filePath)
+		return boshsettings.DNSRecords{}, bosherr.WrapErrorf(err, "reading %s from blobstore", filePath)
+	}
+
+	publicKey := a.settingsService.GetSettings().Env.Bosh.DNSRecordsPublicKey
+	if publicKey != "" {
+		err = verifyDNSRecordsSignature(publicKey, contents, signature)
+		if err != nil {
+			return boshsettings.DNSRecords{}, bosherr.WrapErrorf(err, "verifying signature of %s", blobID)
+		}
 	}
 
 	dnsRecords := boshsettings.DNSRecords{}
 	err = json.Unmarshal(contents, &dnsRecords)
 	if err != nil {
-		return "", bosherr.WrapError(err, "unmarshalling DNS records")
+		return boshsettings.DNSRecords{}, bosherr.WrapError(err, "unmarshalling DNS records")
 	}
 
-	a.lock.Lock()
-	defer a.lock.Unlock()
+	return dnsRecords, nil
+}
 
-	localDNSState := state.LocalDNSState{}
-	syncDNSState := a.createSyncDNSState()
-	if syncDNSState.StateFileExists() {
-		localDNSState, err = syncDNSState.LoadState()
-		if err != nil {
-			return "", bosherr.WrapError(err, "loading local DNS state")
-		}
+// applyDelta applies delta records to local records of its base version;
+// full records are fetched when local records are not of the base version.
+// Version of local records is compared instead of local state
+// since state is saved after records
+func (a SyncDNS) applyDelta(localDNSState state.LocalDNSState, delta boshsettings.DNSRecords, opts SyncDNSOptions) (boshsettings.DNSRecords, error) {
+	baseRecords, found, err := a.loadLocalDNSRecords()
+	if err != nil {
+		return boshsettings.DNSRecords{}, bosherr.WrapError(err, "loading local DNS records")
 	}
 
-	//Checking again since don't want to keep lock during blobstore operations
-	if localDNSState.Version >= version {
-		return "synced", nil
+	localVersion := localDNSState.Version
+	if found {
+		localVersion = uint64(baseRecords.Version)
 	}
 
-	localDNSState.Version = version
-	err = syncDNSState.SaveState(localDNSState)
+	if found && localVersion == delta.Delta.BaseVersion {
+		return baseRecords.ApplyDelta(delta), nil
+	}
+
+	if opts.FullBlobID == "" {
+		return boshsettings.DNSRecords{}, bosherr.Errorf(
+			"DNS records delta is based on version %d, local version is %d; full sync is required",
+			delta.Delta.BaseVersion, localVersion)
+	}
+
+	a.logger.Info(a.logTag, "DNS records delta is based on version %d, local version is %d; syncing full DNS records",
+		delta.Delta.BaseVersion, localVersion)
+
+	dnsRecords, err := a.fetchDNSRecords(opts.FullBlobID, opts.FullMultiDigest, opts.FullSignature)
 	if err != nil {
-		return "", bosherr.WrapError(err, "saving local DNS state")
+		return boshsettings.DNSRecords{}, bosherr.WrapError(err, "fetching full DNS records")
 	}
 
-	err = a.platform.SaveDNSRecords(dnsRecords, a.settingsService.GetSettings().AgentID)
+	if dnsRecords.Delta != nil {
+		return boshsettings.DNSRecords{}, bosherr.Errorf("Full DNS records blob %s contains delta records", opts.FullBlobID)
+	}
+
+	return dnsRecords, nil
+}
+
+func (a SyncDNS) localDNSRecordsPath() string {
+	return filepath.Join(a.platform.GetDirProvider().BaseDir(), localDNSRecordsFilename)
+}
+
+func (a SyncDNS) loadLocalDNSRecords() (boshsettings.DNSRecords, bool, error) {
+	fs := a.platform.GetFs()
+	path := a.localDNSRecordsPath()
+
+	if !fs.FileExists(path) {
+		return boshsettings.DNSRecords{}, false, nil
+	}
+
+	contents, err := fs.ReadFile(path)
 	if err != nil {
-		return "", bosherr.WrapError(err, "saving DNS records")
+		return boshsettings.DNSRecords{}, false, bosherr.WrapErrorf(err, "reading %s", path)
 	}
 
-	return "synced", nil
+	dnsRecords := boshsettings.DNSRecords{}
+	err = json.Unmarshal(contents, &dnsRecords)
+	if err != nil {
+		return boshsettings.DNSRecords{}, false, bosherr.WrapErrorf(err, "unmarshalling %s", path)
+	}
+
+	return dnsRecords, true, nil
+}
+
+// saveLocalDNSRecords keeps complete synced records as base for future deltas
+func (a SyncDNS) saveLocalDNSRecords(dnsRecords boshsettings.DNSRecords) error {
+	contents, err := json.Marshal(dnsRecords)
+	if err != nil {
+		return bosherr.WrapError(err, "marshalling DNS records")
+	}
+
+	return a.platform.GetFs().WriteFile(a.localDNSRecordsPath(), contents)
 }
 
 func (a SyncDNS) createSyncDNSState() state.SyncDNSState {
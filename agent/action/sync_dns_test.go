@@ -1,8 +1,17 @@
 package action_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"math/big"
 	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
@@ -214,13 +223,14 @@ var _ = Describe("SyncDNS", func() {
 
 					Context("when saving fails", func() {
 						BeforeEach(func() {
-							fakeFileSystem.WriteFileError = errors.New("fake-write-error")
+							fakeFileSystem.WriteFileErrors[stateFilePath] = errors.New("fake-write-error")
 						})
 
-						It("returns an error", func() {
+						It("returns an error after saving DNS records", func() {
 							_, err := action.Run("fake-blobstore-id", multiDigest, 3)
 							Expect(err).To(HaveOccurred())
 							Expect(err.Error()).To(ContainSubstring("saving local DNS state"))
+							Expect(fakePlatform.SaveDNSRecordsDNSRecords.Records).ToNot(BeEmpty())
 						})
 					})
 				})
@@ -243,10 +253,14 @@ var _ = Describe("SyncDNS", func() {
 						fakePlatform.SaveDNSRecordsError = errors.New("fake-error")
 					})
 
-					It("fails to save DNS records on the platform", func() {
+					It("fails to save DNS records on the platform without saving the version", func() {
 						_, err := action.Run("fake-blobstore-id", multiDigest, 2)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("saving DNS records"))
+
+						contents, err := fakeFileSystem.ReadFileString(stateFilePath)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(contents).To(MatchJSON(`{"version": 1}`))
 					})
 				})
 			})
@@ -333,5 +347,191 @@ var _ = Describe("SyncDNS", func() {
 				}))
 			})
 		})
+
+		Context("when DNS records are a delta against a base version", func() {
+			var (
+				recordsFilePath string
+				fullMultiDigest boshcrypto.MultipleDigest
+			)
+
+			BeforeEach(func() {
+				recordsFilePath = filepath.Join(fakePlatform.GetDirProvider().BaseDir(), "local_dns_records.json")
+				fullMultiDigest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-full-fingerprint"))
+
+				err := fakeFileSystem.WriteFileString("fake-blobstore-file-path", `{
+					"Version": 3,
+					"records": [],
+					"delta": {
+						"base_version": 2,
+						"added": [["fake-ip2", "fake-name2"]],
+						"removed": [["fake-ip0", "fake-name0"]]
+					}
+				}`)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("when local records are of the base version", func() {
+				BeforeEach(func() {
+					err := fakeFileSystem.WriteFileString(stateFilePath, `{"version": 2}`)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = fakeFileSystem.WriteFileString(recordsFilePath, `{"Version":2,"records":[["fake-ip0","fake-name0"],["fake-ip1","fake-name1"]]}`)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("saves local records with delta applied", func() {
+					response, err := action.Run("fake-blobstore-id", multiDigest, 3)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal("synced"))
+
+					expectedRecords := boshsettings.DNSRecords{
+						Version: 3,
+						Records: [][2]string{
+							{"fake-ip1", "fake-name1"},
+							{"fake-ip2", "fake-name2"},
+						},
+					}
+					Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(expectedRecords))
+
+					contents, err := fakeFileSystem.ReadFile(recordsFilePath)
+					Expect(err).ToNot(HaveOccurred())
+
+					savedRecords := boshsettings.DNSRecords{}
+					Expect(json.Unmarshal(contents, &savedRecords)).To(Succeed())
+					Expect(savedRecords).To(Equal(expectedRecords))
+				})
+			})
+
+			Context("when local state was not saved after local records of the base version", func() {
+				BeforeEach(func() {
+					err := fakeFileSystem.WriteFileString(stateFilePath, `{"version": 1}`)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = fakeFileSystem.WriteFileString(recordsFilePath, `{"Version":2,"records":[["fake-ip1","fake-name1"]]}`)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("applies delta to local records", func() {
+					_, err := action.Run("fake-blobstore-id", multiDigest, 3)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
+						Version: 3,
+						Records: [][2]string{
+							{"fake-ip1", "fake-name1"},
+							{"fake-ip2", "fake-name2"},
+						},
+					}))
+				})
+			})
+
+			Context("when local records are not of the base version", func() {
+				BeforeEach(func() {
+					err := fakeFileSystem.WriteFileString(stateFilePath, `{"version": 1}`)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = fakeFileSystem.WriteFileString(recordsFilePath, `{"Version":1,"records":[]}`)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("returns error when full records blob is not given", func() {
+					_, err := action.Run("fake-blobstore-id", multiDigest, 3)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("DNS records delta is based on version 2, local version is 1; full sync is required"))
+
+					Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{}))
+				})
+
+				It("syncs full records blob instead", func() {
+					err := fakeFileSystem.WriteFileString("fake-full-blobstore-file-path", `{"Version":3,"records":[["fake-ip3","fake-name3"]]}`)
+					Expect(err).ToNot(HaveOccurred())
+
+					fakeBlobstore.GetFileNames = []string{"fake-blobstore-file-path", "fake-full-blobstore-file-path"}
+
+					response, err := action.Run("fake-blobstore-id", multiDigest, 3, SyncDNSOptions{
+						FullBlobID:      "fake-full-blobstore-id",
+						FullMultiDigest: fullMultiDigest,
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(response).To(Equal("synced"))
+
+					Expect(fakeBlobstore.GetBlobIDs).To(Equal([]string{"fake-blobstore-id", "fake-full-blobstore-id"}))
+					Expect(fakeBlobstore.GetFingerprints).To(ContainElement(fullMultiDigest))
+
+					Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{
+						Version: 3,
+						Records: [][2]string{{"fake-ip3", "fake-name3"}},
+					}))
+				})
+
+				It("returns error when full records blob contains delta records", func() {
+					err := fakeFileSystem.WriteFileString("fake-full-blobstore-file-path", `{"Version":3,"records":[],"delta":{"base_version":2}}`)
+					Expect(err).ToNot(HaveOccurred())
+
+					fakeBlobstore.GetFileNames = []string{"fake-blobstore-file-path", "fake-full-blobstore-file-path"}
+
+					_, err = action.Run("fake-blobstore-id", multiDigest, 3, SyncDNSOptions{FullBlobID: "fake-full-blobstore-id"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Full DNS records blob fake-full-blobstore-id contains delta records"))
+				})
+			})
+		})
+
+		Context("when DNS records public key is configured", func() {
+			var privateKey *ecdsa.PrivateKey
+
+			sign := func(contents string) string {
+				digest := sha256.Sum256([]byte(contents))
+				r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+				Expect(err).ToNot(HaveOccurred())
+
+				signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+				Expect(err).ToNot(HaveOccurred())
+				return base64.StdEncoding.EncodeToString(signature)
+			}
+
+			BeforeEach(func() {
+				var err error
+				privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				Expect(err).ToNot(HaveOccurred())
+
+				publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeSettingsService.Settings.Env.Bosh.DNSRecordsPublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}))
+			})
+
+			It("saves DNS records with valid signature", func() {
+				contents, err := fakeFileSystem.ReadFileString("fake-blobstore-file-path")
+				Expect(err).ToNot(HaveOccurred())
+
+				response, err := action.Run("fake-blobstore-id", multiDigest, 2, SyncDNSOptions{Signature: sign(contents)})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(response).To(Equal("synced"))
+				Expect(fakePlatform.SaveDNSRecordsDNSRecords.Records).To(HaveLen(2))
+			})
+
+			It("rejects DNS records with invalid signature", func() {
+				response, err := action.Run("fake-blobstore-id", multiDigest, 2, SyncDNSOptions{Signature: sign("other-contents")})
+				Expect(err).To(HaveOccurred())
+				Expect(response).To(Equal(""))
+				Expect(err.Error()).To(ContainSubstring("verifying signature of fake-blobstore-id"))
+
+				Expect(fakePlatform.SaveDNSRecordsDNSRecords).To(Equal(boshsettings.DNSRecords{}))
+				Expect(fakeFileSystem.FileExists(stateFilePath)).To(BeFalse())
+			})
+
+			It("rejects DNS records with malformed signature", func() {
+				_, err := action.Run("fake-blobstore-id", multiDigest, 2, SyncDNSOptions{Signature: base64.StdEncoding.EncodeToString([]byte("fake-signature"))})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("malformed signature"))
+			})
+
+			It("rejects DNS records without signature", func() {
+				_, err := action.Run("fake-blobstore-id", multiDigest, 2)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("DNS records are not signed"))
+			})
+		})
 	})
 })
@@ -69,6 +69,7 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 			func(_ boshtask.Task) error { return action.Cancel() },
 			dispatcher.removeInfo,
 		)
+		task.ProgressFunc = dispatcher.progressFunc(action)
 
 		dispatcher.taskService.StartTask(task)
 	}
@@ -141,6 +142,8 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		}
 	}
 
+	task.ProgressFunc = dispatcher.progressFunc(action)
+
 	dispatcher.taskService.StartTask(task)
 
 	return boshhandler.NewValueResponse(boshtask.StateValue{
@@ -165,6 +168,13 @@ func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 	return boshhandler.NewValueResponse(value)
 }
 
+func (dispatcher concreteActionDispatcher) progressFunc(action boshaction.Action) boshtask.ProgressFunc {
+	if reporter, ok := action.(boshaction.ProgressReporter); ok {
+		return reporter.Progress
+	}
+	return nil
+}
+
 func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
 	err := dispatcher.taskManager.RemoveInfo(task.ID)
 	if err != nil {
@@ -138,6 +138,13 @@ func init() {
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-cancel-err"))
 				})
+
+				It("allows task to report progress of the action", func() {
+					action.ProgressValue = "fake-progress"
+					dispatcher.Dispatch(req)
+
+					Expect(taskService.StartedTasks["fake-generated-task-id"].Progress()).To(Equal("fake-progress"))
+				})
 			}
 
 			Context("when action is not persistent", func() {
@@ -422,6 +429,17 @@ func init() {
 				Expect(secondAction.Canceled).To(BeTrue())
 			})
 
+			It("allows resumed task to report progress of the action", func() {
+				actionFactory.RegisterAction("fake-action-1", firstAction)
+				actionFactory.RegisterAction("fake-action-2", secondAction)
+				firstAction.ProgressValue = "fake-progress-1"
+
+				dispatcher.ResumePreviouslyDispatchedTasks()
+
+				Expect(taskService.StartedTasks["fake-task-id-1"].Progress()).To(Equal("fake-progress-1"))
+				Expect(taskService.StartedTasks["fake-task-id-2"].Progress()).To(BeNil())
+			})
+
 			It("returns error from cancelling task when canceling resumed task fails", func() {
 				actionFactory.RegisterAction("fake-action-1", firstAction)
 				actionFactory.RegisterAction("fake-action-2", secondAction)
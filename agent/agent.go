@@ -1,6 +1,8 @@
 package agent
 
 import (
+	"encoding/json"
+	"path/filepath"
 	"time"
 
 	"github.com/pivotal-golang/clock"
@@ -10,6 +12,8 @@ import (
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshsyslog "github.com/cloudfoundry/bosh-agent/syslog"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -108,24 +112,30 @@ func (a Agent) generateHeartbeats(errCh chan error) {
 	defer a.logger.HandlePanic("Agent Generate Heartbeats")
 
 	// Send initial heartbeat
-	a.sendHeartbeat(errCh)
+	vitals := a.sendHeartbeat(errCh)
+
+	a.sendNetworkRollbackAlert()
+
+	dnsFailureAlerted := a.sendDNSFailureAlert(vitals, false)
 
 	tickChan := time.Tick(a.heartbeatInterval)
 
 	for {
 		select {
 		case <-tickChan:
-			a.sendHeartbeat(errCh)
+			vitals = a.sendHeartbeat(errCh)
+			dnsFailureAlerted = a.sendDNSFailureAlert(vitals, dnsFailureAlerted)
 		}
 	}
 }
 
-func (a Agent) sendHeartbeat(errCh chan error) {
+// sendHeartbeat returns vitals that were sent
+func (a Agent) sendHeartbeat(errCh chan error) boshvitals.Vitals {
 	heartbeat, err := a.getHeartbeat()
 	if err != nil {
 		err = bosherr.WrapError(err, "Building heartbeat")
 		errCh <- err
-		return
+		return boshvitals.Vitals{}
 	}
 
 	err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, heartbeat)
@@ -133,6 +143,75 @@ func (a Agent) sendHeartbeat(errCh chan error) {
 		err = bosherr.WrapError(err, "Sending heartbeat")
 		errCh <- err
 	}
+
+	return heartbeat.Vitals
+}
+
+// sendDNSFailureAlert alerts once when all DNS servers become unhealthy
+// and returns whether alert was sent for current failure
+func (a Agent) sendDNSFailureAlert(vitals boshvitals.Vitals, alerted bool) bool {
+	if !vitals.AllDNSUnhealthy() {
+		return false
+	}
+
+	if alerted {
+		return true
+	}
+
+	alert, err := boshalert.NewDNSFailureAdapter(vitals.DNS, a.uuidGenerator, a.timeService).Alert()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to build DNS failure alert: %s", err.Error())
+		return false
+	}
+
+	err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to send DNS failure alert: %s", err.Error())
+		return false
+	}
+
+	return true
+}
+
+// sendNetworkRollbackAlert reports networks rolled back during bootstrap
+func (a Agent) sendNetworkRollbackAlert() {
+	fs := a.platform.GetFs()
+	rollbackPath := filepath.Join(a.platform.GetDirProvider().BoshDir(), networkRollbackFileName)
+
+	if !fs.FileExists(rollbackPath) {
+		return
+	}
+
+	contents, err := fs.ReadFile(rollbackPath)
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to read network rollback: %s", err.Error())
+		return
+	}
+
+	var rollback boshnet.NetworkRollbackError
+
+	err = json.Unmarshal(contents, &rollback)
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to unmarshal network rollback: %s", err.Error())
+		return
+	}
+
+	alert, err := boshalert.NewNetworkRollbackAdapter(rollback, a.uuidGenerator, a.timeService).Alert()
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to build network rollback alert: %s", err.Error())
+		return
+	}
+
+	err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to send network rollback alert: %s", err.Error())
+		return
+	}
+
+	err = fs.RemoveAll(rollbackPath)
+	if err != nil {
+		a.logger.Warn(agentLogTag, "Failed to remove network rollback: %s", err.Error())
+	}
 }
 
 func (a Agent) getHeartbeat() (Heartbeat, error) {
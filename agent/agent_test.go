@@ -162,6 +162,80 @@ func init() {
 					}))
 				})
 
+				It("sends network rollback alert after initial heartbeat", func() {
+					platform.GetFs().WriteFileString(
+						"/var/vcap/bosh/network_rollback.json",
+						`{"unreachable_targets":["10.0.0.1"]}`,
+					)
+					uuidGenerator.GeneratedUUID = "fake-uuid"
+
+					alertSent := false
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if alertSent {
+							handler.SendErr = errors.New("stop")
+						}
+						alertSent = input.Topic == boshhandler.Alert
+					}
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+
+					Expect(handler.SendInputs()[1]).To(Equal(fakembus.SendInput{
+						Target: boshhandler.HealthMonitor,
+						Topic:  boshhandler.Alert,
+						Message: boshalert.Alert{
+							ID:        "fake-uuid",
+							Severity:  boshalert.SeverityError,
+							Title:     "Network configuration was rolled back",
+							Summary:   "Rolled back network configuration: Targets '10.0.0.1' were not reachable",
+							CreatedAt: timeService.Now().Unix(),
+						},
+					}))
+					Expect(platform.GetFs().FileExists("/var/vcap/bosh/network_rollback.json")).To(BeFalse())
+				})
+
+				It("sends DNS failure alert once when all DNS servers are unhealthy", func() {
+					platform.FakeVitalsService.GetVitals = boshvitals.Vitals{
+						Load: []string{"a", "b", "c"},
+						DNS: []boshvitals.DNSVitals{
+							{Server: "10.0.0.1", Error: "fake-probe-err"},
+						},
+					}
+					uuidGenerator.GeneratedUUID = "fake-uuid"
+
+					sentRequests := 0
+					handler.SendCallback = func(_ fakembus.SendInput) {
+						sentRequests++
+						if sentRequests == 4 {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+
+					var alerts []fakembus.SendInput
+					for _, input := range handler.SendInputs() {
+						if input.Topic == boshhandler.Alert {
+							alerts = append(alerts, input)
+						}
+					}
+
+					Expect(alerts).To(Equal([]fakembus.SendInput{
+						{
+							Target: boshhandler.HealthMonitor,
+							Topic:  boshhandler.Alert,
+							Message: boshalert.Alert{
+								ID:        "fake-uuid",
+								Severity:  boshalert.SeverityCritical,
+								Title:     "All DNS servers failed health check",
+								Summary:   "DNS servers are unhealthy: '10.0.0.1' (fake-probe-err)",
+								CreatedAt: timeService.Now().Unix(),
+							},
+						},
+					}))
+				})
+
 				It("sends periodic heartbeats", func() {
 					sentRequests := 0
 					handler.SendCallback = func(_ fakembus.SendInput) {
@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"fmt"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type diskCheckAdapter struct {
+	diskCID       string
+	result        boshdisk.FileSystemCheckResult
+	uuidGenerator boshuuid.Generator
+	timeService   clock.Clock
+}
+
+func NewDiskCheckAdapter(
+	diskCID string,
+	result boshdisk.FileSystemCheckResult,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &diskCheckAdapter{
+		diskCID:       diskCID,
+		result:        result,
+		uuidGenerator: uuidGenerator,
+		timeService:   timeService,
+	}
+}
+
+func (a *diskCheckAdapter) IsIgnorable() bool {
+	return !a.result.NeedsAttention()
+}
+
+func (a *diskCheckAdapter) Alert() (Alert, error) {
+	uuid, err := a.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	severity := SeverityError
+	title := "Persistent disk file system has errors"
+
+	if a.result.Repaired {
+		severity = SeverityWarning
+		title = "Persistent disk file system was repaired"
+	}
+
+	summary := fmt.Sprintf(
+		"Checking %s file system on '%s' of disk '%s' in %s mode exited with %d: %s",
+		a.result.FileSystemType,
+		a.result.PartitionPath,
+		a.diskCID,
+		a.result.Mode,
+		a.result.ExitStatus,
+		a.result.Output,
+	)
+
+	return Alert{
+		ID:        uuid,
+		Severity:  severity,
+		Title:     title,
+		Summary:   summary,
+		CreatedAt: a.timeService.Now().Unix(),
+	}, nil
+}
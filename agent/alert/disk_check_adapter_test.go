@@ -0,0 +1,99 @@
+package alert_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("diskCheckAdapter", func() {
+	var (
+		result        boshdisk.FileSystemCheckResult
+		timeService   *fakeclock.FakeClock
+		uuidGenerator *fakeuuid.FakeGenerator
+		adapter       Adapter
+	)
+
+	BeforeEach(func() {
+		result = boshdisk.FileSystemCheckResult{
+			PartitionPath:  "/dev/sdc1",
+			FileSystemType: boshdisk.FileSystemExt4,
+			Mode:           boshdisk.FileSystemCheckModeRepair,
+			ExitStatus:     1,
+			Output:         "fake-output",
+		}
+		timeService = fakeclock.NewFakeClock(time.Unix(1457000000, 0))
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+	})
+
+	JustBeforeEach(func() {
+		adapter = NewDiskCheckAdapter("fake-disk-cid", result, uuidGenerator, timeService)
+	})
+
+	Context("when file system is clean", func() {
+		BeforeEach(func() {
+			result.Clean = true
+		})
+
+		It("is ignorable", func() {
+			Expect(adapter.IsIgnorable()).To(BeTrue())
+		})
+	})
+
+	Context("when check was skipped", func() {
+		BeforeEach(func() {
+			result.Skipped = true
+		})
+
+		It("is ignorable", func() {
+			Expect(adapter.IsIgnorable()).To(BeTrue())
+		})
+	})
+
+	Context("when file system was repaired", func() {
+		BeforeEach(func() {
+			result.Repaired = true
+		})
+
+		It("is not ignorable", func() {
+			Expect(adapter.IsIgnorable()).To(BeFalse())
+		})
+
+		It("returns a warning alert describing the repair", func() {
+			alert, err := adapter.Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert).To(Equal(Alert{
+				ID:        "fake-uuid",
+				Severity:  SeverityWarning,
+				Title:     "Persistent disk file system was repaired",
+				Summary:   "Checking ext4 file system on '/dev/sdc1' of disk 'fake-disk-cid' in repair mode exited with 1: fake-output",
+				CreatedAt: 1457000000,
+			}))
+		})
+	})
+
+	Context("when file system has errors that were not repaired", func() {
+		It("returns an error alert", func() {
+			alert, err := adapter.Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Severity).To(Equal(SeverityError))
+			Expect(alert.Title).To(Equal("Persistent disk file system has errors"))
+		})
+	})
+
+	It("returns error when generating uuid fails", func() {
+		uuidGenerator.GenerateError = errors.New("fake-uuid-err")
+
+		_, err := adapter.Alert()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-uuid-err"))
+	})
+})
@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type dnsFailureAdapter struct {
+	dnsVitals     []boshvitals.DNSVitals
+	uuidGenerator boshuuid.Generator
+	timeService   clock.Clock
+}
+
+func NewDNSFailureAdapter(
+	dnsVitals []boshvitals.DNSVitals,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &dnsFailureAdapter{
+		dnsVitals:     dnsVitals,
+		uuidGenerator: uuidGenerator,
+		timeService:   timeService,
+	}
+}
+
+func (a *dnsFailureAdapter) IsIgnorable() bool {
+	return false
+}
+
+func (a *dnsFailureAdapter) Alert() (Alert, error) {
+	uuid, err := a.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	var servers []string
+	for _, dns := range a.dnsVitals {
+		servers = append(servers, fmt.Sprintf("'%s' (%s)", dns.Server, dns.Error))
+	}
+
+	return Alert{
+		ID:        uuid,
+		Severity:  SeverityCritical,
+		Title:     "All DNS servers failed health check",
+		Summary:   fmt.Sprintf("DNS servers are unhealthy: %s", strings.Join(servers, ", ")),
+		CreatedAt: a.timeService.Now().Unix(),
+	}, nil
+}
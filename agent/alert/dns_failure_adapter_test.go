@@ -0,0 +1,57 @@
+package alert_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("dnsFailureAdapter", func() {
+	var (
+		timeService   *fakeclock.FakeClock
+		uuidGenerator *fakeuuid.FakeGenerator
+		adapter       Adapter
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Unix(1457000000, 0))
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+
+		adapter = NewDNSFailureAdapter([]boshvitals.DNSVitals{
+			{Server: "10.0.0.1", Error: "i/o timeout"},
+			{Server: "10.0.0.2", Error: "connection refused"},
+		}, uuidGenerator, timeService)
+	})
+
+	It("is not ignorable", func() {
+		Expect(adapter.IsIgnorable()).To(BeFalse())
+	})
+
+	It("returns a critical alert describing unhealthy DNS servers", func() {
+		alert, err := adapter.Alert()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(alert).To(Equal(Alert{
+			ID:        "fake-uuid",
+			Severity:  SeverityCritical,
+			Title:     "All DNS servers failed health check",
+			Summary:   "DNS servers are unhealthy: '10.0.0.1' (i/o timeout), '10.0.0.2' (connection refused)",
+			CreatedAt: 1457000000,
+		}))
+	})
+
+	It("returns error when uuid cannot be generated", func() {
+		uuidGenerator.GenerateError = errors.New("fake-uuid-err")
+
+		_, err := adapter.Alert()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-uuid-err"))
+	})
+})
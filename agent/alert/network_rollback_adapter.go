@@ -0,0 +1,54 @@
+package alert
+
+import (
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/pivotal-golang/clock"
+)
+
+type networkRollbackAdapter struct {
+	rollback      boshnet.NetworkRollbackError
+	uuidGenerator boshuuid.Generator
+	timeService   clock.Clock
+}
+
+func NewNetworkRollbackAdapter(
+	rollback boshnet.NetworkRollbackError,
+	uuidGenerator boshuuid.Generator,
+	timeService clock.Clock,
+) Adapter {
+	return &networkRollbackAdapter{
+		rollback:      rollback,
+		uuidGenerator: uuidGenerator,
+		timeService:   timeService,
+	}
+}
+
+func (a *networkRollbackAdapter) IsIgnorable() bool {
+	return false
+}
+
+func (a *networkRollbackAdapter) Alert() (Alert, error) {
+	uuid, err := a.uuidGenerator.Generate()
+	if err != nil {
+		return Alert{}, bosherr.WrapError(err, "Generating uuid")
+	}
+
+	severity := SeverityError
+	title := "Network configuration was rolled back"
+
+	// Agent may be running with neither new nor previous networks applied
+	if a.rollback.RestoreError != "" {
+		severity = SeverityCritical
+		title = "Network configuration could not be rolled back"
+	}
+
+	return Alert{
+		ID:        uuid,
+		Severity:  severity,
+		Title:     title,
+		Summary:   a.rollback.Error(),
+		CreatedAt: a.timeService.Now().Unix(),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package alert_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("networkRollbackAdapter", func() {
+	var (
+		rollback      boshnet.NetworkRollbackError
+		timeService   *fakeclock.FakeClock
+		uuidGenerator *fakeuuid.FakeGenerator
+		adapter       Adapter
+	)
+
+	BeforeEach(func() {
+		rollback = boshnet.NetworkRollbackError{
+			UnreachableTargets: []string{"10.0.0.6:4222", "10.0.0.1"},
+		}
+		timeService = fakeclock.NewFakeClock(time.Unix(1457000000, 0))
+		uuidGenerator = &fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"}
+	})
+
+	JustBeforeEach(func() {
+		adapter = NewNetworkRollbackAdapter(rollback, uuidGenerator, timeService)
+	})
+
+	It("is not ignorable", func() {
+		Expect(adapter.IsIgnorable()).To(BeFalse())
+	})
+
+	It("returns an error alert describing unreachable targets", func() {
+		alert, err := adapter.Alert()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(alert).To(Equal(Alert{
+			ID:        "fake-uuid",
+			Severity:  SeverityError,
+			Title:     "Network configuration was rolled back",
+			Summary:   "Rolled back network configuration: Targets '10.0.0.6:4222', '10.0.0.1' were not reachable",
+			CreatedAt: 1457000000,
+		}))
+	})
+
+	Context("when setting up networking failed", func() {
+		BeforeEach(func() {
+			rollback = boshnet.NetworkRollbackError{SetupError: "fake-setup-err"}
+		})
+
+		It("describes setup failure", func() {
+			alert, err := adapter.Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Summary).To(Equal("Rolled back network configuration: fake-setup-err"))
+		})
+	})
+
+	Context("when previous configuration could not be restored", func() {
+		BeforeEach(func() {
+			rollback.RestoreError = "fake-restore-err"
+		})
+
+		It("returns a critical alert", func() {
+			alert, err := adapter.Alert()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(alert.Severity).To(Equal(SeverityCritical))
+			Expect(alert.Title).To(Equal("Network configuration could not be rolled back"))
+			Expect(alert.Summary).To(HaveSuffix("; restoring previous network configuration failed: fake-restore-err"))
+		})
+	})
+
+	It("returns error if generating uuid fails", func() {
+		uuidGenerator.GenerateError = errors.New("fake-uuid-err")
+
+		_, err := adapter.Alert()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-uuid-err"))
+	})
+})
@@ -0,0 +1,452 @@
+package blobstore
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	boshUtilsBlobStore "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+const chunkedLogTag = "chunkedBlobstore"
+
+type chunkedBlobstore struct {
+	client        ChunkedClient
+	fs            boshsys.FileSystem
+	uuidGen       boshuuid.Generator
+	partialDir    string
+	chunkSize     int64
+	parallelism   int
+	chunkAttempts int
+	logger        boshlog.Logger
+
+	// Transfers of the same blob share partial files and must not overlap
+	transferLocks *transferLocks
+}
+
+// transferState is persisted after every transferred chunk
+// so that interrupted transfers resume with missing chunks
+type transferState struct {
+	BlobID          string
+	Size            int64
+	ChunkSize       int64
+	SourceModTime   time.Time `json:",omitempty"`
+	CompletedChunks []int
+}
+
+type transferLocks struct {
+	lock  sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewChunkedBlobstore keeps partial downloads and transfer progress in partialDir;
+// chunks of a blob are transferred by up to parallelism workers
+// and each chunk is attempted up to chunkAttempts times
+func NewChunkedBlobstore(
+	client ChunkedClient,
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	partialDir string,
+	chunkSize int64,
+	parallelism int,
+	chunkAttempts int,
+	logger boshlog.Logger,
+) boshUtilsBlobStore.Blobstore {
+	return chunkedBlobstore{
+		client:        client,
+		fs:            fs,
+		uuidGen:       uuidGen,
+		partialDir:    partialDir,
+		chunkSize:     chunkSize,
+		parallelism:   parallelism,
+		chunkAttempts: chunkAttempts,
+		logger:        logger,
+		transferLocks: &transferLocks{locks: map[string]*sync.Mutex{}},
+	}
+}
+
+func (b chunkedBlobstore) Get(blobID string, digest boshcrypto.Digest) (string, error) {
+	unlock := b.transferLocks.acquire(blobID)
+	defer unlock()
+
+	size, err := b.client.Size(blobID)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting blob size")
+	}
+
+	err = b.fs.MkdirAll(b.partialDir, os.FileMode(0700))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating partial blobs dir")
+	}
+
+	dataPath := filepath.Join(b.partialDir, blobID)
+	statePath := dataPath + ".state"
+
+	state := b.loadState(statePath)
+	if state.BlobID != blobID || state.Size != size || state.ChunkSize != b.chunkSize {
+		state = transferState{BlobID: blobID, Size: size, ChunkSize: b.chunkSize}
+		_ = b.fs.RemoveAll(dataPath)
+	} else {
+		b.logger.Info(chunkedLogTag, "Resuming download of blob '%s' with %d chunks already downloaded", blobID, len(state.CompletedChunks))
+	}
+
+	file, err := b.fs.OpenFile(dataPath, os.O_RDWR|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening partial blob")
+	}
+
+	err = b.transferChunks(&state, statePath, func(offset, length int64) error {
+		return b.client.GetRange(blobID, offset, length, &offsetWriter{file: file, offset: offset})
+	})
+
+	closeErr := file.Close()
+
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Downloading blob '%s'", blobID)
+	}
+
+	if closeErr != nil {
+		return "", bosherr.WrapError(closeErr, "Closing partial blob")
+	}
+
+	err = b.verifyDigest(dataPath, digest)
+	if err != nil {
+		// Corrupted chunks cannot be found so download starts over next time
+		_ = b.fs.RemoveAll(dataPath)
+		_ = b.fs.RemoveAll(statePath)
+		return "", bosherr.WrapErrorf(err, "Checking downloaded blob '%s'", blobID)
+	}
+
+	blobPath := filepath.Join(b.partialDir, fmt.Sprintf("%s-%s", blobID, b.newUUID()))
+
+	err = b.fs.Rename(dataPath, blobPath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Moving downloaded blob")
+	}
+
+	_ = b.fs.RemoveAll(statePath)
+
+	return blobPath, nil
+}
+
+func (b chunkedBlobstore) CleanUp(fileName string) error {
+	return b.fs.RemoveAll(fileName)
+}
+
+// Create resumes upload of the same unchanged file, e.g. when upload is retried,
+// with blob id chosen by the interrupted upload
+func (b chunkedBlobstore) Create(fileName string) (string, error) {
+	unlock := b.transferLocks.acquire(fileName)
+	defer unlock()
+
+	fileInfo, err := b.fs.Stat(fileName)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting file info")
+	}
+
+	size := fileInfo.Size()
+
+	if size <= b.chunkSize {
+		return b.createWithSinglePut(fileName, size)
+	}
+
+	err = b.fs.MkdirAll(b.partialDir, os.FileMode(0700))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating partial blobs dir")
+	}
+
+	statePath := filepath.Join(b.partialDir, fmt.Sprintf("upload-%x.state", sha1.Sum([]byte(fileName))))
+
+	state := b.loadState(statePath)
+	if state.BlobID == "" || state.Size != size || state.ChunkSize != b.chunkSize || !state.SourceModTime.Equal(fileInfo.ModTime()) {
+		state = transferState{BlobID: b.newUUID(), Size: size, ChunkSize: b.chunkSize, SourceModTime: fileInfo.ModTime()}
+	} else {
+		b.logger.Info(chunkedLogTag, "Resuming upload of blob '%s' with %d chunks already uploaded", state.BlobID, len(state.CompletedChunks))
+	}
+
+	b.saveState(statePath, state)
+
+	file, err := b.fs.OpenFile(fileName, os.O_RDONLY, os.FileMode(0))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening file")
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	err = b.transferChunks(&state, statePath, func(offset, length int64) error {
+		return b.client.PutRange(state.BlobID, offset, size, io.NewSectionReader(file, offset, length), length)
+	})
+
+	if unsupportedErr, ok := err.(RangedUploadUnsupportedError); ok {
+		b.logger.Warn(chunkedLogTag, "%s; uploading blob with a single request", unsupportedErr.Error())
+
+		err = b.putWhole(state.BlobID, file, size)
+		if err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", bosherr.WrapErrorf(err, "Uploading blob '%s'", state.BlobID)
+	} else {
+		// Servers which ignore ranges of uploads keep only the last chunk
+		uploadedSize, err := b.client.Size(state.BlobID)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Getting uploaded blob size")
+		}
+
+		if uploadedSize != size {
+			b.logger.Warn(chunkedLogTag, "Uploaded blob '%s' has %d bytes instead of %d; blobstore may not support ranged uploads, uploading blob with a single request", state.BlobID, uploadedSize, size)
+
+			err = b.putWhole(state.BlobID, file, size)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// State is kept until upload is verified so that failed uploads are resumed
+	_ = b.fs.RemoveAll(statePath)
+
+	return state.BlobID, nil
+}
+
+// putWhole uploads whole file with a single request and verifies uploaded size
+func (b chunkedBlobstore) putWhole(blobID string, file io.ReaderAt, size int64) error {
+	err := b.client.Put(blobID, io.NewSectionReader(file, 0, size), size)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Uploading blob '%s'", blobID)
+	}
+
+	uploadedSize, err := b.client.Size(blobID)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting uploaded blob size")
+	}
+
+	if uploadedSize != size {
+		return bosherr.Errorf("Uploaded blob '%s' has %d bytes instead of %d", blobID, uploadedSize, size)
+	}
+
+	return nil
+}
+
+func (b chunkedBlobstore) createWithSinglePut(fileName string, size int64) (string, error) {
+	file, err := b.fs.OpenFile(fileName, os.O_RDONLY, os.FileMode(0))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening file")
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	blobID := b.newUUID()
+
+	err = b.client.Put(blobID, file, size)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Uploading blob '%s'", blobID)
+	}
+
+	return blobID, nil
+}
+
+func (b chunkedBlobstore) Validate() error {
+	if b.chunkSize <= 0 {
+		return bosherr.Error("Chunk size must be > 0")
+	}
+
+	if b.parallelism <= 0 {
+		return bosherr.Error("Parallelism must be > 0")
+	}
+
+	if b.chunkAttempts <= 0 {
+		return bosherr.Error("Chunk attempts must be > 0")
+	}
+
+	return nil
+}
+
+func (b chunkedBlobstore) Delete(blobID string) error {
+	_ = b.fs.RemoveAll(filepath.Join(b.partialDir, blobID))
+	_ = b.fs.RemoveAll(filepath.Join(b.partialDir, blobID+".state"))
+
+	return b.client.Delete(blobID)
+}
+
+// transferChunks transfers chunks missing from state in parallel;
+// chunks transferred before a failure stay recorded in state
+func (b chunkedBlobstore) transferChunks(state *transferState, statePath string, transferChunk func(offset, length int64) error) error {
+	completed := map[int]bool{}
+	for _, index := range state.CompletedChunks {
+		completed[index] = true
+	}
+
+	chunkCount := int((state.Size + b.chunkSize - 1) / b.chunkSize)
+
+	pendingChunks := make(chan int, chunkCount)
+	for index := 0; index < chunkCount; index++ {
+		if !completed[index] {
+			pendingChunks <- index
+		}
+	}
+	close(pendingChunks)
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		firstErr error
+	)
+
+	for i := 0; i < b.parallelism; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range pendingChunks {
+				lock.Lock()
+				_, unsupported := firstErr.(RangedUploadUnsupportedError)
+				lock.Unlock()
+
+				// Remaining chunks would be rejected as well
+				if unsupported {
+					continue
+				}
+
+				offset := int64(index) * b.chunkSize
+				length := b.chunkSize
+				if offset+length > state.Size {
+					length = state.Size - offset
+				}
+
+				err := b.transferChunkWithAttempts(index, offset, length, transferChunk)
+
+				lock.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					state.CompletedChunks = append(state.CompletedChunks, index)
+					sort.Ints(state.CompletedChunks)
+					b.saveState(statePath, *state)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+func (b chunkedBlobstore) transferChunkWithAttempts(index int, offset, length int64, transferChunk func(offset, length int64) error) error {
+	var err error
+
+	for attempt := 1; attempt <= b.chunkAttempts; attempt++ {
+		err = transferChunk(offset, length)
+		if err == nil {
+			return nil
+		}
+
+		// Rejected ranged uploads are not retried and are returned unwrapped
+		// so that blob can be uploaded with a single request instead
+		if _, ok := err.(RangedUploadUnsupportedError); ok {
+			return err
+		}
+
+		b.logger.Info(chunkedLogTag, "Failed to transfer chunk %d with error '%s', attempt %d out of %d", index, err.Error(), attempt, b.chunkAttempts)
+	}
+
+	return bosherr.WrapErrorf(err, "Transferring chunk %d", index)
+}
+
+func (b chunkedBlobstore) verifyDigest(path string, digest boshcrypto.Digest) error {
+	file, err := b.fs.OpenFile(path, os.O_RDONLY, os.FileMode(0))
+	if err != nil {
+		return bosherr.WrapError(err, "Opening downloaded blob")
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return digest.Verify(file)
+}
+
+// loadState returns empty state when transfer was not started or state is unreadable
+func (b chunkedBlobstore) loadState(statePath string) transferState {
+	var state transferState
+
+	contents, err := b.fs.ReadFile(statePath)
+	if err != nil {
+		return transferState{}
+	}
+
+	err = json.Unmarshal(contents, &state)
+	if err != nil {
+		b.logger.Warn(chunkedLogTag, "Ignoring unreadable transfer state '%s': %s", statePath, err.Error())
+		return transferState{}
+	}
+
+	return state
+}
+
+// saveState failures only cost resumability so they are logged
+func (b chunkedBlobstore) saveState(statePath string, state transferState) {
+	contents, err := json.Marshal(state)
+	if err == nil {
+		err = b.fs.WriteFile(statePath, contents)
+	}
+
+	if err != nil {
+		b.logger.Warn(chunkedLogTag, "Failed to save transfer state '%s': %s", statePath, err.Error())
+	}
+}
+
+func (b chunkedBlobstore) newUUID() string {
+	uuid, err := b.uuidGen.Generate()
+	if err != nil {
+		// Blob ids only need to be unique
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return uuid
+}
+
+func (l *transferLocks) acquire(key string) func() {
+	l.lock.Lock()
+	keyLock, found := l.locks[key]
+	if !found {
+		keyLock = &sync.Mutex{}
+		l.locks[key] = keyLock
+	}
+	l.lock.Unlock()
+
+	keyLock.Lock()
+
+	return keyLock.Unlock
+}
+
+// offsetWriter writes sequentially to file starting at offset
+type offsetWriter struct {
+	file   boshsys.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(data []byte) (int, error) {
+	n, err := w.file.WriteAt(data, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
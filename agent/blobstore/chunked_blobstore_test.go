@@ -0,0 +1,261 @@
+package blobstore_test
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	fakeblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore/fakes"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("chunkedBlobstore", func() {
+	var (
+		client     *fakeblobstore.FakeChunkedClient
+		fs         boshsys.FileSystem
+		uuidGen    *fakeuuid.FakeGenerator
+		tmpDir     string
+		partialDir string
+		logger     boshlog.Logger
+		chunked    boshblob.Blobstore
+		contents   []byte
+		digest     boshcrypto.Digest
+	)
+
+	newBlobstore := func(chunkSize int64, parallelism, attempts int) boshblob.Blobstore {
+		return blobstore.NewChunkedBlobstore(client, fs, uuidGen, partialDir, chunkSize, parallelism, attempts, logger)
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "chunked-blobstore")
+		Expect(err).ToNot(HaveOccurred())
+
+		client = fakeblobstore.NewFakeChunkedClient()
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		uuidGen = fakeuuid.NewFakeGenerator()
+		partialDir = filepath.Join(tmpDir, "partial")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		contents = []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+		digest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, fmt.Sprintf("%x", sha1.Sum(contents)))
+
+		chunked = newBlobstore(10, 2, 2)
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	Describe("Get", func() {
+		BeforeEach(func() {
+			client.Blobs["fake-blob-id"] = contents
+		})
+
+		It("downloads all chunks and returns path to verified blob", func() {
+			fileName, err := chunked.Get("fake-blob-id", digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(filepath.Join(partialDir, "fake-blob-id-fake-uuid-0")))
+
+			downloaded, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(downloaded).To(Equal(contents))
+
+			Expect(client.GetRangeOffsets).To(ConsistOf(int64(0), int64(10), int64(20), int64(30)))
+			Expect(filepath.Join(partialDir, "fake-blob-id.state")).ToNot(BeAnExistingFile())
+		})
+
+		It("retries failed chunks", func() {
+			client.GetRangeErrs[20] = []error{errors.New("fake-get-range-err")}
+
+			fileName, err := chunked.Get("fake-blob-id", digest)
+			Expect(err).ToNot(HaveOccurred())
+
+			downloaded, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(downloaded).To(Equal(contents))
+		})
+
+		It("resumes download with chunks missing after previous download failed", func() {
+			client.GetRangeErrs[20] = []error{errors.New("fake-get-range-err-1"), errors.New("fake-get-range-err-2")}
+
+			_, err := chunked.Get("fake-blob-id", digest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-range-err-2"))
+			Expect(filepath.Join(partialDir, "fake-blob-id.state")).To(BeAnExistingFile())
+
+			client.GetRangeOffsets = nil
+
+			fileName, err := chunked.Get("fake-blob-id", digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.GetRangeOffsets).To(Equal([]int64{20}))
+
+			downloaded, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(downloaded).To(Equal(contents))
+		})
+
+		It("starts over when blob changed size since previous download failed", func() {
+			client.GetRangeErrs[20] = []error{errors.New("fake-get-range-err-1"), errors.New("fake-get-range-err-2")}
+
+			_, err := chunked.Get("fake-blob-id", digest)
+			Expect(err).To(HaveOccurred())
+
+			contents = []byte("changed-contents")
+			client.Blobs["fake-blob-id"] = contents
+			client.GetRangeOffsets = nil
+
+			fileName, err := chunked.Get("fake-blob-id", boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, fmt.Sprintf("%x", sha1.Sum(contents))))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.GetRangeOffsets).To(ConsistOf(int64(0), int64(10)))
+
+			downloaded, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(downloaded).To(Equal(contents))
+		})
+
+		It("removes partial blob when digest does not match so that next download starts over", func() {
+			_, err := chunked.Get("fake-blob-id", boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-wrong-digest"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Checking downloaded blob 'fake-blob-id'"))
+
+			Expect(filepath.Join(partialDir, "fake-blob-id")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(partialDir, "fake-blob-id.state")).ToNot(BeAnExistingFile())
+		})
+
+		It("returns error when size cannot be determined", func() {
+			client.SizeErr = errors.New("fake-size-err")
+
+			_, err := chunked.Get("fake-blob-id", digest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-size-err"))
+		})
+	})
+
+	Describe("Create", func() {
+		var fileName string
+
+		BeforeEach(func() {
+			fileName = filepath.Join(tmpDir, "fake-file")
+			Expect(ioutil.WriteFile(fileName, contents, 0600)).To(Succeed())
+		})
+
+		It("uploads file in chunks", func() {
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-uuid-0"))
+
+			Expect(client.Blobs["fake-uuid-0"]).To(Equal(contents))
+			Expect(client.PutRangeOffsets).To(ConsistOf(int64(0), int64(10), int64(20), int64(30)))
+		})
+
+		It("uploads file of single chunk without ranges", func() {
+			chunked = newBlobstore(100, 2, 2)
+
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.PutBlobIDs).To(Equal([]string{blobID}))
+			Expect(client.Blobs[blobID]).To(Equal(contents))
+			Expect(client.PutRangeOffsets).To(BeEmpty())
+		})
+
+		It("resumes upload to the same blob after previous upload failed", func() {
+			client.PutRangeErrs[10] = []error{errors.New("fake-put-range-err-1"), errors.New("fake-put-range-err-2")}
+
+			_, err := chunked.Create(fileName)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-put-range-err-2"))
+
+			client.PutRangeOffsets = nil
+
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-uuid-0"))
+			Expect(client.PutRangeOffsets).To(Equal([]int64{10}))
+			Expect(client.Blobs[blobID]).To(Equal(contents))
+		})
+
+		It("uploads blob with a single request when blobstore does not support ranged uploads", func() {
+			client.IgnoreContentRange = true
+
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-uuid-0"))
+
+			Expect(client.PutBlobIDs).To(Equal([]string{blobID}))
+			Expect(client.Blobs[blobID]).To(Equal(contents))
+		})
+
+		It("uploads blob with a single request when blobstore rejects ranged uploads", func() {
+			chunked = newBlobstore(10, 1, 2)
+
+			unsupportedErr := blobstore.RangedUploadUnsupportedError{BlobID: "fake-uuid-0", StatusCode: 501}
+			for _, offset := range []int64{0, 10, 20, 30} {
+				client.PutRangeErrs[offset] = []error{unsupportedErr}
+			}
+
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-uuid-0"))
+
+			Expect(client.PutRangeOffsets).To(Equal([]int64{0}))
+			Expect(client.PutBlobIDs).To(Equal([]string{blobID}))
+			Expect(client.Blobs[blobID]).To(Equal(contents))
+
+			stateFiles, err := filepath.Glob(filepath.Join(partialDir, "upload-*.state"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stateFiles).To(BeEmpty())
+		})
+
+		It("keeps upload state when single request upload fails so that next upload resumes", func() {
+			client.IgnoreContentRange = true
+			client.PutErr = errors.New("fake-put-err")
+
+			_, err := chunked.Create(fileName)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-put-err"))
+
+			client.PutErr = nil
+			client.PutRangeOffsets = nil
+
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-uuid-0"))
+			Expect(client.PutRangeOffsets).To(BeEmpty())
+			Expect(client.Blobs[blobID]).To(Equal(contents))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes blob and its partial download", func() {
+			client.Blobs["fake-blob-id"] = contents
+			Expect(os.MkdirAll(partialDir, 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(partialDir, "fake-blob-id"), []byte("partial"), 0600)).To(Succeed())
+
+			Expect(chunked.Delete("fake-blob-id")).To(Succeed())
+			Expect(client.DeletedBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(filepath.Join(partialDir, "fake-blob-id")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("requires positive chunk size, parallelism and attempts", func() {
+			Expect(newBlobstore(10, 2, 2).Validate()).To(Succeed())
+			Expect(newBlobstore(0, 2, 2).Validate()).To(HaveOccurred())
+			Expect(newBlobstore(10, 0, 2).Validate()).To(HaveOccurred())
+			Expect(newBlobstore(10, 2, 0).Validate()).To(HaveOccurred())
+		})
+	})
+})
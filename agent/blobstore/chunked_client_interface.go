@@ -0,0 +1,34 @@
+package blobstore
+
+import (
+	"fmt"
+	"io"
+)
+
+// ChunkedClient transfers parts of blobs so that failed transfers
+// can be resumed from the first missing byte
+type ChunkedClient interface {
+	Size(blobID string) (int64, error)
+
+	// GetRange writes exactly length bytes of blob starting at offset to writer
+	GetRange(blobID string, offset, length int64, writer io.Writer) error
+
+	Put(blobID string, reader io.Reader, size int64) error
+
+	// PutRange stores length bytes from reader at offset of blob of totalSize bytes;
+	// RangedUploadUnsupportedError is returned when blobstore rejects ranged uploads
+	PutRange(blobID string, offset, totalSize int64, reader io.Reader, length int64) error
+
+	Delete(blobID string) error
+}
+
+// RangedUploadUnsupportedError is returned when blobstore rejects uploads
+// of blob ranges so that blob has to be uploaded with a single request
+type RangedUploadUnsupportedError struct {
+	BlobID     string
+	StatusCode int
+}
+
+func (e RangedUploadUnsupportedError) Error() string {
+	return fmt.Sprintf("Putting range of blob '%s': blobstore does not support ranged uploads, responded with status %d", e.BlobID, e.StatusCode)
+}
@@ -0,0 +1,271 @@
+package blobstore
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const davChunkedClientLogTag = "davChunkedClient"
+
+const (
+	davDialTimeout           = 30 * time.Second
+	davTLSHandshakeTimeout   = 30 * time.Second
+	davResponseHeaderTimeout = 2 * time.Minute
+
+	// Connections fail when no data is read or written for this long
+	davIdleTimeout = 2 * time.Minute
+)
+
+type davChunkedClient struct {
+	endpoint   string
+	user       string
+	password   string
+	httpClient *http.Client
+	logger     boshlog.Logger
+}
+
+// NewDAVChunkedClient uses dav blobstore options, i.e. endpoint, user, password
+// and optional tls.cert.ca; blobs are stored at the same paths as by blobstore CLI
+func NewDAVChunkedClient(options map[string]interface{}, logger boshlog.Logger) (ChunkedClient, error) {
+	endpoint, ok := options["endpoint"].(string)
+	if !ok || endpoint == "" {
+		return nil, bosherr.Error("Dav blobstore options must include endpoint")
+	}
+
+	user, _ := options["user"].(string)
+	password, _ := options["password"].(string)
+
+	dialer := &net.Dialer{Timeout: davDialTimeout, KeepAlive: 30 * time.Second}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: func(network, address string) (net.Conn, error) {
+			conn, err := dialer.Dial(network, address)
+			if err != nil {
+				return nil, err
+			}
+			return idleTimeoutConn{Conn: conn, timeout: davIdleTimeout}, nil
+		},
+		TLSHandshakeTimeout:   davTLSHandshakeTimeout,
+		ResponseHeaderTimeout: davResponseHeaderTimeout,
+	}
+
+	caCert := davCACert(options)
+	if caCert != "" {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, bosherr.Error("Parsing dav blobstore CA certificate")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: certPool, MinVersion: tls.VersionTLS12}
+	}
+
+	return davChunkedClient{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		user:       user,
+		password:   password,
+		httpClient: &http.Client{Transport: transport},
+		logger:     logger,
+	}, nil
+}
+
+func davCACert(options map[string]interface{}) string {
+	tlsOptions, _ := options["tls"].(map[string]interface{})
+	certOptions, _ := tlsOptions["cert"].(map[string]interface{})
+	caCert, _ := certOptions["ca"].(string)
+	return caCert
+}
+
+func (c davChunkedClient) Size(blobID string) (int64, error) {
+	response, err := c.do("HEAD", blobID, nil, -1, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, bosherr.Errorf("Getting size of blob '%s': dav blobstore responded with status %d", blobID, response.StatusCode)
+	}
+
+	if response.ContentLength < 0 {
+		return 0, bosherr.Errorf("Getting size of blob '%s': dav blobstore did not respond with content length", blobID)
+	}
+
+	return response.ContentLength, nil
+}
+
+func (c davChunkedClient) GetRange(blobID string, offset, length int64, writer io.Writer) error {
+	if length == 0 {
+		return nil
+	}
+
+	headers := map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)}
+
+	response, err := c.do("GET", blobID, nil, -1, headers)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent:
+	case response.StatusCode == http.StatusOK && offset == 0:
+		// Servers may ignore range requests; leading bytes are still usable
+	default:
+		return bosherr.Errorf("Getting bytes %d-%d of blob '%s': dav blobstore responded with status %d", offset, offset+length-1, blobID, response.StatusCode)
+	}
+
+	_, err = io.CopyN(writer, response.Body, length)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading bytes %d-%d of blob '%s'", offset, offset+length-1, blobID)
+	}
+
+	return nil
+}
+
+func (c davChunkedClient) Put(blobID string, reader io.Reader, size int64) error {
+	return c.put(blobID, reader, size, nil)
+}
+
+// PutRange uploads range with Content-Range header which is not part of
+// HTTP/1.1 for PUT requests; servers that reject it respond with
+// 400 (RFC 7231) or 501 (e.g. nginx)
+func (c davChunkedClient) PutRange(blobID string, offset, totalSize int64, reader io.Reader, length int64) error {
+	headers := map[string]string{"Content-Range": fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, totalSize)}
+
+	err := c.put(blobID, reader, length, headers)
+	if statusErr, ok := err.(davPutStatusError); ok {
+		if statusErr.statusCode == http.StatusBadRequest || statusErr.statusCode == http.StatusNotImplemented {
+			return RangedUploadUnsupportedError{BlobID: blobID, StatusCode: statusErr.statusCode}
+		}
+	}
+
+	return err
+}
+
+type davPutStatusError struct {
+	blobID     string
+	statusCode int
+}
+
+func (e davPutStatusError) Error() string {
+	return fmt.Sprintf("Putting blob '%s': dav blobstore responded with status %d", e.blobID, e.statusCode)
+}
+
+func (c davChunkedClient) put(blobID string, reader io.Reader, length int64, headers map[string]string) error {
+	response, err := c.do("PUT", blobID, reader, length, headers)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return davPutStatusError{blobID: blobID, statusCode: response.StatusCode}
+	}
+
+	return nil
+}
+
+func (c davChunkedClient) Delete(blobID string) error {
+	response, err := c.do("DELETE", blobID, nil, -1, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return bosherr.Errorf("Deleting blob '%s': dav blobstore responded with status %d", blobID, response.StatusCode)
+	}
+
+	return nil
+}
+
+func (c davChunkedClient) do(method, blobID string, body io.Reader, contentLength int64, headers map[string]string) (*http.Response, error) {
+	// Empty body is left out so that zero length is not taken as unknown length
+	if contentLength == 0 {
+		body = nil
+	}
+
+	request, err := http.NewRequest(method, c.blobURL(blobID), body)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Building %s request for blob '%s'", method, blobID)
+	}
+
+	if contentLength >= 0 {
+		request.ContentLength = contentLength
+	}
+
+	if c.user != "" {
+		request.SetBasicAuth(c.user, c.password)
+	}
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	c.logger.Debug(davChunkedClientLogTag, "Sending %s request for blob '%s' %v", method, blobID, headers)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Sending %s request for blob '%s'", method, blobID)
+	}
+
+	return response, nil
+}
+
+// blobURL matches blob layout of dav blobstore CLI which prefixes
+// blob ids with first byte of their sha1 to spread blobs across directories
+func (c davChunkedClient) blobURL(blobID string) string {
+	digest := sha1.Sum([]byte(blobID))
+	return fmt.Sprintf("%s/%02x/%s", c.endpoint, digest[0], blobID)
+}
+
+// idleTimeoutConn extends deadline before every read and write
+// so that stalled transfers fail while slow transfers of large blobs continue
+type idleTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c idleTimeoutConn) Read(b []byte) (int, error) {
+	err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Read(b)
+}
+
+func (c idleTimeoutConn) Write(b []byte) (int, error) {
+	err := c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,320 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+type fakeDAVRequest struct {
+	Method        string
+	Path          string
+	Range         string
+	ContentRange  string
+	ContentLength int64
+	Body          []byte
+}
+
+type fakeDAVServer struct {
+	lock     sync.Mutex
+	requests []fakeDAVRequest
+	status   int
+	body     []byte
+}
+
+func (s *fakeDAVServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	user, password, _ := r.BasicAuth()
+	if user != "fake-user" || password != "fake-password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.requests = append(s.requests, fakeDAVRequest{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Range:         r.Header.Get("Range"),
+		ContentRange:  r.Header.Get("Content-Range"),
+		ContentLength: r.ContentLength,
+		Body:          body,
+	})
+
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(s.body)))
+	w.WriteHeader(s.status)
+	if r.Method == "GET" {
+		_, _ = w.Write(s.body)
+	}
+}
+
+func (s *fakeDAVServer) Requests() []fakeDAVRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests
+}
+
+var _ = Describe("davChunkedClient", func() {
+	var (
+		davServer *fakeDAVServer
+		server    *httptest.Server
+		client    blobstore.ChunkedClient
+		blobPath  string
+	)
+
+	BeforeEach(func() {
+		davServer = &fakeDAVServer{status: http.StatusOK}
+		server = httptest.NewServer(davServer)
+
+		var err error
+		client, err = blobstore.NewDAVChunkedClient(map[string]interface{}{
+			"endpoint": server.URL + "/",
+			"user":     "fake-user",
+			"password": "fake-password",
+		}, boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).ToNot(HaveOccurred())
+
+		blobPath = fmt.Sprintf("/%02x/fake-blob-id", sha1.Sum([]byte("fake-blob-id"))[0])
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("requires endpoint", func() {
+		_, err := blobstore.NewDAVChunkedClient(map[string]interface{}{}, boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid CA certificate", func() {
+		_, err := blobstore.NewDAVChunkedClient(map[string]interface{}{
+			"endpoint": server.URL,
+			"tls":      map[string]interface{}{"cert": map[string]interface{}{"ca": "fake-ca"}},
+		}, boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("Size", func() {
+		It("returns content length of blob", func() {
+			davServer.body = []byte("fake-contents")
+
+			size, err := client.Size("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(size).To(Equal(int64(13)))
+
+			Expect(davServer.Requests()).To(Equal([]fakeDAVRequest{{Method: "HEAD", Path: blobPath, Body: []byte{}}}))
+		})
+
+		It("returns error when blob is not found", func() {
+			davServer.status = http.StatusNotFound
+
+			_, err := client.Size("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("status 404"))
+		})
+	})
+
+	Describe("GetRange", func() {
+		It("requests range of blob", func() {
+			davServer.status = http.StatusPartialContent
+			davServer.body = []byte("fake")
+
+			buffer := &bytes.Buffer{}
+			Expect(client.GetRange("fake-blob-id", 10, 4, buffer)).To(Succeed())
+			Expect(buffer.String()).To(Equal("fake"))
+
+			Expect(davServer.Requests()[0].Range).To(Equal("bytes=10-13"))
+		})
+
+		It("accepts whole blob for first range when server ignores ranges", func() {
+			davServer.body = []byte("fake-contents")
+
+			buffer := &bytes.Buffer{}
+			Expect(client.GetRange("fake-blob-id", 0, 4, buffer)).To(Succeed())
+			Expect(buffer.String()).To(Equal("fake"))
+		})
+
+		It("returns error when server ignores ranges after first one", func() {
+			davServer.body = []byte("fake-contents")
+
+			err := client.GetRange("fake-blob-id", 4, 4, &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("status 200"))
+		})
+
+		It("returns error when server responds with fewer bytes than requested", func() {
+			davServer.status = http.StatusPartialContent
+			davServer.body = []byte("fa")
+
+			err := client.GetRange("fake-blob-id", 0, 4, &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Put", func() {
+		It("uploads whole blob", func() {
+			davServer.status = http.StatusCreated
+
+			Expect(client.Put("fake-blob-id", strings.NewReader("fake-contents"), 13)).To(Succeed())
+
+			request := davServer.Requests()[0]
+			Expect(request.Method).To(Equal("PUT"))
+			Expect(request.Path).To(Equal(blobPath))
+			Expect(request.ContentRange).To(BeEmpty())
+			Expect(string(request.Body)).To(Equal("fake-contents"))
+		})
+
+		It("uploads empty blob with zero content length", func() {
+			davServer.status = http.StatusCreated
+
+			Expect(client.Put("fake-blob-id", strings.NewReader(""), 0)).To(Succeed())
+
+			request := davServer.Requests()[0]
+			Expect(request.ContentLength).To(Equal(int64(0)))
+			Expect(request.Body).To(BeEmpty())
+		})
+
+		It("returns error when server rejects upload", func() {
+			davServer.status = http.StatusForbidden
+
+			err := client.Put("fake-blob-id", strings.NewReader("fake-contents"), 13)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("status 403"))
+		})
+	})
+
+	Describe("PutRange", func() {
+		It("uploads range of blob with content range", func() {
+			davServer.status = http.StatusNoContent
+
+			Expect(client.PutRange("fake-blob-id", 10, 30, strings.NewReader("fake"), 4)).To(Succeed())
+
+			request := davServer.Requests()[0]
+			Expect(request.ContentRange).To(Equal("bytes 10-13/30"))
+			Expect(string(request.Body)).To(Equal("fake"))
+		})
+
+		It("returns ranged upload unsupported error when server does not implement content range", func() {
+			davServer.status = http.StatusNotImplemented
+
+			err := client.PutRange("fake-blob-id", 10, 30, strings.NewReader("fake"), 4)
+			Expect(err).To(Equal(blobstore.RangedUploadUnsupportedError{BlobID: "fake-blob-id", StatusCode: 501}))
+		})
+
+		It("returns ranged upload unsupported error when server rejects content range as bad request", func() {
+			davServer.status = http.StatusBadRequest
+
+			err := client.PutRange("fake-blob-id", 10, 30, strings.NewReader("fake"), 4)
+			Expect(err).To(Equal(blobstore.RangedUploadUnsupportedError{BlobID: "fake-blob-id", StatusCode: 400}))
+		})
+
+		It("returns error when server rejects upload for other reasons", func() {
+			davServer.status = http.StatusForbidden
+
+			err := client.PutRange("fake-blob-id", 10, 30, strings.NewReader("fake"), 4)
+			Expect(err).To(HaveOccurred())
+			Expect(err).ToNot(BeAssignableToTypeOf(blobstore.RangedUploadUnsupportedError{}))
+			Expect(err.Error()).To(ContainSubstring("status 403"))
+		})
+	})
+
+	Context("when used by chunked blobstore", func() {
+		var (
+			nginxServer *httptest.Server
+			blobs       map[string][]byte
+			blobsLock   sync.Mutex
+			tmpDir      string
+		)
+
+		BeforeEach(func() {
+			blobs = map[string][]byte{}
+
+			// Like nginx, server does not implement PUT requests with Content-Range
+			nginxServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+
+				blobsLock.Lock()
+				defer blobsLock.Unlock()
+
+				switch {
+				case r.Method == "PUT" && r.Header.Get("Content-Range") != "":
+					w.WriteHeader(http.StatusNotImplemented)
+				case r.Method == "PUT":
+					blobs[r.URL.Path] = body
+					w.WriteHeader(http.StatusCreated)
+				case r.Method == "HEAD":
+					blob, found := blobs[r.URL.Path]
+					if !found {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+					w.WriteHeader(http.StatusOK)
+				default:
+					w.WriteHeader(http.StatusMethodNotAllowed)
+				}
+			}))
+
+			var err error
+			client, err = blobstore.NewDAVChunkedClient(map[string]interface{}{"endpoint": nginxServer.URL}, boshlog.NewLogger(boshlog.LevelNone))
+			Expect(err).ToNot(HaveOccurred())
+
+			tmpDir, err = ioutil.TempDir("", "dav-chunked-client")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			nginxServer.Close()
+			_ = os.RemoveAll(tmpDir)
+		})
+
+		It("uploads blob larger than a chunk with a single request when server rejects ranged uploads", func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			fs := boshsys.NewOsFileSystem(logger)
+
+			fileName := filepath.Join(tmpDir, "fake-file")
+			Expect(ioutil.WriteFile(fileName, []byte("0123456789abcdefghijklmnopqrstuvwxyz"), 0600)).To(Succeed())
+
+			chunked := blobstore.NewChunkedBlobstore(client, fs, fakeuuid.NewFakeGenerator(), filepath.Join(tmpDir, "partial"), 10, 2, 3, logger)
+
+			blobID, err := chunked.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			blobsLock.Lock()
+			defer blobsLock.Unlock()
+			Expect(blobs[fmt.Sprintf("/%02x/%s", sha1.Sum([]byte(blobID))[0], blobID)]).To(Equal([]byte("0123456789abcdefghijklmnopqrstuvwxyz")))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes blob", func() {
+			davServer.status = http.StatusNoContent
+
+			Expect(client.Delete("fake-blob-id")).To(Succeed())
+			Expect(davServer.Requests()[0].Method).To(Equal("DELETE"))
+		})
+
+		It("treats missing blob as deleted", func() {
+			davServer.status = http.StatusNotFound
+
+			Expect(client.Delete("fake-blob-id")).To(Succeed())
+		})
+	})
+})
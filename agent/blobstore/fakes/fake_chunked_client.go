@@ -0,0 +1,161 @@
+package fakes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// FakeChunkedClient keeps blobs in memory; GetRangeErrs and PutRangeErrs
+// are returned by calls for given offsets until used up
+type FakeChunkedClient struct {
+	Blobs map[string][]byte
+
+	SizeErr   error
+	PutErr    error
+	DeleteErr error
+
+	GetRangeErrs map[int64][]error
+	PutRangeErrs map[int64][]error
+
+	// IgnoreContentRange stores only the last uploaded chunk like servers without ranged uploads
+	IgnoreContentRange bool
+
+	GetRangeOffsets []int64
+	PutRangeOffsets []int64
+	PutBlobIDs      []string
+	DeletedBlobIDs  []string
+
+	lock sync.Mutex
+}
+
+func NewFakeChunkedClient() *FakeChunkedClient {
+	return &FakeChunkedClient{
+		Blobs:        map[string][]byte{},
+		GetRangeErrs: map[int64][]error{},
+		PutRangeErrs: map[int64][]error{},
+	}
+}
+
+func (c *FakeChunkedClient) Size(blobID string) (int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.SizeErr != nil {
+		return 0, c.SizeErr
+	}
+
+	blob, found := c.Blobs[blobID]
+	if !found {
+		return 0, fmt.Errorf("fake-blob-not-found '%s'", blobID)
+	}
+
+	return int64(len(blob)), nil
+}
+
+func (c *FakeChunkedClient) GetRange(blobID string, offset, length int64, writer io.Writer) error {
+	c.lock.Lock()
+	c.GetRangeOffsets = append(c.GetRangeOffsets, offset)
+	err := c.nextErr(c.GetRangeErrs, offset)
+	blob, found := c.Blobs[blobID]
+	c.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("fake-blob-not-found '%s'", blobID)
+	}
+
+	_, err = writer.Write(blob[offset : offset+length])
+	return err
+}
+
+func (c *FakeChunkedClient) Put(blobID string, reader io.Reader, size int64) error {
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.PutBlobIDs = append(c.PutBlobIDs, blobID)
+
+	if c.PutErr != nil {
+		return c.PutErr
+	}
+
+	if int64(len(contents)) != size {
+		return errors.New("fake-put-size-mismatch")
+	}
+
+	c.Blobs[blobID] = contents
+
+	return nil
+}
+
+func (c *FakeChunkedClient) PutRange(blobID string, offset, totalSize int64, reader io.Reader, length int64) error {
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.PutRangeOffsets = append(c.PutRangeOffsets, offset)
+
+	err = c.nextErr(c.PutRangeErrs, offset)
+	if err != nil {
+		return err
+	}
+
+	if int64(len(contents)) != length {
+		return errors.New("fake-put-range-length-mismatch")
+	}
+
+	if c.IgnoreContentRange {
+		c.Blobs[blobID] = contents
+		return nil
+	}
+
+	blob, found := c.Blobs[blobID]
+	if !found || int64(len(blob)) != totalSize {
+		blob = make([]byte, totalSize)
+		c.Blobs[blobID] = blob
+	}
+
+	copy(blob[offset:], contents)
+
+	return nil
+}
+
+func (c *FakeChunkedClient) Delete(blobID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.DeletedBlobIDs = append(c.DeletedBlobIDs, blobID)
+
+	if c.DeleteErr != nil {
+		return c.DeleteErr
+	}
+
+	delete(c.Blobs, blobID)
+
+	return nil
+}
+
+func (c *FakeChunkedClient) nextErr(errs map[int64][]error, offset int64) error {
+	offsetErrs := errs[offset]
+	if len(offsetErrs) == 0 {
+		return nil
+	}
+
+	errs[offset] = offsetErrs[1:]
+
+	return offsetErrs[0]
+}
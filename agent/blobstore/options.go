@@ -0,0 +1,39 @@
+package blobstore
+
+// Options configure how agent transfers blobs
+type Options struct {
+	// Blobs of dav blobstores are transferred in chunks by agent
+	// instead of blobstore CLI when set; downloads resume from partial files
+	// and uploads of multiple chunks require server support of Content-Range on PUT
+	ChunkedTransfers bool
+
+	ChunkSizeInMB int
+	Parallelism   int
+	ChunkAttempts int
+}
+
+// BlobstoreTypeDav is the only blobstore type with chunked transfers
+const BlobstoreTypeDav = "dav"
+
+const (
+	defaultChunkSizeInMB = 64
+	defaultParallelism   = 4
+	defaultChunkAttempts = 3
+)
+
+// WithDefaults returns options with zero values replaced by defaults
+func (o Options) WithDefaults() Options {
+	if o.ChunkSizeInMB == 0 {
+		o.ChunkSizeInMB = defaultChunkSizeInMB
+	}
+
+	if o.Parallelism == 0 {
+		o.Parallelism = defaultParallelism
+	}
+
+	if o.ChunkAttempts == 0 {
+		o.ChunkAttempts = defaultChunkAttempts
+	}
+
+	return o
+}
@@ -0,0 +1,95 @@
+package blobstore
+
+import (
+	"sync"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshUtilsBlobStore "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const reloadableLogTag = "reloadableBlobstore"
+
+// Factory builds blobstore configured with blobstore settings
+type Factory func(blobstoreSettings boshsettings.Blobstore) (boshUtilsBlobStore.Blobstore, error)
+
+// ReloadableBlobstore rebuilds its blobstore when blobstore settings change,
+// e.g. when blobstore credentials are rotated
+type ReloadableBlobstore interface {
+	boshUtilsBlobStore.Blobstore
+	boshsettings.Subscriber
+}
+
+type reloadableBlobstore struct {
+	factory Factory
+	logger  boshlog.Logger
+
+	innerBlobstore boshUtilsBlobStore.Blobstore
+	lock           sync.RWMutex
+}
+
+func NewReloadableBlobstore(
+	factory Factory,
+	blobstoreSettings boshsettings.Blobstore,
+	logger boshlog.Logger,
+) (ReloadableBlobstore, error) {
+	innerBlobstore, err := factory(blobstoreSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reloadableBlobstore{
+		factory:        factory,
+		logger:         logger,
+		innerBlobstore: innerBlobstore,
+	}, nil
+}
+
+func (b *reloadableBlobstore) SettingsChanged(diff boshsettings.Diff) error {
+	if !diff.BlobstoreChanged {
+		return nil
+	}
+
+	// Blobstore with previous settings keeps being used when new settings are invalid
+	innerBlobstore, err := b.factory(diff.New.Blobstore)
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading blobstore")
+	}
+
+	b.lock.Lock()
+	b.innerBlobstore = innerBlobstore
+	b.lock.Unlock()
+
+	b.logger.Info(reloadableLogTag, "Reloaded blobstore with changed settings")
+
+	return nil
+}
+
+func (b *reloadableBlobstore) Get(blobID string, digest boshcrypto.Digest) (string, error) {
+	return b.blobstore().Get(blobID, digest)
+}
+
+func (b *reloadableBlobstore) CleanUp(fileName string) error {
+	return b.blobstore().CleanUp(fileName)
+}
+
+func (b *reloadableBlobstore) Create(fileName string) (string, error) {
+	return b.blobstore().Create(fileName)
+}
+
+func (b *reloadableBlobstore) Validate() error {
+	return b.blobstore().Validate()
+}
+
+func (b *reloadableBlobstore) Delete(blobID string) error {
+	return b.blobstore().Delete(blobID)
+}
+
+func (b *reloadableBlobstore) blobstore() boshUtilsBlobStore.Blobstore {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.innerBlobstore
+}
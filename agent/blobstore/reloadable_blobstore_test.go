@@ -0,0 +1,102 @@
+package blobstore_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
+	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("reloadableBlobstore", func() {
+	var (
+		blobstores          map[string]*fakeblob.FakeBlobstore
+		factoryErr          error
+		factorySettings     []boshsettings.Blobstore
+		reloadableBlobstore blobstore.ReloadableBlobstore
+	)
+
+	BeforeEach(func() {
+		blobstores = map[string]*fakeblob.FakeBlobstore{
+			"fake-old-provider": {CreateBlobID: "fake-old-blob-id"},
+			"fake-new-provider": {CreateBlobID: "fake-new-blob-id"},
+		}
+		factoryErr = nil
+		factorySettings = nil
+
+		factory := func(blobstoreSettings boshsettings.Blobstore) (boshblob.Blobstore, error) {
+			factorySettings = append(factorySettings, blobstoreSettings)
+			if factoryErr != nil {
+				return nil, factoryErr
+			}
+			return blobstores[blobstoreSettings.Type], nil
+		}
+
+		var err error
+		reloadableBlobstore, err = blobstore.NewReloadableBlobstore(
+			factory,
+			boshsettings.Blobstore{Type: "fake-old-provider"},
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("delegates to blobstore built with initial settings", func() {
+		blobID, err := reloadableBlobstore.Create("fake-file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("fake-old-blob-id"))
+	})
+
+	It("returns error when initial blobstore cannot be built", func() {
+		factory := func(boshsettings.Blobstore) (boshblob.Blobstore, error) {
+			return nil, errors.New("fake-factory-err")
+		}
+
+		_, err := blobstore.NewReloadableBlobstore(factory, boshsettings.Blobstore{}, boshlog.NewLogger(boshlog.LevelNone))
+		Expect(err).To(MatchError("fake-factory-err"))
+	})
+
+	Describe("SettingsChanged", func() {
+		newDiff := func() boshsettings.Diff {
+			return boshsettings.NewDiff(
+				boshsettings.Settings{Blobstore: boshsettings.Blobstore{Type: "fake-old-provider"}},
+				boshsettings.Settings{Blobstore: boshsettings.Blobstore{Type: "fake-new-provider"}},
+			)
+		}
+
+		It("rebuilds blobstore when blobstore settings changed", func() {
+			err := reloadableBlobstore.SettingsChanged(newDiff())
+			Expect(err).ToNot(HaveOccurred())
+
+			blobID, err := reloadableBlobstore.Create("fake-file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-new-blob-id"))
+		})
+
+		It("does not rebuild blobstore when blobstore settings did not change", func() {
+			err := reloadableBlobstore.SettingsChanged(boshsettings.NewDiff(
+				boshsettings.Settings{AgentID: "fake-old-agent-id"},
+				boshsettings.Settings{AgentID: "fake-new-agent-id"},
+			))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(factorySettings).To(HaveLen(1))
+		})
+
+		It("keeps using previous blobstore when new blobstore cannot be built", func() {
+			factoryErr = errors.New("fake-factory-err")
+
+			err := reloadableBlobstore.SettingsChanged(newDiff())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-factory-err"))
+
+			blobID, err := reloadableBlobstore.Create("fake-file")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-old-blob-id"))
+		})
+	})
+})
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"path"
 	"path/filepath"
+	"strings"
 
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -15,6 +19,13 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+const (
+	bootstrapLogTag = "bootstrap"
+
+	// networkRollbackFileName keeps network rollback until it is reported
+	networkRollbackFileName = "network_rollback.json"
+)
+
 type Bootstrap interface {
 	Run() error
 }
@@ -85,7 +96,7 @@ func (boot bootstrap) Run() (err error) {
 		return bosherr.WrapError(err, "Setting up hostname")
 	}
 
-	if err = boot.platform.SetupNetworking(settings.Networks); err != nil {
+	if err = boot.setupNetworking(settings); err != nil {
 		return bosherr.WrapError(err, "Setting up networking")
 	}
 
@@ -97,8 +108,9 @@ func (boot bootstrap) Run() (err error) {
 		return bosherr.WrapError(err, "Setting up raw ephemeral disk")
 	}
 
-	ephemeralDiskPath := boot.platform.GetEphemeralDiskPath(settings.EphemeralDiskSettings())
-	if err = boot.platform.SetupEphemeralDiskWithPath(ephemeralDiskPath); err != nil {
+	ephemeralDiskSettings := settings.EphemeralDiskSettings()
+	ephemeralDiskPath := boot.platform.GetEphemeralDiskPath(ephemeralDiskSettings)
+	if err = boot.platform.SetupEphemeralDiskWithPath(ephemeralDiskPath, ephemeralDiskSettings.MountOptions); err != nil {
 		return bosherr.WrapError(err, "Setting up ephemeral disk")
 	}
 
@@ -177,6 +189,51 @@ func (boot bootstrap) Run() (err error) {
 	return nil
 }
 
+func (boot bootstrap) setupNetworking(settings boshsettings.Settings) error {
+	var reachableTargets []string
+
+	// Agent connects to NATS; for other mbus schemes director connects to agent
+	mbusURL, err := url.Parse(settings.Mbus)
+	if err == nil && mbusURL.Scheme == "nats" && mbusURL.Host != "" {
+		reachableTargets = append(reachableTargets, urlTarget(mbusURL, "4222"))
+	}
+
+	// Registry is contacted again whenever settings are refreshed
+	source := boot.settingsService.GetSettingsInfo().Source
+	if source.Type == "Registry" {
+		registryURL, err := url.Parse(source.Endpoint)
+		if err == nil && registryURL.Host != "" {
+			switch registryURL.Scheme {
+			case "http":
+				reachableTargets = append(reachableTargets, urlTarget(registryURL, "80"))
+			case "https":
+				reachableTargets = append(reachableTargets, urlTarget(registryURL, "443"))
+			}
+		}
+	}
+
+	err = boot.platform.SetupNetworkingWithRollback(settings.Networks, reachableTargets)
+	if rollbackErr, ok := err.(boshnet.NetworkRollbackError); ok {
+		// Agent is expected to be reachable with previous networks
+		// so that it can report the rollback once connected
+		boot.logger.Error(bootstrapLogTag, "Networks were rolled back: %s", rollbackErr.Error())
+
+		contents, err := json.Marshal(rollbackErr)
+		if err != nil {
+			return bosherr.WrapError(err, "Marshalling network rollback")
+		}
+
+		err = boot.fs.WriteFile(filepath.Join(boot.dirProvider.BoshDir(), networkRollbackFileName), contents)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing network rollback")
+		}
+
+		return nil
+	}
+
+	return err
+}
+
 func (boot bootstrap) comparePersistentDisk() error {
 	settings := boot.settingsService.GetSettings()
 	updateSettingsPath := filepath.Join(boot.platform.GetDirProvider().BoshDir(), "update_settings.json")
@@ -267,3 +324,14 @@ func (boot bootstrap) lastMountedCid() (string, error) {
 
 	return "", nil
 }
+
+// urlTarget returns host:port of URL using default port when URL does not include one
+func urlTarget(targetURL *url.URL, defaultPort string) string {
+	if _, _, err := net.SplitHostPort(targetURL.Host); err == nil {
+		return targetURL.Host
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(targetURL.Host, "["), "]")
+
+	return net.JoinHostPort(host, defaultPort)
+}
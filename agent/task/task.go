@@ -6,6 +6,8 @@ type CancelFunc func(task Task) error
 
 type EndFunc func(task Task)
 
+type ProgressFunc func() interface{}
+
 type State string
 
 const (
@@ -20,9 +22,10 @@ type Task struct {
 	Value interface{}
 	Error error
 
-	Func       Func
-	CancelFunc CancelFunc
-	EndFunc    EndFunc
+	Func         Func
+	CancelFunc   CancelFunc
+	EndFunc      EndFunc
+	ProgressFunc ProgressFunc
 }
 
 func (t Task) Cancel() error {
@@ -32,7 +35,16 @@ func (t Task) Cancel() error {
 	return nil
 }
 
+// Progress returns nil when task does not report progress
+func (t Task) Progress() interface{} {
+	if t.ProgressFunc != nil {
+		return t.ProgressFunc()
+	}
+	return nil
+}
+
 type StateValue struct {
-	AgentTaskID string `json:"agent_task_id"`
-	State       State  `json:"state"`
+	AgentTaskID string      `json:"agent_task_id"`
+	State       State       `json:"state"`
+	Progress    interface{} `json:"progress,omitempty"`
 }
@@ -23,6 +23,7 @@ import (
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
+	boshdns "github.com/cloudfoundry/bosh-agent/localdns"
 	boshmbus "github.com/cloudfoundry/bosh-agent/mbus"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
@@ -96,14 +97,28 @@ func (app *app) Setup(args []string) error {
 		return bosherr.WrapError(err, "Getting Settings Source")
 	}
 
+	settingsKeyProvider := boshsettings.NewMachineKeyProvider(app.platform.GetFs())
+	if config.Infrastructure.Settings.EncryptionKeyPath != "" {
+		settingsKeyProvider = boshsettings.NewFileKeyProvider(app.platform.GetFs(), config.Infrastructure.Settings.EncryptionKeyPath)
+	}
+
 	settingsService := boshsettings.NewService(
 		app.platform.GetFs(),
 		filepath.Join(app.dirProvider.BoshDir(), "settings.json"),
 		settingsSource,
 		app.platform,
+		boshsettings.NewAESGCMCipher(settingsKeyProvider),
+		timeService,
 		app.logger,
 	)
 
+	if config.Platform.Linux.LocalDNSAddress != "" {
+		err = app.startLocalDNSServer(config.Platform.Linux.LocalDNSAddress, timeService)
+		if err != nil {
+			return bosherr.WrapError(err, "Starting local DNS server")
+		}
+	}
+
 	boot := boshagent.NewBootstrap(
 		app.platform,
 		app.dirProvider,
@@ -123,12 +138,25 @@ func (app *app) Setup(args []string) error {
 	}
 
 	blobManager := boshblob.NewBlobManager(app.platform.GetFs(), app.dirProvider.BlobsDir())
-	blobstore, err := app.setupBlobstore(settingsService.GetSettings().Blobstore, blobManager)
+	blobstore, err := boshagentblobstore.NewReloadableBlobstore(
+		func(blobstoreSettings boshsettings.Blobstore) (boshblob.Blobstore, error) {
+			return app.setupBlobstore(blobstoreSettings, config.Blobstore.WithDefaults(), blobManager)
+		},
+		settingsService.GetSettings().Blobstore,
+		app.logger,
+	)
 
 	if err != nil {
 		return bosherr.WrapError(err, "Getting blobstore")
 	}
 
+	settingsService.Subscribe(boshplatform.NewSettingsSubscriber(app.platform, app.logger))
+	settingsService.Subscribe(blobstore)
+
+	if mbusSubscriber, ok := mbusHandler.(boshsettings.Subscriber); ok {
+		settingsService.Subscribe(mbusSubscriber)
+	}
+
 	monitClientProvider := boshmonit.NewProvider(app.platform, app.logger)
 
 	monitClient, err := monitClientProvider.Get()
@@ -218,6 +246,11 @@ func (app *app) Setup(args []string) error {
 		timeService,
 	)
 
+	if config.Infrastructure.Settings.RefreshIntervalInSeconds > 0 {
+		refreshInterval := time.Duration(config.Infrastructure.Settings.RefreshIntervalInSeconds) * time.Second
+		boshsettings.NewRefresher(settingsService, timeService, app.logger).Start(refreshInterval)
+	}
+
 	return nil
 }
 
@@ -233,6 +266,20 @@ func (app *app) GetPlatform() boshplatform.Platform {
 	return app.platform
 }
 
+// startLocalDNSServer starts serving synced DNS records before bootstrap
+// configures the local DNS server as the first nameserver
+func (app *app) startLocalDNSServer(address string, timeService clock.Clock) error {
+	fs := app.platform.GetFs()
+
+	handler := boshdns.NewHandler(
+		boshdns.NewFileRecordsProvider(fs, boshdns.RecordsPath(app.dirProvider), timeService, app.logger),
+		boshdns.NewResolvConfForwarder(fs, "/etc/resolv.conf", address, app.logger),
+		app.logger,
+	)
+
+	return boshdns.NewServer(net.JoinHostPort(address, "53"), handler, app.logger).Start()
+}
+
 func (app *app) buildApplierAndCompiler(
 	dirProvider boshdirs.Provider,
 	blobstore boshblob.Blobstore,
@@ -319,7 +366,11 @@ func (app *app) fileContents(path string) string {
 	return contents
 }
 
-func (app *app) setupBlobstore(blobstoreSettings boshsettings.Blobstore, blobManager boshblob.BlobManagerInterface) (boshblob.Blobstore, error) {
+func (app *app) setupBlobstore(blobstoreSettings boshsettings.Blobstore, options boshagentblobstore.Options, blobManager boshblob.BlobManagerInterface) (boshblob.Blobstore, error) {
+	if options.ChunkedTransfers && blobstoreSettings.Type == boshagentblobstore.BlobstoreTypeDav {
+		return app.setupChunkedBlobstore(blobstoreSettings, options, blobManager)
+	}
+
 	blobstoreProvider := boshblob.NewProvider(
 		app.platform.GetFs(),
 		app.platform.GetRunner(),
@@ -334,3 +385,32 @@ func (app *app) setupBlobstore(blobstoreSettings boshsettings.Blobstore, blobMan
 
 	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManager, app.logger), nil
 }
+
+func (app *app) setupChunkedBlobstore(blobstoreSettings boshsettings.Blobstore, options boshagentblobstore.Options, blobManager boshblob.BlobManagerInterface) (boshblob.Blobstore, error) {
+	client, err := boshagentblobstore.NewDAVChunkedClient(blobstoreSettings.Options, app.logger)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting chunked blobstore client")
+	}
+
+	chunkedBlobstore := boshagentblobstore.NewChunkedBlobstore(
+		client,
+		app.platform.GetFs(),
+		boshuuid.NewGenerator(),
+		filepath.Join(app.dirProvider.BlobsDir(), "partial"),
+		int64(options.ChunkSizeInMB)*1024*1024,
+		options.Parallelism,
+		options.ChunkAttempts,
+		app.logger,
+	)
+
+	// Whole transfers are retried the same way as by blobstore provider,
+	// each retry resumes with chunks missing
+	blobstore := boshblob.NewRetryableBlobstore(chunkedBlobstore, 3, app.logger)
+
+	err = blobstore.Validate()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Validating chunked blobstore")
+	}
+
+	return boshagentblobstore.NewCascadingBlobstore(blobstore, blobManager, app.logger), nil
+}
@@ -2,7 +2,13 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
 
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -12,8 +18,11 @@ import (
 type Config struct {
 	Platform       boshplatform.Options
 	Infrastructure boshinf.Options
+	Blobstore      boshagentblobstore.Options
 }
 
+// LoadConfigFromPath rejects unknown keys so that misspelled options
+// are not silently ignored, and validates loaded config
 func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
 	var config Config
 
@@ -21,15 +30,219 @@ func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
 		return config, nil
 	}
 
-	bytes, err := fs.ReadFile(path)
+	contents, err := fs.ReadFile(path)
 	if err != nil {
 		return config, bosherr.WrapError(err, "Reading file")
 	}
 
-	err = json.Unmarshal(bytes, &config)
+	err = json.Unmarshal(contents, &config)
 	if err != nil {
 		return config, bosherr.WrapError(err, "Loading file")
 	}
 
+	var rawConfig map[string]interface{}
+
+	err = json.Unmarshal(contents, &rawConfig)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Loading file")
+	}
+
+	if keys := unknownKeys("", rawConfig, reflect.TypeOf(config)); len(keys) > 0 {
+		return config, bosherr.Errorf("Loading file: Unknown keys %s", strings.Join(keys, ", "))
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return config, bosherr.WrapError(err, "Validating file")
+	}
+
 	return config, nil
 }
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownKeys returns paths of keys which do not match any field of
+// corresponding struct, e.g. Platform.Linux.SkipDiskSetupp; values of types
+// which unmarshal themselves are expected to reject unknown keys on their own
+func unknownKeys(path string, value interface{}, valueType reflect.Type) []string {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	if reflect.PtrTo(valueType).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	var keys []string
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		fields := jsonFields(valueType)
+
+		for _, key := range sortedKeys(valueMap) {
+			field, found := findJSONField(fields, key)
+			if !found {
+				keys = append(keys, joinKeyPath(path, key))
+				continue
+			}
+
+			keys = append(keys, unknownKeys(joinKeyPath(path, key), valueMap[key], field.Type)...)
+		}
+
+	case reflect.Map:
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		for _, key := range sortedKeys(valueMap) {
+			keys = append(keys, unknownKeys(joinKeyPath(path, key), valueMap[key], valueType.Elem())...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+
+		for i, elem := range values {
+			keys = append(keys, unknownKeys(fmt.Sprintf("%s[%d]", path, i), elem, valueType.Elem())...)
+		}
+	}
+
+	return keys
+}
+
+// jsonFields returns exported fields of struct including
+// fields promoted from embedded structs
+func jsonFields(structType reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// findJSONField matches key case insensitively the way encoding/json does
+func findJSONField(fields []reflect.StructField, key string) (reflect.StructField, bool) {
+	for _, field := range fields {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	var keys []string
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Validate checks option values and combinations of options
+// which cannot be used together; returned error lists every problem found
+func (c Config) Validate() error {
+	var problems []string
+
+	linux := c.Platform.Linux
+
+	if linux.SkipDiskSetup && linux.CreatePartitionIfNoEphemeralDisk {
+		problems = append(problems, "Platform.Linux.SkipDiskSetup cannot be used with Platform.Linux.CreatePartitionIfNoEphemeralDisk")
+	}
+
+	if linux.SkipDiskSetup && linux.ScrubEphemeralDisk {
+		problems = append(problems, "Platform.Linux.SkipDiskSetup cannot be used with Platform.Linux.ScrubEphemeralDisk")
+	}
+
+	problems = append(problems, validateOneOf("Platform.Linux.DevicePathResolutionType", linux.DevicePathResolutionType, "virtio", "scsi")...)
+	problems = append(problems, validateOneOf("Platform.Linux.PartitionerType", linux.PartitionerType, "parted")...)
+	problems = append(problems, validateOneOf("Platform.Linux.PersistentDiskFSCheckMode", linux.PersistentDiskFSCheckMode, "off", "check", "repair")...)
+	problems = append(problems, validateOneOf("Platform.Linux.NetworkManagerType", linux.NetworkManagerType, "networkd")...)
+
+	problems = append(problems, validateNotNegative("Platform.Linux.DiskTrimIntervalInSeconds", linux.DiskTrimIntervalInSeconds)...)
+	problems = append(problems, validateNotNegative("Platform.Linux.DNSHealthCheckIntervalInSeconds", linux.DNSHealthCheckIntervalInSeconds)...)
+	problems = append(problems, validateNotNegative("Infrastructure.Settings.RefreshIntervalInSeconds", c.Infrastructure.Settings.RefreshIntervalInSeconds)...)
+	problems = append(problems, validateNotNegative("Blobstore.ChunkSizeInMB", c.Blobstore.ChunkSizeInMB)...)
+	problems = append(problems, validateNotNegative("Blobstore.Parallelism", c.Blobstore.Parallelism)...)
+	problems = append(problems, validateNotNegative("Blobstore.ChunkAttempts", c.Blobstore.ChunkAttempts)...)
+
+	if linux.LocalDNSAddress != "" && net.ParseIP(linux.LocalDNSAddress) == nil {
+		problems = append(problems, fmt.Sprintf("Platform.Linux.LocalDNSAddress must be an IP address, got '%s'", linux.LocalDNSAddress))
+	}
+
+	if len(problems) > 0 {
+		return bosherr.Errorf("Invalid config: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateOneOf allows empty values which select default behaviour
+func validateOneOf(path, value string, allowedValues ...string) []string {
+	if value == "" {
+		return nil
+	}
+
+	for _, allowedValue := range allowedValues {
+		if value == allowedValue {
+			return nil
+		}
+	}
+
+	return []string{fmt.Sprintf("%s must be one of %s, got '%s'", path, strings.Join(allowedValues, ", "), value)}
+}
+
+func validateNotNegative(path string, value int) []string {
+	if value < 0 {
+		return []string{fmt.Sprintf("%s must not be negative, got %d", path, value)}
+	}
+	return nil
+}
+
+// WithDefaults returns config with defaults filled in the way agent uses it
+func (c Config) WithDefaults() Config {
+	c.Infrastructure.Settings = c.Infrastructure.Settings.WithDefaults()
+	c.Blobstore = c.Blobstore.WithDefaults()
+	return c
+}
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshagentblobstore "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -34,7 +35,8 @@ var _ = Describe("LoadConfigFromPath", func() {
 				  "Sources": [
 				  	{
 					  	"Type": "HTTP",
-					  	"URI": "http://fake-uri"
+					  	"URI": "http://fake-uri",
+					  	"Token": {"Path": "/fake-token-path", "TTLInSeconds": 300}
 					  },
 					  {
 					  	"Type": "ConfigDrive",
@@ -58,6 +60,24 @@ var _ = Describe("LoadConfigFromPath", func() {
 						"URI": "/fake-uri",
 						"Headers": {"fake": "headers"},
 						"SettingsPath": "/fake-settings-path"
+					  },
+					  {
+						"Type": "NoCloud",
+						"SeedDirs": ["/fake-seed-dir"],
+						"DiskPaths": ["/fake-disk-path"]
+					  },
+					  {
+						"Type": "OpenStack",
+						"URI": "http://fake-openstack-uri"
+					  },
+					  {
+						"Type": "GCE",
+						"SettingsAttribute": "fake-attribute"
+					  },
+					  {
+						"Type": "Azure",
+						"OVFEnvFileName": "fake-ovf-env.xml",
+						"ReportReady": true
 					  }
 				  ],
 				  "UseServerName": true,
@@ -82,7 +102,8 @@ var _ = Describe("LoadConfigFromPath", func() {
 				Settings: boshinf.SettingsOptions{
 					Sources: []boshinf.SourceOptions{
 						boshinf.HTTPSourceOptions{
-							URI: "http://fake-uri",
+							URI:   "http://fake-uri",
+							Token: boshinf.TokenOptions{Path: "/fake-token-path", TTLInSeconds: 300},
 						},
 						boshinf.ConfigDriveSourceOptions{
 							DiskPaths:    []string{"/fake-disk-path1", "/fake-disk-path2"},
@@ -103,6 +124,20 @@ var _ = Describe("LoadConfigFromPath", func() {
 							Headers:      map[string]string{"fake": "headers"},
 							SettingsPath: "/fake-settings-path",
 						},
+						boshinf.NoCloudSourceOptions{
+							SeedDirs:  []string{"/fake-seed-dir"},
+							DiskPaths: []string{"/fake-disk-path"},
+						},
+						boshinf.OpenStackSourceOptions{
+							URI: "http://fake-openstack-uri",
+						},
+						boshinf.GCESourceOptions{
+							SettingsAttribute: "fake-attribute",
+						},
+						boshinf.AzureSourceOptions{
+							OVFEnvFileName: "fake-ovf-env.xml",
+							ReportReady:    true,
+						},
 					},
 					UseServerName: true,
 					UseRegistry:   true,
@@ -273,4 +308,111 @@ var _ = Describe("LoadConfigFromPath", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("Unmarshalling source type 'CDROM'"))
 	})
+
+	It("returns an error when config has unknown keys", func() {
+		fs.WriteFileString("/fake-config.conf", `{
+			"Platform": {
+				"Linux": {"SkipDiskSetupp": true, "skipdisksetup": true}
+			},
+			"Blobstore": {"Chunksize": 1}
+		}`)
+
+		_, err := LoadConfigFromPath(fs, "/fake-config.conf")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown keys Blobstore.Chunksize, Platform.Linux.SkipDiskSetupp"))
+	})
+
+	It("returns an error when source options have unknown keys", func() {
+		fs.WriteFileString("/fake-config.conf", `{
+			"Infrastructure": {
+			  "Settings": {
+				  "Sources": [{
+				  	"Type": "HTTP",
+				  	"URI": "http://fake-uri",
+				  	"UserDataPth": "/fake-user-data-path"
+				  }]
+				}
+			}
+		}`)
+
+		_, err := LoadConfigFromPath(fs, "/fake-config.conf")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling source type 'HTTP'"))
+		Expect(err.Error()).To(ContainSubstring("UserDataPth"))
+	})
+
+	It("returns an error when config is invalid", func() {
+		fs.WriteFileString("/fake-config.conf", `{
+			"Platform": {
+				"Linux": {
+					"SkipDiskSetup": true,
+					"CreatePartitionIfNoEphemeralDisk": true,
+					"PartitionerType": "fake-partitioner"
+				}
+			}
+		}`)
+
+		_, err := LoadConfigFromPath(fs, "/fake-config.conf")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Validating file: Invalid config: " +
+			"Platform.Linux.SkipDiskSetup cannot be used with Platform.Linux.CreatePartitionIfNoEphemeralDisk; " +
+			"Platform.Linux.PartitionerType must be one of parted, got 'fake-partitioner'"))
+	})
+})
+
+var _ = Describe("Config", func() {
+	Describe("Validate", func() {
+		It("accepts empty config", func() {
+			Expect(Config{}.Validate()).To(Succeed())
+		})
+
+		It("reports every invalid option", func() {
+			config := Config{
+				Platform: boshplatform.Options{
+					Linux: boshplatform.LinuxOptions{
+						SkipDiskSetup:                   true,
+						ScrubEphemeralDisk:              true,
+						DevicePathResolutionType:        "fake-type",
+						PersistentDiskFSCheckMode:       "fake-mode",
+						NetworkManagerType:              "fake-manager",
+						DiskTrimIntervalInSeconds:       -1,
+						DNSHealthCheckIntervalInSeconds: -2,
+						LocalDNSAddress:                 "fake-address",
+					},
+				},
+				Infrastructure: boshinf.Options{
+					Settings: boshinf.SettingsOptions{RefreshIntervalInSeconds: -3},
+				},
+				Blobstore: boshagentblobstore.Options{ChunkSizeInMB: -4, Parallelism: -5, ChunkAttempts: -6},
+			}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Invalid config: " +
+				"Platform.Linux.SkipDiskSetup cannot be used with Platform.Linux.ScrubEphemeralDisk; " +
+				"Platform.Linux.DevicePathResolutionType must be one of virtio, scsi, got 'fake-type'; " +
+				"Platform.Linux.PersistentDiskFSCheckMode must be one of off, check, repair, got 'fake-mode'; " +
+				"Platform.Linux.NetworkManagerType must be one of networkd, got 'fake-manager'; " +
+				"Platform.Linux.DiskTrimIntervalInSeconds must not be negative, got -1; " +
+				"Platform.Linux.DNSHealthCheckIntervalInSeconds must not be negative, got -2; " +
+				"Infrastructure.Settings.RefreshIntervalInSeconds must not be negative, got -3; " +
+				"Blobstore.ChunkSizeInMB must not be negative, got -4; " +
+				"Blobstore.Parallelism must not be negative, got -5; " +
+				"Blobstore.ChunkAttempts must not be negative, got -6; " +
+				"Platform.Linux.LocalDNSAddress must be an IP address, got 'fake-address'"))
+		})
+	})
+
+	Describe("WithDefaults", func() {
+		It("fills blobstore chunked transfer defaults", func() {
+			config := Config{Blobstore: boshagentblobstore.Options{ChunkedTransfers: true, Parallelism: 8}}
+
+			Expect(config.WithDefaults().Blobstore).To(Equal(boshagentblobstore.Options{
+				ChunkedTransfers: true,
+				ChunkSizeInMB:    64,
+				Parallelism:      8,
+				ChunkAttempts:    3,
+			}))
+		})
+	})
 })
@@ -11,6 +11,9 @@ type Options struct {
 	BaseDirectory      string
 	JobSupervisor      string
 	ConfigPath         string
+
+	// Effective config is printed instead of running agent when set
+	PrintConfig bool `json:"-"`
 }
 
 func ParseOptions(args []string) (Options, error) {
@@ -24,6 +27,7 @@ func ParseOptions(args []string) (Options, error) {
 	flagSet.StringVar(&opts.ConfigPath, "C", "", "Config path")
 	flagSet.StringVar(&opts.JobSupervisor, "M", "monit", "Set jobsupervisor")
 	flagSet.StringVar(&opts.BaseDirectory, "b", "/var/vcap", "Set Base Directory")
+	flagSet.BoolVar(&opts.PrintConfig, "print-config", false, "Print effective config and exit")
 
 	// The following two options are accepted but ignored for compatibility with the old agent
 	var systemRoot string
@@ -25,4 +25,14 @@ var _ = Describe("ParseOptions", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(opts.ConfigPath).To(Equal(""))
 	})
+
+	It("parses print config flag", func() {
+		opts, err := ParseOptions([]string{"bosh-agent", "-C", "/fake-path", "--print-config"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.PrintConfig).To(BeTrue())
+
+		opts, err = ParseOptions([]string{"bosh-agent"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.PrintConfig).To(BeFalse())
+	})
 })
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type effectiveConfig struct {
+	Options Options
+	Config
+}
+
+// PrintConfig prints command line options and config loaded from opts.ConfigPath
+// with defaults filled in, e.g. bosh-agent -C agent.json --print-config;
+// returns false when config cannot be loaded
+func PrintConfig(fs boshsys.FileSystem, opts Options, out io.Writer) bool {
+	config, err := LoadConfigFromPath(fs, opts.ConfigPath)
+	if err != nil {
+		fmt.Fprintf(out, "Loading config '%s': %s\n", opts.ConfigPath, err.Error())
+		return false
+	}
+
+	configBytes, err := json.MarshalIndent(effectiveConfig{Options: opts, Config: config.WithDefaults()}, "", "  ")
+	if err != nil {
+		fmt.Fprintf(out, "Marshalling config: %s\n", err.Error())
+		return false
+	}
+
+	fmt.Fprintf(out, "%s\n", configBytes)
+	return true
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("PrintConfig", func() {
+	var (
+		fs   *fakesys.FakeFileSystem
+		out  *bytes.Buffer
+		opts Options
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		out = &bytes.Buffer{}
+		opts = Options{ConfigPath: "/fake-config.conf", PlatformName: "fake-platform", BaseDirectory: "/fake-base-dir"}
+	})
+
+	It("prints options and config with defaults", func() {
+		fs.WriteFileString("/fake-config.conf", `{
+			"Platform": {"Linux": {"SkipDiskSetup": true}},
+			"Infrastructure": {
+				"Settings": {
+					"Sources": [
+						{"Type": "GCE"},
+						{"Type": "HTTP", "URI": "http://fake-uri", "UserDataPath": "/fake-user-data-path"}
+					]
+				}
+			}
+		}`)
+
+		Expect(PrintConfig(fs, opts, out)).To(BeTrue())
+
+		var printed map[string]interface{}
+		Expect(json.Unmarshal(out.Bytes(), &printed)).To(Succeed())
+
+		Expect(printed["Options"]).To(HaveKeyWithValue("PlatformName", "fake-platform"))
+		Expect(printed["Options"]).To(HaveKeyWithValue("BaseDirectory", "/fake-base-dir"))
+		Expect(printed["Options"]).ToNot(HaveKey("PrintConfig"))
+
+		Expect(printed["Platform"]).To(HaveKeyWithValue("Linux", HaveKeyWithValue("SkipDiskSetup", true)))
+
+		settings := printed["Infrastructure"].(map[string]interface{})["Settings"].(map[string]interface{})
+		sources := settings["Sources"].([]interface{})
+		Expect(sources).To(HaveLen(2))
+		Expect(sources[0]).To(Equal(map[string]interface{}{
+			"Type":              "GCE",
+			"URI":               "http://metadata.google.internal",
+			"SettingsAttribute": "bosh_settings",
+		}))
+		Expect(sources[1]).To(HaveKeyWithValue("Type", "HTTP"))
+		Expect(sources[1]).To(HaveKeyWithValue("SettingsPath", "/fake-user-data-path"))
+
+		Expect(settings["Registry"]).To(HaveKeyWithValue("MaxAttempts", float64(3)))
+	})
+
+	It("reports config which cannot be loaded", func() {
+		fs.WriteFileString("/fake-config.conf", `{"Platfrom": {}}`)
+
+		Expect(PrintConfig(fs, opts, out)).To(BeFalse())
+		Expect(out.String()).To(ContainSubstring("Loading config '/fake-config.conf'"))
+		Expect(out.String()).To(ContainSubstring("Unknown keys Platfrom"))
+	})
+})
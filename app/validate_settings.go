@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"io"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// ValidateSettingsCommand is the first argument which runs agent binary
+// as a settings validator, e.g. bosh-agent validate_settings settings.json
+const ValidateSettingsCommand = "validate_settings"
+
+// ValidateSettings prints every problem of settings file at path
+// so that CPI authors can check generated settings; returns false when invalid
+func ValidateSettings(fs boshsys.FileSystem, args []string, out io.Writer) bool {
+	if len(args) != 1 {
+		fmt.Fprintf(out, "Usage: bosh-agent %s <settings-path>\n", ValidateSettingsCommand)
+		return false
+	}
+
+	contents, err := fs.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(out, "Reading settings file '%s': %s\n", args[0], err.Error())
+		return false
+	}
+
+	_, err = boshsettings.ParseSettings(contents)
+	if err != nil {
+		validationErrs, ok := err.(boshsettings.ValidationErrors)
+		if !ok {
+			fmt.Fprintf(out, "%s\n", err.Error())
+			return false
+		}
+
+		fmt.Fprintf(out, "Settings in '%s' are invalid:\n", args[0])
+		for _, validationErr := range validationErrs {
+			fmt.Fprintf(out, "  - %s\n", validationErr.Error())
+		}
+		return false
+	}
+
+	fmt.Fprintf(out, "Settings in '%s' are valid\n", args[0])
+	return true
+}
@@ -0,0 +1,300 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttp "github.com/cloudfoundry/bosh-utils/http"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	// Instance metadata does not return custom data; CPI must also set settings as user data
+	azureUserDataPath   = "/metadata/instance/compute/userData?api-version=2021-02-01&format=text"
+	azurePublicKeysPath = "/metadata/instance/compute/publicKeys?api-version=2021-02-01"
+
+	azureWireServerVersion = "2012-11-30"
+)
+
+// AzureWireServerRequestTimeout bounds each request to wire server
+// since VM is reported ready while settings are being fetched
+const AzureWireServerRequestTimeout = 10 * time.Second
+
+// AzureSettingsSource reads base64 encoded settings from custom data
+// of OVF environment on provisioning CDROM, falling back to user data
+// from instance metadata service;
+// optionally it reports VM as ready to Azure wire server once settings are read
+type AzureSettingsSource struct {
+	ovfEnvFileName  string
+	metadataService DynamicMetadataService
+
+	// Custom data location which answered last settings request
+	customDataSource     boshsettings.SourceInfo
+	customDataSourceLock sync.RWMutex
+
+	wireServerURI    string
+	wireServerClient boshhttp.Client
+	reportReady      bool
+	readyReported    bool
+	readyLock        sync.Mutex
+
+	platform boshplatform.Platform
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type azureOVFEnv struct {
+	CustomData string           `xml:"ProvisioningSection>LinuxProvisioningConfigurationSet>CustomData"`
+	PublicKeys []azurePublicKey `xml:"ProvisioningSection>LinuxProvisioningConfigurationSet>SSH>PublicKeys>PublicKey"`
+}
+
+type azurePublicKey struct {
+	Path  string `xml:"Path" json:"path"`
+	Value string `xml:"Value" json:"keyData"`
+}
+
+type azureGoalState struct {
+	Incarnation string `xml:"Incarnation"`
+	ContainerID string `xml:"Container>ContainerId"`
+	InstanceID  string `xml:"Container>RoleInstanceList>RoleInstance>InstanceId"`
+}
+
+const azureHealthTemplate = `<?xml version="1.0" encoding="utf-8"?>
+<Health xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+  <GoalStateIncarnation>%s</GoalStateIncarnation>
+  <Container>
+    <ContainerId>%s</ContainerId>
+    <RoleInstanceList>
+      <Role>
+        <InstanceId>%s</InstanceId>
+        <Health>
+          <State>Ready</State>
+        </Health>
+      </Role>
+    </RoleInstanceList>
+  </Container>
+</Health>`
+
+func NewAzureSettingsSource(
+	ovfEnvFileName string,
+	metadataService DynamicMetadataService,
+	wireServerURI string,
+	wireServerClient boshhttp.Client,
+	reportReady bool,
+	platform boshplatform.Platform,
+	logger boshlog.Logger,
+) *AzureSettingsSource {
+	return &AzureSettingsSource{
+		ovfEnvFileName:  ovfEnvFileName,
+		metadataService: metadataService,
+
+		wireServerURI:    wireServerURI,
+		wireServerClient: wireServerClient,
+		reportReady:      reportReady,
+
+		platform: platform,
+
+		logTag: "AzureSettingsSource",
+		logger: logger,
+	}
+}
+
+// PublicSSHKeyForUsername returns key installed into
+// authorized keys of the user, or the first key when none matches
+func (s *AzureSettingsSource) PublicSSHKeyForUsername(username string) (string, error) {
+	var publicKeys []azurePublicKey
+
+	ovfEnv, err := s.loadOVFEnv()
+	if err == nil {
+		publicKeys = ovfEnv.PublicKeys
+	} else {
+		s.logger.Debug(s.logTag, "Falling back to instance metadata for public keys: %s", err.Error())
+
+		contents, err := s.metadataService.GetValueAtPath(azurePublicKeysPath)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Reading Azure public keys from instance metadata")
+		}
+
+		err = json.Unmarshal([]byte(contents), &publicKeys)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Parsing Azure public keys from instance metadata")
+		}
+	}
+
+	if len(publicKeys) == 0 {
+		return "", nil
+	}
+
+	authorizedKeysPath := fmt.Sprintf("/home/%s/.ssh/authorized_keys", username)
+
+	for _, publicKey := range publicKeys {
+		if publicKey.Path == authorizedKeysPath {
+			return strings.TrimSpace(publicKey.Value), nil
+		}
+	}
+
+	return strings.TrimSpace(publicKeys[0].Value), nil
+}
+
+func (s *AzureSettingsSource) Settings() (boshsettings.Settings, error) {
+	var settings boshsettings.Settings
+
+	customData, customDataSource, err := s.loadCustomData()
+	if err != nil {
+		return settings, err
+	}
+
+	decodedCustomData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(customData))
+	if err != nil {
+		return settings, bosherr.WrapError(err, "Decoding Azure custom data")
+	}
+
+	err = json.Unmarshal(decodedCustomData, &settings)
+	if err != nil {
+		return settings, bosherr.WrapError(err, "Parsing Azure settings from custom data")
+	}
+
+	s.customDataSourceLock.Lock()
+	s.customDataSource = customDataSource
+	s.customDataSourceLock.Unlock()
+
+	if s.reportReady {
+		s.reportReadyOnce()
+	}
+
+	return settings, nil
+}
+
+func (s *AzureSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	s.customDataSourceLock.RLock()
+	defer s.customDataSourceLock.RUnlock()
+
+	if s.customDataSource.Type == "" {
+		return boshsettings.SourceInfo{Type: "Azure"}
+	}
+	return s.customDataSource
+}
+
+func (s *AzureSettingsSource) loadCustomData() (string, boshsettings.SourceInfo, error) {
+	ovfEnv, err := s.loadOVFEnv()
+	if err == nil && ovfEnv.CustomData != "" {
+		return ovfEnv.CustomData, boshsettings.SourceInfo{Type: "Azure", Endpoint: s.ovfEnvFileName}, nil
+	}
+
+	if err != nil {
+		s.logger.Debug(s.logTag, "Falling back to instance metadata for user data: %s", err.Error())
+	}
+
+	userData, err := s.metadataService.GetValueAtPath(azureUserDataPath)
+	if err != nil {
+		return "", boshsettings.SourceInfo{}, bosherr.WrapError(err, "Reading Azure user data from instance metadata")
+	}
+
+	return userData, boshsettings.SourceInfo{Type: "Azure", Endpoint: azureUserDataPath}, nil
+}
+
+func (s *AzureSettingsSource) loadOVFEnv() (azureOVFEnv, error) {
+	var ovfEnv azureOVFEnv
+
+	contents, err := s.platform.GetFileContentsFromCDROM(s.ovfEnvFileName)
+	if err != nil {
+		return ovfEnv, bosherr.WrapErrorf(err, "Reading '%s' from CDROM", s.ovfEnvFileName)
+	}
+
+	err = xml.Unmarshal(contents, &ovfEnv)
+	if err != nil {
+		return ovfEnv, bosherr.WrapErrorf(err, "Parsing OVF environment '%s'", s.ovfEnvFileName)
+	}
+
+	return ovfEnv, nil
+}
+
+// reportReadyOnce does not fail settings fetching because
+// settings are usable even if Azure keeps waiting for the VM
+func (s *AzureSettingsSource) reportReadyOnce() {
+	s.readyLock.Lock()
+	defer s.readyLock.Unlock()
+
+	if s.readyReported {
+		return
+	}
+
+	err := s.postReady()
+	if err != nil {
+		s.logger.Warn(s.logTag, "Failed to report ready to Azure wire server: %s", err.Error())
+		return
+	}
+
+	s.readyReported = true
+}
+
+func (s *AzureSettingsSource) postReady() error {
+	contents, err := s.doWireServerRequest("GET", "/machine/?comp=goalstate", nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting goal state")
+	}
+
+	var goalState azureGoalState
+
+	err = xml.Unmarshal(contents, &goalState)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing goal state")
+	}
+
+	health := fmt.Sprintf(azureHealthTemplate, goalState.Incarnation, goalState.ContainerID, goalState.InstanceID)
+
+	_, err = s.doWireServerRequest("POST", "/machine/?comp=health", []byte(health))
+	if err != nil {
+		return bosherr.WrapError(err, "Posting health")
+	}
+
+	return nil
+}
+
+func (s *AzureSettingsSource) doWireServerRequest(method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, s.wireServerURI+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("x-ms-version", azureWireServerVersion)
+	req.Header.Set("x-ms-agent-name", "bosh-agent")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	}
+
+	resp, err := s.wireServerClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.logger.Warn(s.logTag, "Failed to close wire server response body: %s", err.Error())
+		}
+	}()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading wire server response body")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, bosherr.Errorf("Wire server responded with status %d: %s", resp.StatusCode, string(contents))
+	}
+
+	return contents, nil
+}
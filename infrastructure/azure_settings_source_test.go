@@ -0,0 +1,203 @@
+package infrastructure_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("AzureSettingsSource", func() {
+	var (
+		platform        *fakeplatform.FakePlatform
+		metadataServer  *ghttp.Server
+		wireServer      *ghttp.Server
+		reportReady     bool
+		source          *AzureSettingsSource
+		encodedSettings string
+		ovfEnvWithKeys  string
+		buildSource     func()
+	)
+
+	ovfEnv := func(customData string) string {
+		return `<?xml version="1.0" encoding="utf-8"?>
+<Environment xmlns="http://schemas.dmtf.org/ovf/environment/1" xmlns:wa="http://schemas.microsoft.com/windowsazure">
+  <wa:ProvisioningSection>
+    <LinuxProvisioningConfigurationSet xmlns="http://schemas.microsoft.com/windowsazure">
+      <UserName>fake-username</UserName>
+      <CustomData>` + customData + `</CustomData>
+      <SSH>
+        <PublicKeys>
+          <PublicKey>
+            <Path>/home/other-user/.ssh/authorized_keys</Path>
+            <Value>ssh-rsa other-key</Value>
+          </PublicKey>
+          <PublicKey>
+            <Path>/home/fake-username/.ssh/authorized_keys</Path>
+            <Value>ssh-rsa fake-key</Value>
+          </PublicKey>
+        </PublicKeys>
+      </SSH>
+    </LinuxProvisioningConfigurationSet>
+  </wa:ProvisioningSection>
+</Environment>`
+	}
+
+	BeforeEach(func() {
+		platform = fakeplatform.NewFakePlatform()
+		metadataServer = ghttp.NewServer()
+		wireServer = ghttp.NewServer()
+		reportReady = false
+		encodedSettings = base64.StdEncoding.EncodeToString([]byte(`{"agent_id": "fake-agent-id"}`))
+		ovfEnvWithKeys = ovfEnv(encodedSettings)
+
+		buildSource = func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			headers := map[string]string{"Metadata": "true"}
+			metadataService := NewHTTPMetadataServiceWithCustomRetryDelay(metadataServer.URL(), headers, "", "", "", TokenOptions{}, nil, platform, logger, 0)
+			source = NewAzureSettingsSource("ovf-env.xml", metadataService, wireServer.URL(), &http.Client{}, reportReady, platform, logger)
+		}
+	})
+
+	JustBeforeEach(func() {
+		buildSource()
+	})
+
+	AfterEach(func() {
+		metadataServer.Close()
+		wireServer.Close()
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		It("returns public key of the user from OVF environment", func() {
+			platform.GetFileContentsFromCDROMContents = []byte(ovfEnvWithKeys)
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key"))
+			Expect(platform.GetFileContentsFromCDROMPath).To(Equal("ovf-env.xml"))
+		})
+
+		It("returns public key from instance metadata when OVF environment is not available", func() {
+			platform.GetFileContentsFromCDROMErr = errors.New("fake-cdrom-error")
+
+			metadataServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/metadata/instance/compute/publicKeys", "api-version=2021-02-01"),
+				ghttp.VerifyHeaderKV("Metadata", "true"),
+				ghttp.RespondWith(http.StatusOK, `[{"keyData": "ssh-rsa fake-key", "path": "/home/fake-username/.ssh/authorized_keys"}]`),
+			))
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key"))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings from custom data of OVF environment", func() {
+			platform.GetFileContentsFromCDROMContents = []byte(ovfEnvWithKeys)
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+			Expect(metadataServer.ReceivedRequests()).To(BeEmpty())
+			Expect(wireServer.ReceivedRequests()).To(BeEmpty())
+		})
+
+		It("returns settings from user data of instance metadata when OVF environment is not available", func() {
+			platform.GetFileContentsFromCDROMErr = errors.New("fake-cdrom-error")
+
+			metadataServer.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/metadata/instance/compute/userData", "api-version=2021-02-01&format=text"),
+				ghttp.VerifyHeaderKV("Metadata", "true"),
+				ghttp.RespondWith(http.StatusOK, encodedSettings),
+			))
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+		})
+
+		It("returns an error when custom data is not base64 encoded", func() {
+			platform.GetFileContentsFromCDROMContents = []byte(ovfEnv("{not-base64}"))
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decoding Azure custom data"))
+		})
+
+		Context("when reporting ready", func() {
+			BeforeEach(func() {
+				reportReady = true
+				platform.GetFileContentsFromCDROMContents = []byte(ovfEnvWithKeys)
+
+				wireServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/machine/", "comp=goalstate"),
+						ghttp.VerifyHeaderKV("x-ms-version", "2012-11-30"),
+						ghttp.RespondWith(http.StatusOK, `<?xml version="1.0" encoding="utf-8"?>
+<GoalState>
+  <Incarnation>3</Incarnation>
+  <Container>
+    <ContainerId>fake-container-id</ContainerId>
+    <RoleInstanceList>
+      <RoleInstance>
+        <InstanceId>fake-instance-id</InstanceId>
+      </RoleInstance>
+    </RoleInstanceList>
+  </Container>
+</GoalState>`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/machine/", "comp=health"),
+						ghttp.VerifyHeaderKV("x-ms-version", "2012-11-30"),
+						func(w http.ResponseWriter, r *http.Request) {
+							defer GinkgoRecover()
+
+							body, err := ioutil.ReadAll(r.Body)
+							Expect(err).ToNot(HaveOccurred())
+							Expect(string(body)).To(ContainSubstring("<GoalStateIncarnation>3</GoalStateIncarnation>"))
+							Expect(string(body)).To(ContainSubstring("<ContainerId>fake-container-id</ContainerId>"))
+							Expect(string(body)).To(ContainSubstring("<InstanceId>fake-instance-id</InstanceId>"))
+							Expect(string(body)).To(ContainSubstring("<State>Ready</State>"))
+						},
+					),
+				)
+			})
+
+			It("reports ready to wire server only once", func() {
+				_, err := source.Settings()
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = source.Settings()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(wireServer.ReceivedRequests()).To(HaveLen(2))
+			})
+		})
+
+		Context("when reporting ready fails", func() {
+			BeforeEach(func() {
+				reportReady = true
+				platform.GetFileContentsFromCDROMContents = []byte(ovfEnvWithKeys)
+
+				wireServer.AllowUnhandledRequests = true
+				wireServer.UnhandledRequestStatusCode = http.StatusInternalServerError
+			})
+
+			It("returns settings", func() {
+				settings, err := source.Settings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings.AgentID).To(Equal("fake-agent-id"))
+			})
+		})
+	})
+})
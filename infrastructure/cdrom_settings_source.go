@@ -33,6 +33,10 @@ func NewCDROMSettingsSource(
 	}
 }
 
+func (s CDROMSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "CDROM", Endpoint: s.settingsFileName}
+}
+
 func (s CDROMSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
 	return "", nil
 }
@@ -0,0 +1,76 @@
+package infrastructure
+
+import (
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/pivotal-golang/clock"
+)
+
+// CircuitBreaker fails requests fast after consecutive failures
+// until reset timeout passes; then a single trial request is let through
+// and its outcome closes or re-opens the breaker
+type CircuitBreaker struct {
+	failureThreshold int
+	resetTimeout     time.Duration
+	timeService      clock.Clock
+
+	lock                sync.Mutex
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInProgress     bool
+}
+
+// NewCircuitBreaker returns breaker which never opens when failureThreshold is not positive
+func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration, timeService clock.Clock) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		resetTimeout:     resetTimeout,
+		timeService:      timeService,
+	}
+}
+
+// Allow returns error when breaker is open
+func (b *CircuitBreaker) Allow() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.isOpen() {
+		return nil
+	}
+
+	retryAt := b.openedAt.Add(b.resetTimeout)
+
+	if b.trialInProgress || b.timeService.Now().Before(retryAt) {
+		return bosherr.Errorf("Circuit breaker is open after %d consecutive failures; retrying after %s", b.consecutiveFailures, retryAt.Format(time.RFC3339))
+	}
+
+	b.trialInProgress = true
+
+	return nil
+}
+
+func (b *CircuitBreaker) RecordSuccess() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.consecutiveFailures = 0
+	b.trialInProgress = false
+}
+
+func (b *CircuitBreaker) RecordFailure() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.consecutiveFailures++
+	b.trialInProgress = false
+
+	if b.isOpen() {
+		b.openedAt = b.timeService.Now()
+	}
+}
+
+func (b *CircuitBreaker) isOpen() bool {
+	return b.failureThreshold > 0 && b.consecutiveFailures >= b.failureThreshold
+}
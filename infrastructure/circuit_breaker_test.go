@@ -0,0 +1,89 @@
+package infrastructure_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	var (
+		timeService    *fakeclock.FakeClock
+		circuitBreaker *CircuitBreaker
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		circuitBreaker = NewCircuitBreaker(2, 30*time.Second, timeService)
+	})
+
+	It("allows requests until failure threshold is reached", func() {
+		Expect(circuitBreaker.Allow()).To(Succeed())
+		circuitBreaker.RecordFailure()
+		Expect(circuitBreaker.Allow()).To(Succeed())
+		circuitBreaker.RecordFailure()
+
+		err := circuitBreaker.Allow()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Circuit breaker is open after 2 consecutive failures"))
+	})
+
+	It("resets failure count after success", func() {
+		circuitBreaker.RecordFailure()
+		circuitBreaker.RecordSuccess()
+		circuitBreaker.RecordFailure()
+
+		Expect(circuitBreaker.Allow()).To(Succeed())
+	})
+
+	Context("when breaker is open", func() {
+		BeforeEach(func() {
+			circuitBreaker.RecordFailure()
+			circuitBreaker.RecordFailure()
+		})
+
+		It("lets single trial request through after reset timeout", func() {
+			timeService.Increment(29 * time.Second)
+			Expect(circuitBreaker.Allow()).ToNot(Succeed())
+
+			timeService.Increment(1 * time.Second)
+			Expect(circuitBreaker.Allow()).To(Succeed())
+			Expect(circuitBreaker.Allow()).ToNot(Succeed())
+		})
+
+		It("closes when trial request succeeds", func() {
+			timeService.Increment(30 * time.Second)
+			Expect(circuitBreaker.Allow()).To(Succeed())
+			circuitBreaker.RecordSuccess()
+
+			Expect(circuitBreaker.Allow()).To(Succeed())
+			Expect(circuitBreaker.Allow()).To(Succeed())
+		})
+
+		It("opens again for reset timeout when trial request fails", func() {
+			timeService.Increment(30 * time.Second)
+			Expect(circuitBreaker.Allow()).To(Succeed())
+			circuitBreaker.RecordFailure()
+
+			timeService.Increment(29 * time.Second)
+			Expect(circuitBreaker.Allow()).ToNot(Succeed())
+
+			timeService.Increment(1 * time.Second)
+			Expect(circuitBreaker.Allow()).To(Succeed())
+		})
+	})
+
+	It("never opens when failure threshold is not positive", func() {
+		circuitBreaker = NewCircuitBreaker(-1, 30*time.Second, timeService)
+
+		for i := 0; i < 10; i++ {
+			circuitBreaker.RecordFailure()
+		}
+
+		Expect(circuitBreaker.Allow()).To(Succeed())
+	})
+})
@@ -1,6 +1,9 @@
 package infrastructure
 
 import (
+	"fmt"
+	"sync"
+
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -9,10 +12,18 @@ type ComplexSettingsSource struct {
 	metadataService  MetadataService
 	registryProvider RegistryProvider
 
+	// sourceInfo is shared by copies of source
+	sourceInfo *complexSourceInfo
+
 	logTag string
 	logger boshlog.Logger
 }
 
+type complexSourceInfo struct {
+	info boshsettings.SourceInfo
+	lock sync.Mutex
+}
+
 func NewComplexSettingsSource(
 	metadataService MetadataService,
 	registryProvider RegistryProvider,
@@ -22,11 +33,22 @@ func NewComplexSettingsSource(
 		metadataService:  metadataService,
 		registryProvider: registryProvider,
 
+		sourceInfo: &complexSourceInfo{info: boshsettings.SourceInfo{Type: "Registry"}},
+
 		logTag: "ComplexSettingsSource",
 		logger: logger,
 	}
 }
 
+// SourceInfo includes registry endpoint (without credentials) and metadata
+// service which pointed to it when settings were last returned
+func (s ComplexSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	s.sourceInfo.lock.Lock()
+	defer s.sourceInfo.lock.Unlock()
+
+	return s.sourceInfo.info
+}
+
 func (s ComplexSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
 	return s.metadataService.GetPublicKey()
 }
@@ -37,5 +59,36 @@ func (s ComplexSettingsSource) Settings() (boshsettings.Settings, error) {
 		return boshsettings.Settings{}, err
 	}
 
-	return registry.GetSettings()
+	settings, err := registry.GetSettings()
+	if err != nil {
+		return boshsettings.Settings{}, err
+	}
+
+	s.recordSourceInfo()
+
+	return settings, nil
+}
+
+func (s ComplexSettingsSource) recordSourceInfo() {
+	info := boshsettings.SourceInfo{Type: "Registry"}
+
+	registryEndpoint, err := s.metadataService.GetRegistryEndpoint()
+	if err != nil {
+		s.logger.Warn(s.logTag, "Failed to get registry endpoint for settings info: %s", err.Error())
+	} else {
+		info.Endpoint = boshsettings.RedactURL(registryEndpoint)
+	}
+
+	metadataService := s.metadataService
+	if multiSourceService, ok := metadataService.(*MultiSourceMetadataService); ok {
+		metadataService = multiSourceService.SelectedService()
+	}
+
+	if metadataService != nil {
+		info.MetadataService = fmt.Sprintf("%T", metadataService)
+	}
+
+	s.sourceInfo.lock.Lock()
+	s.sourceInfo.info = info
+	s.sourceInfo.lock.Unlock()
 }
@@ -83,7 +83,7 @@ func (ms *configDriveMetadataService) GetRegistryEndpoint() (string, error) {
 	nameServers := ms.userDataContents.DNS.Nameserver
 
 	if len(nameServers) == 0 {
-		ms.logger.Debug(ms.logTag, "Getting registry endpoint %s", endpoint)
+		ms.logger.Debug(ms.logTag, "Getting registry endpoint %s", boshsettings.RedactURL(endpoint))
 		return endpoint, nil
 	}
 
@@ -92,10 +92,14 @@ func (ms *configDriveMetadataService) GetRegistryEndpoint() (string, error) {
 		return "", bosherr.WrapError(err, "Resolving registry endpoint")
 	}
 
-	ms.logger.Debug(ms.logTag, "Registry endpoint %s was resolved to %s", endpoint, resolvedEndpoint)
+	ms.logger.Debug(ms.logTag, "Registry endpoint %s was resolved to %s", boshsettings.RedactURL(endpoint), boshsettings.RedactURL(resolvedEndpoint))
 	return resolvedEndpoint, nil
 }
 
+func (ms *configDriveMetadataService) GetRegistryTLS() (RegistryTLS, error) {
+	return ms.userDataContents.RegistryTLS(), nil
+}
+
 func (ms *configDriveMetadataService) GetNetworks() (boshsettings.Networks, error) {
 	return ms.userDataContents.Networks, nil
 }
@@ -2,6 +2,7 @@ package infrastructure
 
 import (
 	"encoding/json"
+	"path"
 
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -21,6 +22,9 @@ type ConfigDriveSettingsSource struct {
 	metadataPath string
 	settingsPath string
 
+	// Disk which answered last settings request
+	settingsDiskPath string
+
 	platform boshplatform.Platform
 
 	logTag string
@@ -47,7 +51,7 @@ func NewConfigDriveSettingsSource(
 }
 
 func (s *ConfigDriveSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
-	metadataContent, err := s.loadFileFromConfigDrive(s.metadataPath)
+	metadataContent, _, err := s.loadFileFromConfigDrive(s.metadataPath)
 	if err != nil {
 		return "", err
 	}
@@ -68,11 +72,13 @@ func (s *ConfigDriveSettingsSource) PublicSSHKeyForUsername(string) (string, err
 }
 
 func (s *ConfigDriveSettingsSource) Settings() (boshsettings.Settings, error) {
-	settingsContent, err := s.loadFileFromConfigDrive(s.settingsPath)
+	settingsContent, diskPath, err := s.loadFileFromConfigDrive(s.settingsPath)
 	if err != nil {
 		return boshsettings.Settings{}, err
 	}
 
+	s.settingsDiskPath = diskPath
+
 	var settings boshsettings.Settings
 	err = json.Unmarshal(settingsContent, &settings)
 	if err != nil {
@@ -83,7 +89,11 @@ func (s *ConfigDriveSettingsSource) Settings() (boshsettings.Settings, error) {
 	return settings, err
 }
 
-func (s *ConfigDriveSettingsSource) loadFileFromConfigDrive(contentPath string) ([]byte, error) {
+func (s *ConfigDriveSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "ConfigDrive", Endpoint: path.Join(s.settingsDiskPath, s.settingsPath)}
+}
+
+func (s *ConfigDriveSettingsSource) loadFileFromConfigDrive(contentPath string) ([]byte, string, error) {
 	var err error
 	var contents [][]byte
 
@@ -92,10 +102,10 @@ func (s *ConfigDriveSettingsSource) loadFileFromConfigDrive(contentPath string)
 
 		if err == nil {
 			s.logger.Debug(s.logTag, "Successfully loaded file '%s' from config drive: '%s'", contentPath, diskPath)
-			return contents[0], nil
+			return contents[0], diskPath, nil
 		}
 		s.logger.Warn(s.logTag, "Failed to load config from %s - %s", diskPath, err.Error())
 	}
 
-	return []byte{}, bosherr.WrapErrorf(err, "Loading file '%s' from config drive", contentPath)
+	return []byte{}, "", bosherr.WrapErrorf(err, "Loading file '%s' from config drive", contentPath)
 }
@@ -1,6 +1,7 @@
 package fakes
 
 import (
+	"github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 )
 
@@ -19,6 +20,9 @@ type FakeMetadataService struct {
 	RegistryEndpoint       string
 	GetRegistryEndpointErr error
 
+	RegistryTLS       infrastructure.RegistryTLS
+	GetRegistryTLSErr error
+
 	Networks    boshsettings.Networks
 	NetworksErr error
 
@@ -45,6 +49,10 @@ func (ms FakeMetadataService) GetRegistryEndpoint() (string, error) {
 	return ms.RegistryEndpoint, ms.GetRegistryEndpointErr
 }
 
+func (ms FakeMetadataService) GetRegistryTLS() (infrastructure.RegistryTLS, error) {
+	return ms.RegistryTLS, ms.GetRegistryTLSErr
+}
+
 func (ms FakeMetadataService) GetNetworks() (boshsettings.Networks, error) {
 	return ms.Networks, ms.NetworksErr
 }
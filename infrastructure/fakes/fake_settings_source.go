@@ -10,6 +10,7 @@ type FakeSettingsSource struct {
 
 	SettingsValue boshsettings.Settings
 	SettingsErr   error
+	SettingsStub  func() (boshsettings.Settings, error)
 }
 
 func (s FakeSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
@@ -17,5 +18,9 @@ func (s FakeSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
 }
 
 func (s FakeSettingsSource) Settings() (boshsettings.Settings, error) {
+	if s.SettingsStub != nil {
+		return s.SettingsStub()
+	}
+
 	return s.SettingsValue, s.SettingsErr
 }
@@ -91,7 +91,7 @@ func (ms fileMetadataService) GetServerName() (string, error) {
 		return "", bosherr.WrapError(err, "Unmarshalling user data")
 	}
 
-	ms.logger.Debug(ms.logTag, "Read user data '%#v'", userData)
+	ms.logger.Debug(ms.logTag, "Read user data '%#v'", userData.Redacted())
 
 	return userData.Server.Name, nil
 }
@@ -111,11 +111,28 @@ func (ms fileMetadataService) GetRegistryEndpoint() (string, error) {
 		return "", bosherr.WrapError(err, "Unmarshalling user data")
 	}
 
-	ms.logger.Debug(ms.logTag, "Read user data '%#v'", userData)
+	ms.logger.Debug(ms.logTag, "Read user data '%#v'", userData.Redacted())
 
 	return userData.Registry.Endpoint, nil
 }
 
+func (ms fileMetadataService) GetRegistryTLS() (RegistryTLS, error) {
+	var userData UserDataContentsType
+
+	contents, err := ms.fs.ReadFile(ms.userDataFilePath)
+	if err != nil {
+		// Settings file of older bosh-warden-cpi is used as file registry
+		return RegistryTLS{}, nil
+	}
+
+	err = json.Unmarshal([]byte(contents), &userData)
+	if err != nil {
+		return RegistryTLS{}, bosherr.WrapError(err, "Unmarshalling user data")
+	}
+
+	return userData.RegistryTLS(), nil
+}
+
 func (ms fileMetadataService) GetNetworks() (boshsettings.Networks, error) {
 	var userData UserDataContentsType
 
@@ -129,7 +146,7 @@ func (ms fileMetadataService) GetNetworks() (boshsettings.Networks, error) {
 		return nil, bosherr.WrapError(err, "Unmarshalling user data")
 	}
 
-	ms.logger.Debug(ms.logTag, "Read user data '%#v'", userData)
+	ms.logger.Debug(ms.logTag, "Read user data '%#v'", userData.Redacted())
 
 	return userData.Networks, nil
 }
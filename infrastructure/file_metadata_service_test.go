@@ -155,6 +155,42 @@ var _ = Describe("FileMetadataService", func() {
 		})
 	})
 
+	Describe("GetRegistryTLS", func() {
+		Context("when metadata service file exists", func() {
+			BeforeEach(func() {
+				userDataContents := `{"registry":{"endpoint":"https://fake-registry:25777","tls":{"ca_cert":"fake-ca-cert"}}}`
+				fs.WriteFileString("fake-userdata-file-path", userDataContents)
+			})
+
+			It("returns registry TLS settings with server name taken from registry endpoint", func() {
+				registryTLS, err := metadataService.GetRegistryTLS()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registryTLS).To(Equal(RegistryTLS{CACert: "fake-ca-cert", ServerName: "fake-registry"}))
+			})
+		})
+
+		Context("when registry endpoint does not include port", func() {
+			BeforeEach(func() {
+				userDataContents := `{"registry":{"endpoint":"https://[fd00::1]","tls":{"ca_cert":"fake-ca-cert"}}}`
+				fs.WriteFileString("fake-userdata-file-path", userDataContents)
+			})
+
+			It("returns registry TLS settings with server name taken from registry endpoint", func() {
+				registryTLS, err := metadataService.GetRegistryTLS()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registryTLS).To(Equal(RegistryTLS{CACert: "fake-ca-cert", ServerName: "fd00::1"}))
+			})
+		})
+
+		Context("when metadata service file does not exist", func() {
+			It("returns empty registry TLS settings", func() {
+				registryTLS, err := metadataService.GetRegistryTLS()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(registryTLS).To(Equal(RegistryTLS{}))
+			})
+		})
+	})
+
 	Describe("IsAvailable", func() {
 		Context("when file does not exist", func() {
 			It("returns false", func() {
@@ -0,0 +1,58 @@
+package infrastructure
+
+import (
+	"encoding/json"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// FileSettingsSource reads complete settings from a file
+// placed on the VM by the infrastructure
+type FileSettingsSource struct {
+	settingsPath string
+	fs           boshsys.FileSystem
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewFileSettingsSource(
+	settingsPath string,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) *FileSettingsSource {
+	return &FileSettingsSource{
+		settingsPath: settingsPath,
+		fs:           fs,
+
+		logTag: "FileSettingsSource",
+		logger: logger,
+	}
+}
+
+func (s FileSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "File", Endpoint: s.settingsPath}
+}
+
+func (s FileSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
+	return "", nil
+}
+
+func (s *FileSettingsSource) Settings() (boshsettings.Settings, error) {
+	var settings boshsettings.Settings
+
+	contents, err := s.fs.ReadFile(s.settingsPath)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Reading settings file '%s'", s.settingsPath)
+	}
+
+	err = json.Unmarshal(contents, &settings)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Parsing file settings from '%s'", s.settingsPath)
+	}
+
+	return settings, nil
+}
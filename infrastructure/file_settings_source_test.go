@@ -0,0 +1,47 @@
+package infrastructure_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("FileSettingsSource", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		source *FileSettingsSource
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		source = NewFileSettingsSource("/fake-settings-path", fs, logger)
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		It("returns an empty string", func() {
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal(""))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings read from the file", func() {
+			fs.WriteFileString("/fake-settings-path", `{"agent_id": "fake-agent-id"}`)
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+		})
+
+		It("returns an error when file does not exist", func() {
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading settings file '/fake-settings-path'"))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"strings"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const gceAttributesPath = "/computeMetadata/v1/instance/attributes/"
+
+// GCESettingsSource reads settings from a GCE instance attribute
+// and public keys from ssh-keys attribute
+type GCESettingsSource struct {
+	metadataService   DynamicMetadataService
+	settingsAttribute string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewGCESettingsSource(
+	metadataService DynamicMetadataService,
+	settingsAttribute string,
+	logger boshlog.Logger,
+) *GCESettingsSource {
+	return &GCESettingsSource{
+		metadataService:   metadataService,
+		settingsAttribute: settingsAttribute,
+
+		logTag: "GCESettingsSource",
+		logger: logger,
+	}
+}
+
+func (s GCESettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "GCE", Endpoint: gceAttributesPath + s.settingsAttribute}
+}
+
+// PublicSSHKeyForUsername returns key of the user from ssh-keys attribute
+// which lists keys as 'username:key' lines
+func (s GCESettingsSource) PublicSSHKeyForUsername(username string) (string, error) {
+	contents, err := s.metadataService.GetValueAtPath(gceAttributesPath + "ssh-keys")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading GCE ssh-keys attribute")
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(parts) == 2 && parts[0] == username {
+			return strings.TrimSpace(parts[1]), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (s *GCESettingsSource) Settings() (boshsettings.Settings, error) {
+	var settings boshsettings.Settings
+
+	contents, err := s.metadataService.GetValueAtPath(gceAttributesPath + s.settingsAttribute)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Reading GCE attribute '%s'", s.settingsAttribute)
+	}
+
+	err = json.Unmarshal([]byte(contents), &settings)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Parsing GCE settings from attribute '%s'", s.settingsAttribute)
+	}
+
+	return settings, nil
+}
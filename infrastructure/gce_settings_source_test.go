@@ -0,0 +1,78 @@
+package infrastructure_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("GCESettingsSource", func() {
+	var (
+		server *ghttp.Server
+		source *GCESettingsSource
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		platform := fakeplatform.NewFakePlatform()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		headers := map[string]string{"Metadata-Flavor": "Google"}
+		metadataService := NewHTTPMetadataServiceWithCustomRetryDelay(server.URL(), headers, "", "", "", TokenOptions{}, nil, platform, logger, 0)
+		source = NewGCESettingsSource(metadataService, "fake-attribute", logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/computeMetadata/v1/instance/attributes/ssh-keys"),
+				ghttp.VerifyHeaderKV("Metadata-Flavor", "Google"),
+				ghttp.RespondWith(http.StatusOK, "other-user:ssh-rsa other-key\nfake-username:ssh-rsa fake-key fake-username\n"),
+			))
+		})
+
+		It("returns public key of the user", func() {
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key fake-username"))
+		})
+
+		It("returns an empty string when user has no key", func() {
+			publicKey, err := source.PublicSSHKeyForUsername("unknown-user")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal(""))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings from the attribute", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/computeMetadata/v1/instance/attributes/fake-attribute"),
+				ghttp.VerifyHeaderKV("Metadata-Flavor", "Google"),
+				ghttp.RespondWith(http.StatusOK, `{"agent_id": "fake-agent-id"}`),
+			))
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+		})
+
+		It("returns an error when attribute is not available", func() {
+			server.AllowUnhandledRequests = true
+			server.UnhandledRequestStatusCode = http.StatusNotFound
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading GCE attribute 'fake-attribute'"))
+		})
+	})
+})
@@ -20,6 +20,7 @@ type httpMetadataService struct {
 	userdataPath    string
 	instanceIDPath  string
 	sshKeysPath     string
+	tokenClient     *metadataTokenClient
 	resolver        DNSResolver
 	platform        boshplat.Platform
 	logTag          string
@@ -33,6 +34,7 @@ func NewHTTPMetadataService(
 	userdataPath string,
 	instanceIDPath string,
 	sshKeysPath string,
+	tokenOptions TokenOptions,
 	resolver DNSResolver,
 	platform boshplat.Platform,
 	logger boshlog.Logger,
@@ -43,6 +45,7 @@ func NewHTTPMetadataService(
 		userdataPath:    userdataPath,
 		instanceIDPath:  instanceIDPath,
 		sshKeysPath:     sshKeysPath,
+		tokenClient:     newMetadataTokenClient(&http.Client{}, metadataHost, tokenOptions, logger),
 		resolver:        resolver,
 		platform:        platform,
 		logTag:          "httpMetadataService",
@@ -57,6 +60,7 @@ func NewHTTPMetadataServiceWithCustomRetryDelay(
 	userdataPath string,
 	instanceIDPath string,
 	sshKeysPath string,
+	tokenOptions TokenOptions,
 	resolver DNSResolver,
 	platform boshplat.Platform,
 	logger boshlog.Logger,
@@ -68,6 +72,7 @@ func NewHTTPMetadataServiceWithCustomRetryDelay(
 		userdataPath:    userdataPath,
 		instanceIDPath:  instanceIDPath,
 		sshKeysPath:     sshKeysPath,
+		tokenClient:     newMetadataTokenClient(&http.Client{}, metadataHost, tokenOptions, logger),
 		resolver:        resolver,
 		platform:        platform,
 		logTag:          "httpMetadataService",
@@ -203,6 +208,15 @@ func (ms httpMetadataService) GetRegistryEndpoint() (string, error) {
 	return endpoint, nil
 }
 
+func (ms httpMetadataService) GetRegistryTLS() (RegistryTLS, error) {
+	userData, err := ms.getUserData()
+	if err != nil {
+		return RegistryTLS{}, bosherr.WrapError(err, "Getting user data")
+	}
+
+	return userData.RegistryTLS(), nil
+}
+
 func (ms httpMetadataService) GetNetworks() (boshsettings.Networks, error) {
 	return nil, nil
 }
@@ -277,8 +291,13 @@ func (ms httpMetadataService) doGet(url string) (*http.Response, error) {
 		req.Header.Add(key, value)
 	}
 
+	var delegate boshhttp.Client = &http.Client{}
+	if ms.tokenClient != nil {
+		delegate = ms.tokenClient
+	}
+
 	client := boshhttp.NewRetryClient(
-		&http.Client{},
+		delegate,
 		10,
 		ms.retryDelay,
 		ms.logger,
@@ -9,6 +9,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 
 	fakeinf "github.com/cloudfoundry/bosh-agent/infrastructure/fakes"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
@@ -35,7 +36,7 @@ func describeHTTPMetadataService() {
 		dnsResolver = &fakeinf.FakeDNSResolver{}
 		platform = fakeplat.NewFakePlatform()
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		metadataService = NewHTTPMetadataService("fake-metadata-host", metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", dnsResolver, platform, logger)
+		metadataService = NewHTTPMetadataService("fake-metadata-host", metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger)
 	})
 
 	ItEnsuresMinimalNetworkSetup := func(subject func() (string, error)) {
@@ -102,7 +103,7 @@ func describeHTTPMetadataService() {
 		Context("when the ssh keys path is present", func() {
 			BeforeEach(func() {
 				sshKeysPath = "/ssh-keys"
-				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", sshKeysPath, dnsResolver, platform, logger)
+				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", sshKeysPath, TokenOptions{}, dnsResolver, platform, logger)
 			})
 
 			It("returns fetched public key", func() {
@@ -119,7 +120,7 @@ func describeHTTPMetadataService() {
 		Context("when the ssh keys path is not present", func() {
 			BeforeEach(func() {
 				sshKeysPath = ""
-				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", sshKeysPath, dnsResolver, platform, logger)
+				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", sshKeysPath, TokenOptions{}, dnsResolver, platform, logger)
 			})
 
 			It("returns an empty ssh key", func() {
@@ -156,7 +157,7 @@ func describeHTTPMetadataService() {
 		Context("when the instance ID path is present", func() {
 			BeforeEach(func() {
 				instanceIDPath = "/instanceid"
-				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", instanceIDPath, "/ssh-keys", dnsResolver, platform, logger)
+				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", instanceIDPath, "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger)
 			})
 
 			It("returns fetched instance id", func() {
@@ -173,7 +174,7 @@ func describeHTTPMetadataService() {
 		Context("when the instance ID path is not present", func() {
 			BeforeEach(func() {
 				instanceIDPath = ""
-				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", instanceIDPath, "/ssh-keys", dnsResolver, platform, logger)
+				metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", instanceIDPath, "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger)
 			})
 
 			It("returns an empty instance ID", func() {
@@ -213,7 +214,7 @@ func describeHTTPMetadataService() {
 
 			handler := http.HandlerFunc(handlerFunc)
 			ts = httptest.NewServer(handler)
-			metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", dnsResolver, platform, logger)
+			metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger)
 		})
 
 		AfterEach(func() {
@@ -250,6 +251,43 @@ func describeHTTPMetadataService() {
 		})
 	})
 
+	Describe("GetRegistryTLS", func() {
+		var ts *httptest.Server
+
+		BeforeEach(func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/user-data"))
+
+				w.Write([]byte(`{"registry":{"endpoint":"https://fake-registry.com","tls":{
+					"ca_cert":"fake-ca-cert",
+					"client_cert":"fake-client-cert",
+					"client_key":"fake-client-key",
+					"server_name":"fake-server-name"
+				}}}`))
+			})
+
+			ts = httptest.NewServer(handler)
+			metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger)
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("returns registry TLS settings from user data", func() {
+			registryTLS, err := metadataService.GetRegistryTLS()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(registryTLS).To(Equal(RegistryTLS{
+				CACert:     "fake-ca-cert",
+				ClientCert: "fake-client-cert",
+				ClientKey:  "fake-client-key",
+				ServerName: "fake-server-name",
+			}))
+		})
+	})
+
 	Describe("GetRegistryEndpoint", func() {
 		var (
 			ts          *httptest.Server
@@ -285,7 +323,7 @@ func describeHTTPMetadataService() {
 
 			handler := http.HandlerFunc(handlerFunc)
 			ts = httptest.NewServer(handler)
-			metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", dnsResolver, platform, logger)
+			metadataService = NewHTTPMetadataService(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger)
 		})
 
 		AfterEach(func() {
@@ -399,7 +437,7 @@ func describeHTTPMetadataService() {
 			It("returns the successfully resolved registry endpoint", func() {
 				handler := http.HandlerFunc(createHandlerFunc(9))
 				ts = httptest.NewServer(handler)
-				metadataService = NewHTTPMetadataServiceWithCustomRetryDelay(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", dnsResolver, platform, logger, 0*time.Second)
+				metadataService = NewHTTPMetadataServiceWithCustomRetryDelay(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger, 0*time.Second)
 
 				endpoint, err := metadataService.GetRegistryEndpoint()
 				Expect(err).ToNot(HaveOccurred())
@@ -412,7 +450,7 @@ func describeHTTPMetadataService() {
 			It("returns an error containing the HTTP Response", func() {
 				handler := http.HandlerFunc(createHandlerFunc(10))
 				ts = httptest.NewServer(handler)
-				metadataService = NewHTTPMetadataServiceWithCustomRetryDelay(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", dnsResolver, platform, logger, 0*time.Second)
+				metadataService = NewHTTPMetadataServiceWithCustomRetryDelay(ts.URL, metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", TokenOptions{}, dnsResolver, platform, logger, 0*time.Second)
 
 				_, err := metadataService.GetRegistryEndpoint()
 				Expect(err).ToNot(BeNil())
@@ -422,4 +460,84 @@ func describeHTTPMetadataService() {
 		})
 
 	})
+
+	Describe("when session token is configured", func() {
+		var (
+			server *ghttp.Server
+		)
+
+		BeforeEach(func() {
+			server = ghttp.NewServer()
+			tokenOptions := TokenOptions{Path: "/latest/api/token", TTLInSeconds: 300}
+			metadataService = NewHTTPMetadataServiceWithCustomRetryDelay(server.URL(), metadataHeaders, "/user-data", "/instanceid", "/ssh-keys", tokenOptions, dnsResolver, platform, logger, 0*time.Second)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		respondWithToken := func(token string) http.HandlerFunc {
+			return ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/latest/api/token"),
+				ghttp.VerifyHeaderKV("X-aws-ec2-metadata-token-ttl-seconds", "300"),
+				ghttp.RespondWith(http.StatusOK, token),
+			)
+		}
+
+		It("requests token once and sends it with every request", func() {
+			server.AppendHandlers(
+				respondWithToken("fake-token"),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/instanceid"),
+					ghttp.VerifyHeaderKV("X-aws-ec2-metadata-token", "fake-token"),
+					ghttp.VerifyHeaderKV("key", "value"),
+					ghttp.RespondWith(http.StatusOK, "fake-instance-id"),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/ssh-keys"),
+					ghttp.VerifyHeaderKV("X-aws-ec2-metadata-token", "fake-token"),
+					ghttp.RespondWith(http.StatusOK, "fake-public-key"),
+				),
+			)
+
+			instanceID, err := metadataService.GetInstanceID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceID).To(Equal("fake-instance-id"))
+
+			publicKey, err := metadataService.GetPublicKey()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("fake-public-key"))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("requests new token when metadata service rejects token", func() {
+			server.AppendHandlers(
+				respondWithToken("fake-expired-token"),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("X-aws-ec2-metadata-token", "fake-expired-token"),
+					ghttp.RespondWith(http.StatusUnauthorized, ""),
+				),
+				respondWithToken("fake-token"),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/instanceid"),
+					ghttp.VerifyHeaderKV("X-aws-ec2-metadata-token", "fake-token"),
+					ghttp.RespondWith(http.StatusOK, "fake-instance-id"),
+				),
+			)
+
+			instanceID, err := metadataService.GetInstanceID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceID).To(Equal("fake-instance-id"))
+		})
+
+		It("returns an error when token cannot be requested", func() {
+			server.AllowUnhandledRequests = true
+			server.UnhandledRequestStatusCode = http.StatusForbidden
+
+			_, err := metadataService.GetInstanceID()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Requesting session token from url %s/latest/api/token: status 403", server.URL()))
+		})
+	})
 }
@@ -1,31 +1,47 @@
 package infrastructure
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	boshplat "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 type httpRegistry struct {
 	metadataService   MetadataService
 	platform          boshplat.Platform
 	useServerNameAsID bool
+	retryPolicy       RetryPolicy
+	circuitBreaker    *CircuitBreaker
+	logTag            string
+	logger            boshlog.Logger
 }
 
 func NewHTTPRegistry(
 	metadataService MetadataService,
 	platform boshplat.Platform,
 	useServerNameAsID bool,
+	retryPolicy RetryPolicy,
+	circuitBreaker *CircuitBreaker,
+	logger boshlog.Logger,
 ) Registry {
 	return httpRegistry{
 		metadataService:   metadataService,
 		platform:          platform,
 		useServerNameAsID: useServerNameAsID,
+		retryPolicy:       retryPolicy,
+		circuitBreaker:    circuitBreaker,
+		logTag:            "httpRegistry",
+		logger:            logger,
 	}
 }
 
@@ -33,6 +49,16 @@ type settingsWrapperType struct {
 	Settings string
 }
 
+// registryResponseError is returned for responses with unexpected status;
+// only server errors are retried
+type registryResponseError struct {
+	statusCode int
+}
+
+func (e registryResponseError) Error() string {
+	return fmt.Sprintf("Registry responded with status %d", e.statusCode)
+}
+
 func (r httpRegistry) GetSettings() (boshsettings.Settings, error) {
 	var settings boshsettings.Settings
 
@@ -56,6 +82,16 @@ func (r httpRegistry) GetSettings() (boshsettings.Settings, error) {
 		return settings, bosherr.WrapError(err, "Getting registry endpoint")
 	}
 
+	registryTLS, err := r.metadataService.GetRegistryTLS()
+	if err != nil {
+		return settings, bosherr.WrapError(err, "Getting registry TLS settings")
+	}
+
+	client, err := r.buildClient(registryEndpoint, registryTLS)
+	if err != nil {
+		return settings, bosherr.WrapError(err, "Building registry client")
+	}
+
 	networks, err := r.metadataService.GetNetworks()
 	if err != nil {
 		return settings, bosherr.WrapError(err, "Getting networks")
@@ -69,18 +105,10 @@ func (r httpRegistry) GetSettings() (boshsettings.Settings, error) {
 	}
 
 	settingsURL := fmt.Sprintf("%s/instances/%s/settings", registryEndpoint, identifier)
-	wrapperResponse, err := http.Get(settingsURL)
-	if err != nil {
-		return settings, bosherr.WrapError(err, "Getting settings from url")
-	}
 
-	defer func() {
-		_ = wrapperResponse.Body.Close()
-	}()
-
-	wrapperBytes, err := ioutil.ReadAll(wrapperResponse.Body)
+	wrapperBytes, err := r.getWithRetries(client, settingsURL)
 	if err != nil {
-		return settings, bosherr.WrapError(err, "Reading settings response body")
+		return settings, bosherr.WrapError(err, "Getting settings from url")
 	}
 
 	var wrapper settingsWrapperType
@@ -97,3 +125,112 @@ func (r httpRegistry) GetSettings() (boshsettings.Settings, error) {
 
 	return settings, nil
 }
+
+// buildClient pins registry CA when given; plain HTTP is rejected in that case
+// so that registry credentials are never sent to an unverified endpoint.
+// Certificate is verified against server name, which metadata services default
+// to registry host before it was resolved to an IP address
+func (r httpRegistry) buildClient(registryEndpoint string, registryTLS RegistryTLS) (*http.Client, error) {
+	client := &http.Client{Timeout: r.retryPolicy.AttemptTimeout}
+
+	isHTTPS := strings.HasPrefix(registryEndpoint, "https://")
+
+	if registryTLS.CACert == "" && registryTLS.ClientCert == "" {
+		if !isHTTPS || registryTLS.ServerName == "" {
+			return client, nil
+		}
+	} else if !isHTTPS {
+		return nil, bosherr.Errorf("Registry endpoint '%s' must use https when registry TLS is configured", boshsettings.RedactURL(registryEndpoint))
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: registryTLS.ServerName,
+	}
+
+	if registryTLS.CACert != "" {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM([]byte(registryTLS.CACert)) {
+			return nil, bosherr.Error("Parsing registry CA certificate")
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if registryTLS.ClientCert != "" {
+		clientCert, err := tls.X509KeyPair([]byte(registryTLS.ClientCert), []byte(registryTLS.ClientKey))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing registry client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	client.Transport = &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+
+	return client, nil
+}
+
+// getWithRetries retries network errors and server errors according to retry policy;
+// circuit breaker is consulted once per call so that retries of a single call
+// count as a single failure
+func (r httpRegistry) getWithRetries(client *http.Client, url string) ([]byte, error) {
+	err := r.circuitBreaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts := r.retryPolicy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		var body []byte
+
+		body, err = r.get(client, url)
+		if err == nil {
+			r.circuitBreaker.RecordSuccess()
+			return body, nil
+		}
+
+		if responseErr, ok := err.(registryResponseError); ok && responseErr.statusCode < 500 {
+			break
+		}
+
+		if attempt >= maxAttempts {
+			break
+		}
+
+		delay := r.retryPolicy.Delay(attempt)
+		r.logger.Debug(r.logTag, "Attempt %d of %d failed, retrying in %s: %s", attempt, maxAttempts, delay, err.Error())
+		time.Sleep(delay)
+	}
+
+	r.circuitBreaker.RecordFailure()
+
+	return nil, err
+}
+
+func (r httpRegistry) get(client *http.Client, url string) ([]byte, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, registryResponseError{statusCode: response.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading settings response body")
+	}
+
+	return body, nil
+}
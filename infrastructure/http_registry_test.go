@@ -1,11 +1,15 @@
 package infrastructure_test
 
 import (
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -14,6 +18,8 @@ import (
 	fakeinf "github.com/cloudfoundry/bosh-agent/infrastructure/fakes"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 var _ = Describe("httpRegistry", describeHTTPRegistry)
@@ -23,27 +29,53 @@ func describeHTTPRegistry() {
 		metadataService *fakeinf.FakeMetadataService
 		registry        Registry
 		platform        *fakeplat.FakePlatform
+		retryPolicy     RetryPolicy
+		timeService     *fakeclock.FakeClock
+		circuitBreaker  *CircuitBreaker
+		logger          boshlog.Logger
 	)
 
 	BeforeEach(func() {
 		metadataService = &fakeinf.FakeMetadataService{}
 		platform = &fakeplat.FakePlatform{}
-		registry = NewHTTPRegistry(metadataService, platform, false)
+		retryPolicy = RetryPolicy{MaxAttempts: 3, AttemptTimeout: 5 * time.Second}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		circuitBreaker = NewCircuitBreaker(2, 30*time.Second, timeService)
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		registry = NewHTTPRegistry(metadataService, platform, false, retryPolicy, circuitBreaker, logger)
 	})
 
 	Describe("GetSettings", func() {
 		var (
-			ts           *httptest.Server
-			settingsJSON string
+			ts                  *httptest.Server
+			boshRegistryHandler http.HandlerFunc
+			settingsJSON        string
+			responseStatuses    []int
+			requestCount        int
 		)
 
 		BeforeEach(func() {
-			boshRegistryHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			responseStatuses = nil
+			requestCount = 0
+
+			boshRegistryHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				GinkgoRecover()
 
 				Expect(r.Method).To(Equal("GET"))
 				Expect(r.URL.Path).To(Equal("/instances/fake-identifier/settings"))
 
+				requestCount++
+
+				if len(responseStatuses) > 0 {
+					status := responseStatuses[0]
+					responseStatuses = responseStatuses[1:]
+
+					if status != http.StatusOK {
+						w.WriteHeader(status)
+						return
+					}
+				}
+
 				w.Write([]byte(settingsJSON))
 			})
 
@@ -59,7 +91,7 @@ func describeHTTPRegistry() {
 				settingsJSON = `{"settings": "{\"agent_id\":\"my-agent-id\"}"}`
 				metadataService.InstanceID = "fake-identifier"
 				metadataService.RegistryEndpoint = ts.URL
-				registry = NewHTTPRegistry(metadataService, platform, false)
+				registry = NewHTTPRegistry(metadataService, platform, false, retryPolicy, circuitBreaker, logger)
 			})
 
 			Context("when the metadata has Networks information", func() {
@@ -120,7 +152,7 @@ func describeHTTPRegistry() {
 
 		Context("when registry is configured to not use server name as id", func() {
 			BeforeEach(func() {
-				registry = NewHTTPRegistry(metadataService, platform, false)
+				registry = NewHTTPRegistry(metadataService, platform, false, retryPolicy, circuitBreaker, logger)
 				metadataService.InstanceID = "fake-identifier"
 				metadataService.RegistryEndpoint = ts.URL
 			})
@@ -284,7 +316,7 @@ func describeHTTPRegistry() {
 
 		Context("when registry is configured to use server name as id", func() {
 			BeforeEach(func() {
-				registry = NewHTTPRegistry(metadataService, platform, true)
+				registry = NewHTTPRegistry(metadataService, platform, true, retryPolicy, circuitBreaker, logger)
 				metadataService.ServerName = "fake-identifier"
 				metadataService.RegistryEndpoint = ts.URL
 			})
@@ -337,5 +369,198 @@ func describeHTTPRegistry() {
 				Expect(settings).To(Equal(boshsettings.Settings{}))
 			})
 		})
+
+		Context("when registry fails", func() {
+			BeforeEach(func() {
+				settingsJSON = `{"settings": "{\"agent_id\":\"my-agent-id\"}"}`
+				metadataService.InstanceID = "fake-identifier"
+				metadataService.RegistryEndpoint = ts.URL
+			})
+
+			It("retries server errors up to max attempts", func() {
+				responseStatuses = []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK}
+
+				settings, err := registry.GetSettings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings).To(Equal(boshsettings.Settings{AgentID: "my-agent-id"}))
+				Expect(requestCount).To(Equal(3))
+			})
+
+			It("returns error after max attempts", func() {
+				responseStatuses = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Getting settings from url: Registry responded with status 502"))
+				Expect(requestCount).To(Equal(3))
+			})
+
+			It("does not retry client errors", func() {
+				responseStatuses = []int{http.StatusNotFound}
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Registry responded with status 404"))
+				Expect(requestCount).To(Equal(1))
+			})
+
+			It("fails fast while circuit breaker is open and tries again after reset timeout", func() {
+				responseStatuses = []int{http.StatusNotFound, http.StatusNotFound}
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				_, err = registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(requestCount).To(Equal(2))
+
+				_, err = registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Circuit breaker is open after 2 consecutive failures"))
+				Expect(requestCount).To(Equal(2))
+
+				timeService.Increment(31 * time.Second)
+
+				settings, err := registry.GetSettings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings).To(Equal(boshsettings.Settings{AgentID: "my-agent-id"}))
+				Expect(requestCount).To(Equal(3))
+			})
+		})
+
+		Context("when registry TLS is configured", func() {
+			var tlsServer *httptest.Server
+
+			BeforeEach(func() {
+				settingsJSON = `{"settings": "{\"agent_id\":\"my-agent-id\"}"}`
+				tlsServer = httptest.NewTLSServer(boshRegistryHandler)
+
+				caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.TLS.Certificates[0].Certificate[0]})
+
+				metadataService.InstanceID = "fake-identifier"
+				metadataService.RegistryEndpoint = tlsServer.URL
+				metadataService.RegistryTLS = RegistryTLS{CACert: string(caCert), ServerName: "example.com"}
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			It("returns settings fetched from registry verified with pinned CA", func() {
+				settings, err := registry.GetSettings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings).To(Equal(boshsettings.Settings{AgentID: "my-agent-id"}))
+			})
+
+			It("returns error when registry certificate is not valid for server name", func() {
+				metadataService.RegistryTLS.ServerName = "fake-registry.com"
+				retryPolicy.MaxAttempts = 1
+				registry = NewHTTPRegistry(metadataService, platform, false, retryPolicy, circuitBreaker, logger)
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("certificate"))
+				Expect(requestCount).To(Equal(0))
+			})
+
+			It("returns error when registry endpoint does not use https", func() {
+				metadataService.RegistryEndpoint = ts.URL
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must use https when registry TLS is configured"))
+				Expect(requestCount).To(Equal(0))
+			})
+
+			It("returns error when client certificate cannot be parsed", func() {
+				metadataService.RegistryTLS.ClientCert = "fake-client-cert"
+				metadataService.RegistryTLS.ClientKey = "fake-client-key"
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Parsing registry client certificate"))
+			})
+
+			It("returns error if metadata service fails to return registry TLS settings", func() {
+				metadataService.GetRegistryTLSErr = errors.New("fake-get-registry-tls-err")
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Getting registry TLS settings: fake-get-registry-tls-err"))
+			})
+		})
+
+		Context("when registry endpoint is resolved to an IP address", func() {
+			var (
+				tlsServer      *httptest.Server
+				userDataServer *httptest.Server
+				registryTLS    map[string]string
+			)
+
+			BeforeEach(func() {
+				settingsJSON = `{"settings": "{\"agent_id\":\"my-agent-id\"}"}`
+
+				// Test certificate is valid for 127.0.0.1 but not for 127.0.0.2
+				listener, err := net.Listen("tcp", "127.0.0.2:0")
+				Expect(err).ToNot(HaveOccurred())
+
+				tlsServer = httptest.NewUnstartedServer(boshRegistryHandler)
+				tlsServer.Listener.Close()
+				tlsServer.Listener = listener
+				tlsServer.StartTLS()
+
+				caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.TLS.Certificates[0].Certificate[0]})
+				registryTLS = map[string]string{"ca_cert": string(caCert)}
+
+				userDataServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					port := tlsServer.URL[strings.LastIndex(tlsServer.URL, ":")+1:]
+
+					userData, err := json.Marshal(map[string]interface{}{
+						"registry": map[string]interface{}{"endpoint": "https://example.com:" + port, "tls": registryTLS},
+						"server":   map[string]string{"name": "fake-identifier"},
+						"dns":      map[string][]string{"nameserver": {"fake-dns-server-ip"}},
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					w.Write(userData)
+				}))
+
+				dnsResolver := &fakeinf.FakeDNSResolver{}
+				dnsResolver.RegisterRecord(fakeinf.FakeDNSRecord{
+					DNSServers: []string{"fake-dns-server-ip"},
+					Host:       "example.com",
+					IP:         "127.0.0.2",
+				})
+
+				resolvingMetadataService := NewHTTPMetadataService(
+					userDataServer.URL, nil, "", "", "", TokenOptions{},
+					NewRegistryEndpointResolver(dnsResolver), platform, logger,
+				)
+
+				retryPolicy.MaxAttempts = 1
+				registry = NewHTTPRegistry(resolvingMetadataService, platform, true, retryPolicy, circuitBreaker, logger)
+			})
+
+			AfterEach(func() {
+				userDataServer.Close()
+				tlsServer.Close()
+			})
+
+			It("verifies registry certificate against host name from user data", func() {
+				settings, err := registry.GetSettings()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(settings).To(Equal(boshsettings.Settings{AgentID: "my-agent-id"}))
+			})
+
+			It("verifies registry certificate against server name from user data when given", func() {
+				registryTLS["server_name"] = "fake-registry.com"
+
+				_, err := registry.GetSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("certificate"))
+				Expect(requestCount).To(Equal(0))
+			})
+		})
 	})
 }
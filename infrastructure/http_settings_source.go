@@ -0,0 +1,57 @@
+package infrastructure
+
+import (
+	"encoding/json"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// HTTPSettingsSource reads complete settings from a metadata service path
+// (e.g. user data) instead of fetching them from registry
+type HTTPSettingsSource struct {
+	metadataService DynamicMetadataService
+	settingsPath    string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewHTTPSettingsSource(
+	metadataService DynamicMetadataService,
+	settingsPath string,
+	logger boshlog.Logger,
+) *HTTPSettingsSource {
+	return &HTTPSettingsSource{
+		metadataService: metadataService,
+		settingsPath:    settingsPath,
+
+		logTag: "HTTPSettingsSource",
+		logger: logger,
+	}
+}
+
+func (s HTTPSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "HTTP", Endpoint: s.settingsPath}
+}
+
+func (s HTTPSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
+	return s.metadataService.GetPublicKey()
+}
+
+func (s *HTTPSettingsSource) Settings() (boshsettings.Settings, error) {
+	var settings boshsettings.Settings
+
+	contents, err := s.metadataService.GetValueAtPath(s.settingsPath)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Reading settings from metadata service path '%s'", s.settingsPath)
+	}
+
+	err = json.Unmarshal([]byte(contents), &settings)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Parsing metadata service settings from '%s'", s.settingsPath)
+	}
+
+	return settings, nil
+}
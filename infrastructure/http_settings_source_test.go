@@ -0,0 +1,77 @@
+package infrastructure_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("HTTPSettingsSource", func() {
+	var (
+		server *ghttp.Server
+		source *HTTPSettingsSource
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		platform := fakeplatform.NewFakePlatform()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		headers := map[string]string{"key": "value"}
+		metadataService := NewHTTPMetadataServiceWithCustomRetryDelay(server.URL(), headers, "/fake-user-data-path", "", "/fake-ssh-keys-path", TokenOptions{}, nil, platform, logger, 0)
+		source = NewHTTPSettingsSource(metadataService, "/fake-settings-path", logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		It("returns public key from metadata service", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/fake-ssh-keys-path"),
+				ghttp.RespondWith(http.StatusOK, "ssh-rsa fake-key"),
+			))
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key"))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings read from settings path", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/fake-settings-path"),
+				ghttp.VerifyHeaderKV("key", "value"),
+				ghttp.RespondWith(http.StatusOK, `{"agent_id": "fake-agent-id"}`),
+			))
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+		})
+
+		It("returns an error when settings cannot be read", func() {
+			server.AllowUnhandledRequests = true
+			server.UnhandledRequestStatusCode = http.StatusNotFound
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading settings from metadata service path '/fake-settings-path'"))
+		})
+
+		It("returns an error when settings are not JSON", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "fake-invalid-json"))
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing metadata service settings"))
+		})
+	})
+})
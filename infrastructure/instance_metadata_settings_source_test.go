@@ -6,6 +6,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -30,7 +31,7 @@ func describeInstanceMetadataSettingsSource() {
 		settingsPath = "/computeMetadata/v1/instance/attributes/bosh_settings"
 		platform = fakeplat.NewFakePlatform()
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		metadataSource = NewInstanceMetadataSettingsSource("http://fake-metadata-host", metadataHeaders, settingsPath, platform, logger)
+		metadataSource = NewInstanceMetadataSettingsSource("http://fake-metadata-host", metadataHeaders, TokenOptions{}, settingsPath, platform, logger)
 	})
 
 	Describe("PublicSSHKeyForUsername", func() {
@@ -63,7 +64,7 @@ func describeInstanceMetadataSettingsSource() {
 		BeforeEach(func() {
 			handler := http.HandlerFunc(handlerFunc)
 			ts = httptest.NewServer(handler)
-			metadataSource = NewInstanceMetadataSettingsSource(ts.URL, metadataHeaders, settingsPath, platform, logger)
+			metadataSource = NewInstanceMetadataSettingsSource(ts.URL, metadataHeaders, TokenOptions{}, settingsPath, platform, logger)
 		})
 
 		AfterEach(func() {
@@ -77,10 +78,44 @@ func describeInstanceMetadataSettingsSource() {
 		})
 
 		It("returns an error if reading from the instance metadata endpoint fails", func() {
-			metadataSource = NewInstanceMetadataSettingsSourceWithoutRetryDelay("bad-registry-endpoint", metadataHeaders, settingsPath, platform, logger)
+			metadataSource = NewInstanceMetadataSettingsSourceWithoutRetryDelay("bad-registry-endpoint", metadataHeaders, TokenOptions{}, settingsPath, platform, logger)
 			_, err := metadataSource.Settings()
 			Expect(err).To(HaveOccurred())
 		})
 
+		Context("when session token is configured", func() {
+			var (
+				server *ghttp.Server
+			)
+
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+				tokenOptions := TokenOptions{Path: "/fake-token-path", TTLHeader: "fake-ttl-header", Header: "fake-token-header"}
+				metadataSource = NewInstanceMetadataSettingsSource(server.URL(), metadataHeaders, tokenOptions, settingsPath, platform, logger)
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("returns settings read with the session token", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/fake-token-path"),
+						ghttp.VerifyHeaderKV("fake-ttl-header", "21600"),
+						ghttp.RespondWith(http.StatusOK, "fake-token"),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", settingsPath),
+						ghttp.VerifyHeaderKV("fake-token-header", "fake-token"),
+						ghttp.RespondWith(http.StatusOK, `{"agent_id": "123"}`),
+					),
+				)
+
+				settings, err := metadataSource.Settings()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(settings.AgentID).To(Equal("123"))
+			})
+		})
 	})
 }
@@ -1,6 +1,10 @@
 package infrastructure
 
 import (
+	"net"
+	"net/url"
+	"strings"
+
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 )
 
@@ -10,6 +14,7 @@ type MetadataService interface {
 	GetInstanceID() (string, error)
 	GetServerName() (string, error)
 	GetRegistryEndpoint() (string, error)
+	GetRegistryTLS() (RegistryTLS, error)
 	GetNetworks() (boshsettings.Networks, error)
 }
 
@@ -21,9 +26,22 @@ type MetadataServiceProvider interface {
 	Get() MetadataService
 }
 
+// RegistryTLS holds PEM encoded CA certificate pinned for registry
+// and optional client certificate presented to registry
+type RegistryTLS struct {
+	CACert     string `json:"ca_cert"`
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+
+	// Name registry certificate is verified against;
+	// defaults to registry host before it was resolved
+	ServerName string `json:"server_name"`
+}
+
 type UserDataContentsType struct {
 	Registry struct {
 		Endpoint string
+		TLS      RegistryTLS `json:"tls"`
 	}
 	Server struct {
 		Name string // Name given by CPI e.g. vm-384sd4-r7re9e...
@@ -34,6 +52,39 @@ type UserDataContentsType struct {
 	Networks boshsettings.Networks
 }
 
+// Redacted returns copy of user data without registry credentials
+func (u UserDataContentsType) Redacted() UserDataContentsType {
+	u.Registry.Endpoint = boshsettings.RedactURL(u.Registry.Endpoint)
+	u.Registry.TLS.ClientKey = ""
+	return u
+}
+
+// RegistryTLS returns registry TLS settings with server name
+// taken from registry endpoint when not set
+func (u UserDataContentsType) RegistryTLS() RegistryTLS {
+	registryTLS := u.Registry.TLS
+
+	if registryTLS.ServerName == "" {
+		endpointURL, err := url.Parse(u.Registry.Endpoint)
+		if err == nil {
+			registryTLS.ServerName, _ = splitURLHost(endpointURL)
+		}
+	}
+
+	return registryTLS
+}
+
+// splitURLHost returns host name without IPv6 brackets
+// and port which is empty when URL does not include it
+func splitURLHost(u *url.URL) (string, string) {
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return strings.TrimSuffix(strings.TrimPrefix(u.Host, "["), "]"), ""
+	}
+
+	return host, port
+}
+
 type DynamicMetadataService interface {
 	MetadataService
 	GetValueAtPath(string) (string, error)
@@ -0,0 +1,169 @@
+package infrastructure
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttp "github.com/cloudfoundry/bosh-utils/http"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	defaultTokenTTLInSeconds = 21600
+	defaultTokenTTLHeader    = "X-aws-ec2-metadata-token-ttl-seconds"
+	defaultTokenHeader       = "X-aws-ec2-metadata-token"
+)
+
+// TokenOptions configure session token which is requested with PUT
+// from Path and sent with every metadata request, e.g. AWS IMDSv2;
+// tokens are not used when Path is empty
+type TokenOptions struct {
+	Path         string
+	TTLInSeconds int
+
+	// Header carrying requested TTL when requesting token
+	TTLHeader string
+
+	// Header carrying token in metadata requests
+	Header string
+}
+
+// withDefaults fills TTL and headers of AWS IMDSv2 when token is used
+func (o TokenOptions) withDefaults() TokenOptions {
+	if o.Path == "" {
+		return o
+	}
+
+	if o.TTLInSeconds <= 0 {
+		o.TTLInSeconds = defaultTokenTTLInSeconds
+	}
+
+	o.TTLHeader = valueOrDefault(o.TTLHeader, defaultTokenTTLHeader)
+	o.Header = valueOrDefault(o.Header, defaultTokenHeader)
+
+	return o
+}
+
+// metadataTokenClient caches session token until it expires
+// or metadata service rejects it with 401
+type metadataTokenClient struct {
+	delegate boshhttp.Client
+
+	tokenURL string
+	options  TokenOptions
+
+	tokenLock      sync.Mutex
+	token          string
+	tokenExpiresAt time.Time
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func newMetadataTokenClient(
+	delegate boshhttp.Client,
+	metadataHost string,
+	options TokenOptions,
+	logger boshlog.Logger,
+) *metadataTokenClient {
+	if options.Path == "" {
+		return nil
+	}
+
+	options = options.withDefaults()
+
+	return &metadataTokenClient{
+		delegate: delegate,
+
+		tokenURL: metadataHost + options.Path,
+		options:  options,
+
+		logTag: "metadataTokenClient",
+		logger: logger,
+	}
+}
+
+func (c *metadataTokenClient) Do(req *http.Request) (*http.Response, error) {
+	token, err := c.getToken(false)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(c.options.Header, token)
+
+	// Requests with body are not repeated since body was already read
+	resp, err := c.delegate.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || req.Body != nil {
+		return resp, err
+	}
+
+	c.logger.Debug(c.logTag, "Metadata service rejected session token, requesting new token")
+
+	_, _ = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	token, err = c.getToken(true)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(c.options.Header, token)
+
+	return c.delegate.Do(req)
+}
+
+func (c *metadataTokenClient) getToken(refresh bool) (string, error) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+
+	if !refresh && c.token != "" && time.Now().Before(c.tokenExpiresAt) {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequest("PUT", c.tokenURL, nil)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Building session token request")
+	}
+
+	req.Header.Set(c.options.TTLHeader, fmt.Sprintf("%d", c.options.TTLInSeconds))
+
+	requestedAt := time.Now()
+
+	resp, err := c.delegate.Do(req)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Requesting session token from url %s", c.tokenURL)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.logger.Warn(c.logTag, "Failed to close response body when requesting session token: %s", err.Error())
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading session token response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", bosherr.Errorf("Requesting session token from url %s: status %d", c.tokenURL, resp.StatusCode)
+	}
+
+	token := strings.TrimSpace(string(body))
+	if token == "" {
+		return "", bosherr.Errorf("Requesting session token from url %s: empty token", c.tokenURL)
+	}
+
+	// Refresh token ahead of expiry so requests in flight do not use expired token
+	ttl := time.Duration(c.options.TTLInSeconds) * time.Second
+
+	c.token = token
+	c.tokenExpiresAt = requestedAt.Add(ttl - ttl/10)
+
+	return c.token, nil
+}
@@ -21,6 +21,15 @@ func NewMultiSettingsSource(sources ...boshsettings.Source) (boshsettings.Source
 	return &MultiSettingsSource{sources: sources}, err
 }
 
+// SourceInfo describes source which answered settings request
+func (s *MultiSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	if s.selectedSettingsSource == nil {
+		return boshsettings.SourceInfo{Type: "Multi"}
+	}
+
+	return boshsettings.DescribeSource(s.selectedSettingsSource)
+}
+
 func (s *MultiSettingsSource) PublicSSHKeyForUsername(username string) (string, error) {
 	if s.selectedSSHKeySource != nil {
 		return s.selectedSSHKeySource.PublicSSHKeyForUsername(username)
@@ -8,7 +8,9 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/infrastructure"
 	fakeinf "github.com/cloudfoundry/bosh-agent/infrastructure/fakes"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 var _ = Describe("MultiSettingsSource", func() {
@@ -122,4 +124,22 @@ var _ = Describe("MultiSettingsSource", func() {
 			})
 		})
 	})
+
+	Describe("SourceInfo", func() {
+		It("describes the source which answered settings request", func() {
+			platform := fakeplatform.NewFakePlatform()
+			platform.GetFileContentsFromCDROMContents = []byte(`{"agent_id": "fake-agent-id"}`)
+
+			failingSource := fakeinf.FakeSettingsSource{SettingsErr: errors.New("fake-settings-err")}
+			cdromSource := NewCDROMSettingsSource("fake-file-name", platform, boshlog.NewLogger(boshlog.LevelNone))
+
+			source, err := NewMultiSettingsSource(failingSource, cdromSource)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(boshsettings.DescribeSource(source)).To(Equal(boshsettings.SourceInfo{Type: "CDROM", Endpoint: "fake-file-name"}))
+		})
+	})
 })
@@ -29,6 +29,10 @@ func (ms *MultiSourceMetadataService) GetRegistryEndpoint() (string, error) {
 	return ms.getSelectedService().GetRegistryEndpoint()
 }
 
+func (ms *MultiSourceMetadataService) GetRegistryTLS() (RegistryTLS, error) {
+	return ms.getSelectedService().GetRegistryTLS()
+}
+
 func (ms *MultiSourceMetadataService) GetNetworks() (boshsettings.Networks, error) {
 	return ms.getSelectedService().GetNetworks()
 }
@@ -37,6 +41,11 @@ func (ms *MultiSourceMetadataService) IsAvailable() bool {
 	return true
 }
 
+// SelectedService returns first available service which answers all requests
+func (ms *MultiSourceMetadataService) SelectedService() MetadataService {
+	return ms.getSelectedService()
+}
+
 func (ms *MultiSourceMetadataService) getSelectedService() MetadataService {
 	if ms.selectedService == nil {
 		for _, service := range ms.Services {
@@ -0,0 +1,126 @@
+package infrastructure
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/localdns"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const nativeDNSResolverLogTag = "Native DNS Resolver"
+
+// DefaultDNSQueryTimeout is how long resolver waits for a single DNS server to answer a query
+const DefaultDNSQueryTimeout = 2 * time.Second
+
+// NativeDNSResolver queries given DNS servers directly; queries are sent
+// over UDP and repeated over TCP when response is truncated
+type NativeDNSResolver struct {
+	timeout time.Duration
+	logger  boshlog.Logger
+}
+
+// NewNativeDNSResolver returns resolver waiting up to timeout for each query
+func NewNativeDNSResolver(timeout time.Duration, logger boshlog.Logger) NativeDNSResolver {
+	return NativeDNSResolver{
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// LookupHost returns first IPv4 address of host,
+// or first IPv6 address when host has no IPv4 addresses
+func (res NativeDNSResolver) LookupHost(dnsServers []string, host string) (string, error) {
+	if host == "localhost" {
+		return "127.0.0.1", nil
+	}
+
+	ip := net.ParseIP(host)
+	if ip != nil {
+		return host, nil
+	}
+
+	var err error
+	var ipString string
+
+	if len(dnsServers) == 0 {
+		err = errors.New("No DNS servers provided")
+	}
+
+	for _, dnsServer := range dnsServers {
+		ipString, err = res.lookupHostWithDNSServer(dnsServer, host)
+		if err == nil {
+			return ipString, nil
+		}
+
+		res.logger.Debug(nativeDNSResolverLogTag, "Failed to resolve '%s' with DNS server '%s': %s", host, dnsServer, err.Error())
+	}
+
+	return "", err
+}
+
+func (res NativeDNSResolver) lookupHostWithDNSServer(dnsServer string, host string) (string, error) {
+	for _, qtype := range []uint16{localdns.TypeA, localdns.TypeAAAA} {
+		addresses, err := res.query(dnsServerAddress(dnsServer), host, qtype)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Querying DNS server '%s'", dnsServer)
+		}
+
+		if len(addresses) > 0 {
+			return addresses[0].String(), nil
+		}
+	}
+
+	return "", bosherr.Errorf("Resolving host '%s': no addresses found", host)
+}
+
+func (res NativeDNSResolver) query(serverAddress string, host string, qtype uint16) ([]net.IP, error) {
+	query, err := localdns.BuildQuery(host, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := res.exchange("udp", serverAddress, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Truncated {
+		res.logger.Debug(nativeDNSResolverLogTag, "Response from '%s' is truncated, retrying over TCP", serverAddress)
+
+		response, err = res.exchange("tcp", serverAddress, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch response.Rcode {
+	case localdns.RcodeSuccess:
+	case localdns.RcodeNameError:
+		return nil, bosherr.Errorf("Host '%s' does not exist", host)
+	default:
+		return nil, bosherr.Errorf("DNS server responded with error code %d", response.Rcode)
+	}
+
+	return response.Addresses, nil
+}
+
+func (res NativeDNSResolver) exchange(network, serverAddress string, query []byte) (localdns.Response, error) {
+	msg, err := localdns.Exchange(network, serverAddress, query, res.timeout)
+	if err != nil {
+		return localdns.Response{}, bosherr.WrapErrorf(err, "Exchanging query over %s", strings.ToUpper(network))
+	}
+
+	return localdns.ParseResponse(query, msg)
+}
+
+// dnsServerAddress adds default DNS port to servers given without port
+func dnsServerAddress(dnsServer string) string {
+	if _, _, err := net.SplitHostPort(dnsServer); err == nil {
+		return dnsServer
+	}
+	return net.JoinHostPort(dnsServer, "53")
+}
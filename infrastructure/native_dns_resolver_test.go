@@ -0,0 +1,292 @@
+package infrastructure_test
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// fakeDNSServer answers A and AAAA queries over UDP and TCP on the same port
+type fakeDNSServer struct {
+	udpConn     net.PacketConn
+	tcpListener net.Listener
+
+	lock            sync.Mutex
+	records         map[string][]net.IP
+	truncateUDP     bool
+	nameError       bool
+	udpQueryCount   int
+	tcpQueryCount   int
+	receivedQueries chan string
+}
+
+func newFakeDNSServer() *fakeDNSServer {
+	server := &fakeDNSServer{
+		records:         map[string][]net.IP{},
+		receivedQueries: make(chan string, 100),
+	}
+
+	var err error
+
+	for i := 0; i < 10; i++ {
+		server.udpConn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		server.tcpListener, err = net.Listen("tcp", server.udpConn.LocalAddr().String())
+		if err == nil {
+			break
+		}
+		server.udpConn.Close()
+	}
+	Expect(err).ToNot(HaveOccurred())
+
+	go server.serveUDP()
+	go server.serveTCP()
+
+	return server
+}
+
+func (s *fakeDNSServer) Address() string {
+	return s.udpConn.LocalAddr().String()
+}
+
+func (s *fakeDNSServer) AddRecord(name string, ip string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records[name] = append(s.records[name], net.ParseIP(ip))
+}
+
+func (s *fakeDNSServer) TruncateUDP() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.truncateUDP = true
+}
+
+func (s *fakeDNSServer) RespondWithNameError() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nameError = true
+}
+
+func (s *fakeDNSServer) QueryCounts() (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.udpQueryCount, s.tcpQueryCount
+}
+
+func (s *fakeDNSServer) Close() {
+	s.udpConn.Close()
+	s.tcpListener.Close()
+}
+
+func (s *fakeDNSServer) serveUDP() {
+	buf := make([]byte, 512)
+
+	for {
+		n, addr, err := s.udpConn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		s.udpConn.WriteTo(s.respond(buf[:n], true), addr)
+	}
+}
+
+func (s *fakeDNSServer) serveTCP() {
+	for {
+		conn, err := s.tcpListener.Accept()
+		if err != nil {
+			return
+		}
+
+		lengthBuf := make([]byte, 2)
+		io.ReadFull(conn, lengthBuf)
+		query := make([]byte, binary.BigEndian.Uint16(lengthBuf))
+		io.ReadFull(conn, query)
+
+		response := s.respond(query, false)
+
+		binary.BigEndian.PutUint16(lengthBuf, uint16(len(response)))
+		conn.Write(append(lengthBuf, response...))
+		conn.Close()
+	}
+}
+
+// respond copies question and answers it with records of requested type;
+// answer names point to question name
+func (s *fakeDNSServer) respond(query []byte, udp bool) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if udp {
+		s.udpQueryCount++
+	} else {
+		s.tcpQueryCount++
+	}
+
+	questionEnd := 12
+	var labels []string
+
+	for query[questionEnd] != 0 {
+		length := int(query[questionEnd])
+		labels = append(labels, string(query[questionEnd+1:questionEnd+1+length]))
+		questionEnd += length + 1
+	}
+	questionEnd += 5
+
+	qtype := binary.BigEndian.Uint16(query[questionEnd-4:])
+	name := strings.Join(labels, ".")
+	s.receivedQueries <- name
+
+	response := append([]byte{}, query[:questionEnd]...)
+	flags := uint16(0x8180)
+
+	var answers []net.IP
+
+	switch {
+	case udp && s.truncateUDP:
+		flags |= 0x0200
+	case s.nameError:
+		flags |= 3
+	default:
+		for _, ip := range s.records[name] {
+			if (qtype == 1 && ip.To4() != nil) || (qtype == 28 && ip.To4() == nil) {
+				answers = append(answers, ip)
+			}
+		}
+	}
+
+	binary.BigEndian.PutUint16(response[2:], flags)
+	binary.BigEndian.PutUint16(response[6:], uint16(len(answers)))
+
+	for _, ip := range answers {
+		data := ip.To4()
+		if data == nil {
+			data = ip.To16()
+		}
+
+		record := []byte{0xC0, 12, 0, 0, 0, 1, 0, 0, 0, 60, 0, 0}
+		binary.BigEndian.PutUint16(record[2:], qtype)
+		binary.BigEndian.PutUint16(record[10:], uint16(len(data)))
+		response = append(append(response, record...), data...)
+	}
+
+	return response
+}
+
+var _ = Describe("NativeDNSResolver", func() {
+	var (
+		server   *fakeDNSServer
+		resolver NativeDNSResolver
+	)
+
+	BeforeEach(func() {
+		server = newFakeDNSServer()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		resolver = NewNativeDNSResolver(200*time.Millisecond, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("LookupHost", func() {
+		Context("when host is an ip", func() {
+			It("returns the ip without querying dns servers", func() {
+				ip, err := resolver.LookupHost([]string{server.Address()}, "74.125.239.101")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(Equal("74.125.239.101"))
+				Expect(server.receivedQueries).To(BeEmpty())
+			})
+		})
+
+		Context("when host is not an ip", func() {
+			It("returns 127.0.0.1 for 'localhost'", func() {
+				ip, err := resolver.LookupHost([]string{server.Address()}, "localhost")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(Equal("127.0.0.1"))
+			})
+
+			It("returns IPv4 address of resolved host", func() {
+				server.AddRecord("fake-registry.com", "10.0.0.5")
+				server.AddRecord("fake-registry.com", "fd00::5")
+
+				ip, err := resolver.LookupHost([]string{server.Address()}, "fake-registry.com.")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(Equal("10.0.0.5"))
+
+				udpQueryCount, _ := server.QueryCounts()
+				Expect(udpQueryCount).To(Equal(1))
+			})
+
+			It("returns IPv6 address when host has no IPv4 addresses", func() {
+				server.AddRecord("fake-registry.com", "fd00::5")
+
+				ip, err := resolver.LookupHost([]string{server.Address()}, "fake-registry.com")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(Equal("fd00::5"))
+			})
+
+			It("repeats query over TCP when UDP response is truncated", func() {
+				server.AddRecord("fake-registry.com", "10.0.0.5")
+				server.TruncateUDP()
+
+				ip, err := resolver.LookupHost([]string{server.Address()}, "fake-registry.com")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(Equal("10.0.0.5"))
+
+				_, tcpQueryCount := server.QueryCounts()
+				Expect(tcpQueryCount).To(Equal(1))
+			})
+
+			It("tries next dns server when dns server does not respond", func() {
+				unresponsiveServer, err := net.ListenPacket("udp", "127.0.0.1:0")
+				Expect(err).ToNot(HaveOccurred())
+				defer unresponsiveServer.Close()
+
+				server.AddRecord("fake-registry.com", "10.0.0.5")
+
+				ip, err := resolver.LookupHost([]string{unresponsiveServer.LocalAddr().String(), server.Address()}, "fake-registry.com")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ip).To(Equal("10.0.0.5"))
+			})
+
+			It("returns error if there are 0 dns servers", func() {
+				ip, err := resolver.LookupHost([]string{}, "fake-registry.com")
+				Expect(err).To(MatchError("No DNS servers provided"))
+				Expect(ip).To(BeEmpty())
+			})
+
+			It("returns error if host does not exist", func() {
+				server.RespondWithNameError()
+
+				ip, err := resolver.LookupHost([]string{server.Address()}, "fake-registry.com")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Host 'fake-registry.com' does not exist"))
+				Expect(ip).To(BeEmpty())
+			})
+
+			It("returns error if host has no addresses", func() {
+				ip, err := resolver.LookupHost([]string{server.Address()}, "fake-registry.com")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("no addresses found"))
+				Expect(ip).To(BeEmpty())
+			})
+
+			It("returns error if host name is invalid", func() {
+				_, err := resolver.LookupHost([]string{server.Address()}, "fake..registry.com")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid name"))
+			})
+		})
+	})
+})
@@ -0,0 +1,214 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// NoCloudSettingsSource reads settings from cloud-init NoCloud data source;
+// user data holds settings and meta data holds public keys
+type NoCloudSettingsSource struct {
+	seedDirs     []string
+	diskPaths    []string
+	metaDataPath string
+	userDataPath string
+
+	// Seed directory or disk which answered last settings request
+	userDataLocation string
+
+	platform boshplatform.Platform
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type noCloudMetaData struct {
+	InstanceID string
+	PublicKeys []string
+}
+
+func NewNoCloudSettingsSource(
+	seedDirs []string,
+	diskPaths []string,
+	metaDataPath string,
+	userDataPath string,
+	platform boshplatform.Platform,
+	logger boshlog.Logger,
+) *NoCloudSettingsSource {
+	return &NoCloudSettingsSource{
+		seedDirs:     seedDirs,
+		diskPaths:    diskPaths,
+		metaDataPath: metaDataPath,
+		userDataPath: userDataPath,
+
+		platform: platform,
+
+		logTag: "NoCloudSettingsSource",
+		logger: logger,
+	}
+}
+
+func (s *NoCloudSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
+	contents, _, err := s.loadFile(s.metaDataPath)
+	if err != nil {
+		return "", err
+	}
+
+	metaData, err := parseNoCloudMetaData(contents)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing NoCloud meta data from '%s'", s.metaDataPath)
+	}
+
+	if len(metaData.PublicKeys) > 0 {
+		return metaData.PublicKeys[0], nil
+	}
+
+	return "", nil
+}
+
+func (s *NoCloudSettingsSource) Settings() (boshsettings.Settings, error) {
+	var settings boshsettings.Settings
+
+	contents, location, err := s.loadFile(s.userDataPath)
+	if err != nil {
+		return settings, err
+	}
+
+	err = json.Unmarshal(contents, &settings)
+	if err != nil {
+		return settings, bosherr.WrapErrorf(err, "Parsing NoCloud settings from '%s'", s.userDataPath)
+	}
+
+	s.userDataLocation = location
+
+	return settings, nil
+}
+
+func (s *NoCloudSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "NoCloud", Endpoint: path.Join(s.userDataLocation, s.userDataPath)}
+}
+
+// loadFile reads file from the first seed directory or
+// disk (e.g. labeled cidata) which has it
+func (s *NoCloudSettingsSource) loadFile(fileName string) ([]byte, string, error) {
+	var err error
+
+	for _, seedDir := range s.seedDirs {
+		var contents []byte
+
+		contents, err = s.platform.GetFs().ReadFile(path.Join(seedDir, fileName))
+		if err == nil {
+			s.logger.Debug(s.logTag, "Loaded file '%s' from seed directory '%s'", fileName, seedDir)
+			return contents, seedDir, nil
+		}
+	}
+
+	for _, diskPath := range s.diskPaths {
+		var contents [][]byte
+
+		contents, err = s.platform.GetFilesContentsFromDisk(diskPath, []string{fileName})
+		if err == nil {
+			s.logger.Debug(s.logTag, "Loaded file '%s' from disk '%s'", fileName, diskPath)
+			return contents[0], diskPath, nil
+		}
+
+		s.logger.Warn(s.logTag, "Failed to load file '%s' from disk '%s': %s", fileName, diskPath, err.Error())
+	}
+
+	if err == nil {
+		return nil, "", bosherr.Errorf("Loading NoCloud file '%s': no seed directories or disks configured", fileName)
+	}
+
+	return nil, "", bosherr.WrapErrorf(err, "Loading NoCloud file '%s'", fileName)
+}
+
+// parseNoCloudMetaData parses JSON meta data or
+// YAML meta data with top level keys and a list of public keys
+func parseNoCloudMetaData(contents []byte) (noCloudMetaData, error) {
+	var metaData noCloudMetaData
+
+	trimmed := strings.TrimSpace(string(contents))
+
+	if strings.HasPrefix(trimmed, "{") {
+		var jsonMetaData struct {
+			InstanceID string      `json:"instance-id"`
+			PublicKeys interface{} `json:"public-keys"`
+		}
+
+		err := json.Unmarshal([]byte(trimmed), &jsonMetaData)
+		if err != nil {
+			return metaData, err
+		}
+
+		metaData.InstanceID = jsonMetaData.InstanceID
+
+		switch publicKeys := jsonMetaData.PublicKeys.(type) {
+		case string:
+			metaData.PublicKeys = []string{publicKeys}
+		case []interface{}:
+			for _, publicKey := range publicKeys {
+				if key, ok := publicKey.(string); ok {
+					metaData.PublicKeys = append(metaData.PublicKeys, key)
+				}
+			}
+		}
+
+		return metaData, nil
+	}
+
+	inPublicKeys := false
+
+	for _, line := range strings.Split(trimmed, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		isNested := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "-")
+
+		if isNested {
+			item := strings.TrimSpace(line)
+			if inPublicKeys && strings.HasPrefix(item, "-") {
+				metaData.PublicKeys = append(metaData.PublicKeys, unquoteYAML(strings.TrimPrefix(item, "-")))
+			}
+			continue
+		}
+
+		inPublicKeys = false
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return metaData, bosherr.Errorf("Unexpected meta data line '%s'", line)
+		}
+
+		key, value := strings.TrimSpace(parts[0]), unquoteYAML(parts[1])
+
+		switch key {
+		case "instance-id":
+			metaData.InstanceID = value
+		case "public-keys":
+			if value == "" {
+				inPublicKeys = true
+			} else {
+				metaData.PublicKeys = append(metaData.PublicKeys, value)
+			}
+		}
+	}
+
+	return metaData, nil
+}
+
+func unquoteYAML(value string) string {
+	value = strings.TrimSpace(value)
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
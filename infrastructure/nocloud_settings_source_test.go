@@ -0,0 +1,110 @@
+package infrastructure_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("NoCloudSettingsSource", func() {
+	var (
+		platform *fakeplatform.FakePlatform
+		source   *NoCloudSettingsSource
+	)
+
+	BeforeEach(func() {
+		platform = fakeplatform.NewFakePlatform()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		source = NewNoCloudSettingsSource(
+			[]string{"/fake-seed-dir-1", "/fake-seed-dir-2"},
+			[]string{"/fake-disk-path"},
+			"meta-data",
+			"user-data",
+			platform,
+			logger,
+		)
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		It("returns first public key from YAML meta data", func() {
+			platform.Fs.WriteFileString("/fake-seed-dir-1/meta-data", `
+instance-id: fake-instance-id
+local-hostname: fake-hostname
+public-keys:
+  - "ssh-rsa fake-key-1"
+  - ssh-rsa fake-key-2
+`)
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key-1"))
+		})
+
+		It("returns public key from JSON meta data", func() {
+			platform.Fs.WriteFileString("/fake-seed-dir-2/meta-data", `{"instance-id": "fake-instance-id", "public-keys": ["ssh-rsa fake-key"]}`)
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key"))
+		})
+
+		It("returns an empty string when meta data has no public keys", func() {
+			platform.Fs.WriteFileString("/fake-seed-dir-1/meta-data", "instance-id: fake-instance-id\n")
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal(""))
+		})
+
+		It("returns an error when meta data cannot be parsed", func() {
+			platform.Fs.WriteFileString("/fake-seed-dir-1/meta-data", "fake-invalid-line\n")
+
+			_, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing NoCloud meta data"))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings from user data in the first seed directory which has it", func() {
+			platform.Fs.WriteFileString("/fake-seed-dir-2/user-data", `{"agent_id": "fake-agent-id"}`)
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+		})
+
+		It("returns settings from disk when seed directories do not have user data", func() {
+			platform.SetGetFilesContentsFromDisk("/fake-disk-path/user-data", []byte(`{"agent_id": "fake-agent-id"}`), nil)
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+			Expect(platform.GetFileContentsFromDiskDiskPaths).To(Equal([]string{"/fake-disk-path"}))
+			Expect(source.SourceInfo()).To(Equal(boshsettings.SourceInfo{Type: "NoCloud", Endpoint: "/fake-disk-path/user-data"}))
+		})
+
+		It("returns an error when user data cannot be found", func() {
+			platform.SetGetFilesContentsFromDisk("/fake-disk-path/user-data", nil, errors.New("fake-read-disk-error"))
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Loading NoCloud file 'user-data'"))
+			Expect(err.Error()).To(ContainSubstring("fake-read-disk-error"))
+		})
+
+		It("returns an error when user data is not settings JSON", func() {
+			platform.Fs.WriteFileString("/fake-seed-dir-1/user-data", "#cloud-config\n")
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing NoCloud settings from 'user-data'"))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"sort"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	openStackMetaDataPath = "/openstack/latest/meta_data.json"
+	openStackUserDataPath = "/openstack/latest/user_data"
+)
+
+// OpenStackSettingsSource reads settings from user data of
+// OpenStack metadata service and public key from its meta data
+type OpenStackSettingsSource struct {
+	metadataService DynamicMetadataService
+
+	logTag string
+	logger boshlog.Logger
+}
+
+type openStackMetaData struct {
+	PublicKeys map[string]string `json:"public_keys"`
+}
+
+func NewOpenStackSettingsSource(
+	metadataService DynamicMetadataService,
+	logger boshlog.Logger,
+) *OpenStackSettingsSource {
+	return &OpenStackSettingsSource{
+		metadataService: metadataService,
+
+		logTag: "OpenStackSettingsSource",
+		logger: logger,
+	}
+}
+
+func (s OpenStackSettingsSource) SourceInfo() boshsettings.SourceInfo {
+	return boshsettings.SourceInfo{Type: "OpenStack", Endpoint: openStackUserDataPath}
+}
+
+func (s OpenStackSettingsSource) PublicSSHKeyForUsername(string) (string, error) {
+	contents, err := s.metadataService.GetValueAtPath(openStackMetaDataPath)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading OpenStack meta data")
+	}
+
+	var metaData openStackMetaData
+
+	err = json.Unmarshal([]byte(contents), &metaData)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Parsing OpenStack meta data")
+	}
+
+	var names []string
+
+	for name := range metaData.PublicKeys {
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	// Keys are named by user; pick one deterministically
+	sort.Strings(names)
+
+	return metaData.PublicKeys[names[0]], nil
+}
+
+func (s *OpenStackSettingsSource) Settings() (boshsettings.Settings, error) {
+	var settings boshsettings.Settings
+
+	contents, err := s.metadataService.GetValueAtPath(openStackUserDataPath)
+	if err != nil {
+		return settings, bosherr.WrapError(err, "Reading OpenStack user data")
+	}
+
+	err = json.Unmarshal([]byte(contents), &settings)
+	if err != nil {
+		return settings, bosherr.WrapError(err, "Parsing OpenStack settings from user data")
+	}
+
+	return settings, nil
+}
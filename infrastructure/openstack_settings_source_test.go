@@ -0,0 +1,83 @@
+package infrastructure_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("OpenStackSettingsSource", func() {
+	var (
+		server *ghttp.Server
+		source *OpenStackSettingsSource
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		platform := fakeplatform.NewFakePlatform()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		metadataService := NewHTTPMetadataServiceWithCustomRetryDelay(server.URL(), nil, "", "", "", TokenOptions{}, nil, platform, logger, 0)
+		source = NewOpenStackSettingsSource(metadataService, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("PublicSSHKeyForUsername", func() {
+		It("returns public key from meta data", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/openstack/latest/meta_data.json"),
+				ghttp.RespondWith(http.StatusOK, `{"uuid": "fake-uuid", "public_keys": {"fake-key-name": "ssh-rsa fake-key"}}`),
+			))
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal("ssh-rsa fake-key"))
+		})
+
+		It("returns an empty string when meta data has no public keys", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"uuid": "fake-uuid"}`))
+
+			publicKey, err := source.PublicSSHKeyForUsername("fake-username")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicKey).To(Equal(""))
+		})
+	})
+
+	Describe("Settings", func() {
+		It("returns settings from user data", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/openstack/latest/user_data"),
+				ghttp.RespondWith(http.StatusOK, `{"agent_id": "fake-agent-id"}`),
+			))
+
+			settings, err := source.Settings()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(settings.AgentID).To(Equal("fake-agent-id"))
+		})
+
+		It("returns an error when user data is not available", func() {
+			server.AllowUnhandledRequests = true
+			server.UnhandledRequestStatusCode = http.StatusNotFound
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading OpenStack user data"))
+		})
+
+		It("returns an error when user data is not settings JSON", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `#cloud-config`))
+
+			_, err := source.Settings()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing OpenStack settings from user data"))
+		})
+	})
+})
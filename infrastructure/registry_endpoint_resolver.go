@@ -1,7 +1,7 @@
 package infrastructure
 
 import (
-	"fmt"
+	"net"
 	"net/url"
 	"strings"
 
@@ -24,14 +24,17 @@ func (r registryEndpointResolver) LookupHost(dnsServers []string, endpoint strin
 		return "", bosherr.WrapError(err, "Parsing registry named endpoint")
 	}
 
-	registryHostAndPort := strings.Split(registryURL.Host, ":")
-	registryIP, err := r.delegate.LookupHost(dnsServers, registryHostAndPort[0])
+	registryHost, port := splitURLHost(registryURL)
+
+	registryIP, err := r.delegate.LookupHost(dnsServers, registryHost)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Looking up registry")
 	}
 
-	if len(registryHostAndPort) == 2 {
-		registryURL.Host = fmt.Sprintf("%s:%s", registryIP, registryHostAndPort[1])
+	if port != "" {
+		registryURL.Host = net.JoinHostPort(registryIP, port)
+	} else if strings.Contains(registryIP, ":") {
+		registryURL.Host = "[" + registryIP + "]"
 	} else {
 		registryURL.Host = registryIP
 	}
@@ -50,6 +50,26 @@ var _ = Describe("RegistryEndpointResolver", func() {
 			})
 		})
 
+		Context("when registry endpoint resolves to IPv6 address", func() {
+			BeforeEach(func() {
+				delegate.RegisterRecord(fakeinf.FakeDNSRecord{
+					DNSServers: dnsServers,
+					Host:       "fake-registry.com",
+					IP:         "fd00::5",
+				})
+			})
+
+			It("returns the resolved registry endpoint with bracketed address", func() {
+				resolvedEndpoint, err := registryEndpointResolver.LookupHost(dnsServers, "http://fake-registry.com:8877")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resolvedEndpoint).To(Equal("http://[fd00::5]:8877"))
+
+				resolvedEndpoint, err = registryEndpointResolver.LookupHost(dnsServers, "http://fake-registry.com")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resolvedEndpoint).To(Equal("http://[fd00::5]"))
+			})
+		})
+
 		Context("when registry endpoint is not successfully resolved", func() {
 			BeforeEach(func() {
 				delegate.LookupHostErr = errors.New("fake-lookup-host-err")
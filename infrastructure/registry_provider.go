@@ -2,11 +2,14 @@ package infrastructure
 
 import (
 	"strings"
+	"time"
 
 	boshplat "github.com/cloudfoundry/bosh-agent/platform"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
 )
 
 type RegistryProvider interface {
@@ -18,6 +21,8 @@ type registryProvider struct {
 	useServerName   bool
 	platform        boshplat.Platform
 	fs              boshsys.FileSystem
+	retryPolicy     RetryPolicy
+	circuitBreaker  *CircuitBreaker
 	logTag          string
 	logger          boshlog.Logger
 }
@@ -27,13 +32,25 @@ func NewRegistryProvider(
 	platform boshplat.Platform,
 	useServerName bool,
 	fs boshsys.FileSystem,
+	options RegistryOptions,
 	logger boshlog.Logger,
 ) RegistryProvider {
+	options = options.withDefaults()
+
+	// Breaker is shared by registries so that failures survive settings refreshes
+	circuitBreaker := NewCircuitBreaker(
+		options.CircuitBreakerFailureThreshold,
+		time.Duration(options.CircuitBreakerResetTimeoutInSeconds)*time.Second,
+		clock.NewClock(),
+	)
+
 	return &registryProvider{
 		metadataService: metadataService,
 		platform:        platform,
 		useServerName:   useServerName,
 		fs:              fs,
+		retryPolicy:     options.RetryPolicy(),
+		circuitBreaker:  circuitBreaker,
 		logTag:          "registryProvider",
 		logger:          logger,
 	}
@@ -46,10 +63,10 @@ func (p *registryProvider) GetRegistry() (Registry, error) {
 	}
 
 	if strings.HasPrefix(registryEndpoint, "http") {
-		p.logger.Debug(p.logTag, "Using http registry at %s", registryEndpoint)
-		return NewHTTPRegistry(p.metadataService, p.platform, p.useServerName), nil
+		p.logger.Debug(p.logTag, "Using http registry at %s", boshsettings.RedactURL(registryEndpoint))
+		return NewHTTPRegistry(p.metadataService, p.platform, p.useServerName, p.retryPolicy, p.circuitBreaker, p.logger), nil
 	}
 
-	p.logger.Debug(p.logTag, "Using file registry at %s", registryEndpoint)
+	p.logger.Debug(p.logTag, "Using file registry at %s", boshsettings.RedactURL(registryEndpoint))
 	return NewFileRegistry(registryEndpoint, p.fs), nil
 }
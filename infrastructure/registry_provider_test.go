@@ -2,6 +2,7 @@ package infrastructure_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -11,6 +12,7 @@ import (
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock"
 )
 
 var _ = Describe("RegistryProvider", func() {
@@ -19,6 +21,8 @@ var _ = Describe("RegistryProvider", func() {
 		platform         *fakeplat.FakePlatform
 		useServerName    bool
 		fs               *fakesys.FakeFileSystem
+		options          RegistryOptions
+		logger           boshlog.Logger
 		registryProvider RegistryProvider
 	)
 
@@ -27,13 +31,22 @@ var _ = Describe("RegistryProvider", func() {
 		platform = &fakeplat.FakePlatform{}
 		useServerName = false
 		fs = fakesys.NewFakeFileSystem()
+		options = RegistryOptions{}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
 	})
 
 	JustBeforeEach(func() {
-		logger := boshlog.NewLogger(boshlog.LevelNone)
-		registryProvider = NewRegistryProvider(metadataService, platform, useServerName, fs, logger)
+		registryProvider = NewRegistryProvider(metadataService, platform, useServerName, fs, options, logger)
 	})
 
+	defaultRetryPolicy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialDelay:   500 * time.Millisecond,
+		MaxDelay:       5 * time.Second,
+		AttemptTimeout: 30 * time.Second,
+	}
+	defaultCircuitBreaker := NewCircuitBreaker(3, 30*time.Second, clock.NewClock())
+
 	Describe("GetRegistry", func() {
 		Context("when metadata service returns registry http endpoint", func() {
 			BeforeEach(func() {
@@ -46,7 +59,7 @@ var _ = Describe("RegistryProvider", func() {
 				It("returns an http registry that does not use server name as id", func() {
 					registry, err := registryProvider.GetRegistry()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(registry).To(Equal(NewHTTPRegistry(metadataService, platform, false)))
+					Expect(registry).To(Equal(NewHTTPRegistry(metadataService, platform, false, defaultRetryPolicy, defaultCircuitBreaker, logger)))
 				})
 			})
 
@@ -56,7 +69,34 @@ var _ = Describe("RegistryProvider", func() {
 				It("returns an http registry that uses server name as id", func() {
 					registry, err := registryProvider.GetRegistry()
 					Expect(err).ToNot(HaveOccurred())
-					Expect(registry).To(Equal(NewHTTPRegistry(metadataService, platform, true)))
+					Expect(registry).To(Equal(NewHTTPRegistry(metadataService, platform, true, defaultRetryPolicy, defaultCircuitBreaker, logger)))
+				})
+			})
+
+			Context("when registry retries and circuit breaker are configured", func() {
+				BeforeEach(func() {
+					options = RegistryOptions{
+						MaxAttempts:                         5,
+						RetryDelayInMilliseconds:            100,
+						MaxRetryDelayInMilliseconds:         1000,
+						RequestTimeoutInSeconds:             10,
+						CircuitBreakerFailureThreshold:      2,
+						CircuitBreakerResetTimeoutInSeconds: 60,
+					}
+				})
+
+				It("returns an http registry with configured retry policy and circuit breaker", func() {
+					registry, err := registryProvider.GetRegistry()
+					Expect(err).ToNot(HaveOccurred())
+
+					retryPolicy := RetryPolicy{
+						MaxAttempts:    5,
+						InitialDelay:   100 * time.Millisecond,
+						MaxDelay:       1 * time.Second,
+						AttemptTimeout: 10 * time.Second,
+					}
+					circuitBreaker := NewCircuitBreaker(2, 60*time.Second, clock.NewClock())
+					Expect(registry).To(Equal(NewHTTPRegistry(metadataService, platform, false, retryPolicy, circuitBreaker, logger)))
 				})
 			})
 		})
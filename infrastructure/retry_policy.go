@@ -0,0 +1,31 @@
+package infrastructure
+
+import (
+	"time"
+)
+
+// RetryPolicy describes how failed requests are retried;
+// delay doubles after every failed attempt up to MaxDelay
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	// Requests taking longer are cancelled and count as failed attempts
+	AttemptTimeout time.Duration
+}
+
+// Delay returns how long to wait after given failed attempt (starting at 1)
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.InitialDelay
+
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return delay
+}
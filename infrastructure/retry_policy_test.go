@@ -0,0 +1,28 @@
+package infrastructure_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/infrastructure"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	Describe("Delay", func() {
+		It("doubles delay after every failed attempt up to max delay", func() {
+			policy := RetryPolicy{InitialDelay: 500 * time.Millisecond, MaxDelay: 3 * time.Second}
+
+			Expect(policy.Delay(1)).To(Equal(500 * time.Millisecond))
+			Expect(policy.Delay(2)).To(Equal(1 * time.Second))
+			Expect(policy.Delay(3)).To(Equal(2 * time.Second))
+			Expect(policy.Delay(4)).To(Equal(3 * time.Second))
+			Expect(policy.Delay(100)).To(Equal(3 * time.Second))
+		})
+
+		It("returns zero when initial delay is not set", func() {
+			Expect(RetryPolicy{MaxDelay: 3 * time.Second}.Delay(3)).To(Equal(time.Duration(0)))
+		})
+	})
+})
@@ -2,12 +2,16 @@ package infrastructure
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
 
 	mapstruc "github.com/mitchellh/mapstructure"
 
 	boshplat "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttp "github.com/cloudfoundry/bosh-utils/http"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
@@ -19,6 +23,63 @@ type SettingsOptions struct {
 	Sources       SourceOptionsSlice
 	UseServerName bool
 	UseRegistry   bool
+
+	// Path to key file supplied by the infrastructure which encrypts
+	// settings persisted on disk; key is derived from machine id when empty
+	EncryptionKeyPath string
+
+	// Settings are periodically refreshed and changes applied when set
+	RefreshIntervalInSeconds int
+
+	Registry RegistryOptions
+}
+
+// RegistryOptions configure retries of registry requests and circuit breaker
+// which fails them fast while registry keeps failing; zero values use defaults
+type RegistryOptions struct {
+	MaxAttempts                 int
+	RetryDelayInMilliseconds    int
+	MaxRetryDelayInMilliseconds int
+	RequestTimeoutInSeconds     int
+
+	// Negative threshold disables circuit breaker
+	CircuitBreakerFailureThreshold      int
+	CircuitBreakerResetTimeoutInSeconds int
+}
+
+const (
+	defaultRegistryMaxAttempts                         = 3
+	defaultRegistryRetryDelayInMilliseconds            = 500
+	defaultRegistryMaxRetryDelayInMilliseconds         = 5000
+	defaultRegistryRequestTimeoutInSeconds             = 30
+	defaultRegistryCircuitBreakerFailureThreshold      = 3
+	defaultRegistryCircuitBreakerResetTimeoutInSeconds = 30
+)
+
+func (o RegistryOptions) withDefaults() RegistryOptions {
+	o.MaxAttempts = intOrDefault(o.MaxAttempts, defaultRegistryMaxAttempts)
+	o.RetryDelayInMilliseconds = intOrDefault(o.RetryDelayInMilliseconds, defaultRegistryRetryDelayInMilliseconds)
+	o.MaxRetryDelayInMilliseconds = intOrDefault(o.MaxRetryDelayInMilliseconds, defaultRegistryMaxRetryDelayInMilliseconds)
+	o.RequestTimeoutInSeconds = intOrDefault(o.RequestTimeoutInSeconds, defaultRegistryRequestTimeoutInSeconds)
+	o.CircuitBreakerFailureThreshold = intOrDefault(o.CircuitBreakerFailureThreshold, defaultRegistryCircuitBreakerFailureThreshold)
+	o.CircuitBreakerResetTimeoutInSeconds = intOrDefault(o.CircuitBreakerResetTimeoutInSeconds, defaultRegistryCircuitBreakerResetTimeoutInSeconds)
+	return o
+}
+
+func (o RegistryOptions) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    o.MaxAttempts,
+		InitialDelay:   time.Duration(o.RetryDelayInMilliseconds) * time.Millisecond,
+		MaxDelay:       time.Duration(o.MaxRetryDelayInMilliseconds) * time.Millisecond,
+		AttemptTimeout: time.Duration(o.RequestTimeoutInSeconds) * time.Second,
+	}
+}
+
+func intOrDefault(value, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
 }
 
 // SourceOptionsSlice is used for unmarshalling different source types
@@ -34,6 +95,13 @@ type HTTPSourceOptions struct {
 	UserDataPath   string
 	InstanceIDPath string
 	SSHKeysPath    string
+
+	// Session token is requested and sent with metadata requests when set
+	Token TokenOptions
+
+	// Path to complete settings when registry is not used;
+	// defaults to UserDataPath
+	SettingsPath string
 }
 
 func (o HTTPSourceOptions) sourceOptionsInterface() {}
@@ -67,11 +135,118 @@ func (o CDROMSourceOptions) sourceOptionsInterface() {}
 type InstanceMetadataSourceOptions struct {
 	URI          string
 	Headers      map[string]string
+	Token        TokenOptions
 	SettingsPath string
 }
 
 func (o InstanceMetadataSourceOptions) sourceOptionsInterface() {}
 
+// NoCloudSourceOptions configure cloud-init NoCloud data source;
+// seed directories are checked before disks (e.g. labeled cidata)
+type NoCloudSourceOptions struct {
+	SeedDirs  []string
+	DiskPaths []string
+
+	MetaDataPath string
+	UserDataPath string
+}
+
+func (o NoCloudSourceOptions) sourceOptionsInterface() {}
+
+type OpenStackSourceOptions struct {
+	URI     string
+	Headers map[string]string
+}
+
+func (o OpenStackSourceOptions) sourceOptionsInterface() {}
+
+type GCESourceOptions struct {
+	URI               string
+	SettingsAttribute string
+}
+
+func (o GCESourceOptions) sourceOptionsInterface() {}
+
+type AzureSourceOptions struct {
+	OVFEnvFileName string
+
+	// Instance metadata service used when CDROM is not available
+	URI string
+
+	WireServerURI string
+	ReportReady   bool
+}
+
+func (o AzureSourceOptions) sourceOptionsInterface() {}
+
+const (
+	defaultNoCloudMetaDataPath = "meta-data"
+	defaultNoCloudUserDataPath = "user-data"
+
+	defaultOpenStackURI = "http://169.254.169.254"
+
+	defaultGCEURI               = "http://metadata.google.internal"
+	defaultGCESettingsAttribute = "bosh_settings"
+
+	defaultAzureOVFEnvFileName = "ovf-env.xml"
+	defaultAzureURI            = "http://169.254.169.254"
+	defaultAzureWireServerURI  = "http://168.63.129.16"
+)
+
+var defaultNoCloudSeedDirs = []string{
+	"/var/lib/cloud/seed/nocloud",
+	"/var/lib/cloud/seed/nocloud-net",
+}
+
+// WithDefaults returns options with defaults filled in
+// the way settings sources are built from them
+func (o SettingsOptions) WithDefaults() SettingsOptions {
+	var sources SourceOptionsSlice
+
+	for _, opts := range o.Sources {
+		switch typedOpts := opts.(type) {
+		case HTTPSourceOptions:
+			typedOpts.Token = typedOpts.Token.withDefaults()
+			typedOpts.SettingsPath = valueOrDefault(typedOpts.SettingsPath, typedOpts.UserDataPath)
+			opts = typedOpts
+
+		case InstanceMetadataSourceOptions:
+			typedOpts.Token = typedOpts.Token.withDefaults()
+			opts = typedOpts
+
+		case NoCloudSourceOptions:
+			if len(typedOpts.SeedDirs) == 0 && len(typedOpts.DiskPaths) == 0 {
+				typedOpts.SeedDirs = defaultNoCloudSeedDirs
+			}
+			typedOpts.MetaDataPath = valueOrDefault(typedOpts.MetaDataPath, defaultNoCloudMetaDataPath)
+			typedOpts.UserDataPath = valueOrDefault(typedOpts.UserDataPath, defaultNoCloudUserDataPath)
+			opts = typedOpts
+
+		case OpenStackSourceOptions:
+			typedOpts.URI = valueOrDefault(typedOpts.URI, defaultOpenStackURI)
+			opts = typedOpts
+
+		case GCESourceOptions:
+			typedOpts.URI = valueOrDefault(typedOpts.URI, defaultGCEURI)
+			typedOpts.SettingsAttribute = valueOrDefault(typedOpts.SettingsAttribute, defaultGCESettingsAttribute)
+			opts = typedOpts
+
+		case AzureSourceOptions:
+			typedOpts.OVFEnvFileName = valueOrDefault(typedOpts.OVFEnvFileName, defaultAzureOVFEnvFileName)
+			typedOpts.URI = valueOrDefault(typedOpts.URI, defaultAzureURI)
+			typedOpts.WireServerURI = valueOrDefault(typedOpts.WireServerURI, defaultAzureWireServerURI)
+			opts = typedOpts
+		}
+
+		sources = append(sources, opts)
+	}
+
+	o.Sources = sources
+	o.Registry = o.Registry.withDefaults()
+
+	return o
+}
+
 type SettingsSourceFactory struct {
 	options  SettingsOptions
 	platform boshplat.Platform
@@ -84,7 +259,7 @@ func NewSettingsSourceFactory(
 	logger boshlog.Logger,
 ) SettingsSourceFactory {
 	return SettingsSourceFactory{
-		options:  options,
+		options:  options.WithDefaults(),
 		platform: platform,
 		logger:   logger,
 	}
@@ -101,8 +276,8 @@ func (f SettingsSourceFactory) New() (boshsettings.Source, error) {
 func (f SettingsSourceFactory) buildWithRegistry() (boshsettings.Source, error) {
 	var metadataServices []MetadataService
 
-	digDNSResolver := NewDigDNSResolver(f.platform.GetRunner(), f.logger)
-	resolver := NewRegistryEndpointResolver(digDNSResolver)
+	dnsResolver := NewNativeDNSResolver(DefaultDNSQueryTimeout, f.logger)
+	resolver := NewRegistryEndpointResolver(dnsResolver)
 
 	for _, opts := range f.options.Sources {
 		var metadataService MetadataService
@@ -115,6 +290,7 @@ func (f SettingsSourceFactory) buildWithRegistry() (boshsettings.Source, error)
 				typedOpts.UserDataPath,
 				typedOpts.InstanceIDPath,
 				typedOpts.SSHKeysPath,
+				typedOpts.Token,
 				resolver,
 				f.platform,
 				f.logger,
@@ -144,12 +320,24 @@ func (f SettingsSourceFactory) buildWithRegistry() (boshsettings.Source, error)
 
 		case InstanceMetadataSourceOptions:
 			return nil, bosherr.Error("Instance Metadata source is not supported when registry is used")
+
+		case NoCloudSourceOptions:
+			return nil, bosherr.Error("NoCloud source is not supported when registry is used")
+
+		case OpenStackSourceOptions:
+			return nil, bosherr.Error("OpenStack source is not supported when registry is used")
+
+		case GCESourceOptions:
+			return nil, bosherr.Error("GCE source is not supported when registry is used")
+
+		case AzureSourceOptions:
+			return nil, bosherr.Error("Azure source is not supported when registry is used")
 		}
 		metadataServices = append(metadataServices, metadataService)
 	}
 
 	metadataService := NewMultiSourceMetadataService(metadataServices...)
-	registryProvider := NewRegistryProvider(metadataService, f.platform, f.options.UseServerName, f.platform.GetFs(), f.logger)
+	registryProvider := NewRegistryProvider(metadataService, f.platform, f.options.UseServerName, f.platform.GetFs(), f.options.Registry, f.logger)
 	settingsSource := NewComplexSettingsSource(metadataService, registryProvider, f.logger)
 
 	return settingsSource, nil
@@ -163,7 +351,19 @@ func (f SettingsSourceFactory) buildWithoutRegistry() (boshsettings.Source, erro
 
 		switch typedOpts := opts.(type) {
 		case HTTPSourceOptions:
-			return nil, bosherr.Error("HTTP source is not supported without registry")
+			metadataService := NewHTTPMetadataService(
+				typedOpts.URI,
+				typedOpts.Headers,
+				typedOpts.UserDataPath,
+				typedOpts.InstanceIDPath,
+				typedOpts.SSHKeysPath,
+				typedOpts.Token,
+				nil,
+				f.platform,
+				f.logger,
+			)
+
+			settingsSource = NewHTTPSettingsSource(metadataService, typedOpts.SettingsPath, f.logger)
 
 		case ConfigDriveSourceOptions:
 			settingsSource = NewConfigDriveSettingsSource(
@@ -175,7 +375,11 @@ func (f SettingsSourceFactory) buildWithoutRegistry() (boshsettings.Source, erro
 			)
 
 		case FileSourceOptions:
-			return nil, bosherr.Error("File source is not supported without registry")
+			settingsSource = NewFileSettingsSource(
+				typedOpts.SettingsPath,
+				f.platform.GetFs(),
+				f.logger,
+			)
 
 		case CDROMSourceOptions:
 			settingsSource = NewCDROMSettingsSource(
@@ -188,10 +392,23 @@ func (f SettingsSourceFactory) buildWithoutRegistry() (boshsettings.Source, erro
 			settingsSource = NewInstanceMetadataSettingsSource(
 				typedOpts.URI,
 				typedOpts.Headers,
+				typedOpts.Token,
 				typedOpts.SettingsPath,
 				f.platform,
 				f.logger,
 			)
+
+		case NoCloudSourceOptions:
+			settingsSource = f.buildNoCloudSource(typedOpts)
+
+		case OpenStackSourceOptions:
+			settingsSource = f.buildOpenStackSource(typedOpts)
+
+		case GCESourceOptions:
+			settingsSource = f.buildGCESource(typedOpts)
+
+		case AzureSourceOptions:
+			settingsSource = f.buildAzureSource(typedOpts)
 		}
 
 		settingsSources = append(settingsSources, settingsSource)
@@ -200,6 +417,81 @@ func (f SettingsSourceFactory) buildWithoutRegistry() (boshsettings.Source, erro
 	return NewMultiSettingsSource(settingsSources...)
 }
 
+func (f SettingsSourceFactory) buildNoCloudSource(opts NoCloudSourceOptions) boshsettings.Source {
+	return NewNoCloudSettingsSource(
+		opts.SeedDirs,
+		opts.DiskPaths,
+		opts.MetaDataPath,
+		opts.UserDataPath,
+		f.platform,
+		f.logger,
+	)
+}
+
+func (f SettingsSourceFactory) buildOpenStackSource(opts OpenStackSourceOptions) boshsettings.Source {
+	metadataService := NewHTTPMetadataService(
+		opts.URI,
+		opts.Headers,
+		"", "", "",
+		TokenOptions{},
+		nil,
+		f.platform,
+		f.logger,
+	)
+
+	return NewOpenStackSettingsSource(metadataService, f.logger)
+}
+
+func (f SettingsSourceFactory) buildGCESource(opts GCESourceOptions) boshsettings.Source {
+	metadataService := NewHTTPMetadataService(
+		opts.URI,
+		map[string]string{"Metadata-Flavor": "Google"},
+		"", "", "",
+		TokenOptions{},
+		nil,
+		f.platform,
+		f.logger,
+	)
+
+	return NewGCESettingsSource(
+		metadataService,
+		opts.SettingsAttribute,
+		f.logger,
+	)
+}
+
+func (f SettingsSourceFactory) buildAzureSource(opts AzureSourceOptions) boshsettings.Source {
+	metadataService := NewHTTPMetadataService(
+		opts.URI,
+		map[string]string{"Metadata": "true"},
+		"", "", "",
+		TokenOptions{},
+		nil,
+		f.platform,
+		f.logger,
+	)
+
+	wireServerClient := boshhttp.NewRetryClient(&http.Client{Timeout: AzureWireServerRequestTimeout}, 10, 1*time.Second, f.logger)
+
+	return NewAzureSettingsSource(
+		opts.OVFEnvFileName,
+		metadataService,
+		opts.WireServerURI,
+		wireServerClient,
+		opts.ReportReady,
+		f.platform,
+		f.logger,
+	)
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// UnmarshalJSON rejects unknown source types and unknown source options
 func (s *SourceOptionsSlice) UnmarshalJSON(data []byte) error {
 	var maps []map[string]interface{}
 
@@ -209,43 +501,128 @@ func (s *SourceOptionsSlice) UnmarshalJSON(data []byte) error {
 	}
 
 	for _, m := range maps {
-		if optType, ok := m["Type"]; ok {
-			var err error
-			var opts SourceOptions
+		optType, ok := m["Type"]
+		if !ok {
+			return bosherr.Error("Missing source type")
+		}
 
-			switch {
-			case optType == "HTTP":
-				var o HTTPSourceOptions
-				err, opts = mapstruc.Decode(m, &o), o
+		var opts SourceOptions
 
-			case optType == "InstanceMetadata":
-				var o InstanceMetadataSourceOptions
-				err, opts = mapstruc.Decode(m, &o), o
+		switch optType {
+		case "HTTP":
+			var o HTTPSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
 
-			case optType == "ConfigDrive":
-				var o ConfigDriveSourceOptions
-				err, opts = mapstruc.Decode(m, &o), o
+		case "InstanceMetadata":
+			var o InstanceMetadataSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
 
-			case optType == "File":
-				var o FileSourceOptions
-				err, opts = mapstruc.Decode(m, &o), o
+		case "ConfigDrive":
+			var o ConfigDriveSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
 
-			case optType == "CDROM":
-				var o CDROMSourceOptions
-				err, opts = mapstruc.Decode(m, &o), o
+		case "File":
+			var o FileSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
 
-			default:
-				err = bosherr.Errorf("Unknown source type '%s'", optType)
-			}
+		case "CDROM":
+			var o CDROMSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
 
-			if err != nil {
-				return bosherr.WrapErrorf(err, "Unmarshalling source type '%s'", optType)
-			}
-			*s = append(*s, opts)
-		} else {
-			return bosherr.Error("Missing source type")
+		case "NoCloud":
+			var o NoCloudSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
+
+		case "OpenStack":
+			var o OpenStackSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
+
+		case "GCE":
+			var o GCESourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
+
+		case "Azure":
+			var o AzureSourceOptions
+			err, opts = decodeSourceOptions(m, &o), o
+
+		default:
+			err = bosherr.Errorf("Unknown source type '%s'", optType)
+		}
+
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmarshalling source type '%s'", optType)
 		}
+		*s = append(*s, opts)
 	}
 
 	return nil
 }
+
+// MarshalJSON includes source types so that marshalled sources can be unmarshalled
+func (s SourceOptionsSlice) MarshalJSON() ([]byte, error) {
+	maps := []map[string]interface{}{}
+
+	for _, opts := range s {
+		optsBytes, err := json.Marshal(opts)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Marshalling source")
+		}
+
+		var m map[string]interface{}
+
+		err = json.Unmarshal(optsBytes, &m)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Unmarshalling source")
+		}
+
+		m["Type"] = sourceOptionsType(opts)
+		maps = append(maps, m)
+	}
+
+	return json.Marshal(maps)
+}
+
+func decodeSourceOptions(m map[string]interface{}, opts interface{}) error {
+	fields := map[string]interface{}{}
+
+	for key, value := range m {
+		if key != "Type" {
+			fields[key] = value
+		}
+	}
+
+	decoder, err := mapstruc.NewDecoder(&mapstruc.DecoderConfig{
+		ErrorUnused: true,
+		Result:      opts,
+	})
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(fields)
+}
+
+func sourceOptionsType(opts SourceOptions) string {
+	switch opts.(type) {
+	case HTTPSourceOptions:
+		return "HTTP"
+	case InstanceMetadataSourceOptions:
+		return "InstanceMetadata"
+	case ConfigDriveSourceOptions:
+		return "ConfigDrive"
+	case FileSourceOptions:
+		return "File"
+	case CDROMSourceOptions:
+		return "CDROM"
+	case NoCloudSourceOptions:
+		return "NoCloud"
+	case OpenStackSourceOptions:
+		return "OpenStack"
+	case GCESourceOptions:
+		return "GCE"
+	case AzureSourceOptions:
+		return "Azure"
+	default:
+		return fmt.Sprintf("%T", opts)
+	}
+}
@@ -1,11 +1,16 @@
 package infrastructure_test
 
 import (
+	"encoding/json"
+	"net/http"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/infrastructure"
 	fakeplat "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshhttp "github.com/cloudfoundry/bosh-utils/http"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
@@ -42,10 +47,10 @@ var _ = Describe("SettingsSourceFactory", func() {
 					})
 
 					It("returns a settings source that uses HTTP to fetch settings", func() {
-						resolver := NewRegistryEndpointResolver(NewDigDNSResolver(platform.GetRunner(), logger))
-						httpMetadataService := NewHTTPMetadataService("http://fake-url", nil, "", "", "", resolver, platform, logger)
+						resolver := NewRegistryEndpointResolver(NewNativeDNSResolver(DefaultDNSQueryTimeout, logger))
+						httpMetadataService := NewHTTPMetadataService("http://fake-url", nil, "", "", "", TokenOptions{}, resolver, platform, logger)
 						multiSourceMetadataService := NewMultiSourceMetadataService(httpMetadataService)
-						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), logger)
+						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), RegistryOptions{}, logger)
 						httpSettingsSource := NewComplexSettingsSource(multiSourceMetadataService, registryProvider, logger)
 
 						settingsSource, err := factory.New()
@@ -69,7 +74,7 @@ var _ = Describe("SettingsSourceFactory", func() {
 					})
 
 					It("returns a settings source that uses config drive to fetch settings", func() {
-						resolver := NewRegistryEndpointResolver(NewDigDNSResolver(platform.GetRunner(), logger))
+						resolver := NewRegistryEndpointResolver(NewNativeDNSResolver(DefaultDNSQueryTimeout, logger))
 						configDriveMetadataService := NewConfigDriveMetadataService(
 							resolver,
 							platform,
@@ -79,7 +84,7 @@ var _ = Describe("SettingsSourceFactory", func() {
 							logger,
 						)
 						multiSourceMetadataService := NewMultiSourceMetadataService(configDriveMetadataService)
-						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), logger)
+						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), RegistryOptions{}, logger)
 						configDriveSettingsSource := NewComplexSettingsSource(multiSourceMetadataService, registryProvider, logger)
 
 						settingsSource, err := factory.New()
@@ -109,7 +114,7 @@ var _ = Describe("SettingsSourceFactory", func() {
 							logger,
 						)
 						multiSourceMetadataService := NewMultiSourceMetadataService(fileMetadataService)
-						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), logger)
+						registryProvider := NewRegistryProvider(multiSourceMetadataService, platform, useServerName, platform.GetFs(), RegistryOptions{}, logger)
 						fileSettingsSource := NewComplexSettingsSource(multiSourceMetadataService, registryProvider, logger)
 
 						settingsSource, err := factory.New()
@@ -133,6 +138,18 @@ var _ = Describe("SettingsSourceFactory", func() {
 						Expect(err.Error()).To(ContainSubstring("CDROM source is not supported when registry is used"))
 					})
 				})
+
+				Context("when using NoCloud source", func() {
+					BeforeEach(func() {
+						options.Sources = []SourceOptions{NoCloudSourceOptions{}}
+					})
+
+					It("returns error because it is not supported", func() {
+						_, err := factory.New()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("NoCloud source is not supported when registry is used"))
+					})
+				})
 			}
 
 			Context("when UseServerName is set to true", func() {
@@ -156,10 +173,53 @@ var _ = Describe("SettingsSourceFactory", func() {
 					}
 				})
 
-				It("returns error because it is not supported", func() {
-					_, err := factory.New()
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(ContainSubstring("HTTP source is not supported without registry"))
+				It("returns a settings source that reads settings from user data", func() {
+					options.Sources = []SourceOptions{
+						HTTPSourceOptions{URI: "http://fake-url", UserDataPath: "/fake-user-data-path", SSHKeysPath: "/fake-ssh-keys-path"},
+					}
+
+					httpMetadataService := NewHTTPMetadataService("http://fake-url", nil, "/fake-user-data-path", "", "/fake-ssh-keys-path", TokenOptions{}, nil, platform, logger)
+					httpSettingsSource := NewHTTPSettingsSource(httpMetadataService, "/fake-user-data-path", logger)
+
+					multiSettingsSource, err := NewMultiSettingsSource(httpSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+
+				It("returns a settings source that uses session token when it is configured", func() {
+					tokenOptions := TokenOptions{Path: "/fake-token-path"}
+					options.Sources = []SourceOptions{
+						HTTPSourceOptions{URI: "http://fake-url", UserDataPath: "/fake-user-data-path", Token: tokenOptions},
+					}
+
+					httpMetadataService := NewHTTPMetadataService("http://fake-url", nil, "/fake-user-data-path", "", "", tokenOptions, nil, platform, logger)
+					httpSettingsSource := NewHTTPSettingsSource(httpMetadataService, "/fake-user-data-path", logger)
+
+					multiSettingsSource, err := NewMultiSettingsSource(httpSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+
+				It("returns a settings source that reads settings from settings path when it is set", func() {
+					options.Sources = []SourceOptions{
+						HTTPSourceOptions{URI: "http://fake-url", UserDataPath: "/fake-user-data-path", SettingsPath: "/fake-settings-path"},
+					}
+
+					httpMetadataService := NewHTTPMetadataService("http://fake-url", nil, "/fake-user-data-path", "", "", TokenOptions{}, nil, platform, logger)
+					httpSettingsSource := NewHTTPSettingsSource(httpMetadataService, "/fake-settings-path", logger)
+
+					multiSettingsSource, err := NewMultiSettingsSource(httpSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
 				})
 			})
 
@@ -205,10 +265,19 @@ var _ = Describe("SettingsSourceFactory", func() {
 					}
 				})
 
-				It("returns error because it is not supported", func() {
-					_, err := factory.New()
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(ContainSubstring("File source is not supported without registry"))
+				It("returns a settings source that reads settings from settings file", func() {
+					options.Sources = []SourceOptions{
+						FileSourceOptions{SettingsPath: "/fake-settings-path"},
+					}
+
+					fileSettingsSource := NewFileSettingsSource("/fake-settings-path", platform.GetFs(), logger)
+
+					multiSettingsSource, err := NewMultiSettingsSource(fileSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
 				})
 			})
 
@@ -238,6 +307,186 @@ var _ = Describe("SettingsSourceFactory", func() {
 					Expect(settingsSource).To(Equal(multiSettingsSource))
 				})
 			})
+
+			Context("when using NoCloud source", func() {
+				It("returns a settings source that uses default seed directories", func() {
+					options.Sources = []SourceOptions{NoCloudSourceOptions{}}
+
+					noCloudSettingsSource := NewNoCloudSettingsSource(
+						[]string{"/var/lib/cloud/seed/nocloud", "/var/lib/cloud/seed/nocloud-net"},
+						nil,
+						"meta-data",
+						"user-data",
+						platform,
+						logger,
+					)
+
+					multiSettingsSource, err := NewMultiSettingsSource(noCloudSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+
+				It("returns a settings source that uses only configured disks", func() {
+					options.Sources = []SourceOptions{
+						NoCloudSourceOptions{DiskPaths: []string{"/dev/disk/by-label/cidata"}, UserDataPath: "fake-user-data"},
+					}
+
+					noCloudSettingsSource := NewNoCloudSettingsSource(
+						nil,
+						[]string{"/dev/disk/by-label/cidata"},
+						"meta-data",
+						"fake-user-data",
+						platform,
+						logger,
+					)
+
+					multiSettingsSource, err := NewMultiSettingsSource(noCloudSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
+
+			Context("when using OpenStack source", func() {
+				It("returns a settings source that uses default metadata service", func() {
+					options.Sources = []SourceOptions{OpenStackSourceOptions{}}
+
+					metadataService := NewHTTPMetadataService("http://169.254.169.254", nil, "", "", "", TokenOptions{}, nil, platform, logger)
+					openStackSettingsSource := NewOpenStackSettingsSource(metadataService, logger)
+
+					multiSettingsSource, err := NewMultiSettingsSource(openStackSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
+
+			Context("when using GCE source", func() {
+				It("returns a settings source that uses default metadata server and attribute", func() {
+					options.Sources = []SourceOptions{GCESourceOptions{}}
+
+					metadataService := NewHTTPMetadataService(
+						"http://metadata.google.internal",
+						map[string]string{"Metadata-Flavor": "Google"},
+						"", "", "",
+						TokenOptions{},
+						nil,
+						platform,
+						logger,
+					)
+					gceSettingsSource := NewGCESettingsSource(metadataService, "bosh_settings", logger)
+
+					multiSettingsSource, err := NewMultiSettingsSource(gceSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
+
+			Context("when using Azure source", func() {
+				It("returns a settings source that uses OVF environment and default endpoints", func() {
+					options.Sources = []SourceOptions{AzureSourceOptions{ReportReady: true}}
+
+					metadataService := NewHTTPMetadataService(
+						"http://169.254.169.254",
+						map[string]string{"Metadata": "true"},
+						"", "", "",
+						TokenOptions{},
+						nil,
+						platform,
+						logger,
+					)
+					azureSettingsSource := NewAzureSettingsSource(
+						"ovf-env.xml",
+						metadataService,
+						"http://168.63.129.16",
+						boshhttp.NewRetryClient(&http.Client{Timeout: AzureWireServerRequestTimeout}, 10, 1*time.Second, logger),
+						true,
+						platform,
+						logger,
+					)
+
+					multiSettingsSource, err := NewMultiSettingsSource(azureSettingsSource)
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsSource, err := NewSettingsSourceFactory(options, platform, logger).New()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(settingsSource).To(Equal(multiSettingsSource))
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("SettingsOptions", func() {
+	Describe("WithDefaults", func() {
+		It("fills defaults of sources and registry", func() {
+			options := SettingsOptions{
+				Sources: SourceOptionsSlice{
+					HTTPSourceOptions{URI: "http://fake-uri", UserDataPath: "/fake-user-data-path", Token: TokenOptions{Path: "/fake-token-path"}},
+					NoCloudSourceOptions{},
+					GCESourceOptions{SettingsAttribute: "fake-attribute"},
+					CDROMSourceOptions{FileName: "/fake-file-name"},
+				},
+			}
+
+			Expect(options.WithDefaults()).To(Equal(SettingsOptions{
+				Sources: SourceOptionsSlice{
+					HTTPSourceOptions{
+						URI:          "http://fake-uri",
+						UserDataPath: "/fake-user-data-path",
+						SettingsPath: "/fake-user-data-path",
+						Token: TokenOptions{
+							Path:         "/fake-token-path",
+							TTLInSeconds: 21600,
+							TTLHeader:    "X-aws-ec2-metadata-token-ttl-seconds",
+							Header:       "X-aws-ec2-metadata-token",
+						},
+					},
+					NoCloudSourceOptions{
+						SeedDirs:     []string{"/var/lib/cloud/seed/nocloud", "/var/lib/cloud/seed/nocloud-net"},
+						MetaDataPath: "meta-data",
+						UserDataPath: "user-data",
+					},
+					GCESourceOptions{URI: "http://metadata.google.internal", SettingsAttribute: "fake-attribute"},
+					CDROMSourceOptions{FileName: "/fake-file-name"},
+				},
+				Registry: RegistryOptions{
+					MaxAttempts:                         3,
+					RetryDelayInMilliseconds:            500,
+					MaxRetryDelayInMilliseconds:         5000,
+					RequestTimeoutInSeconds:             30,
+					CircuitBreakerFailureThreshold:      3,
+					CircuitBreakerResetTimeoutInSeconds: 30,
+				},
+			}))
 		})
 	})
 })
+
+var _ = Describe("SourceOptionsSlice", func() {
+	It("marshals sources with their types so that they can be unmarshalled", func() {
+		sources := SourceOptionsSlice{
+			HTTPSourceOptions{URI: "http://fake-uri", Headers: map[string]string{"fake": "header"}},
+			AzureSourceOptions{ReportReady: true},
+		}
+
+		sourcesBytes, err := json.Marshal(sources)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(sourcesBytes)).To(ContainSubstring(`"Type":"HTTP"`))
+		Expect(string(sourcesBytes)).To(ContainSubstring(`"Type":"Azure"`))
+
+		var unmarshalledSources SourceOptionsSlice
+		Expect(json.Unmarshal(sourcesBytes, &unmarshalledSources)).To(Succeed())
+		Expect(unmarshalledSources).To(Equal(sources))
+	})
+})
@@ -0,0 +1,15 @@
+package fakes
+
+type FakeForwarder struct {
+	ForwardNetworks []string
+	ForwardQueries  [][]byte
+
+	ForwardResponse []byte
+	ForwardErr      error
+}
+
+func (f *FakeForwarder) Forward(network string, query []byte) ([]byte, error) {
+	f.ForwardNetworks = append(f.ForwardNetworks, network)
+	f.ForwardQueries = append(f.ForwardQueries, query)
+	return f.ForwardResponse, f.ForwardErr
+}
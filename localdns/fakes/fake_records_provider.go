@@ -0,0 +1,14 @@
+package fakes
+
+import (
+	boshdns "github.com/cloudfoundry/bosh-agent/localdns"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type FakeRecordsProvider struct {
+	DNSRecords boshsettings.DNSRecords
+}
+
+func (p *FakeRecordsProvider) Records() boshdns.RecordSet {
+	return boshdns.NewRecordSet(p.DNSRecords)
+}
@@ -0,0 +1,19 @@
+package fakes
+
+type FakeServer struct {
+	Started  bool
+	StartErr error
+
+	Stopped bool
+	StopErr error
+}
+
+func (s *FakeServer) Start() error {
+	s.Started = true
+	return s.StartErr
+}
+
+func (s *FakeServer) Stop() error {
+	s.Stopped = true
+	return s.StopErr
+}
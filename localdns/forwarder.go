@@ -0,0 +1,165 @@
+package localdns
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const forwarderLogTag = "localDNSForwarder"
+
+const forwardTimeout = 2 * time.Second
+
+type Forwarder interface {
+	// Forward sends query to upstream resolvers over given network (udp or tcp)
+	Forward(network string, query []byte) ([]byte, error)
+}
+
+// resolvConfForwarder forwards queries to nameservers listed in resolv.conf
+// other than the local DNS server itself
+type resolvConfForwarder struct {
+	fs             boshsys.FileSystem
+	resolvConfPath string
+	localAddress   string
+	logger         boshlog.Logger
+}
+
+func NewResolvConfForwarder(fs boshsys.FileSystem, resolvConfPath, localAddress string, logger boshlog.Logger) Forwarder {
+	return resolvConfForwarder{
+		fs:             fs,
+		resolvConfPath: resolvConfPath,
+		localAddress:   localAddress,
+		logger:         logger,
+	}
+}
+
+func (f resolvConfForwarder) Forward(network string, query []byte) ([]byte, error) {
+	upstreams, err := f.upstreams()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(upstreams) == 0 {
+		return nil, bosherr.Error("No upstream DNS servers")
+	}
+
+	var lastErr error
+
+	for _, upstream := range upstreams {
+		response, err := Exchange(network, net.JoinHostPort(upstream, "53"), query, forwardTimeout)
+		if err != nil {
+			f.logger.Debug(forwarderLogTag, "Failed to forward query to '%s': %s", upstream, err.Error())
+			lastErr = err
+			continue
+		}
+
+		return response, nil
+	}
+
+	return nil, bosherr.WrapError(lastErr, "Forwarding query to upstream DNS servers")
+}
+
+func (f resolvConfForwarder) upstreams() ([]string, error) {
+	contents, err := f.fs.ReadFileString(f.resolvConfPath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading '%s'", f.resolvConfPath)
+	}
+
+	var upstreams []string
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		if fields[1] == f.localAddress {
+			continue
+		}
+
+		upstreams = append(upstreams, fields[1])
+	}
+
+	return upstreams, nil
+}
+
+// Exchange sends query to DNS server over given network (udp or tcp)
+// and waits up to timeout for the response to it
+func Exchange(network, address string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "tcp" {
+		return exchangeStream(conn, query)
+	}
+
+	_, err = conn.Write(query)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, 65535)
+
+	// Responses to other queries are ignored
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+
+		if n >= 2 && MessageID(buffer[:n]) == MessageID(query) {
+			return append([]byte{}, buffer[:n]...), nil
+		}
+	}
+}
+
+func exchangeStream(conn io.ReadWriter, query []byte) ([]byte, error) {
+	err := writeStreamMessage(conn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return readStreamMessage(conn)
+}
+
+// Messages sent over TCP are prefixed with their two byte length
+func writeStreamMessage(w io.Writer, message []byte) error {
+	prefixed := make([]byte, 2, 2+len(message))
+	binary.BigEndian.PutUint16(prefixed, uint16(len(message)))
+
+	_, err := w.Write(append(prefixed, message...))
+	return err
+}
+
+func readStreamMessage(r io.Reader) ([]byte, error) {
+	length := make([]byte, 2)
+
+	_, err := io.ReadFull(r, length)
+	if err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, binary.BigEndian.Uint16(length))
+
+	_, err = io.ReadFull(r, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
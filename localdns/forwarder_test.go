@@ -0,0 +1,41 @@
+package localdns_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/localdns"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("ResolvConfForwarder", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		forwarder Forwarder
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		forwarder = NewResolvConfForwarder(fs, "/etc/resolv.conf", "169.254.0.2", boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("returns error when resolv.conf cannot be read", func() {
+		fs.WriteFileString("/etc/resolv.conf", "nameserver 8.8.8.8\n")
+		fs.RegisterReadFileError("/etc/resolv.conf", errors.New("fake-read-err"))
+
+		_, err := forwarder.Forward("udp", buildQuery(1, "example.com", TypeA))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-read-err"))
+	})
+
+	It("does not forward queries to local DNS server itself", func() {
+		fs.WriteFileString("/etc/resolv.conf", "# comment\nnameserver 169.254.0.2\nsearch example.com\n")
+
+		_, err := forwarder.Forward("udp", buildQuery(1, "example.com", TypeA))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("No upstream DNS servers"))
+	})
+})
@@ -0,0 +1,101 @@
+package localdns
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const handlerLogTag = "localDNSHandler"
+
+// Records may change with every DNS sync so they are not cached by clients
+const recordTTL = 0
+
+type Handler struct {
+	records   RecordsProvider
+	forwarder Forwarder
+	logger    boshlog.Logger
+}
+
+func NewHandler(records RecordsProvider, forwarder Forwarder, logger boshlog.Logger) Handler {
+	return Handler{
+		records:   records,
+		forwarder: forwarder,
+		logger:    logger,
+	}
+}
+
+// ServeDNS answers queries for synced records and forwards other queries;
+// network is the network query was received on (udp or tcp)
+func (h Handler) ServeDNS(network string, query []byte) []byte {
+	if !IsStandardQuery(query) {
+		if len(query) < headerLength {
+			return nil
+		}
+		return BuildErrorResponse(query, RcodeNotImplemented)
+	}
+
+	question, err := ParseQuestion(query)
+	if err != nil {
+		h.logger.Debug(handlerLogTag, "Failed to parse query: %s", err.Error())
+		return BuildErrorResponse(query, RcodeFormatError)
+	}
+
+	if question.Class == ClassINET {
+		answers, found := h.answer(question)
+		if found {
+			response := BuildResponse(query, question, RcodeSuccess, answers)
+			if network == "udp" {
+				response = truncate(response, question)
+			}
+			return response
+		}
+	}
+
+	response, err := h.forwarder.Forward(network, query)
+	if err != nil {
+		h.logger.Warn(handlerLogTag, "Failed to resolve '%s': %s", question.Name, err.Error())
+		return BuildResponse(query, question, RcodeServerFailure, nil)
+	}
+
+	return response
+}
+
+// answer returns answers for names of synced records;
+// known names without records of requested type have no answers
+func (h Handler) answer(question Question) ([]Answer, bool) {
+	records := h.records.Records()
+
+	if question.Type == TypePTR {
+		ip, ok := reverseNameIP(question.Name)
+		if !ok {
+			return nil, false
+		}
+
+		names := records.ReverseLookup(ip)
+		if len(names) == 0 {
+			return nil, false
+		}
+
+		var answers []Answer
+		for _, name := range names {
+			answers = append(answers, NewPTRAnswer(name, recordTTL))
+		}
+
+		return answers, true
+	}
+
+	ips, found := records.Lookup(question.Name)
+	if !found {
+		return nil, false
+	}
+
+	var answers []Answer
+
+	for _, ip := range ips {
+		answer := NewAddressAnswer(ip, recordTTL)
+		if answer.Type == question.Type {
+			answers = append(answers, answer)
+		}
+	}
+
+	return answers, true
+}
@@ -0,0 +1,149 @@
+package localdns_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/localdns"
+	fakedns "github.com/cloudfoundry/bosh-agent/localdns/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		records   *fakedns.FakeRecordsProvider
+		forwarder *fakedns.FakeForwarder
+		handler   Handler
+	)
+
+	BeforeEach(func() {
+		records = &fakedns.FakeRecordsProvider{
+			DNSRecords: boshsettings.DNSRecords{
+				Records: [][2]string{
+					{"10.0.0.1", "web-0.example.bosh"},
+					{"10.0.0.2", "web-0.example.bosh"},
+					{"fd00::1", "web-0.example.bosh"},
+					{"10.0.0.3", "db-0.example.bosh"},
+				},
+			},
+		}
+		forwarder = &fakedns.FakeForwarder{}
+		handler = NewHandler(records, forwarder, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("answers A queries for synced records", func() {
+		response := handler.ServeDNS("udp", buildQuery(42, "Web-0.Example.Bosh", TypeA))
+
+		Expect(MessageID(response)).To(Equal(uint16(42)))
+		Expect(Rcode(response)).To(Equal(RcodeSuccess))
+		Expect(parseAnswers(response)).To(Equal([]testAnswer{
+			{Type: TypeA, TTL: 0, Data: []byte{10, 0, 0, 1}},
+			{Type: TypeA, TTL: 0, Data: []byte{10, 0, 0, 2}},
+		}))
+		Expect(forwarder.ForwardQueries).To(BeEmpty())
+	})
+
+	It("answers AAAA queries for synced records", func() {
+		response := handler.ServeDNS("udp", buildQuery(42, "web-0.example.bosh", TypeAAAA))
+
+		Expect(Rcode(response)).To(Equal(RcodeSuccess))
+		Expect(parseAnswers(response)).To(Equal([]testAnswer{
+			{Type: TypeAAAA, TTL: 0, Data: []byte(net.ParseIP("fd00::1"))},
+		}))
+	})
+
+	It("answers without records when synced name has no records of requested type", func() {
+		response := handler.ServeDNS("udp", buildQuery(42, "db-0.example.bosh", TypeAAAA))
+
+		Expect(Rcode(response)).To(Equal(RcodeSuccess))
+		Expect(parseAnswers(response)).To(BeEmpty())
+		Expect(forwarder.ForwardQueries).To(BeEmpty())
+	})
+
+	It("answers PTR queries for synced records", func() {
+		response := handler.ServeDNS("udp", buildQuery(42, "3.0.0.10.in-addr.arpa", TypePTR))
+
+		Expect(Rcode(response)).To(Equal(RcodeSuccess))
+		Expect(parseAnswers(response)).To(Equal([]testAnswer{
+			{Type: TypePTR, TTL: 0, Data: append([]byte("\x04db-0\x07example\x04bosh"), 0)},
+		}))
+	})
+
+	It("answers PTR queries for synced IPv6 records", func() {
+		name := "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.d.f.ip6.arpa"
+
+		response := handler.ServeDNS("udp", buildQuery(42, name, TypePTR))
+
+		Expect(Rcode(response)).To(Equal(RcodeSuccess))
+		Expect(parseAnswers(response)).To(HaveLen(1))
+	})
+
+	It("forwards queries for unknown names", func() {
+		query := buildQuery(42, "example.com", TypeA)
+		forwarder.ForwardResponse = []byte("fake-response")
+
+		response := handler.ServeDNS("tcp", query)
+
+		Expect(response).To(Equal([]byte("fake-response")))
+		Expect(forwarder.ForwardNetworks).To(Equal([]string{"tcp"}))
+		Expect(forwarder.ForwardQueries).To(Equal([][]byte{query}))
+	})
+
+	It("forwards PTR queries for unknown addresses", func() {
+		forwarder.ForwardResponse = []byte("fake-response")
+
+		response := handler.ServeDNS("udp", buildQuery(42, "8.8.8.8.in-addr.arpa", TypePTR))
+
+		Expect(response).To(Equal([]byte("fake-response")))
+	})
+
+	It("responds with server failure when forwarding fails", func() {
+		forwarder.ForwardErr = errors.New("fake-forward-err")
+
+		response := handler.ServeDNS("udp", buildQuery(42, "example.com", TypeA))
+
+		Expect(MessageID(response)).To(Equal(uint16(42)))
+		Expect(Rcode(response)).To(Equal(RcodeServerFailure))
+	})
+
+	It("responds with format error when question cannot be parsed", func() {
+		query := buildQuery(42, "example.com", TypeA)
+
+		response := handler.ServeDNS("udp", query[:15])
+
+		Expect(MessageID(response)).To(Equal(uint16(42)))
+		Expect(Rcode(response)).To(Equal(RcodeFormatError))
+	})
+
+	It("responds with not implemented to non standard queries", func() {
+		query := buildQuery(42, "web-0.example.bosh", TypeA)
+		query[2] |= 0x10 // opcode STATUS
+
+		response := handler.ServeDNS("udp", query)
+
+		Expect(Rcode(response)).To(Equal(RcodeNotImplemented))
+	})
+
+	It("ignores messages shorter than header", func() {
+		Expect(handler.ServeDNS("udp", []byte{0, 1})).To(BeNil())
+	})
+
+	It("truncates UDP responses larger than 512 bytes", func() {
+		for i := 0; i < 50; i++ {
+			records.DNSRecords.Records = append(records.DNSRecords.Records, [2]string{fmt.Sprintf("10.0.1.%d", i), "many.example.bosh"})
+		}
+
+		response := handler.ServeDNS("udp", buildQuery(42, "many.example.bosh", TypeA))
+		Expect(isTruncated(response)).To(BeTrue())
+		Expect(parseAnswers(response)).To(BeEmpty())
+
+		response = handler.ServeDNS("tcp", buildQuery(42, "many.example.bosh", TypeA))
+		Expect(isTruncated(response)).To(BeFalse())
+		Expect(parseAnswers(response)).To(HaveLen(50))
+	})
+})
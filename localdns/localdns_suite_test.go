@@ -0,0 +1,82 @@
+package localdns_test
+
+import (
+	"encoding/binary"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLocalDNS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Local DNS Suite")
+}
+
+type testAnswer struct {
+	Type uint16
+	TTL  uint32
+	Data []byte
+}
+
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	query := make([]byte, 12)
+	binary.BigEndian.PutUint16(query[0:2], id)
+	binary.BigEndian.PutUint16(query[2:4], 0x0100) // recursion desired
+	binary.BigEndian.PutUint16(query[4:6], 1)
+
+	for _, label := range strings.Split(name, ".") {
+		query = append(query, byte(len(label)))
+		query = append(query, label...)
+	}
+
+	query = append(query, 0, 0, 0, 0, 1)
+	binary.BigEndian.PutUint16(query[len(query)-4:len(query)-2], qtype)
+
+	return query
+}
+
+func skipName(message []byte, offset int) int {
+	for {
+		length := int(message[offset])
+		if length == 0 {
+			return offset + 1
+		}
+		if length&0xc0 == 0xc0 {
+			return offset + 2
+		}
+		offset += 1 + length
+	}
+}
+
+func parseAnswers(response []byte) []testAnswer {
+	Expect(len(response)).To(BeNumerically(">=", 12))
+
+	offset := 12
+	for i := 0; i < int(binary.BigEndian.Uint16(response[4:6])); i++ {
+		offset = skipName(response, offset) + 4
+	}
+
+	var answers []testAnswer
+
+	for i := 0; i < int(binary.BigEndian.Uint16(response[6:8])); i++ {
+		offset = skipName(response, offset)
+		length := int(binary.BigEndian.Uint16(response[offset+8 : offset+10]))
+
+		answers = append(answers, testAnswer{
+			Type: binary.BigEndian.Uint16(response[offset : offset+2]),
+			TTL:  binary.BigEndian.Uint32(response[offset+4 : offset+8]),
+			Data: response[offset+10 : offset+10+length],
+		})
+
+		offset += 10 + length
+	}
+
+	return answers
+}
+
+func isTruncated(response []byte) bool {
+	return binary.BigEndian.Uint16(response[2:4])&0x0200 != 0
+}
@@ -0,0 +1,419 @@
+package localdns
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	TypeA    uint16 = 1
+	TypePTR  uint16 = 12
+	TypeAAAA uint16 = 28
+
+	ClassINET uint16 = 1
+)
+
+const (
+	RcodeSuccess        = 0
+	RcodeFormatError    = 1
+	RcodeServerFailure  = 2
+	RcodeNameError      = 3
+	RcodeNotImplemented = 4
+)
+
+const (
+	headerLength = 12
+
+	// Largest response that is sent over UDP without truncation
+	maxUDPMessageLength = 512
+
+	flagResponse           = 0x8000
+	flagOpcode             = 0x7800
+	flagAuthoritative      = 0x0400
+	flagTruncated          = 0x0200
+	flagRecursionDesired   = 0x0100
+	flagRecursionAvailable = 0x0080
+	flagRcode              = 0x000f
+
+	// Pointer to the name of the only question which directly follows header
+	questionNamePointer = 0xc000 | headerLength
+
+	maxCompressionPointers = 32
+
+	maxNameLength  = 255
+	maxLabelLength = 63
+)
+
+// Question is the only question of a query;
+// end is the offset right after the question in the query
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+
+	end int
+}
+
+// Answer is a resource record answering the question
+type Answer struct {
+	Type uint16
+	TTL  uint32
+	Data []byte
+}
+
+func NewAddressAnswer(ip net.IP, ttl uint32) Answer {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return Answer{Type: TypeA, TTL: ttl, Data: []byte(ipv4)}
+	}
+	return Answer{Type: TypeAAAA, TTL: ttl, Data: []byte(ip.To16())}
+}
+
+func NewPTRAnswer(name string, ttl uint32) Answer {
+	return Answer{Type: TypePTR, TTL: ttl, Data: encodeName(name)}
+}
+
+// ParseQuestion returns the question of a standard query with a single question
+func ParseQuestion(query []byte) (Question, error) {
+	if len(query) < headerLength {
+		return Question{}, bosherr.Error("Message is shorter than header")
+	}
+
+	flags := binary.BigEndian.Uint16(query[2:4])
+	if flags&flagResponse != 0 {
+		return Question{}, bosherr.Error("Message is not a query")
+	}
+
+	if count := binary.BigEndian.Uint16(query[4:6]); count != 1 {
+		return Question{}, bosherr.Errorf("Expected one question, got %d", count)
+	}
+
+	name, offset, err := readName(query, headerLength)
+	if err != nil {
+		return Question{}, bosherr.WrapError(err, "Reading question name")
+	}
+
+	if len(query) < offset+4 {
+		return Question{}, bosherr.Error("Question is truncated")
+	}
+
+	return Question{
+		Name:  name,
+		Type:  binary.BigEndian.Uint16(query[offset : offset+2]),
+		Class: binary.BigEndian.Uint16(query[offset+2 : offset+4]),
+		end:   offset + 4,
+	}, nil
+}
+
+// BuildQuery returns standard query with random ID and a single question
+// asking for recursion, e.g. to query upstream DNS servers
+func BuildQuery(name string, qtype uint16) ([]byte, error) {
+	trimmedName := strings.TrimSuffix(name, ".")
+	if len(trimmedName) == 0 || len(trimmedName) > maxNameLength {
+		return nil, bosherr.Errorf("Invalid name '%s'", name)
+	}
+
+	for _, label := range strings.Split(trimmedName, ".") {
+		if len(label) == 0 || len(label) > maxLabelLength {
+			return nil, bosherr.Errorf("Invalid name '%s'", name)
+		}
+	}
+
+	query := make([]byte, headerLength, headerLength+len(trimmedName)+6)
+
+	_, err := rand.Read(query[0:2])
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating query ID")
+	}
+
+	binary.BigEndian.PutUint16(query[2:4], flagRecursionDesired)
+	binary.BigEndian.PutUint16(query[4:6], 1)
+
+	query = append(query, encodeName(trimmedName)...)
+
+	question := make([]byte, 4)
+	binary.BigEndian.PutUint16(question[0:2], qtype)
+	binary.BigEndian.PutUint16(question[2:4], ClassINET)
+
+	return append(query, question...), nil
+}
+
+// Response is a response to a query built with BuildQuery
+type Response struct {
+	Truncated bool
+	Rcode     int
+	Addresses []net.IP
+}
+
+// ParseResponse returns addresses of the question type found in answers of
+// response to the query; CNAME records are skipped since recursive servers
+// include records of their targets
+func ParseResponse(query, response []byte) (Response, error) {
+	question, err := ParseQuestion(query)
+	if err != nil {
+		return Response{}, bosherr.WrapError(err, "Parsing query")
+	}
+
+	if len(response) < headerLength {
+		return Response{}, bosherr.Error("Message is shorter than header")
+	}
+
+	if MessageID(response) != MessageID(query) {
+		return Response{}, bosherr.Error("Response ID does not match query ID")
+	}
+
+	flags := binary.BigEndian.Uint16(response[2:4])
+	if flags&flagResponse == 0 {
+		return Response{}, bosherr.Error("Message is not a response")
+	}
+
+	parsed := Response{
+		Truncated: flags&flagTruncated != 0,
+		Rcode:     int(flags & flagRcode),
+	}
+
+	if parsed.Truncated || parsed.Rcode != RcodeSuccess {
+		return parsed, nil
+	}
+
+	questionCount := int(binary.BigEndian.Uint16(response[4:6]))
+	answerCount := int(binary.BigEndian.Uint16(response[6:8]))
+	offset := headerLength
+
+	for i := 0; i < questionCount; i++ {
+		_, offset, err = readName(response, offset)
+		if err != nil {
+			return Response{}, bosherr.WrapError(err, "Reading question name")
+		}
+		offset += 4
+	}
+
+	for i := 0; i < answerCount; i++ {
+		_, offset, err = readName(response, offset)
+		if err != nil {
+			return Response{}, bosherr.WrapError(err, "Reading answer name")
+		}
+
+		if offset+10 > len(response) {
+			return Response{}, bosherr.Error("Answer is truncated")
+		}
+
+		recordType := binary.BigEndian.Uint16(response[offset : offset+2])
+		recordClass := binary.BigEndian.Uint16(response[offset+2 : offset+4])
+		dataLength := int(binary.BigEndian.Uint16(response[offset+8 : offset+10]))
+		dataStart := offset + 10
+		offset = dataStart + dataLength
+
+		if offset > len(response) {
+			return Response{}, bosherr.Error("Answer is truncated")
+		}
+
+		if recordType != question.Type || recordClass != ClassINET {
+			continue
+		}
+
+		if (recordType == TypeA && dataLength == net.IPv4len) || (recordType == TypeAAAA && dataLength == net.IPv6len) {
+			parsed.Addresses = append(parsed.Addresses, net.IP(append([]byte{}, response[dataStart:offset]...)))
+		}
+	}
+
+	return parsed, nil
+}
+
+// IsStandardQuery returns true if opcode of the query is QUERY
+func IsStandardQuery(query []byte) bool {
+	return len(query) >= headerLength && binary.BigEndian.Uint16(query[2:4])&flagOpcode == 0
+}
+
+// BuildResponse returns authoritative response to the question of the query
+func BuildResponse(query []byte, question Question, rcode int, answers []Answer) []byte {
+	response := make([]byte, headerLength, question.end+len(answers)*32)
+
+	copy(response[0:2], query[0:2])
+
+	flags := binary.BigEndian.Uint16(query[2:4])&(flagOpcode|flagRecursionDesired) |
+		flagResponse | flagAuthoritative | flagRecursionAvailable | uint16(rcode)&flagRcode
+	binary.BigEndian.PutUint16(response[2:4], flags)
+
+	binary.BigEndian.PutUint16(response[4:6], 1)
+	binary.BigEndian.PutUint16(response[6:8], uint16(len(answers)))
+
+	response = append(response, query[headerLength:question.end]...)
+
+	for _, answer := range answers {
+		record := make([]byte, 12, 12+len(answer.Data))
+		binary.BigEndian.PutUint16(record[0:2], questionNamePointer)
+		binary.BigEndian.PutUint16(record[2:4], answer.Type)
+		binary.BigEndian.PutUint16(record[4:6], ClassINET)
+		binary.BigEndian.PutUint32(record[6:10], answer.TTL)
+		binary.BigEndian.PutUint16(record[10:12], uint16(len(answer.Data)))
+
+		response = append(response, append(record, answer.Data...)...)
+	}
+
+	return response
+}
+
+// BuildErrorResponse returns response without question
+// for queries which question could not be parsed
+func BuildErrorResponse(query []byte, rcode int) []byte {
+	response := make([]byte, headerLength)
+
+	copy(response[0:2], query[0:2])
+
+	flags := binary.BigEndian.Uint16(query[2:4])&(flagOpcode|flagRecursionDesired) |
+		flagResponse | flagRecursionAvailable | uint16(rcode)&flagRcode
+	binary.BigEndian.PutUint16(response[2:4], flags)
+
+	return response
+}
+
+// Rcode returns response code of the message
+func Rcode(message []byte) int {
+	if len(message) < headerLength {
+		return RcodeFormatError
+	}
+	return int(binary.BigEndian.Uint16(message[2:4]) & flagRcode)
+}
+
+// MessageID returns ID that matches response with its query
+func MessageID(message []byte) uint16 {
+	if len(message) < 2 {
+		return 0
+	}
+	return binary.BigEndian.Uint16(message[0:2])
+}
+
+// truncate drops records of response that does not fit into a UDP message
+// so that client retries the query over TCP
+func truncate(response []byte, question Question) []byte {
+	if len(response) <= maxUDPMessageLength {
+		return response
+	}
+
+	truncated := append([]byte{}, response[:question.end]...)
+
+	flags := binary.BigEndian.Uint16(truncated[2:4]) | flagTruncated
+	binary.BigEndian.PutUint16(truncated[2:4], flags)
+
+	// Answer, authority and additional records are dropped
+	for i := 6; i < headerLength; i++ {
+		truncated[i] = 0
+	}
+
+	return truncated
+}
+
+func readName(message []byte, offset int) (string, int, error) {
+	var labels []string
+
+	end := -1
+	pointers := 0
+
+	for {
+		if offset >= len(message) {
+			return "", 0, bosherr.Error("Name is truncated")
+		}
+
+		length := int(message[offset])
+
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+			return strings.Join(labels, "."), end, nil
+
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(message) {
+				return "", 0, bosherr.Error("Name pointer is truncated")
+			}
+
+			pointers++
+			if pointers > maxCompressionPointers {
+				return "", 0, bosherr.Error("Too many name pointers")
+			}
+
+			if end < 0 {
+				end = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(message[offset:offset+2]) & 0x3fff)
+
+		case length&0xc0 != 0:
+			return "", 0, bosherr.Errorf("Unsupported label type '%d'", length>>6)
+
+		default:
+			if offset+1+length > len(message) {
+				return "", 0, bosherr.Error("Label is truncated")
+			}
+
+			labels = append(labels, string(message[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+func encodeName(name string) []byte {
+	var encoded []byte
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		encoded = append(encoded, byte(len(label)))
+		encoded = append(encoded, label...)
+	}
+
+	return append(encoded, 0)
+}
+
+// reverseNameIP returns IP address of in-addr.arpa or ip6.arpa name
+func reverseNameIP(name string) (net.IP, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	if strings.HasSuffix(name, ".in-addr.arpa") {
+		octets := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(octets) != 4 {
+			return nil, false
+		}
+
+		for i, j := 0, len(octets)-1; i < j; i, j = i+1, j-1 {
+			octets[i], octets[j] = octets[j], octets[i]
+		}
+
+		ip := net.ParseIP(strings.Join(octets, "."))
+		return ip, ip != nil
+	}
+
+	if strings.HasSuffix(name, ".ip6.arpa") {
+		nibbles := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(nibbles) != 32 {
+			return nil, false
+		}
+
+		ip := make(net.IP, net.IPv6len)
+
+		for i, nibble := range nibbles {
+			value, err := strconv.ParseUint(nibble, 16, 4)
+			if err != nil || len(nibble) != 1 {
+				return nil, false
+			}
+
+			// First nibble is the lowest nibble of the last byte
+			byteIndex := net.IPv6len - 1 - i/2
+			if i%2 == 0 {
+				ip[byteIndex] |= byte(value)
+			} else {
+				ip[byteIndex] |= byte(value) << 4
+			}
+		}
+
+		return ip, true
+	}
+
+	return nil, false
+}
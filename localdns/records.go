@@ -0,0 +1,172 @@
+package localdns
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const recordsLogTag = "localDNSRecords"
+
+// RecordsReloadInterval is the longest time for synced records to be served
+const RecordsReloadInterval = time.Second
+
+// RecordsPath returns path of synced DNS records served by local DNS server
+func RecordsPath(dirProvider boshdirs.Provider) string {
+	return filepath.Join(dirProvider.InstanceDir(), "dns", "records.json")
+}
+
+// RecordSet indexes DNS records by name and by IP address;
+// names are case insensitive
+type RecordSet struct {
+	ipsByName map[string][]net.IP
+	namesByIP map[string][]string
+}
+
+func NewRecordSet(records boshsettings.DNSRecords) RecordSet {
+	set := RecordSet{
+		ipsByName: map[string][]net.IP{},
+		namesByIP: map[string][]string{},
+	}
+
+	for _, record := range records.Records {
+		ip := net.ParseIP(record[0])
+		if ip == nil {
+			continue
+		}
+
+		name := normalizeName(record[1])
+		if name == "" {
+			continue
+		}
+
+		if !containsIP(set.ipsByName[name], ip) {
+			set.ipsByName[name] = append(set.ipsByName[name], ip)
+		}
+
+		if !containsName(set.namesByIP[ip.String()], name) {
+			set.namesByIP[ip.String()] = append(set.namesByIP[ip.String()], name)
+		}
+	}
+
+	return set
+}
+
+// Lookup returns IP addresses of a name; found is false for unknown names
+func (s RecordSet) Lookup(name string) ([]net.IP, bool) {
+	ips, found := s.ipsByName[normalizeName(name)]
+	return ips, found
+}
+
+// ReverseLookup returns names which resolve to an IP address
+func (s RecordSet) ReverseLookup(ip net.IP) []string {
+	return s.namesByIP[ip.String()]
+}
+
+type RecordsProvider interface {
+	Records() RecordSet
+}
+
+// fileRecordsProvider serves records from a file written by SaveDNSRecords;
+// file is read again at most once per RecordsReloadInterval
+type fileRecordsProvider struct {
+	fs     boshsys.FileSystem
+	path   string
+	clock  clock.Clock
+	logger boshlog.Logger
+
+	lock  *sync.Mutex
+	state *fileRecordsState
+}
+
+type fileRecordsState struct {
+	loadedAt time.Time
+	contents string
+	records  RecordSet
+}
+
+func NewFileRecordsProvider(fs boshsys.FileSystem, path string, clock clock.Clock, logger boshlog.Logger) RecordsProvider {
+	return fileRecordsProvider{
+		fs:     fs,
+		path:   path,
+		clock:  clock,
+		logger: logger,
+		lock:   &sync.Mutex{},
+		state:  &fileRecordsState{records: NewRecordSet(boshsettings.DNSRecords{})},
+	}
+}
+
+func (p fileRecordsProvider) Records() RecordSet {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.clock.Now()
+
+	if !p.state.loadedAt.IsZero() && now.Sub(p.state.loadedAt) < RecordsReloadInterval {
+		return p.state.records
+	}
+
+	p.state.loadedAt = now
+
+	if !p.fs.FileExists(p.path) {
+		p.state.contents = ""
+		p.state.records = NewRecordSet(boshsettings.DNSRecords{})
+		return p.state.records
+	}
+
+	contents, err := p.fs.ReadFileString(p.path)
+	if err != nil {
+		p.logger.Error(recordsLogTag, "Failed to read DNS records: %s", err.Error())
+		return p.state.records
+	}
+
+	if contents == p.state.contents {
+		return p.state.records
+	}
+
+	var records boshsettings.DNSRecords
+
+	err = json.Unmarshal([]byte(contents), &records)
+	if err != nil {
+		// Previous records are served until records are synced again
+		p.logger.Error(recordsLogTag, "Failed to unmarshal DNS records: %s", err.Error())
+		return p.state.records
+	}
+
+	p.state.contents = contents
+	p.state.records = NewRecordSet(records)
+
+	return p.state.records
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, existing := range ips {
+		if existing.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsName(names []string, name string) bool {
+	for _, existing := range names {
+		if existing == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package localdns_test
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-agent/localdns"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("RecordsPath", func() {
+	It("returns path inside instance directory", func() {
+		Expect(RecordsPath(boshdirs.NewProvider("/var/vcap"))).To(Equal("/var/vcap/instance/dns/records.json"))
+	})
+})
+
+var _ = Describe("FileRecordsProvider", func() {
+	var (
+		fs       *fakesys.FakeFileSystem
+		clock    *fakeclock.FakeClock
+		provider RecordsProvider
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		clock = fakeclock.NewFakeClock(time.Now())
+		provider = NewFileRecordsProvider(fs, "/records.json", clock, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	lookup := func(name string) ([]net.IP, bool) {
+		return provider.Records().Lookup(name)
+	}
+
+	It("serves no records when file does not exist", func() {
+		_, found := lookup("web-0.example.bosh")
+		Expect(found).To(BeFalse())
+	})
+
+	It("serves records from file", func() {
+		fs.WriteFileString("/records.json", `{"records":[["10.0.0.1","web-0.example.bosh."]]}`)
+
+		ips, found := lookup("WEB-0.example.bosh")
+		Expect(found).To(BeTrue())
+		Expect(ips).To(HaveLen(1))
+		Expect(ips[0].String()).To(Equal("10.0.0.1"))
+
+		Expect(provider.Records().ReverseLookup(net.ParseIP("10.0.0.1"))).To(Equal([]string{"web-0.example.bosh"}))
+	})
+
+	It("skips records with invalid addresses", func() {
+		fs.WriteFileString("/records.json", `{"records":[["invalid","web-0.example.bosh"]]}`)
+
+		_, found := lookup("web-0.example.bosh")
+		Expect(found).To(BeFalse())
+	})
+
+	It("reads file again after reload interval", func() {
+		fs.WriteFileString("/records.json", `{"records":[["10.0.0.1","web-0.example.bosh"]]}`)
+		_, found := lookup("web-0.example.bosh")
+		Expect(found).To(BeTrue())
+
+		fs.WriteFileString("/records.json", `{"records":[["10.0.0.2","db-0.example.bosh"]]}`)
+		_, found = lookup("db-0.example.bosh")
+		Expect(found).To(BeFalse())
+
+		clock.Increment(RecordsReloadInterval)
+
+		_, found = lookup("db-0.example.bosh")
+		Expect(found).To(BeTrue())
+		_, found = lookup("web-0.example.bosh")
+		Expect(found).To(BeFalse())
+	})
+
+	It("keeps serving previous records when file cannot be parsed", func() {
+		fs.WriteFileString("/records.json", `{"records":[["10.0.0.1","web-0.example.bosh"]]}`)
+		_, found := lookup("web-0.example.bosh")
+		Expect(found).To(BeTrue())
+
+		fs.WriteFileString("/records.json", `invalid`)
+		clock.Increment(RecordsReloadInterval)
+
+		_, found = lookup("web-0.example.bosh")
+		Expect(found).To(BeTrue())
+	})
+})
@@ -0,0 +1,147 @@
+package localdns
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const serverLogTag = "localDNSServer"
+
+const tcpIdleTimeout = 10 * time.Second
+
+type server struct {
+	address string
+	handler Handler
+	logger  boshlog.Logger
+
+	lock        sync.Mutex
+	packetConn  net.PacketConn
+	tcpListener net.Listener
+}
+
+func NewServer(address string, handler Handler, logger boshlog.Logger) Server {
+	return &server{address: address, handler: handler, logger: logger}
+}
+
+func (s *server) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	packetConn, err := net.ListenPacket("udp", s.address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Listening on udp '%s'", s.address)
+	}
+
+	tcpListener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		packetConn.Close()
+		return bosherr.WrapErrorf(err, "Listening on tcp '%s'", s.address)
+	}
+
+	s.packetConn = packetConn
+	s.tcpListener = tcpListener
+
+	go s.serveUDP(packetConn)
+	go s.serveTCP(tcpListener)
+
+	return nil
+}
+
+func (s *server) Stop() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.packetConn != nil {
+		err := s.packetConn.Close()
+		if err != nil {
+			return bosherr.WrapError(err, "Closing udp listener")
+		}
+		s.packetConn = nil
+	}
+
+	if s.tcpListener != nil {
+		err := s.tcpListener.Close()
+		if err != nil {
+			return bosherr.WrapError(err, "Closing tcp listener")
+		}
+		s.tcpListener = nil
+	}
+
+	return nil
+}
+
+func (s *server) serveUDP(conn net.PacketConn) {
+	defer s.logger.HandlePanic("Local DNS UDP server")
+
+	for {
+		buffer := make([]byte, 65535)
+
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			s.logger.Debug(serverLogTag, "Stopped serving udp: %s", err.Error())
+			return
+		}
+
+		go func(query []byte, addr net.Addr) {
+			response := s.handler.ServeDNS("udp", query)
+			if response == nil {
+				return
+			}
+
+			_, err := conn.WriteTo(response, addr)
+			if err != nil {
+				s.logger.Debug(serverLogTag, "Failed to write udp response: %s", err.Error())
+			}
+		}(buffer[:n], addr)
+	}
+}
+
+func (s *server) serveTCP(listener net.Listener) {
+	defer s.logger.HandlePanic("Local DNS TCP server")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.logger.Debug(serverLogTag, "Stopped serving tcp: %s", err.Error())
+			return
+		}
+
+		go s.handleTCPConnection(conn)
+	}
+}
+
+func (s *server) handleTCPConnection(conn net.Conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			s.logger.Debug(serverLogTag, "Failed to close connection: %s", err.Error())
+		}
+	}()
+
+	// Clients may send several queries over one connection
+	for {
+		err := conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		if err != nil {
+			return
+		}
+
+		query, err := readStreamMessage(conn)
+		if err != nil {
+			return
+		}
+
+		response := s.handler.ServeDNS("tcp", query)
+		if response == nil {
+			return
+		}
+
+		err = writeStreamMessage(conn, response)
+		if err != nil {
+			s.logger.Debug(serverLogTag, "Failed to write tcp response: %s", err.Error())
+			return
+		}
+	}
+}
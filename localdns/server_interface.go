@@ -0,0 +1,7 @@
+package localdns
+
+type Server interface {
+	// Start listens on UDP and TCP and serves queries in background
+	Start() error
+	Stop() error
+}
@@ -0,0 +1,152 @@
+package localdns_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/localdns"
+	fakedns "github.com/cloudfoundry/bosh-agent/localdns/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		address string
+		server  Server
+	)
+
+	grabEphemeralPort := func() int {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		defer l.Close()
+
+		_, portStr, err := net.SplitHostPort(l.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+
+		port, err := strconv.Atoi(portStr)
+		Expect(err).ToNot(HaveOccurred())
+
+		return port
+	}
+
+	BeforeEach(func() {
+		address = net.JoinHostPort("127.0.0.1", strconv.Itoa(grabEphemeralPort()))
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		records := &fakedns.FakeRecordsProvider{
+			DNSRecords: boshsettings.DNSRecords{
+				Records: [][2]string{{"10.0.0.1", "web-0.example.bosh"}},
+			},
+		}
+		forwarder := &fakedns.FakeForwarder{ForwardErr: errors.New("fake-forward-err")}
+
+		server = NewServer(address, NewHandler(records, forwarder, logger), logger)
+		Expect(server.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(server.Stop()).To(Succeed())
+	})
+
+	It("serves queries over UDP", func() {
+		conn, err := net.Dial("udp", address)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+
+		Expect(conn.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+
+		_, err = conn.Write(buildQuery(7, "web-0.example.bosh", TypeA))
+		Expect(err).ToNot(HaveOccurred())
+
+		buffer := make([]byte, 512)
+		n, err := conn.Read(buffer)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(MessageID(buffer[:n])).To(Equal(uint16(7)))
+		Expect(parseAnswers(buffer[:n])).To(HaveLen(1))
+	})
+
+	It("answers queries built and exchanged by clients of upstream servers", func() {
+		for _, network := range []string{"udp", "tcp"} {
+			query, err := BuildQuery("web-0.example.bosh.", TypeA)
+			Expect(err).ToNot(HaveOccurred())
+
+			response, err := Exchange(network, address, query, 5*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(MessageID(response)).To(Equal(MessageID(query)))
+			Expect(Rcode(response)).To(Equal(RcodeSuccess))
+			Expect(parseAnswers(response)).To(HaveLen(1))
+		}
+	})
+
+	It("parses addresses from responses to built queries", func() {
+		query, err := BuildQuery("web-0.example.bosh", TypeA)
+		Expect(err).ToNot(HaveOccurred())
+
+		response, err := Exchange("udp", address, query, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		parsed, err := ParseResponse(query, response)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed.Truncated).To(BeFalse())
+		Expect(parsed.Rcode).To(Equal(RcodeSuccess))
+		Expect(parsed.Addresses).To(Equal([]net.IP{net.ParseIP("10.0.0.1").To4()}))
+
+		otherQuery := append([]byte{}, query...)
+		otherQuery[0] ^= 0xff
+
+		_, err = ParseResponse(otherQuery, response)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Response ID does not match query ID"))
+	})
+
+	It("returns error building query for invalid name", func() {
+		_, err := BuildQuery("web-0..example.bosh", TypeA)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid name 'web-0..example.bosh'"))
+	})
+
+	It("serves several queries over one TCP connection", func() {
+		conn, err := net.Dial("tcp", address)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+
+		Expect(conn.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+
+		for _, id := range []uint16{1, 2} {
+			query := buildQuery(id, "web-0.example.bosh", TypeA)
+
+			_, err = conn.Write(append([]byte{0, byte(len(query))}, query...))
+			Expect(err).ToNot(HaveOccurred())
+
+			length := make([]byte, 2)
+			_, err = io.ReadFull(conn, length)
+			Expect(err).ToNot(HaveOccurred())
+
+			response := make([]byte, int(length[0])<<8|int(length[1]))
+			_, err = io.ReadFull(conn, response)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(MessageID(response)).To(Equal(id))
+			Expect(parseAnswers(response)).To(HaveLen(1))
+		}
+	})
+
+	It("fails to start when address is in use", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		other := NewServer(address, NewHandler(&fakedns.FakeRecordsProvider{}, &fakedns.FakeForwarder{}, logger), logger)
+
+		err := other.Start()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Listening on udp"))
+	})
+})
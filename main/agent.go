@@ -58,6 +58,22 @@ func startAgent(logger logger.Logger) error {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == boshapp.ValidateSettingsCommand {
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		if !boshapp.ValidateSettings(fs, os.Args[2:], os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if opts, err := boshapp.ParseOptions(os.Args); err == nil && opts.PrintConfig {
+		fs := boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		if !boshapp.PrintConfig(fs, opts, os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	asyncLog := boshlog.NewAsyncWriterLogger(boshlog.LevelDebug, os.Stdout, os.Stderr)
 	logger := newSignalableLogger(asyncLog)
 
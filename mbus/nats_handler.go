@@ -38,6 +38,12 @@ type natsHandler struct {
 	client          yagnats.NATSClient
 	platform        boshplatform.Platform
 
+	// Client reconnects using connection info updated when mbus settings change;
+	// changed connection info is applied on client's goroutine before reconnecting
+	connInfo        *yagnats.ConnectionInfo
+	changedConnInfo *yagnats.ConnectionInfo
+	connInfoLock    sync.Mutex
+
 	handlerFuncs     []boshhandler.Func
 	handlerFuncsLock sync.Mutex
 
@@ -77,12 +83,18 @@ func (h *natsHandler) Run(handlerFunc boshhandler.Func) error {
 func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	h.RegisterAdditionalFunc(handlerFunc)
 
-	connProvider, err := h.getConnectionInfo()
+	connProvider, err := h.getConnectionInfo(h.settingsService.GetSettings())
 	if err != nil {
 		return bosherr.WrapError(err, "Getting connection info")
 	}
 
+	h.connInfoLock.Lock()
+	h.connInfo = connProvider
+	h.connInfoLock.Unlock()
+
 	h.client.BeforeConnectCallback(func() {
+		h.applyChangedConnectionInfo()
+
 		hostSplit := strings.Split(connProvider.Addr, ":")
 		ip := hostSplit[0]
 
@@ -187,9 +199,52 @@ func (h *natsHandler) runUntilInterrupted() {
 	}
 }
 
-func (h *natsHandler) getConnectionInfo() (*yagnats.ConnectionInfo, error) {
-	settings := h.settingsService.GetSettings()
+// SettingsChanged updates connection info used when client reconnects
+// so that rotated mbus credentials are picked up without agent restart
+func (h *natsHandler) SettingsChanged(diff boshsettings.Diff) error {
+	if diff.AgentIDChanged {
+		h.logger.Warn(h.logTag, "Agent ID changed; agent must be restarted to subscribe with new agent ID")
+	}
+
+	if !diff.MbusChanged {
+		return nil
+	}
+
+	h.connInfoLock.Lock()
+	defer h.connInfoLock.Unlock()
+
+	if h.connInfo == nil {
+		return nil
+	}
+
+	connInfo, err := h.getConnectionInfo(diff.New)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting changed connection info")
+	}
+
+	h.changedConnInfo = connInfo
+
+	h.logger.Info(h.logTag, "Updated connection info with changed mbus settings")
+
+	return nil
+}
+
+// applyChangedConnectionInfo must be called before client reads connection info
+func (h *natsHandler) applyChangedConnectionInfo() {
+	h.connInfoLock.Lock()
+	defer h.connInfoLock.Unlock()
+
+	if h.changedConnInfo == nil {
+		return
+	}
+
+	h.connInfo.Addr = h.changedConnInfo.Addr
+	h.connInfo.Username = h.changedConnInfo.Username
+	h.connInfo.Password = h.changedConnInfo.Password
+	h.changedConnInfo = nil
+}
 
+func (h *natsHandler) getConnectionInfo(settings boshsettings.Settings) (*yagnats.ConnectionInfo, error) {
 	natsURL, err := url.Parse(settings.Mbus)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Parsing Nats URL")
@@ -1,6 +1,7 @@
 package notification
 
 import (
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 )
 
@@ -15,3 +16,7 @@ func NewNotifier(handler boshhandler.Handler) Notifier {
 func (n concreteNotifier) NotifyShutdown() error {
 	return n.handler.Send(boshhandler.HealthMonitor, boshhandler.Shutdown, nil)
 }
+
+func (n concreteNotifier) NotifyAlert(alert boshalert.Alert) error {
+	return n.handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+}
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	fakembus "github.com/cloudfoundry/bosh-agent/mbus/fakes"
 	. "github.com/cloudfoundry/bosh-agent/notification"
@@ -44,4 +45,39 @@ var _ = Describe("concreteNotifier", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-send-error"))
 		})
 	})
+
+	Describe("NotifyAlert", func() {
+		var (
+			handler  *fakembus.FakeHandler
+			notifier Notifier
+		)
+
+		BeforeEach(func() {
+			handler = fakembus.NewFakeHandler()
+			notifier = NewNotifier(handler)
+		})
+
+		It("sends alert message to health manager", func() {
+			alert := boshalert.Alert{ID: "fake-id", Severity: boshalert.SeverityWarning, Title: "fake-title"}
+
+			err := notifier.NotifyAlert(alert)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(handler.SendInputs()).To(Equal([]fakembus.SendInput{
+				{
+					Target:  boshhandler.HealthMonitor,
+					Topic:   boshhandler.Alert,
+					Message: alert,
+				},
+			}))
+		})
+
+		It("returns error if sending alert message fails", func() {
+			handler.SendErr = errors.New("fake-send-error")
+
+			err := notifier.NotifyAlert(boshalert.Alert{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-send-error"))
+		})
+	})
 })
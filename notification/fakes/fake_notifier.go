@@ -1,8 +1,15 @@
 package fakes
 
+import (
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
 type FakeNotifier struct {
 	NotifiedShutdown  bool
 	NotifyShutdownErr error
+
+	NotifiedAlerts []boshalert.Alert
+	NotifyAlertErr error
 }
 
 func NewFakeNotifier() *FakeNotifier {
@@ -13,3 +20,8 @@ func (n *FakeNotifier) NotifyShutdown() error {
 	n.NotifiedShutdown = true
 	return n.NotifyShutdownErr
 }
+
+func (n *FakeNotifier) NotifyAlert(alert boshalert.Alert) error {
+	n.NotifiedAlerts = append(n.NotifiedAlerts, alert)
+	return n.NotifyAlertErr
+}
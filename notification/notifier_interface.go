@@ -1,5 +1,10 @@
 package notification
 
+import (
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
 type Notifier interface {
 	NotifyShutdown() (err error)
+	NotifyAlert(alert boshalert.Alert) (err error)
 }
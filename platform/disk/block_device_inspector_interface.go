@@ -0,0 +1,14 @@
+package disk
+
+type BlockDeviceInfo struct {
+	// Only set for whole devices that have partition table
+	PartitionTableType string
+
+	// Only set for partitions or devices that have file system
+	FileSystemType FileSystemType
+	UUID           string
+}
+
+type BlockDeviceInspector interface {
+	Inspect(devicePath string) (BlockDeviceInfo, error)
+}
@@ -34,6 +34,7 @@ func (s cmdMountsSearcher) SearchMounts() ([]Mount, error) {
 		mounts = append(mounts, Mount{
 			PartitionPath: mountFields[0],
 			MountPoint:    mountFields[2],
+			Options:       strings.Split(strings.Trim(mountFields[5], "()"), ","),
 		})
 	}
 
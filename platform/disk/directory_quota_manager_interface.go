@@ -0,0 +1,7 @@
+package disk
+
+type DirectoryQuotaManager interface {
+	// SetQuotas must only be called on mounted partitions;
+	// directories that do not exist yet are created
+	SetQuotas(partitionPath, mountPoint string, quotas []DirectoryQuota) error
+}
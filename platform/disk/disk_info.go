@@ -0,0 +1,41 @@
+package disk
+
+type DiskType string
+
+const (
+	DiskTypeSystem       DiskType = "system"
+	DiskTypeEphemeral    DiskType = "ephemeral"
+	DiskTypeRawEphemeral DiskType = "raw_ephemeral"
+	DiskTypePersistent   DiskType = "persistent"
+)
+
+// DiskInfo describes how a disk known to the agent is seen on the machine;
+// fields that could not be determined are left empty
+type DiskInfo struct {
+	Type DiskType `json:"type"`
+	CID  string   `json:"cid,omitempty"`
+
+	DevicePath         string `json:"device_path,omitempty"`
+	PartitionTableType string `json:"partition_table_type,omitempty"`
+
+	PartitionPath  string         `json:"partition_path,omitempty"`
+	FileSystemType FileSystemType `json:"filesystem_type,omitempty"`
+	UUID           string         `json:"uuid,omitempty"`
+
+	MountPoint   string         `json:"mount_point,omitempty"`
+	MountOptions []string       `json:"mount_options,omitempty"`
+	Usage        *DiskInfoUsage `json:"usage,omitempty"`
+
+	// Set when disk is mounted either on store or store migration
+	// directory while persistent disk migration is in progress
+	Migrating bool `json:"migrating"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type DiskInfoUsage struct {
+	SizeInKB    uint64 `json:"size_in_kb"`
+	UsedInKB    uint64 `json:"used_in_kb"`
+	InodesTotal uint64 `json:"inodes_total"`
+	InodesUsed  uint64 `json:"inodes_used"`
+}
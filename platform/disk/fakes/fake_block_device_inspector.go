@@ -0,0 +1,16 @@
+package fakes
+
+import (
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeBlockDeviceInspector struct {
+	InspectDevicePaths []string
+	InspectResults     map[string]boshdisk.BlockDeviceInfo
+	InspectErr         error
+}
+
+func (i *FakeBlockDeviceInspector) Inspect(devicePath string) (boshdisk.BlockDeviceInfo, error) {
+	i.InspectDevicePaths = append(i.InspectDevicePaths, devicePath)
+	return i.InspectResults[devicePath], i.InspectErr
+}
@@ -0,0 +1,21 @@
+package fakes
+
+import (
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeDirectoryQuotaManager struct {
+	SetQuotasCalled        bool
+	SetQuotasPartitionPath string
+	SetQuotasMountPoint    string
+	SetQuotasQuotas        []boshdisk.DirectoryQuota
+	SetQuotasErr           error
+}
+
+func (m *FakeDirectoryQuotaManager) SetQuotas(partitionPath, mountPoint string, quotas []boshdisk.DirectoryQuota) error {
+	m.SetQuotasCalled = true
+	m.SetQuotasPartitionPath = partitionPath
+	m.SetQuotasMountPoint = mountPoint
+	m.SetQuotasQuotas = quotas
+	return m.SetQuotasErr
+}
@@ -9,6 +9,10 @@ import (
 type FakeDiskManager struct {
 	FakePartitioner           *FakePartitioner
 	FakeFormatter             *FakeFormatter
+	FakeFileSystemChecker     *FakeFileSystemChecker
+	FakeDirectoryQuotaManager *FakeDirectoryQuotaManager
+	FakeBlockDeviceInspector  *FakeBlockDeviceInspector
+	FakeTrimmer               *FakeTrimmer
 	FakeMounter               *FakeMounter
 	FakeMountsSearcher        *FakeMountsSearcher
 	FakeRootDevicePartitioner *FakePartitioner
@@ -22,6 +26,10 @@ func NewFakeDiskManager() *FakeDiskManager {
 	return &FakeDiskManager{
 		FakePartitioner:           NewFakePartitioner(),
 		FakeFormatter:             &FakeFormatter{},
+		FakeFileSystemChecker:     &FakeFileSystemChecker{},
+		FakeDirectoryQuotaManager: &FakeDirectoryQuotaManager{},
+		FakeBlockDeviceInspector:  &FakeBlockDeviceInspector{},
+		FakeTrimmer:               &FakeTrimmer{},
 		FakeMounter:               &FakeMounter{},
 		FakeMountsSearcher:        &FakeMountsSearcher{},
 		FakeRootDevicePartitioner: NewFakePartitioner(),
@@ -49,6 +57,22 @@ func (m *FakeDiskManager) GetFormatter() boshdisk.Formatter {
 	return m.FakeFormatter
 }
 
+func (m *FakeDiskManager) GetFileSystemChecker() boshdisk.FileSystemChecker {
+	return m.FakeFileSystemChecker
+}
+
+func (m *FakeDiskManager) GetDirectoryQuotaManager() boshdisk.DirectoryQuotaManager {
+	return m.FakeDirectoryQuotaManager
+}
+
+func (m *FakeDiskManager) GetBlockDeviceInspector() boshdisk.BlockDeviceInspector {
+	return m.FakeBlockDeviceInspector
+}
+
+func (m *FakeDiskManager) GetTrimmer() boshdisk.Trimmer {
+	return m.FakeTrimmer
+}
+
 func (m *FakeDiskManager) GetMounter() boshdisk.Mounter {
 	return m.FakeMounter
 }
@@ -0,0 +1,24 @@
+package fakes
+
+import (
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeFileSystemChecker struct {
+	CheckCalled         bool
+	CheckPartitionPaths []string
+	CheckModes          []boshdisk.FileSystemCheckMode
+	CheckResult         boshdisk.FileSystemCheckResult
+	CheckErr            error
+}
+
+func (c *FakeFileSystemChecker) Check(partitionPath string, mode boshdisk.FileSystemCheckMode) (boshdisk.FileSystemCheckResult, error) {
+	c.CheckCalled = true
+	c.CheckPartitionPaths = append(c.CheckPartitionPaths, partitionPath)
+	c.CheckModes = append(c.CheckModes, mode)
+
+	result := c.CheckResult
+	result.PartitionPath = partitionPath
+	result.Mode = mode
+	return result, c.CheckErr
+}
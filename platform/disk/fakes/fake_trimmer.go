@@ -0,0 +1,38 @@
+package fakes
+
+import (
+	"time"
+
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+type FakeTrimmer struct {
+	TrimMountPoints  []string
+	TrimTrimmedBytes map[string]uint64
+	TrimErrs         map[string]error
+}
+
+func (t *FakeTrimmer) Trim(mountPoint string) (uint64, error) {
+	t.TrimMountPoints = append(t.TrimMountPoints, mountPoint)
+	return t.TrimTrimmedBytes[mountPoint], t.TrimErrs[mountPoint]
+}
+
+type FakeTrimScheduler struct {
+	StartInterval time.Duration
+
+	TrimAllResults []boshdisk.TrimResult
+
+	LastResultsResults []boshdisk.TrimResult
+}
+
+func (s *FakeTrimScheduler) Start(interval time.Duration) {
+	s.StartInterval = interval
+}
+
+func (s *FakeTrimScheduler) TrimAll() []boshdisk.TrimResult {
+	return s.TrimAllResults
+}
+
+func (s *FakeTrimScheduler) LastResults() []boshdisk.TrimResult {
+	return s.LastResultsResults
+}
@@ -0,0 +1,44 @@
+package disk
+
+import (
+	"time"
+)
+
+type FileSystemCheckMode string
+
+const (
+	FileSystemCheckModeOff    FileSystemCheckMode = "off"
+	FileSystemCheckModeCheck  FileSystemCheckMode = "check"
+	FileSystemCheckModeRepair FileSystemCheckMode = "repair"
+)
+
+type FileSystemCheckResult struct {
+	PartitionPath  string              `json:"partition_path"`
+	FileSystemType FileSystemType      `json:"filesystem_type"`
+	Mode           FileSystemCheckMode `json:"mode"`
+
+	// Set when file system could not be checked,
+	// e.g. partition is not formatted or xfs log needs to be replayed
+	Skipped bool `json:"skipped"`
+
+	// Clean is true when no errors were found on the file system
+	Clean bool `json:"clean"`
+
+	// Repaired is true when errors were found and corrected
+	Repaired bool `json:"repaired"`
+
+	ExitStatus int       `json:"exit_status"`
+	Output     string    `json:"output,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// NeedsAttention returns true if file system had errors,
+// regardless of whether they were repaired or not.
+func (r FileSystemCheckResult) NeedsAttention() bool {
+	return !r.Skipped && (r.Repaired || !r.Clean)
+}
+
+type FileSystemChecker interface {
+	// Check must only be called on partitions that are not mounted
+	Check(partitionPath string, mode FileSystemCheckMode) (FileSystemCheckResult, error)
+}
@@ -0,0 +1,51 @@
+package disk
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// blkid exits with 2 when device does not contain any recognized signatures
+const blkidNothingFound = 2
+
+type linuxBlockDeviceInspector struct {
+	runner boshsys.CmdRunner
+}
+
+func NewLinuxBlockDeviceInspector(runner boshsys.CmdRunner) BlockDeviceInspector {
+	return linuxBlockDeviceInspector{runner: runner}
+}
+
+func (i linuxBlockDeviceInspector) Inspect(devicePath string) (BlockDeviceInfo, error) {
+	var info BlockDeviceInfo
+
+	// e.g. 'PTTYPE=dos' for a disk or 'UUID=...\nTYPE=ext4' for a partition
+	stdout, _, exitStatus, err := i.runner.RunCommand("blkid", "-p", "-o", "export", devicePath)
+	if err != nil {
+		if exitStatus == blkidNothingFound {
+			return info, nil
+		}
+
+		return info, bosherr.WrapErrorf(err, "Shelling out to blkid for %s", devicePath)
+	}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		switch parts[0] {
+		case "PTTYPE":
+			info.PartitionTableType = parts[1]
+		case "TYPE":
+			info.FileSystemType = FileSystemType(parts[1])
+		case "UUID":
+			info.UUID = parts[1]
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,59 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("linuxBlockDeviceInspector", func() {
+	var (
+		runner    *fakesys.FakeCmdRunner
+		inspector BlockDeviceInspector
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		inspector = NewLinuxBlockDeviceInspector(runner)
+	})
+
+	It("returns partition table type of a disk", func() {
+		runner.AddCmdResult("blkid -p -o export /dev/sdb", fakesys.FakeCmdResult{
+			Stdout: "DEVNAME=/dev/sdb\nPTUUID=0b6e4a6c\nPTTYPE=gpt\n",
+		})
+
+		info, err := inspector.Inspect("/dev/sdb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info).To(Equal(BlockDeviceInfo{PartitionTableType: "gpt"}))
+	})
+
+	It("returns file system type and UUID of a partition", func() {
+		runner.AddCmdResult("blkid -p -o export /dev/sdb1", fakesys.FakeCmdResult{
+			Stdout: "DEVNAME=/dev/sdb1\nUUID=fake-uuid\nVERSION=1.0\nTYPE=ext4\nUSAGE=filesystem\n",
+		})
+
+		info, err := inspector.Inspect("/dev/sdb1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info).To(Equal(BlockDeviceInfo{FileSystemType: FileSystemExt4, UUID: "fake-uuid"}))
+	})
+
+	It("returns empty info when nothing is found on the device", func() {
+		runner.AddCmdResult("blkid -p -o export /dev/sdb", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("fake-blkid-err")})
+
+		info, err := inspector.Inspect("/dev/sdb")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info).To(Equal(BlockDeviceInfo{}))
+	})
+
+	It("returns error when blkid fails", func() {
+		runner.AddCmdResult("blkid -p -o export /dev/sdb", fakesys.FakeCmdResult{ExitStatus: 4, Error: errors.New("fake-blkid-err")})
+
+		_, err := inspector.Inspect("/dev/sdb")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-blkid-err"))
+	})
+})
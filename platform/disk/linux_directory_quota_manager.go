@@ -0,0 +1,80 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const quotaDirPermissions = os.FileMode(0755)
+
+type linuxDirectoryQuotaManager struct {
+	runner boshsys.CmdRunner
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewLinuxDirectoryQuotaManager(runner boshsys.CmdRunner, fs boshsys.FileSystem, logger boshlog.Logger) DirectoryQuotaManager {
+	return linuxDirectoryQuotaManager{
+		runner: runner,
+		fs:     fs,
+		logger: logger,
+		logTag: "linuxDirectoryQuotaManager",
+	}
+}
+
+func (m linuxDirectoryQuotaManager) SetQuotas(partitionPath, mountPoint string, quotas []DirectoryQuota) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	fsType, err := getPartitionFormatType(m.runner, partitionPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking filesystem format of partition")
+	}
+
+	// ext4 project quotas require file system features
+	// that are not enabled when agent formats the disk
+	if fsType != FileSystemXFS {
+		return bosherr.Errorf("Directory quotas are not supported on '%s' file system", fsType)
+	}
+
+	for i, quota := range quotas {
+		// Project IDs are derived from quota order and are only meaningful on this disk
+		projectID := i + 1
+		dirPath := filepath.Join(mountPoint, quota.Path)
+
+		err := m.fs.MkdirAll(dirPath, quotaDirPermissions)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating quota directory %s", dirPath)
+		}
+
+		m.logger.Info(m.logTag, "Limiting `%s' to %d MB with project %d", dirPath, quota.SizeInMB, projectID)
+
+		err = m.runXFSQuota(mountPoint, fmt.Sprintf("project -s -p %s %d", dirPath, projectID))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Setting up project for %s", dirPath)
+		}
+
+		err = m.runXFSQuota(mountPoint, fmt.Sprintf("limit -p bhard=%dm %d", quota.SizeInMB, projectID))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Limiting project for %s", dirPath)
+		}
+	}
+
+	return nil
+}
+
+func (m linuxDirectoryQuotaManager) runXFSQuota(mountPoint, command string) error {
+	_, _, _, err := m.runner.RunCommand("xfs_quota", "-x", "-c", command, mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Shelling out to xfs_quota")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("linuxDirectoryQuotaManager", func() {
+	var (
+		runner       *fakesys.FakeCmdRunner
+		fs           *fakesys.FakeFileSystem
+		quotaManager DirectoryQuotaManager
+		quotas       []DirectoryQuota
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		quotaManager = NewLinuxDirectoryQuotaManager(runner, fs, boshlog.NewLogger(boshlog.LevelNone))
+		quotas = []DirectoryQuota{
+			{Path: "fake-job", SizeInMB: 1024},
+			{Path: "other-job/data", SizeInMB: 10},
+		}
+	})
+
+	It("does nothing when there are no quotas", func() {
+		err := quotaManager.SetQuotas("/dev/sdb1", "/var/vcap/store", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runner.RunCommands).To(BeEmpty())
+	})
+
+	Context("when partition is formatted with xfs", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="xfs" yyyy zzzz`})
+		})
+
+		It("creates directories and limits them with project quotas", func() {
+			err := quotaManager.SetQuotas("/dev/sdb1", "/var/vcap/store", quotas)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/var/vcap/store/fake-job")).To(BeTrue())
+			Expect(fs.FileExists("/var/vcap/store/other-job/data")).To(BeTrue())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"blkid", "-p", "/dev/sdb1"},
+				{"xfs_quota", "-x", "-c", "project -s -p /var/vcap/store/fake-job 1", "/var/vcap/store"},
+				{"xfs_quota", "-x", "-c", "limit -p bhard=1024m 1", "/var/vcap/store"},
+				{"xfs_quota", "-x", "-c", "project -s -p /var/vcap/store/other-job/data 2", "/var/vcap/store"},
+				{"xfs_quota", "-x", "-c", "limit -p bhard=10m 2", "/var/vcap/store"},
+			}))
+		})
+
+		It("returns error when xfs_quota fails", func() {
+			runner.AddCmdResult(
+				"xfs_quota -x -c limit -p bhard=1024m 1 /var/vcap/store",
+				fakesys.FakeCmdResult{Error: errors.New("fake-xfs-quota-err")},
+			)
+
+			err := quotaManager.SetQuotas("/dev/sdb1", "/var/vcap/store", quotas)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-xfs-quota-err"))
+		})
+
+		It("returns error when directory cannot be created", func() {
+			fs.MkdirAllError = errors.New("fake-mkdir-err")
+
+			err := quotaManager.SetQuotas("/dev/sdb1", "/var/vcap/store", quotas)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-mkdir-err"))
+		})
+	})
+
+	It("returns error when partition is not formatted with xfs", func() {
+		runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext4" yyyy zzzz`})
+
+		err := quotaManager.SetQuotas("/dev/sdb1", "/var/vcap/store", quotas)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not supported on 'ext4'"))
+		Expect(len(runner.RunCommands)).To(Equal(1))
+	})
+})
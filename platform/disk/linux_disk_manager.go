@@ -16,6 +16,10 @@ type linuxDiskManager struct {
 	rootDevicePartitioner Partitioner
 	partedPartitioner     Partitioner
 	formatter             Formatter
+	fileSystemChecker     FileSystemChecker
+	directoryQuotaManager DirectoryQuotaManager
+	blockDeviceInspector  BlockDeviceInspector
+	trimmer               Trimmer
 	mounter               Mounter
 	mountsSearcher        MountsSearcher
 	fs                    boshsys.FileSystem
@@ -71,6 +75,10 @@ func NewLinuxDiskManager(
 		rootDevicePartitioner: NewRootDevicePartitioner(logger, runner, uint64(20*1024*1024)),
 		partedPartitioner:     NewPartedPartitioner(logger, runner, clock.NewClock()),
 		formatter:             NewLinuxFormatter(runner, fs),
+		fileSystemChecker:     NewLinuxFileSystemChecker(runner, fs, clock.NewClock(), logger),
+		directoryQuotaManager: NewLinuxDirectoryQuotaManager(runner, fs, logger),
+		blockDeviceInspector:  NewLinuxBlockDeviceInspector(runner),
+		trimmer:               NewLinuxTrimmer(runner),
 		mounter:               mounter,
 		mountsSearcher:        mountsSearcher,
 		fs:                    fs,
@@ -83,9 +91,20 @@ func (m linuxDiskManager) GetPartitioner() Partitioner           { return m.part
 func (m linuxDiskManager) GetPartedPartitioner() Partitioner     { return m.partedPartitioner }
 func (m linuxDiskManager) GetRootDevicePartitioner() Partitioner { return m.rootDevicePartitioner }
 
-func (m linuxDiskManager) GetFormatter() Formatter           { return m.formatter }
-func (m linuxDiskManager) GetMounter() Mounter               { return m.mounter }
-func (m linuxDiskManager) GetMountsSearcher() MountsSearcher { return m.mountsSearcher }
+func (m linuxDiskManager) GetFormatter() Formatter                 { return m.formatter }
+func (m linuxDiskManager) GetFileSystemChecker() FileSystemChecker { return m.fileSystemChecker }
+func (m linuxDiskManager) GetMounter() Mounter                     { return m.mounter }
+func (m linuxDiskManager) GetMountsSearcher() MountsSearcher       { return m.mountsSearcher }
+
+func (m linuxDiskManager) GetDirectoryQuotaManager() DirectoryQuotaManager {
+	return m.directoryQuotaManager
+}
+
+func (m linuxDiskManager) GetBlockDeviceInspector() BlockDeviceInspector {
+	return m.blockDeviceInspector
+}
+
+func (m linuxDiskManager) GetTrimmer() Trimmer { return m.trimmer }
 
 func (m linuxDiskManager) GetDiskUtil(diskPath string) boshdevutil.DeviceUtil {
 	return NewDiskUtil(diskPath, m.runner, m.mounter, m.fs, m.logger)
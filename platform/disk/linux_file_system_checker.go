@@ -0,0 +1,170 @@
+package disk
+
+import (
+	"os"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	// e2fsck exit codes (see man e2fsck)
+	e2fsckErrorsCorrected       = 1
+	e2fsckErrorsCorrectedReboot = 2
+	e2fsckErrorsUncorrected     = 4
+
+	// xfs_repair exit codes (see man xfs_repair)
+	xfsRepairCorruptionDetected = 1
+	xfsRepairDirtyLog           = 2
+)
+
+type linuxFileSystemChecker struct {
+	runner      boshsys.CmdRunner
+	fs          boshsys.FileSystem
+	timeService clock.Clock
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func NewLinuxFileSystemChecker(runner boshsys.CmdRunner, fs boshsys.FileSystem, timeService clock.Clock, logger boshlog.Logger) FileSystemChecker {
+	return linuxFileSystemChecker{
+		runner:      runner,
+		fs:          fs,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "linuxFileSystemChecker",
+	}
+}
+
+func (c linuxFileSystemChecker) Check(partitionPath string, mode FileSystemCheckMode) (FileSystemCheckResult, error) {
+	result := FileSystemCheckResult{
+		PartitionPath: partitionPath,
+		Mode:          mode,
+	}
+
+	if mode == FileSystemCheckModeOff {
+		result.Skipped = true
+		return result, nil
+	}
+
+	// Bind mounted persistent disks are directories that cannot be checked
+	isBlockDevice, err := c.isBlockDevice(partitionPath)
+	if err != nil {
+		return result, bosherr.WrapErrorf(err, "Getting info of partition `%s'", partitionPath)
+	}
+
+	if !isBlockDevice {
+		c.logger.Debug(c.logTag, "Skipping check of `%s' which is not a block device", partitionPath)
+		result.Skipped = true
+		return result, nil
+	}
+
+	fsType, err := getPartitionFormatType(c.runner, partitionPath)
+	if err != nil {
+		return result, bosherr.WrapError(err, "Checking filesystem format of partition")
+	}
+
+	result.FileSystemType = fsType
+	result.CheckedAt = c.timeService.Now()
+
+	switch {
+	case fsType == FileSystemXFS:
+		return c.checkXFS(result)
+
+	case strings.HasPrefix(string(fsType), "ext"):
+		return c.checkExt(result)
+
+	default:
+		c.logger.Debug(c.logTag, "Skipping check of `%s' with unsupported filesystem `%s'", partitionPath, fsType)
+		result.Skipped = true
+		return result, nil
+	}
+}
+
+func (c linuxFileSystemChecker) isBlockDevice(path string) (bool, error) {
+	fileInfo, err := c.fs.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	mode := fileInfo.Mode()
+
+	return mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0, nil
+}
+
+func (c linuxFileSystemChecker) checkExt(result FileSystemCheckResult) (FileSystemCheckResult, error) {
+	args := []string{"-n", result.PartitionPath}
+	if result.Mode == FileSystemCheckModeRepair {
+		args = []string{"-y", result.PartitionPath}
+	}
+
+	c.logger.Info(c.logTag, "Checking `%s' with e2fsck %v", result.PartitionPath, args)
+
+	stdout, stderr, exitStatus, err := c.runner.RunCommand("e2fsck", args...)
+	result.ExitStatus = exitStatus
+	result.Output = strings.TrimSpace(stdout + stderr)
+
+	if err == nil {
+		result.Clean = true
+		return result, nil
+	}
+
+	switch {
+	case exitStatus == e2fsckErrorsCorrected, exitStatus == e2fsckErrorsCorrectedReboot:
+		result.Repaired = true
+		return result, nil
+
+	case exitStatus == e2fsckErrorsUncorrected && result.Mode == FileSystemCheckModeCheck:
+		return result, nil
+
+	default:
+		return result, bosherr.WrapErrorf(err, "Shelling out to e2fsck (exit status %d)", exitStatus)
+	}
+}
+
+func (c linuxFileSystemChecker) checkXFS(result FileSystemCheckResult) (FileSystemCheckResult, error) {
+	c.logger.Info(c.logTag, "Checking `%s' with xfs_repair -n", result.PartitionPath)
+
+	// xfs_repair does not report whether it fixed anything,
+	// so always run a dry run first to find out if repair is necessary
+	stdout, stderr, exitStatus, err := c.runner.RunCommand("xfs_repair", "-n", result.PartitionPath)
+	result.ExitStatus = exitStatus
+	result.Output = strings.TrimSpace(stdout + stderr)
+
+	if err == nil {
+		result.Clean = true
+		return result, nil
+	}
+
+	switch exitStatus {
+	case xfsRepairDirtyLog:
+		// Log is replayed when file system is mounted
+		c.logger.Info(c.logTag, "Skipping check of `%s' with dirty log", result.PartitionPath)
+		result.Skipped = true
+		return result, nil
+
+	case xfsRepairCorruptionDetected:
+		if result.Mode != FileSystemCheckModeRepair {
+			return result, nil
+		}
+
+	default:
+		return result, bosherr.WrapErrorf(err, "Shelling out to xfs_repair (exit status %d)", exitStatus)
+	}
+
+	c.logger.Info(c.logTag, "Repairing `%s' with xfs_repair", result.PartitionPath)
+
+	stdout, stderr, exitStatus, err = c.runner.RunCommand("xfs_repair", result.PartitionPath)
+	result.ExitStatus = exitStatus
+	result.Output = strings.TrimSpace(stdout + stderr)
+
+	if err != nil {
+		return result, bosherr.WrapErrorf(err, "Shelling out to xfs_repair (exit status %d)", exitStatus)
+	}
+
+	result.Repaired = true
+	return result, nil
+}
@@ -0,0 +1,218 @@
+package disk_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("linuxFileSystemChecker", func() {
+	var (
+		runner  *fakesys.FakeCmdRunner
+		fs      *fakesys.FakeFileSystem
+		now     time.Time
+		checker FileSystemChecker
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		err := fs.WriteFile("/dev/sdb1", []byte{})
+		Expect(err).ToNot(HaveOccurred())
+		fs.GetFileTestStat("/dev/sdb1").FileMode = os.ModeDevice | 0660
+		now = time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+		checker = NewLinuxFileSystemChecker(runner, fs, fakeclock.NewFakeClock(now), boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Context("when mode is off", func() {
+		It("skips the check without running any commands", func() {
+			result, err := checker.Check("/dev/sdb1", FileSystemCheckModeOff)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Skipped).To(BeTrue())
+			Expect(result.NeedsAttention()).To(BeFalse())
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Context("when partition is not a block device", func() {
+		BeforeEach(func() {
+			err := fs.MkdirAll("/var/vcap/store-dir", 0700)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("skips the check of bind mounted directory without running any commands", func() {
+			result, err := checker.Check("/var/vcap/store-dir", FileSystemCheckModeRepair)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Skipped).To(BeTrue())
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Context("when partition cannot be stat'ed", func() {
+		BeforeEach(func() {
+			err := fs.Symlink("/dev/fake-missing-device", "/dev/sdc1")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error without running any commands", func() {
+			_, err := checker.Check("/dev/sdc1", FileSystemCheckModeRepair)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Getting info of partition `/dev/sdc1'"))
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Context("when partition is not formatted", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("Exit code 2")})
+		})
+
+		It("skips the check", func() {
+			result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Skipped).To(BeTrue())
+			Expect(runner.RunCommands).To(Equal([][]string{{"blkid", "-p", "/dev/sdb1"}}))
+		})
+	})
+
+	It("returns error when file system type cannot be determined", func() {
+		runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 4, Stderr: "fake-stderr", Error: errors.New("fake-blkid-err")})
+
+		_, err := checker.Check("/dev/sdb1", FileSystemCheckModeCheck)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-blkid-err"))
+	})
+
+	Context("when partition is formatted with ext4", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="ext4" yyyy zzzz`})
+		})
+
+		Context("when mode is check", func() {
+			It("runs e2fsck without making changes and reports clean file system", func() {
+				runner.AddCmdResult("e2fsck -n /dev/sdb1", fakesys.FakeCmdResult{Stdout: "fake-output\n"})
+
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeCheck)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunCommands[1]).To(Equal([]string{"e2fsck", "-n", "/dev/sdb1"}))
+				Expect(result).To(Equal(FileSystemCheckResult{
+					PartitionPath:  "/dev/sdb1",
+					FileSystemType: FileSystemExt4,
+					Mode:           FileSystemCheckModeCheck,
+					Clean:          true,
+					Output:         "fake-output",
+					CheckedAt:      now,
+				}))
+			})
+
+			It("reports uncorrected errors without failing", func() {
+				runner.AddCmdResult("e2fsck -n /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 4, Error: errors.New("fake-e2fsck-err")})
+
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeCheck)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Clean).To(BeFalse())
+				Expect(result.Repaired).To(BeFalse())
+				Expect(result.ExitStatus).To(Equal(4))
+				Expect(result.NeedsAttention()).To(BeTrue())
+			})
+
+			It("returns error when e2fsck fails to run", func() {
+				runner.AddCmdResult("e2fsck -n /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 8, Error: errors.New("fake-e2fsck-err")})
+
+				_, err := checker.Check("/dev/sdb1", FileSystemCheckModeCheck)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-e2fsck-err"))
+			})
+		})
+
+		Context("when mode is repair", func() {
+			It("runs e2fsck answering yes to all questions", func() {
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunCommands[1]).To(Equal([]string{"e2fsck", "-y", "/dev/sdb1"}))
+				Expect(result.Clean).To(BeTrue())
+				Expect(result.NeedsAttention()).To(BeFalse())
+			})
+
+			It("reports corrected errors", func() {
+				runner.AddCmdResult("e2fsck -y /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-e2fsck-err")})
+
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Repaired).To(BeTrue())
+				Expect(result.NeedsAttention()).To(BeTrue())
+			})
+
+			It("returns error when errors could not be corrected", func() {
+				runner.AddCmdResult("e2fsck -y /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 4, Error: errors.New("fake-e2fsck-err")})
+
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("exit status 4"))
+				Expect(result.Clean).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when partition is formatted with xfs", func() {
+		BeforeEach(func() {
+			runner.AddCmdResult("blkid -p /dev/sdb1", fakesys.FakeCmdResult{Stdout: `xxxxx TYPE="xfs" yyyy zzzz`})
+		})
+
+		It("reports clean file system when dry run finds no errors", func() {
+			result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Clean).To(BeTrue())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"blkid", "-p", "/dev/sdb1"},
+				{"xfs_repair", "-n", "/dev/sdb1"},
+			}))
+		})
+
+		It("skips the check when log needs to be replayed by mounting", func() {
+			runner.AddCmdResult("xfs_repair -n /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 2, Error: errors.New("fake-xfs-err")})
+
+			result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Skipped).To(BeTrue())
+			Expect(len(runner.RunCommands)).To(Equal(2))
+		})
+
+		Context("when dry run detects corruption", func() {
+			BeforeEach(func() {
+				runner.AddCmdResult("xfs_repair -n /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-xfs-err")})
+			})
+
+			It("only reports corruption when mode is check", func() {
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeCheck)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Clean).To(BeFalse())
+				Expect(result.Repaired).To(BeFalse())
+				Expect(len(runner.RunCommands)).To(Equal(2))
+			})
+
+			It("repairs file system when mode is repair", func() {
+				result, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Repaired).To(BeTrue())
+				Expect(runner.RunCommands[2]).To(Equal([]string{"xfs_repair", "/dev/sdb1"}))
+			})
+
+			It("returns error when repair fails", func() {
+				runner.AddCmdResult("xfs_repair /dev/sdb1", fakesys.FakeCmdResult{ExitStatus: 1, Error: errors.New("fake-xfs-repair-err")})
+
+				_, err := checker.Check("/dev/sdb1", FileSystemCheckModeRepair)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-xfs-repair-err"))
+			})
+		})
+	})
+})
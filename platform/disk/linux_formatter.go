@@ -20,7 +20,7 @@ func NewLinuxFormatter(runner boshsys.CmdRunner, fs boshsys.FileSystem) Formatte
 }
 
 func (f linuxFormatter) Format(partitionPath string, fsType FileSystemType) (err error) {
-	existingFsType, err := f.getPartitionFormatType(partitionPath)
+	existingFsType, err := getPartitionFormatType(f.runner, partitionPath)
 	if err != nil {
 		return bosherr.WrapError(err, "Checking filesystem format of partition")
 	}
@@ -72,8 +72,8 @@ func (f linuxFormatter) makeFileSystemExt4(partitionPath string) error {
 	return err
 }
 
-func (f linuxFormatter) getPartitionFormatType(partitionPath string) (FileSystemType, error) {
-	stdout, stderr, exitStatus, err := f.runner.RunCommand("blkid", "-p", partitionPath)
+func getPartitionFormatType(runner boshsys.CmdRunner, partitionPath string) (FileSystemType, error) {
+	stdout, stderr, exitStatus, err := runner.RunCommand("blkid", "-p", partitionPath)
 
 	if err != nil {
 		if exitStatus == 2 && stderr == "" {
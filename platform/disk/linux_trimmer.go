@@ -0,0 +1,40 @@
+package disk
+
+import (
+	"regexp"
+	"strconv"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// e.g. '/var/vcap/data: 1.2 GiB (1288490188 bytes) trimmed'
+// or '/var/vcap/data: 1288490188 bytes were trimmed' on older util-linux
+var fstrimTrimmedBytesRegexp = regexp.MustCompile(`(\d+) bytes`)
+
+type linuxTrimmer struct {
+	runner boshsys.CmdRunner
+}
+
+func NewLinuxTrimmer(runner boshsys.CmdRunner) Trimmer {
+	return linuxTrimmer{runner: runner}
+}
+
+func (t linuxTrimmer) Trim(mountPoint string) (uint64, error) {
+	stdout, _, _, err := t.runner.RunCommand("fstrim", "-v", mountPoint)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Shelling out to fstrim")
+	}
+
+	matches := fstrimTrimmedBytesRegexp.FindStringSubmatch(stdout)
+	if matches == nil {
+		return 0, bosherr.Errorf("Parsing fstrim output '%s'", stdout)
+	}
+
+	trimmedBytes, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing trimmed bytes '%s'", matches[1])
+	}
+
+	return trimmedBytes, nil
+}
@@ -0,0 +1,61 @@
+package disk_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("linuxTrimmer", func() {
+	var (
+		runner  *fakesys.FakeCmdRunner
+		trimmer Trimmer
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		trimmer = NewLinuxTrimmer(runner)
+	})
+
+	It("returns number of trimmed bytes", func() {
+		runner.AddCmdResult("fstrim -v /var/vcap/data", fakesys.FakeCmdResult{
+			Stdout: "/var/vcap/data: 1.2 GiB (1288490188 bytes) trimmed\n",
+		})
+
+		trimmedBytes, err := trimmer.Trim("/var/vcap/data")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(trimmedBytes).To(Equal(uint64(1288490188)))
+	})
+
+	It("parses output of older fstrim versions", func() {
+		runner.AddCmdResult("fstrim -v /var/vcap/data", fakesys.FakeCmdResult{
+			Stdout: "/var/vcap/data: 4096 bytes were trimmed\n",
+		})
+
+		trimmedBytes, err := trimmer.Trim("/var/vcap/data")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(trimmedBytes).To(Equal(uint64(4096)))
+	})
+
+	It("returns error when fstrim fails", func() {
+		runner.AddCmdResult("fstrim -v /var/vcap/data", fakesys.FakeCmdResult{
+			Error: errors.New("fake-fstrim-err"),
+		})
+
+		_, err := trimmer.Trim("/var/vcap/data")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-fstrim-err"))
+	})
+
+	It("returns error when output cannot be parsed", func() {
+		runner.AddCmdResult("fstrim -v /var/vcap/data", fakesys.FakeCmdResult{Stdout: "fake-output"})
+
+		_, err := trimmer.Trim("/var/vcap/data")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-output"))
+	})
+})
@@ -9,6 +9,10 @@ type Manager interface {
 	GetRootDevicePartitioner() Partitioner
 	GetPartedPartitioner() Partitioner
 	GetFormatter() Formatter
+	GetFileSystemChecker() FileSystemChecker
+	GetDirectoryQuotaManager() DirectoryQuotaManager
+	GetBlockDeviceInspector() BlockDeviceInspector
+	GetTrimmer() Trimmer
 	GetMounter() Mounter
 	GetMountsSearcher() MountsSearcher
 	GetDiskUtil(diskPath string) boshdevutil.DeviceUtil
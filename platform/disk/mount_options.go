@@ -0,0 +1,135 @@
+package disk
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// DirectoryQuota limits disk usage of a directory on a mounted disk.
+// Path is relative to the disk mount point.
+type DirectoryQuota struct {
+	Path     string `json:"path"`
+	SizeInMB uint64 `json:"size_in_mb"`
+}
+
+const ProjectQuotaMountOption = "prjquota"
+
+// Mount options that could be requested via settings. Options that change
+// mount semantics (ro, bind, remount, etc.) are managed by the agent itself.
+var allowedMountOptions = map[string]bool{
+	"noatime":     true,
+	"nodiratime":  true,
+	"relatime":    true,
+	"strictatime": true,
+	"lazytime":    true,
+	"nodev":       true,
+	"nosuid":      true,
+	"noexec":      true,
+	"sync":        true,
+	"dirsync":     true,
+	"discard":     true,
+	"nodiscard":   true,
+	"inode64":     true,
+	"usrquota":    true,
+	"grpquota":    true,
+	"prjquota":    true,
+	"uquota":      true,
+	"gquota":      true,
+	"pquota":      true,
+}
+
+var allowedMountOptionValues = map[string]func(string) bool{
+	"commit": isNumericMountOptionValue,
+	"data": func(value string) bool {
+		return value == "ordered" || value == "writeback" || value == "journal"
+	},
+}
+
+func isNumericMountOptionValue(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 32)
+	return err == nil
+}
+
+func isAllowedMountOption(option string) bool {
+	if allowedMountOptions[option] {
+		return true
+	}
+
+	parts := strings.SplitN(option, "=", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	isValidValue, found := allowedMountOptionValues[parts[0]]
+	return found && isValidValue(parts[1])
+}
+
+func ValidateMountOptions(options []string) error {
+	for _, option := range options {
+		if !isAllowedMountOption(option) {
+			return bosherr.Errorf("Mount option '%s' is not allowed", option)
+		}
+	}
+
+	return nil
+}
+
+// FilterMountOptions returns only allowed mount options,
+// e.g. to carry over options reported for an existing mount
+func FilterMountOptions(options []string) []string {
+	var filtered []string
+
+	for _, option := range options {
+		if isAllowedMountOption(option) {
+			filtered = append(filtered, option)
+		}
+	}
+
+	return filtered
+}
+
+func HasProjectQuotaMountOption(options []string) bool {
+	for _, option := range options {
+		if option == ProjectQuotaMountOption || option == "pquota" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MountOptionsArgs converts mount options into mount command arguments
+func MountOptionsArgs(options []string) []string {
+	if len(options) == 0 {
+		return nil
+	}
+
+	return []string{"-o", strings.Join(options, ",")}
+}
+
+func ValidateDirectoryQuotas(quotas []DirectoryQuota) error {
+	paths := map[string]bool{}
+
+	for _, quota := range quotas {
+		path := filepath.Clean(quota.Path)
+
+		if quota.Path == "" || filepath.IsAbs(path) || path == "." || strings.HasPrefix(path, "..") {
+			return bosherr.Errorf("Quota path '%s' must be relative to disk mount point", quota.Path)
+		}
+
+		if quota.SizeInMB == 0 {
+			return bosherr.Errorf("Quota size for '%s' must be greater than 0", quota.Path)
+		}
+
+		if paths[path] {
+			return bosherr.Errorf("Quota for '%s' is specified more than once", quota.Path)
+		}
+
+		paths[path] = true
+	}
+
+	return nil
+}
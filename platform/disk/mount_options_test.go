@@ -0,0 +1,86 @@
+package disk_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+var _ = Describe("mount options", func() {
+	Describe("ValidateMountOptions", func() {
+		It("allows whitelisted options", func() {
+			err := ValidateMountOptions([]string{"noatime", "nodev", "discard", "prjquota", "commit=30", "data=writeback"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects options that are managed by the agent", func() {
+			err := ValidateMountOptions([]string{"noatime", "ro"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Mount option 'ro' is not allowed"))
+		})
+
+		It("rejects options with invalid values", func() {
+			Expect(ValidateMountOptions([]string{"commit=never"})).To(HaveOccurred())
+			Expect(ValidateMountOptions([]string{"data=fake"})).To(HaveOccurred())
+			Expect(ValidateMountOptions([]string{"noatime,bind"})).To(HaveOccurred())
+		})
+	})
+
+	Describe("FilterMountOptions", func() {
+		It("keeps only whitelisted options", func() {
+			filtered := FilterMountOptions([]string{"rw", "noatime", "attr2", "prjquota", "data=ordered"})
+			Expect(filtered).To(Equal([]string{"noatime", "prjquota", "data=ordered"}))
+		})
+	})
+
+	Describe("MountOptionsArgs", func() {
+		It("returns no arguments when there are no options", func() {
+			Expect(MountOptionsArgs(nil)).To(BeNil())
+		})
+
+		It("joins options into a single -o argument", func() {
+			Expect(MountOptionsArgs([]string{"noatime", "nodev"})).To(Equal([]string{"-o", "noatime,nodev"}))
+		})
+	})
+
+	Describe("HasProjectQuotaMountOption", func() {
+		It("detects both spellings of project quota option", func() {
+			Expect(HasProjectQuotaMountOption([]string{"noatime", "prjquota"})).To(BeTrue())
+			Expect(HasProjectQuotaMountOption([]string{"pquota"})).To(BeTrue())
+			Expect(HasProjectQuotaMountOption([]string{"usrquota"})).To(BeFalse())
+		})
+	})
+
+	Describe("ValidateDirectoryQuotas", func() {
+		It("allows quotas on distinct relative paths", func() {
+			err := ValidateDirectoryQuotas([]DirectoryQuota{
+				{Path: "fake-job", SizeInMB: 1024},
+				{Path: "other-job/data", SizeInMB: 10},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects paths outside of disk mount point", func() {
+			Expect(ValidateDirectoryQuotas([]DirectoryQuota{{Path: "/var/vcap/store", SizeInMB: 1}})).To(HaveOccurred())
+			Expect(ValidateDirectoryQuotas([]DirectoryQuota{{Path: "../fake-dir", SizeInMB: 1}})).To(HaveOccurred())
+			Expect(ValidateDirectoryQuotas([]DirectoryQuota{{Path: ".", SizeInMB: 1}})).To(HaveOccurred())
+			Expect(ValidateDirectoryQuotas([]DirectoryQuota{{Path: "", SizeInMB: 1}})).To(HaveOccurred())
+		})
+
+		It("rejects zero size", func() {
+			err := ValidateDirectoryQuotas([]DirectoryQuota{{Path: "fake-job"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("must be greater than 0"))
+		})
+
+		It("rejects duplicate paths", func() {
+			err := ValidateDirectoryQuotas([]DirectoryQuota{
+				{Path: "fake-job", SizeInMB: 1},
+				{Path: "fake-job/", SizeInMB: 2},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("more than once"))
+		})
+	})
+})
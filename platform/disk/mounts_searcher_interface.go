@@ -3,6 +3,7 @@ package disk
 type Mount struct {
 	PartitionPath string
 	MountPoint    string
+	Options       []string
 }
 
 type MountsSearcher interface {
@@ -0,0 +1,23 @@
+package disk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PartitionPath follows kernel partition naming: device mapper partitions
+// get -part suffix and devices ending with a digit (e.g. nvme0n1) get p separator
+func PartitionPath(devicePath string, number int) string {
+	if strings.Contains(devicePath, "/dev/mapper/") {
+		return fmt.Sprintf("%s-part%d", devicePath, number)
+	}
+
+	if devicePath != "" {
+		last := devicePath[len(devicePath)-1]
+		if last >= '0' && last <= '9' {
+			return fmt.Sprintf("%sp%d", devicePath, number)
+		}
+	}
+
+	return fmt.Sprintf("%s%d", devicePath, number)
+}
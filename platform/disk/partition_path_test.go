@@ -0,0 +1,23 @@
+package disk_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+var _ = Describe("PartitionPath", func() {
+	It("appends partition number to device path", func() {
+		Expect(PartitionPath("/dev/sdb", 2)).To(Equal("/dev/sdb2"))
+	})
+
+	It("separates partition number with p when device path ends with a digit", func() {
+		Expect(PartitionPath("/dev/nvme1n1", 2)).To(Equal("/dev/nvme1n1p2"))
+		Expect(PartitionPath("/dev/mmcblk0", 1)).To(Equal("/dev/mmcblk0p1"))
+	})
+
+	It("uses part suffix for device mapper devices", func() {
+		Expect(PartitionPath("/dev/mapper/fake-device-1", 1)).To(Equal("/dev/mapper/fake-device-1-part1"))
+	})
+})
@@ -0,0 +1,454 @@
+package disk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var errMigrationCanceled = bosherr.Error("Persistent disk migration was canceled")
+
+type persistentDiskMigrationState struct {
+	FromMountPoint   string         `json:"from_mount_point"`
+	ToMountPoint     string         `json:"to_mount_point"`
+	FromMountOptions []string       `json:"from_mount_options"`
+	Phase            MigrationPhase `json:"phase"`
+	Entries          []string       `json:"entries"`
+	CopiedEntries    int            `json:"copied_entries"`
+}
+
+type persistentDiskMigrator struct {
+	runner         boshsys.CmdRunner
+	fs             boshsys.FileSystem
+	mounter        Mounter
+	mountsSearcher MountsSearcher
+	statePath      string
+
+	// Access to state and canceled must be synchronized via lock
+	state    *persistentDiskMigrationState
+	canceled bool
+	lock     sync.Mutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewPersistentDiskMigrator returns migrator that keeps its progress in statePath
+// so that migration could be resumed after agent restart
+func NewPersistentDiskMigrator(
+	runner boshsys.CmdRunner,
+	fs boshsys.FileSystem,
+	mounter Mounter,
+	mountsSearcher MountsSearcher,
+	statePath string,
+	logger boshlog.Logger,
+) PersistentDiskMigrator {
+	return &persistentDiskMigrator{
+		runner:         runner,
+		fs:             fs,
+		mounter:        mounter,
+		mountsSearcher: mountsSearcher,
+		statePath:      statePath,
+		logger:         logger,
+		logTag:         "persistentDiskMigrator",
+	}
+}
+
+func (m *persistentDiskMigrator) Migrate(fromMountPoint, toMountPoint string) error {
+	m.lock.Lock()
+	m.canceled = false
+	m.lock.Unlock()
+
+	defer m.setState(nil)
+
+	state, err := m.loadOrStartMigration(fromMountPoint, toMountPoint)
+	if err != nil {
+		return err
+	}
+
+	if state.Phase == MigrationPhaseCopying {
+		err = m.copyEntries(&state)
+		if err == errMigrationCanceled {
+			return m.rollBack(state)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.Phase == MigrationPhaseVerifying {
+		err = m.verify(&state)
+		if err == errMigrationCanceled {
+			return m.rollBack(state)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = m.switchMountPoints(state)
+	if err != nil {
+		return err
+	}
+
+	err = m.fs.RemoveAll(m.statePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing persistent disk migration state")
+	}
+
+	return nil
+}
+
+func (m *persistentDiskMigrator) Cancel() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.state != nil && m.state.Phase == MigrationPhaseSwitching {
+		return bosherr.Error("Persistent disk migration cannot be canceled while switching mount points")
+	}
+
+	m.canceled = true
+
+	return nil
+}
+
+func (m *persistentDiskMigrator) Progress() (MigrationProgress, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.state == nil {
+		return MigrationProgress{}, false
+	}
+
+	return MigrationProgress{
+		FromMountPoint: m.state.FromMountPoint,
+		ToMountPoint:   m.state.ToMountPoint,
+		Phase:          m.state.Phase,
+		TotalEntries:   len(m.state.Entries),
+		CopiedEntries:  m.state.CopiedEntries,
+	}, true
+}
+
+func (m *persistentDiskMigrator) loadOrStartMigration(fromMountPoint, toMountPoint string) (persistentDiskMigrationState, error) {
+	var state persistentDiskMigrationState
+
+	if m.fs.FileExists(m.statePath) {
+		bytes, err := m.fs.ReadFile(m.statePath)
+		if err != nil {
+			return state, bosherr.WrapError(err, "Reading persistent disk migration state")
+		}
+
+		err = json.Unmarshal(bytes, &state)
+		if err != nil {
+			return state, bosherr.WrapError(err, "Unmarshalling persistent disk migration state")
+		}
+
+		if state.FromMountPoint != fromMountPoint || state.ToMountPoint != toMountPoint {
+			return state, bosherr.Errorf("Migration from %s to %s is already in progress", state.FromMountPoint, state.ToMountPoint)
+		}
+
+		m.logger.Info(m.logTag, "Resuming migration from %s to %s in phase %s", fromMountPoint, toMountPoint, state.Phase)
+
+		m.setState(&state)
+
+		if state.Phase == MigrationPhaseCopying {
+			// Old disk might have been remounted read-write after agent restart
+			err = m.ensureReadonly(fromMountPoint)
+			if err != nil {
+				return state, err
+			}
+		}
+
+		return state, nil
+	}
+
+	mounts, err := m.mountsSearcher.SearchMounts()
+	if err != nil {
+		return state, bosherr.WrapError(err, "Searching mounts")
+	}
+
+	fromMount, found := findMountByMountPoint(mounts, fromMountPoint)
+	if !found {
+		return state, bosherr.Errorf("Old disk is not mounted on %s", fromMountPoint)
+	}
+
+	_, found = findMountByMountPoint(mounts, toMountPoint)
+	if !found {
+		return state, bosherr.Errorf("New disk is not mounted on %s", toMountPoint)
+	}
+
+	state = persistentDiskMigrationState{
+		FromMountPoint:   fromMountPoint,
+		ToMountPoint:     toMountPoint,
+		FromMountOptions: FilterMountOptions(fromMount.Options),
+		Phase:            MigrationPhaseCopying,
+	}
+
+	err = m.ensureReadonly(fromMountPoint)
+	if err != nil {
+		return state, err
+	}
+
+	state.Entries, err = m.listEntries(fromMountPoint)
+	if err != nil {
+		return state, err
+	}
+
+	err = m.saveState(state)
+	if err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+// listEntries returns top level entries of a mount point;
+// each of them is copied separately so copying resumes with the first entry not fully copied
+func (m *persistentDiskMigrator) listEntries(mountPoint string) ([]string, error) {
+	stdout, _, _, err := m.runner.RunCommand("find", mountPoint, "-mindepth", "1", "-maxdepth", "1", "-printf", "%f\\n")
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listing entries of %s", mountPoint)
+	}
+
+	entries := []string{}
+
+	for _, entry := range strings.Split(stdout, "\n") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Strings(entries)
+
+	return entries, nil
+}
+
+func (m *persistentDiskMigrator) copyEntries(state *persistentDiskMigrationState) error {
+	for i := state.CopiedEntries; i < len(state.Entries); i++ {
+		if m.isCanceled() {
+			return errMigrationCanceled
+		}
+
+		entry := state.Entries[i]
+
+		m.logger.Debug(m.logTag, "Copying entry %d of %d: %s", i+1, len(state.Entries), entry)
+
+		// Golang does not implement a file copy that would allow us to preserve dates...
+		// So we have to shell out to tar to perform the copy instead of delegating to the FileSystem
+		tarCopy := fmt.Sprintf(
+			"(tar -C %s -cf - %s) | (tar -C %s -xpf -)",
+			state.FromMountPoint, shellQuote("./"+entry), state.ToMountPoint,
+		)
+
+		_, _, _, err := m.runner.RunCommand("sh", "-c", tarCopy)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Copying '%s' from old disk to new disk", entry)
+		}
+
+		state.CopiedEntries = i + 1
+
+		err = m.saveState(*state)
+		if err != nil {
+			return err
+		}
+	}
+
+	state.Phase = MigrationPhaseVerifying
+
+	return m.saveState(*state)
+}
+
+func (m *persistentDiskMigrator) ensureReadonly(mountPoint string) error {
+	mounts, err := m.mountsSearcher.SearchMounts()
+	if err != nil {
+		return bosherr.WrapError(err, "Searching mounts")
+	}
+
+	mount, found := findMountByMountPoint(mounts, mountPoint)
+	if !found {
+		return bosherr.Errorf("Old disk is not mounted on %s", mountPoint)
+	}
+
+	for _, option := range mount.Options {
+		if option == "ro" {
+			return nil
+		}
+	}
+
+	err = m.mounter.RemountAsReadonly(mountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Remounting persistent disk as readonly")
+	}
+
+	return nil
+}
+
+func (m *persistentDiskMigrator) rollBack(state persistentDiskMigrationState) error {
+	m.logger.Info(m.logTag, "Canceling migration from %s to %s", state.FromMountPoint, state.ToMountPoint)
+
+	err := m.mounter.Remount(state.FromMountPoint, state.FromMountPoint, MountOptionsArgs(state.FromMountOptions)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Remounting old disk as writable")
+	}
+
+	err = m.fs.RemoveAll(m.statePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing persistent disk migration state")
+	}
+
+	return errMigrationCanceled
+}
+
+func (m *persistentDiskMigrator) verify(state *persistentDiskMigrationState) error {
+	fromCount, err := m.countFiles(state.FromMountPoint)
+	if err != nil {
+		return err
+	}
+
+	toCount, err := m.countFiles(state.ToMountPoint)
+	if err != nil {
+		return err
+	}
+
+	if fromCount != toCount {
+		// Next attempt will copy everything again
+		state.Phase = MigrationPhaseCopying
+		state.CopiedEntries = 0
+
+		err = m.saveState(*state)
+		if err != nil {
+			return err
+		}
+
+		return bosherr.Errorf(
+			"Verifying copied files: old disk has %d files (%d bytes), new disk has %d files (%d bytes)",
+			fromCount.files, fromCount.bytes, toCount.files, toCount.bytes,
+		)
+	}
+
+	return m.startSwitching(state)
+}
+
+// startSwitching checks for cancellation and enters switching phase atomically
+// so that Cancel either stops migration or is rejected
+func (m *persistentDiskMigrator) startSwitching(state *persistentDiskMigrationState) error {
+	m.lock.Lock()
+	if m.canceled {
+		m.lock.Unlock()
+		return errMigrationCanceled
+	}
+
+	state.Phase = MigrationPhaseSwitching
+	switchingState := *state
+	m.state = &switchingState
+	m.lock.Unlock()
+
+	return m.saveState(*state)
+}
+
+type migrationFileCount struct {
+	files uint64
+	bytes uint64
+}
+
+func (m *persistentDiskMigrator) countFiles(mountPoint string) (migrationFileCount, error) {
+	var count migrationFileCount
+
+	countCmd := fmt.Sprintf(
+		`find %s -xdev -type f -printf '%%s\n' | awk '{n++; s+=$1} END {printf "%%d %%.0f\n", n, s}'`,
+		mountPoint,
+	)
+
+	stdout, _, _, err := m.runner.RunCommand("sh", "-c", countCmd)
+	if err != nil {
+		return count, bosherr.WrapErrorf(err, "Counting files on %s", mountPoint)
+	}
+
+	_, err = fmt.Sscanf(stdout, "%d %d", &count.files, &count.bytes)
+	if err != nil {
+		return count, bosherr.WrapErrorf(err, "Parsing file count of %s", mountPoint)
+	}
+
+	return count, nil
+}
+
+func (m *persistentDiskMigrator) switchMountPoints(state persistentDiskMigrationState) error {
+	mounts, err := m.mountsSearcher.SearchMounts()
+	if err != nil {
+		return bosherr.WrapError(err, "Searching mounts")
+	}
+
+	toMount, found := findMountByMountPoint(mounts, state.ToMountPoint)
+	if !found {
+		// Agent restarted after new disk was moved to its final mount point
+		if _, found = findMountByMountPoint(mounts, state.FromMountPoint); found {
+			return nil
+		}
+
+		return bosherr.Errorf("Neither %s nor %s is mounted", state.FromMountPoint, state.ToMountPoint)
+	}
+
+	_, err = m.mounter.Unmount(state.FromMountPoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmounting old persistent disk")
+	}
+
+	mountOptions := FilterMountOptions(toMount.Options)
+
+	err = m.mounter.Remount(state.ToMountPoint, state.FromMountPoint, MountOptionsArgs(mountOptions)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Remounting new disk on original mountpoint")
+	}
+
+	return nil
+}
+
+func (m *persistentDiskMigrator) isCanceled() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.canceled
+}
+
+func (m *persistentDiskMigrator) setState(state *persistentDiskMigrationState) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.state = state
+}
+
+func (m *persistentDiskMigrator) saveState(state persistentDiskMigrationState) error {
+	m.setState(&state)
+
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling persistent disk migration state")
+	}
+
+	err = m.fs.WriteFile(m.statePath, bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing persistent disk migration state")
+	}
+
+	return nil
+}
+
+func findMountByMountPoint(mounts []Mount, mountPoint string) (Mount, bool) {
+	for _, mount := range mounts {
+		if mount.MountPoint == mountPoint {
+			return mount, true
+		}
+	}
+
+	return Mount{}, false
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,34 @@
+package disk
+
+type MigrationPhase string
+
+const (
+	MigrationPhaseCopying   MigrationPhase = "copying"
+	MigrationPhaseVerifying MigrationPhase = "verifying"
+	MigrationPhaseSwitching MigrationPhase = "switching"
+)
+
+// MigrationProgress counts top level entries of old disk (e.g. job directories),
+// so progress and resumption have coarse granularity: an entry interrupted
+// while being copied is copied again from the start
+type MigrationProgress struct {
+	FromMountPoint string         `json:"from_mount_point"`
+	ToMountPoint   string         `json:"to_mount_point"`
+	Phase          MigrationPhase `json:"phase"`
+	TotalEntries   int            `json:"total_entries"`
+	CopiedEntries  int            `json:"copied_entries"`
+}
+
+type PersistentDiskMigrator interface {
+	// Migrate copies contents of old disk to new disk and mounts new disk
+	// in place of old one once copy is verified. Interrupted migration
+	// (e.g. due to agent restart) continues with the first entry not yet copied.
+	Migrate(fromMountPoint, toMountPoint string) error
+
+	// Cancel stops migration before mount points are switched
+	// and makes old disk writable again
+	Cancel() error
+
+	// Progress returns false when no migration is in progress
+	Progress() (MigrationProgress, bool)
+}
@@ -0,0 +1,272 @@
+package disk_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+const (
+	fromCountCmd = `find /fake-store -xdev -type f -printf '%s\n' | awk '{n++; s+=$1} END {printf "%d %.0f\n", n, s}'`
+	toCountCmd   = `find /fake-target -xdev -type f -printf '%s\n' | awk '{n++; s+=$1} END {printf "%d %.0f\n", n, s}'`
+	statePath    = "/fake-bosh/persistent_disk_migration.json"
+)
+
+var _ = Describe("persistentDiskMigrator", func() {
+	var (
+		runner         *fakesys.FakeCmdRunner
+		fs             *fakesys.FakeFileSystem
+		mounter        *fakedisk.FakeMounter
+		mountsSearcher *fakedisk.FakeMountsSearcher
+		migrator       PersistentDiskMigrator
+
+		entriesResult   fakesys.FakeCmdResult
+		fromCountResult fakesys.FakeCmdResult
+		toCountResult   fakesys.FakeCmdResult
+	)
+
+	BeforeEach(func() {
+		runner = fakesys.NewFakeCmdRunner()
+		fs = fakesys.NewFakeFileSystem()
+		mounter = &fakedisk.FakeMounter{}
+		mountsSearcher = &fakedisk.FakeMountsSearcher{
+			SearchMountsMounts: []Mount{
+				{PartitionPath: "/dev/sdb1", MountPoint: "/fake-store", Options: []string{"rw", "noatime"}},
+				{PartitionPath: "/dev/sdc1", MountPoint: "/fake-target", Options: []string{"rw", "nodev"}},
+			},
+		}
+		migrator = NewPersistentDiskMigrator(runner, fs, mounter, mountsSearcher, statePath, boshlog.NewLogger(boshlog.LevelNone))
+
+		entriesResult = fakesys.FakeCmdResult{Stdout: "b-dir\n.a-file\n"}
+		fromCountResult = fakesys.FakeCmdResult{Stdout: "3 4096\n"}
+		toCountResult = fakesys.FakeCmdResult{Stdout: "3 4096\n"}
+	})
+
+	JustBeforeEach(func() {
+		runner.AddCmdResult("find /fake-store -mindepth 1 -maxdepth 1 -printf %f\\n", entriesResult)
+		runner.AddCmdResult("sh -c "+fromCountCmd, fromCountResult)
+		runner.AddCmdResult("sh -c "+toCountCmd, toCountResult)
+	})
+
+	readState := func() map[string]interface{} {
+		var state map[string]interface{}
+		contents, err := fs.ReadFile(statePath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(json.Unmarshal(contents, &state)).To(Succeed())
+		return state
+	}
+
+	writeState := func(state map[string]interface{}) {
+		contents, err := json.Marshal(state)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.WriteFile(statePath, contents)).To(Succeed())
+	}
+
+	Describe("Migrate", func() {
+		It("copies each top level entry, verifies copy and switches mount points", func() {
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mounter.RemountAsReadonlyPath).To(Equal("/fake-store"))
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"find", "/fake-store", "-mindepth", "1", "-maxdepth", "1", "-printf", "%f\\n"},
+				{"sh", "-c", "(tar -C /fake-store -cf - './.a-file') | (tar -C /fake-target -xpf -)"},
+				{"sh", "-c", "(tar -C /fake-store -cf - './b-dir') | (tar -C /fake-target -xpf -)"},
+				{"sh", "-c", fromCountCmd},
+				{"sh", "-c", toCountCmd},
+			}))
+
+			Expect(mounter.UnmountPartitionPathOrMountPoint).To(Equal("/fake-store"))
+			Expect(mounter.RemountFromMountPoint).To(Equal("/fake-target"))
+			Expect(mounter.RemountToMountPoint).To(Equal("/fake-store"))
+			Expect(mounter.RemountMountOptions).To(Equal([]string{"-o", "nodev"}))
+
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+
+			_, found := migrator.Progress()
+			Expect(found).To(BeFalse())
+		})
+
+		Context("when entry names contain special characters", func() {
+			BeforeEach(func() {
+				entriesResult = fakesys.FakeCmdResult{Stdout: "it's here\n"}
+			})
+
+			It("quotes them", func() {
+				err := migrator.Migrate("/fake-store", "/fake-target")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunCommands[1]).To(Equal([]string{"sh", "-c", `(tar -C /fake-store -cf - './it'\''s here') | (tar -C /fake-target -xpf -)`}))
+			})
+		})
+
+		It("does not remount old disk when it is already read-only", func() {
+			mountsSearcher.SearchMountsMounts[0].Options = []string{"ro", "noatime"}
+
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mounter.RemountAsReadonlyCalled).To(BeFalse())
+		})
+
+		It("returns error when new disk is not mounted", func() {
+			mountsSearcher.SearchMountsMounts = mountsSearcher.SearchMountsMounts[:1]
+
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("New disk is not mounted on /fake-target"))
+			Expect(mounter.RemountAsReadonlyCalled).To(BeFalse())
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+
+		It("keeps progress when copying fails so that it can be resumed", func() {
+			runner.AddCmdResult(
+				"sh -c (tar -C /fake-store -cf - './b-dir') | (tar -C /fake-target -xpf -)",
+				fakesys.FakeCmdResult{Error: errors.New("fake-tar-err")},
+			)
+
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-tar-err"))
+
+			state := readState()
+			Expect(state["phase"]).To(Equal("copying"))
+			Expect(state["entries"]).To(Equal([]interface{}{".a-file", "b-dir"}))
+			Expect(state["copied_entries"]).To(Equal(float64(1)))
+			Expect(mounter.RemountFromMountPoint).To(BeEmpty())
+		})
+
+		Context("when migration was interrupted", func() {
+			BeforeEach(func() {
+				writeState(map[string]interface{}{
+					"from_mount_point":   "/fake-store",
+					"to_mount_point":     "/fake-target",
+					"from_mount_options": []string{"noatime"},
+					"phase":              "copying",
+					"entries":            []string{".a-file", "b-dir"},
+					"copied_entries":     1,
+				})
+			})
+
+			It("copies only remaining entries", func() {
+				err := migrator.Migrate("/fake-store", "/fake-target")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mounter.RemountAsReadonlyPath).To(Equal("/fake-store"))
+				Expect(runner.RunCommands[0]).To(Equal([]string{"sh", "-c", "(tar -C /fake-store -cf - './b-dir') | (tar -C /fake-target -xpf -)"}))
+				Expect(len(runner.RunCommands)).To(Equal(3))
+				Expect(mounter.RemountToMountPoint).To(Equal("/fake-store"))
+			})
+
+			It("returns error when different mount points are migrated", func() {
+				err := migrator.Migrate("/fake-store", "/fake-other")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Migration from /fake-store to /fake-target is already in progress"))
+			})
+
+			It("finishes switching when new disk was already moved", func() {
+				writeState(map[string]interface{}{
+					"from_mount_point": "/fake-store",
+					"to_mount_point":   "/fake-target",
+					"phase":            "switching",
+				})
+				mountsSearcher.SearchMountsMounts = []Mount{{PartitionPath: "/dev/sdc1", MountPoint: "/fake-store"}}
+
+				err := migrator.Migrate("/fake-store", "/fake-target")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(runner.RunCommands).To(BeEmpty())
+				Expect(mounter.RemountFromMountPoint).To(BeEmpty())
+				Expect(fs.FileExists(statePath)).To(BeFalse())
+			})
+		})
+
+		Context("when copied files do not match", func() {
+			BeforeEach(func() {
+				toCountResult = fakesys.FakeCmdResult{Stdout: "2 1024\n"}
+			})
+
+			It("starts copying over on next attempt", func() {
+				err := migrator.Migrate("/fake-store", "/fake-target")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("old disk has 3 files (4096 bytes), new disk has 2 files (1024 bytes)"))
+				Expect(mounter.RemountFromMountPoint).To(BeEmpty())
+
+				state := readState()
+				Expect(state["phase"]).To(Equal("copying"))
+				Expect(state["copied_entries"]).To(Equal(float64(0)))
+			})
+		})
+
+		Context("when file count cannot be parsed", func() {
+			BeforeEach(func() {
+				fromCountResult = fakesys.FakeCmdResult{Stdout: "garbage"}
+			})
+
+			It("returns error", func() {
+				err := migrator.Migrate("/fake-store", "/fake-target")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Parsing file count of /fake-store"))
+			})
+		})
+	})
+
+	Describe("Cancel", func() {
+		It("stops copying and makes old disk writable again", func() {
+			runner.SetCmdCallback("sh -c (tar -C /fake-store -cf - './.a-file') | (tar -C /fake-target -xpf -)", func() {
+				Expect(migrator.Cancel()).To(Succeed())
+			})
+
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Persistent disk migration was canceled"))
+
+			Expect(len(runner.RunCommands)).To(Equal(2))
+			Expect(mounter.RemountFromMountPoint).To(Equal("/fake-store"))
+			Expect(mounter.RemountToMountPoint).To(Equal("/fake-store"))
+			Expect(mounter.RemountMountOptions).To(Equal([]string{"-o", "noatime"}))
+			Expect(mounter.UnmountPartitionPathOrMountPoint).To(BeEmpty())
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+
+		It("does not switch mount points when canceled while verifying", func() {
+			runner.SetCmdCallback("sh -c "+toCountCmd, func() {
+				Expect(migrator.Cancel()).To(Succeed())
+			})
+
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Persistent disk migration was canceled"))
+
+			Expect(mounter.UnmountPartitionPathOrMountPoint).To(BeEmpty())
+			Expect(mounter.RemountFromMountPoint).To(Equal("/fake-store"))
+			Expect(mounter.RemountToMountPoint).To(Equal("/fake-store"))
+			Expect(fs.FileExists(statePath)).To(BeFalse())
+		})
+	})
+
+	Describe("Progress", func() {
+		It("reports copied entries while migration is in progress", func() {
+			var progress MigrationProgress
+
+			runner.SetCmdCallback("sh -c "+fromCountCmd, func() {
+				progress, _ = migrator.Progress()
+			})
+
+			err := migrator.Migrate("/fake-store", "/fake-target")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(progress).To(Equal(MigrationProgress{
+				FromMountPoint: "/fake-store",
+				ToMountPoint:   "/fake-target",
+				Phase:          MigrationPhaseVerifying,
+				TotalEntries:   2,
+				CopiedEntries:  2,
+			}))
+		})
+	})
+})
@@ -33,6 +33,7 @@ func (s procMountsSearcher) SearchMounts() ([]Mount, error) {
 		mounts = append(mounts, Mount{
 			PartitionPath: mountFields[0],
 			MountPoint:    mountFields[1],
+			Options:       strings.Split(mountFields[3], ","),
 		})
 	}
 
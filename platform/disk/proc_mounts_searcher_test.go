@@ -35,10 +35,10 @@ none /tmp/warden/cgroup tmpfs rw,relatime 0 0`,
 				mounts, err := searcher.SearchMounts()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(mounts).To(Equal([]Mount{
-					Mount{PartitionPath: "none", MountPoint: "/run/lock"},
-					Mount{PartitionPath: "none", MountPoint: "/run/shm"},
-					Mount{PartitionPath: "/dev/sda1", MountPoint: "/boot"},
-					Mount{PartitionPath: "none", MountPoint: "/tmp/warden/cgroup"},
+					Mount{PartitionPath: "none", MountPoint: "/run/lock", Options: []string{"rw", "nosuid", "nodev", "noexec", "relatime", "size=5120k"}},
+					Mount{PartitionPath: "none", MountPoint: "/run/shm", Options: []string{"rw", "nosuid", "nodev", "relatime"}},
+					Mount{PartitionPath: "/dev/sda1", MountPoint: "/boot", Options: []string{"rw", "relatime", "errors=continue"}},
+					Mount{PartitionPath: "none", MountPoint: "/tmp/warden/cgroup", Options: []string{"rw", "relatime"}},
 				}))
 			})
 
@@ -54,8 +54,8 @@ none /run/shm tmpfs rw,nosuid,nodev,relatime 0 0
 				mounts, err := searcher.SearchMounts()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(mounts).To(Equal([]Mount{
-					Mount{PartitionPath: "none", MountPoint: "/run/shm"},
-					Mount{PartitionPath: "/dev/sda1", MountPoint: "/boot"},
+					Mount{PartitionPath: "none", MountPoint: "/run/shm", Options: []string{"rw", "nosuid", "nodev", "relatime"}},
+					Mount{PartitionPath: "/dev/sda1", MountPoint: "/boot", Options: []string{"rw", "relatime", "errors=continue"}},
 				}))
 			})
 		})
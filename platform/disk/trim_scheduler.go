@@ -0,0 +1,130 @@
+package disk
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
+)
+
+type trimScheduler struct {
+	trimmer        Trimmer
+	mountsSearcher MountsSearcher
+	mountPoints    []string
+	timeService    clock.Clock
+
+	lastResults     map[string]TrimResult
+	lastResultsLock sync.RWMutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewTrimScheduler returns scheduler that trims given mount points
+// only while something is mounted on them
+func NewTrimScheduler(
+	trimmer Trimmer,
+	mountsSearcher MountsSearcher,
+	mountPoints []string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) TrimScheduler {
+	return &trimScheduler{
+		trimmer:        trimmer,
+		mountsSearcher: mountsSearcher,
+		mountPoints:    mountPoints,
+		timeService:    timeService,
+		lastResults:    map[string]TrimResult{},
+		logger:         logger,
+		logTag:         "trimScheduler",
+	}
+}
+
+func (s *trimScheduler) Start(interval time.Duration) {
+	ticker := s.timeService.NewTicker(interval)
+
+	go func() {
+		for range ticker.C() {
+			s.TrimAll()
+		}
+	}()
+}
+
+func (s *trimScheduler) TrimAll() []TrimResult {
+	var results []TrimResult
+
+	mounts, err := s.mountsSearcher.SearchMounts()
+	if err != nil {
+		s.logger.Error(s.logTag, "Failed to search mounts: %s", err)
+		return results
+	}
+
+	for _, mountPoint := range s.mountPoints {
+		if !s.isTrimmable(mounts, mountPoint) {
+			continue
+		}
+
+		result := TrimResult{MountPoint: mountPoint}
+
+		result.TrimmedBytes, err = s.trimmer.Trim(mountPoint)
+		result.TrimmedAt = s.timeService.Now()
+
+		if err != nil {
+			s.logger.Error(s.logTag, "Failed to trim `%s': %s", mountPoint, err)
+			result.Error = err.Error()
+		} else {
+			s.logger.Info(s.logTag, "Trimmed %d bytes on `%s'", result.TrimmedBytes, mountPoint)
+		}
+
+		s.lastResultsLock.Lock()
+		s.lastResults[mountPoint] = result
+		s.lastResultsLock.Unlock()
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// isTrimmable skips mount points that are not mounted
+// or are mounted read-only, e.g. during persistent disk migration
+func (s *trimScheduler) isTrimmable(mounts []Mount, mountPoint string) bool {
+	for _, mount := range mounts {
+		if mount.MountPoint != mountPoint {
+			continue
+		}
+
+		for _, option := range mount.Options {
+			if option == "ro" {
+				s.logger.Debug(s.logTag, "Skipping read-only mount point `%s'", mountPoint)
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (s *trimScheduler) LastResults() []TrimResult {
+	s.lastResultsLock.RLock()
+	defer s.lastResultsLock.RUnlock()
+
+	var results []TrimResult
+	for _, result := range s.lastResults {
+		results = append(results, result)
+	}
+
+	sort.Sort(trimResultsByMountPoint(results))
+
+	return results
+}
+
+type trimResultsByMountPoint []TrimResult
+
+func (r trimResultsByMountPoint) Len() int           { return len(r) }
+func (r trimResultsByMountPoint) Less(i, j int) bool { return r[i].MountPoint < r[j].MountPoint }
+func (r trimResultsByMountPoint) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
@@ -0,0 +1,103 @@
+package disk_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("trimScheduler", func() {
+	var (
+		trimmer        *fakedisk.FakeTrimmer
+		mountsSearcher *fakedisk.FakeMountsSearcher
+		timeService    *fakeclock.FakeClock
+		now            time.Time
+		scheduler      TrimScheduler
+	)
+
+	BeforeEach(func() {
+		trimmer = &fakedisk.FakeTrimmer{
+			TrimTrimmedBytes: map[string]uint64{"/fake-data": 1024, "/fake-store": 2048},
+		}
+		mountsSearcher = &fakedisk.FakeMountsSearcher{
+			SearchMountsMounts: []Mount{
+				{PartitionPath: "/dev/sdb2", MountPoint: "/fake-data", Options: []string{"rw"}},
+				{PartitionPath: "/dev/sdc1", MountPoint: "/fake-store", Options: []string{"rw", "noatime"}},
+			},
+		}
+		now = time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+		timeService = fakeclock.NewFakeClock(now)
+		scheduler = NewTrimScheduler(trimmer, mountsSearcher, []string{"/fake-store", "/fake-data"}, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	Describe("TrimAll", func() {
+		It("trims mounted mount points", func() {
+			results := scheduler.TrimAll()
+			Expect(results).To(Equal([]TrimResult{
+				{MountPoint: "/fake-store", TrimmedBytes: 2048, TrimmedAt: now},
+				{MountPoint: "/fake-data", TrimmedBytes: 1024, TrimmedAt: now},
+			}))
+		})
+
+		It("skips mount points that are not mounted or mounted read-only", func() {
+			mountsSearcher.SearchMountsMounts = []Mount{
+				{PartitionPath: "/dev/sdc1", MountPoint: "/fake-store", Options: []string{"ro"}},
+			}
+
+			results := scheduler.TrimAll()
+			Expect(results).To(BeEmpty())
+			Expect(trimmer.TrimMountPoints).To(BeEmpty())
+		})
+
+		It("records failures and continues with other mount points", func() {
+			trimmer.TrimErrs = map[string]error{"/fake-store": errors.New("fake-trim-err")}
+
+			results := scheduler.TrimAll()
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Error).To(Equal("fake-trim-err"))
+			Expect(results[1].Error).To(BeEmpty())
+		})
+
+		It("does not trim anything when mounts cannot be searched", func() {
+			mountsSearcher.SearchMountsErr = errors.New("fake-search-err")
+
+			Expect(scheduler.TrimAll()).To(BeEmpty())
+			Expect(trimmer.TrimMountPoints).To(BeEmpty())
+		})
+	})
+
+	Describe("LastResults", func() {
+		It("returns most recent results sorted by mount point", func() {
+			Expect(scheduler.LastResults()).To(BeEmpty())
+
+			scheduler.TrimAll()
+
+			trimmer.TrimTrimmedBytes["/fake-store"] = 0
+			timeService.Increment(time.Hour)
+			scheduler.TrimAll()
+
+			Expect(scheduler.LastResults()).To(Equal([]TrimResult{
+				{MountPoint: "/fake-data", TrimmedBytes: 1024, TrimmedAt: now.Add(time.Hour)},
+				{MountPoint: "/fake-store", TrimmedBytes: 0, TrimmedAt: now.Add(time.Hour)},
+			}))
+		})
+	})
+
+	Describe("Start", func() {
+		It("trims periodically", func() {
+			scheduler.Start(time.Hour)
+
+			Consistently(scheduler.LastResults).Should(BeEmpty())
+
+			timeService.Increment(time.Hour)
+			Eventually(scheduler.LastResults).Should(HaveLen(2))
+		})
+	})
+})
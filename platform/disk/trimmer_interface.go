@@ -0,0 +1,27 @@
+package disk
+
+import (
+	"time"
+)
+
+type TrimResult struct {
+	MountPoint   string    `json:"mount_point"`
+	TrimmedBytes uint64    `json:"trimmed_bytes"`
+	TrimmedAt    time.Time `json:"trimmed_at"`
+	Error        string    `json:"error,omitempty"`
+}
+
+type Trimmer interface {
+	// Trim discards unused blocks on a mounted file system
+	Trim(mountPoint string) (trimmedBytes uint64, err error)
+}
+
+type TrimScheduler interface {
+	// Start periodically trims managed mount points in the background
+	Start(interval time.Duration)
+
+	TrimAll() []TrimResult
+
+	// LastResults returns most recent result for each trimmed mount point
+	LastResults() []TrimResult
+}
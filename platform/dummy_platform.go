@@ -9,6 +9,8 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -66,7 +68,7 @@ func NewDummyPlatform(
 		copier:             boshcmd.NewGenericCpCopier(fs, logger),
 		dirProvider:        dirProvider,
 		devicePathResolver: devicePathResolver,
-		vitalsService:      boshvitals.NewService(collector, dirProvider),
+		vitalsService:      boshvitals.NewService(collector, dirProvider, nil, nil),
 		certManager:        boshcert.NewDummyCertManager(fs, cmdRunner, 0, logger),
 		logger:             logger,
 	}
@@ -148,6 +150,14 @@ func (p dummyPlatform) SetupNetworking(networks boshsettings.Networks) (err erro
 	return
 }
 
+func (p dummyPlatform) SetupNetworkingWithRollback(networks boshsettings.Networks, reachableTargets []string) (err error) {
+	return
+}
+
+func (p dummyPlatform) PlanNetworking(networks boshsettings.Networks) (plan boshnet.NetworkPlan, err error) {
+	return
+}
+
 func (p dummyPlatform) GetConfiguredNetworkInterfaces() (interfaces []string, err error) {
 	return
 }
@@ -164,7 +174,7 @@ func (p dummyPlatform) SetTimeWithNtpServers(servers []string) (err error) {
 	return
 }
 
-func (p dummyPlatform) SetupEphemeralDiskWithPath(devicePath string) (err error) {
+func (p dummyPlatform) SetupEphemeralDiskWithPath(devicePath string, mountOptions []string) (err error) {
 	return
 }
 
@@ -263,6 +273,14 @@ func (p dummyPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetting
 	return p.fs.WriteFile(p.mountsPath(), mountsJSON)
 }
 
+func (p dummyPlatform) GetPersistentDiskFileSystemCheck(diskSettings boshsettings.DiskSettings) (boshdisk.FileSystemCheckResult, bool, error) {
+	return boshdisk.FileSystemCheckResult{}, false, nil
+}
+
+func (p dummyPlatform) GetDiskInfo(diskType boshdisk.DiskType, diskSettings boshsettings.DiskSettings) (boshdisk.DiskInfo, error) {
+	return boshdisk.DiskInfo{Type: diskType, CID: diskSettings.ID}, nil
+}
+
 func (p dummyPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	mounts, err := p.existingMounts()
 	if err != nil {
@@ -332,6 +350,14 @@ func (p dummyPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string
 	return p.fs.WriteFile(diskMigrationsPath, diskMigrationsJSON)
 }
 
+func (p dummyPlatform) CancelPersistentDiskMigration() error {
+	return nil
+}
+
+func (p dummyPlatform) GetPersistentDiskMigrationProgress() (boshdisk.MigrationProgress, bool) {
+	return boshdisk.MigrationProgress{}, false
+}
+
 func (p dummyPlatform) IsMountPoint(mountPointPath string) (partitionPath string, result bool, err error) {
 	mounts, err := p.existingMounts()
 	if err != nil {
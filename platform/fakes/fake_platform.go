@@ -7,6 +7,8 @@ import (
 	fakedpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver/fakes"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	fakevitals "github.com/cloudfoundry/bosh-agent/platform/vitals/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -53,8 +55,9 @@ type FakePlatform struct {
 
 	SetTimeWithNtpServersServers []string
 
-	SetupEphemeralDiskWithPathDevicePath string
-	SetupEphemeralDiskWithPathErr        error
+	SetupEphemeralDiskWithPathDevicePath   string
+	SetupEphemeralDiskWithPathMountOptions []string
+	SetupEphemeralDiskWithPathErr          error
 
 	SetupRawEphemeralDisksDevices   []boshsettings.DiskSettings
 	SetupRawEphemeralDisksErr       error
@@ -82,11 +85,27 @@ type FakePlatform struct {
 	SetupNetworkingNetworks boshsettings.Networks
 	SetupNetworkingErr      error
 
+	SetupNetworkingWithRollbackNetworks boshsettings.Networks
+	SetupNetworkingWithRollbackTargets  []string
+	SetupNetworkingWithRollbackErr      error
+
+	PlanNetworkingNetworks boshsettings.Networks
+	PlanNetworkingPlan     boshnet.NetworkPlan
+	PlanNetworkingErr      error
+
 	MountPersistentDiskCalled     bool
 	MountPersistentDiskSettings   boshsettings.DiskSettings
 	MountPersistentDiskMountPoint string
 	MountPersistentDiskErr        error
 
+	GetPersistentDiskFileSystemCheckResult boshdisk.FileSystemCheckResult
+	GetPersistentDiskFileSystemCheckFound  bool
+	GetPersistentDiskFileSystemCheckErr    error
+
+	GetDiskInfoTypes    []boshdisk.DiskType
+	GetDiskInfoSettings []boshsettings.DiskSettings
+	GetDiskInfoErrs     map[string]error
+
 	UnmountPersistentDiskDidUnmount bool
 	UnmountPersistentDiskSettings   boshsettings.DiskSettings
 
@@ -109,6 +128,13 @@ type FakePlatform struct {
 
 	MigratePersistentDiskFromMountPoint string
 	MigratePersistentDiskToMountPoint   string
+	MigratePersistentDiskErr            error
+
+	CancelPersistentDiskMigrationCalled bool
+	CancelPersistentDiskMigrationErr    error
+
+	GetPersistentDiskMigrationProgressProgress boshdisk.MigrationProgress
+	GetPersistentDiskMigrationProgressFound    bool
 
 	IsPersistentDiskMountableResult bool
 	IsPersistentDiskMountableErr    error
@@ -276,6 +302,17 @@ func (p *FakePlatform) SetupNetworking(networks boshsettings.Networks) error {
 	return p.SetupNetworkingErr
 }
 
+func (p *FakePlatform) SetupNetworkingWithRollback(networks boshsettings.Networks, reachableTargets []string) error {
+	p.SetupNetworkingWithRollbackNetworks = networks
+	p.SetupNetworkingWithRollbackTargets = reachableTargets
+	return p.SetupNetworkingWithRollbackErr
+}
+
+func (p *FakePlatform) PlanNetworking(networks boshsettings.Networks) (boshnet.NetworkPlan, error) {
+	p.PlanNetworkingNetworks = networks
+	return p.PlanNetworkingPlan, p.PlanNetworkingErr
+}
+
 func (p *FakePlatform) GetConfiguredNetworkInterfaces() ([]string, error) {
 	return p.GetConfiguredNetworkInterfacesInterfaces, p.GetConfiguredNetworkInterfacesErr
 }
@@ -293,8 +330,9 @@ func (p *FakePlatform) SetTimeWithNtpServers(servers []string) (err error) {
 	return
 }
 
-func (p *FakePlatform) SetupEphemeralDiskWithPath(devicePath string) (err error) {
+func (p *FakePlatform) SetupEphemeralDiskWithPath(devicePath string, mountOptions []string) (err error) {
 	p.SetupEphemeralDiskWithPathDevicePath = devicePath
+	p.SetupEphemeralDiskWithPathMountOptions = mountOptions
 	return p.SetupEphemeralDiskWithPathErr
 }
 
@@ -341,6 +379,17 @@ func (p *FakePlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetting
 	return p.MountPersistentDiskErr
 }
 
+func (p *FakePlatform) GetPersistentDiskFileSystemCheck(diskSettings boshsettings.DiskSettings) (boshdisk.FileSystemCheckResult, bool, error) {
+	return p.GetPersistentDiskFileSystemCheckResult, p.GetPersistentDiskFileSystemCheckFound, p.GetPersistentDiskFileSystemCheckErr
+}
+
+func (p *FakePlatform) GetDiskInfo(diskType boshdisk.DiskType, diskSettings boshsettings.DiskSettings) (boshdisk.DiskInfo, error) {
+	p.GetDiskInfoTypes = append(p.GetDiskInfoTypes, diskType)
+	p.GetDiskInfoSettings = append(p.GetDiskInfoSettings, diskSettings)
+	info := boshdisk.DiskInfo{Type: diskType, CID: diskSettings.ID, DevicePath: diskSettings.Path}
+	return info, p.GetDiskInfoErrs[diskSettings.Path]
+}
+
 func (p *FakePlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	p.UnmountPersistentDiskSettings = diskSettings
 	didUnmount = p.UnmountPersistentDiskDidUnmount
@@ -387,7 +436,16 @@ func (p *FakePlatform) SetGetFilesContentsFromDisk(fileName string, contents []b
 func (p *FakePlatform) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
 	p.MigratePersistentDiskFromMountPoint = fromMountPoint
 	p.MigratePersistentDiskToMountPoint = toMountPoint
-	return
+	return p.MigratePersistentDiskErr
+}
+
+func (p *FakePlatform) CancelPersistentDiskMigration() error {
+	p.CancelPersistentDiskMigrationCalled = true
+	return p.CancelPersistentDiskMigrationErr
+}
+
+func (p *FakePlatform) GetPersistentDiskMigrationProgress() (boshdisk.MigrationProgress, bool) {
+	return p.GetPersistentDiskMigrationProgressProgress, p.GetPersistentDiskMigrationProgressFound
 }
 
 func (p *FakePlatform) IsMountPoint(path string) (string, bool, error) {
@@ -2,6 +2,7 @@ package platform
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -11,7 +12,10 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/pivotal-golang/clock"
+
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
+	boshdns "github.com/cloudfoundry/bosh-agent/localdns"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
 	boshdevutil "github.com/cloudfoundry/bosh-agent/platform/deviceutil"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
@@ -80,6 +84,27 @@ type LinuxOptions struct {
 	// Strategy for resolving ephemeral & persistent disk partitioners;
 	// possible values: parted, "" (default is sfdisk if disk < 2TB, parted otherwise)
 	PartitionerType string
+
+	// File system check to run on persistent disk right before mounting it;
+	// possible values: off, check, repair, "" (default is off)
+	PersistentDiskFSCheckMode string
+
+	// Interval in seconds between discarding unused blocks with fstrim
+	// on mounted ephemeral and persistent disks; 0 (default) disables trimming
+	DiskTrimIntervalInSeconds int
+
+	// Interval in seconds between checking health of DNS servers in /etc/resolv.conf;
+	// unhealthy DNS servers are moved to the end, 0 (default) disables checking
+	DNSHealthCheckIntervalInSeconds int
+
+	// Backend used to configure network interfaces;
+	// possible values: networkd, "" (default is ifupdown on ubuntu and initscripts on centos)
+	NetworkManagerType string
+
+	// Loopback address of embedded DNS server which serves synced DNS records;
+	// when set, DNS records are no longer written to /etc/hosts
+	// and the address is used as the first DNS server
+	LocalDNSAddress string
 }
 
 type linux struct {
@@ -96,11 +121,13 @@ type linux struct {
 	certManager            boshcert.Manager
 	monitRetryStrategy     boshretry.RetryStrategy
 	devicePathResolver     boshdpresolv.DevicePathResolver
+	lookupPathResolver     boshdpresolv.DevicePathResolver
 	options                LinuxOptions
 	state                  *BootstrapState
 	logger                 boshlog.Logger
 	defaultNetworkResolver boshsettings.DefaultNetworkResolver
 	uuidGenerator          boshuuid.Generator
+	diskMigrator           boshdisk.PersistentDiskMigrator
 }
 
 func NewLinuxPlatform(
@@ -117,6 +144,7 @@ func NewLinuxPlatform(
 	certManager boshcert.Manager,
 	monitRetryStrategy boshretry.RetryStrategy,
 	devicePathResolver boshdpresolv.DevicePathResolver,
+	lookupPathResolver boshdpresolv.DevicePathResolver,
 	state *BootstrapState,
 	options LinuxOptions,
 	logger boshlog.Logger,
@@ -137,11 +165,20 @@ func NewLinuxPlatform(
 		certManager:            certManager,
 		monitRetryStrategy:     monitRetryStrategy,
 		devicePathResolver:     devicePathResolver,
+		lookupPathResolver:     lookupPathResolver,
 		state:                  state,
 		options:                options,
 		logger:                 logger,
 		defaultNetworkResolver: defaultNetworkResolver,
 		uuidGenerator:          uuidGenerator,
+		diskMigrator: boshdisk.NewPersistentDiskMigrator(
+			cmdRunner,
+			fs,
+			diskManager.GetMounter(),
+			diskManager.GetMountsSearcher(),
+			path.Join(dirProvider.BoshDir(), "persistent_disk_migration.json"),
+			logger,
+		),
 	}
 }
 
@@ -206,7 +243,47 @@ func (p linux) GetDevicePathResolver() (devicePathResolver boshdpresolv.DevicePa
 }
 
 func (p linux) SetupNetworking(networks boshsettings.Networks) (err error) {
-	return p.netManager.SetupNetworking(networks, nil)
+	return p.netManager.SetupNetworking(p.withLocalDNS(networks), nil)
+}
+
+func (p linux) PlanNetworking(networks boshsettings.Networks) (boshnet.NetworkPlan, error) {
+	return p.netManager.PlanNetworking(p.withLocalDNS(networks))
+}
+
+func (p linux) SetupNetworkingWithRollback(networks boshsettings.Networks, reachableTargets []string) error {
+	guard := boshnet.NewNetworkGuard(
+		p.netManager,
+		p.fs,
+		boshnet.NewReachabilityChecker(p.cmdRunner),
+		filepath.Join(p.dirProvider.BoshDir(), "applied_networks.json"),
+		NetworkVerificationTimeout,
+		clock.NewClock(),
+		p.logger,
+	)
+
+	return guard.SetupNetworking(p.withLocalDNS(networks), reachableTargets)
+}
+
+// withLocalDNS puts local DNS server in front of DNS servers of each network
+// so that it is the first nameserver regardless of which network provides DNS.
+// DHCP networks get it even without DNS servers since net managers prepend
+// their DNS servers to ones received via DHCP (dhclient prepend, networkd DNS=)
+func (p linux) withLocalDNS(networks boshsettings.Networks) boshsettings.Networks {
+	if p.options.LocalDNSAddress == "" {
+		return networks
+	}
+
+	withLocalDNS := boshsettings.Networks{}
+
+	for name, network := range networks {
+		hasDNS := len(network.DNS) > 0 || network.IsDHCP()
+		if hasDNS && (len(network.DNS) == 0 || network.DNS[0] != p.options.LocalDNSAddress) {
+			network.DNS = append([]string{p.options.LocalDNSAddress}, network.DNS...)
+		}
+		withLocalDNS[name] = network
+	}
+
+	return withLocalDNS
 }
 
 func (p linux) GetConfiguredNetworkInterfaces() ([]string, error) {
@@ -355,7 +432,7 @@ func (p linux) SetupRootDisk(ephemeralDiskPath string) error {
 	_, _, _, err = p.cmdRunner.RunCommand(
 		"resize2fs",
 		"-f",
-		fmt.Sprintf("%s%d", rootDevicePath, rootDeviceNumber),
+		boshdisk.PartitionPath(rootDevicePath, rootDeviceNumber),
 	)
 
 	if err != nil {
@@ -420,6 +497,10 @@ ff02::3 ip6-allhosts
 `
 
 func (p linux) SaveDNSRecords(dnsRecords boshsettings.DNSRecords, hostname string) error {
+	if p.options.LocalDNSAddress != "" {
+		return p.saveLocalDNSRecords(dnsRecords)
+	}
+
 	dnsRecordsContents, err := p.generateDefaultEtcHosts(hostname)
 	if err != nil {
 		return bosherr.WrapError(err, "Generating default /etc/hosts")
@@ -448,6 +529,40 @@ func (p linux) SaveDNSRecords(dnsRecords boshsettings.DNSRecords, hostname strin
 	return nil
 }
 
+// saveLocalDNSRecords atomically replaces records served by local DNS server
+func (p linux) saveLocalDNSRecords(dnsRecords boshsettings.DNSRecords) error {
+	recordsPath := boshdns.RecordsPath(p.dirProvider)
+
+	recordsJSON, err := json.Marshal(dnsRecords)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling DNS records")
+	}
+
+	err = p.fs.MkdirAll(filepath.Dir(recordsPath), os.FileMode(0755))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating %s", filepath.Dir(recordsPath))
+	}
+
+	uuid, err := p.uuidGenerator.Generate()
+	if err != nil {
+		return bosherr.WrapError(err, "Generating UUID")
+	}
+
+	recordsUUIDPath := fmt.Sprintf("%s-%s", recordsPath, uuid)
+
+	err = p.fs.WriteFile(recordsUUIDPath, recordsJSON)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing to %s", recordsUUIDPath)
+	}
+
+	err = p.fs.Rename(recordsUUIDPath, recordsPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Renaming %s to %s", recordsUUIDPath, recordsPath)
+	}
+
+	return nil
+}
+
 func (p linux) SetupHostname(hostname string) error {
 	if !p.state.Linux.HostsConfigured {
 		_, _, _, err := p.cmdRunner.RunCommand("hostname", hostname)
@@ -534,11 +649,16 @@ func (p linux) SetTimeWithNtpServers(servers []string) (err error) {
 	return
 }
 
-func (p linux) SetupEphemeralDiskWithPath(realPath string) error {
+func (p linux) SetupEphemeralDiskWithPath(realPath string, mountOptions []string) error {
 	if p.options.SkipDiskSetup {
 		return nil
 	}
 
+	err := boshdisk.ValidateMountOptions(mountOptions)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating ephemeral disk mount options")
+	}
+
 	p.logger.Info(logTag, "Setting up ephemeral disk...")
 	mountPoint := p.dirProvider.DataDir()
 
@@ -604,7 +724,7 @@ func (p linux) SetupEphemeralDiskWithPath(realPath string) error {
 	}
 
 	p.logger.Info(logTag, "Mounting `%s' at `%s'", dataPartitionPath, mountPoint)
-	err = p.diskManager.GetMounter().Mount(dataPartitionPath, mountPoint)
+	err = p.diskManager.GetMounter().Mount(dataPartitionPath, mountPoint, boshdisk.MountOptionsArgs(mountOptions)...)
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting data partition")
 	}
@@ -990,6 +1110,17 @@ func (p linux) changeTmpDirPermissions(path string) error {
 func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountPoint string) error {
 	p.logger.Debug(logTag, "Mounting persistent disk %+v at %s", diskSetting, mountPoint)
 
+	// Bind mounted persistent disks are directories on a file system not managed by agent
+	if p.options.BindMountPersistentDisk && len(diskSetting.Quotas) > 0 {
+		p.logger.Warn(logTag, "Skipping directory quotas of bind mounted persistent disk %s", diskSetting.ID)
+		diskSetting.Quotas = nil
+	}
+
+	mountOptions, err := p.persistentDiskMountOptions(diskSetting)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating mount options")
+	}
+
 	realPath, _, err := p.devicePathResolver.GetRealDevicePath(diskSetting)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting real device path")
@@ -1001,10 +1132,7 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	}
 	p.logger.Info(logTag, "realPath = %s, devicePath = %s, isMountPoint = %s", realPath, devicePath, isMountPoint)
 
-	partitionPath := realPath + "1"
-	if strings.Contains(realPath, "/dev/mapper/") {
-		partitionPath = realPath + "-part1"
-	}
+	partitionPath := boshdisk.PartitionPath(realPath, 1)
 
 	if isMountPoint {
 		if partitionPath == devicePath {
@@ -1058,12 +1186,24 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 		realPath = partitionPath
 	}
 
-	err = p.diskManager.GetMounter().Mount(realPath, mountPoint)
+	err = p.checkPersistentDiskFileSystem(diskSetting, realPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking file system")
+	}
+
+	err = p.diskManager.GetMounter().Mount(realPath, mountPoint, boshdisk.MountOptionsArgs(mountOptions)...)
 
 	if err != nil {
 		return bosherr.WrapError(err, "Mounting partition")
 	}
 
+	if len(diskSetting.Quotas) > 0 {
+		err = p.diskManager.GetDirectoryQuotaManager().SetQuotas(realPath, mountPoint, diskSetting.Quotas)
+		if err != nil {
+			return bosherr.WrapError(err, "Setting directory quotas")
+		}
+	}
+
 	managedSettingsPath := filepath.Join(p.dirProvider.BoshDir(), "managed_disk_settings.json")
 
 	err = p.fs.WriteFileString(managedSettingsPath, diskSetting.ID)
@@ -1075,6 +1215,117 @@ func (p linux) MountPersistentDisk(diskSetting boshsettings.DiskSettings, mountP
 	return nil
 }
 
+func (p linux) persistentDiskMountOptions(diskSetting boshsettings.DiskSettings) ([]string, error) {
+	mountOptions := diskSetting.MountOptions
+
+	err := boshdisk.ValidateMountOptions(mountOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = boshdisk.ValidateDirectoryQuotas(diskSetting.Quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	// Directory quotas are enforced only when project quota accounting is enabled
+	if len(diskSetting.Quotas) > 0 && !boshdisk.HasProjectQuotaMountOption(mountOptions) {
+		mountOptions = append(append([]string{}, mountOptions...), boshdisk.ProjectQuotaMountOption)
+	}
+
+	return mountOptions, nil
+}
+
+func (p linux) checkPersistentDiskFileSystem(diskSetting boshsettings.DiskSettings, partitionPath string) error {
+	mode := boshdisk.FileSystemCheckMode(p.options.PersistentDiskFSCheckMode)
+	if mode == "" || mode == boshdisk.FileSystemCheckModeOff {
+		return nil
+	}
+
+	// Partition is already mounted e.g. when mount is retried during disk migration
+	mounted, err := p.diskManager.GetMounter().IsMounted(partitionPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Checking whether partition is mounted")
+	}
+
+	if mounted {
+		p.logger.Info(logTag, "Skipping file system check of `%s' which is already mounted", partitionPath)
+		return nil
+	}
+
+	result, checkErr := p.diskManager.GetFileSystemChecker().Check(partitionPath, mode)
+
+	p.logger.Info(logTag, "File system check of `%s' finished: clean = %t, repaired = %t, skipped = %t",
+		partitionPath, result.Clean, result.Repaired, result.Skipped)
+
+	if result.NeedsAttention() {
+		p.logger.Warn(logTag, "File system check of `%s' found errors:\n%s", partitionPath, result.Output)
+	}
+
+	err = p.savePersistentDiskFileSystemCheck(diskSetting.ID, result)
+	if err != nil {
+		return err
+	}
+
+	return checkErr
+}
+
+func (p linux) GetPersistentDiskFileSystemCheck(diskSettings boshsettings.DiskSettings) (boshdisk.FileSystemCheckResult, bool, error) {
+	results, err := p.loadPersistentDiskFileSystemChecks()
+	if err != nil {
+		return boshdisk.FileSystemCheckResult{}, false, err
+	}
+
+	result, found := results[diskSettings.ID]
+	return result, found, nil
+}
+
+func (p linux) savePersistentDiskFileSystemCheck(diskID string, result boshdisk.FileSystemCheckResult) error {
+	results, err := p.loadPersistentDiskFileSystemChecks()
+	if err != nil {
+		return err
+	}
+
+	results[diskID] = result
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling file system check results")
+	}
+
+	err = p.fs.WriteFile(p.persistentDiskFileSystemChecksPath(), resultsJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing file system check results")
+	}
+
+	return nil
+}
+
+func (p linux) loadPersistentDiskFileSystemChecks() (map[string]boshdisk.FileSystemCheckResult, error) {
+	results := map[string]boshdisk.FileSystemCheckResult{}
+
+	resultsPath := p.persistentDiskFileSystemChecksPath()
+	if !p.fs.FileExists(resultsPath) {
+		return results, nil
+	}
+
+	resultsJSON, err := p.fs.ReadFile(resultsPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading file system check results")
+	}
+
+	err = json.Unmarshal(resultsJSON, &results)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling file system check results")
+	}
+
+	return results, nil
+}
+
+func (p linux) persistentDiskFileSystemChecksPath() string {
+	return filepath.Join(p.dirProvider.BoshDir(), "persistent_disk_fs_checks.json")
+}
+
 func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bool, error) {
 	p.logger.Debug(logTag, "Unmounting persistent disk %+v", diskSettings)
 
@@ -1086,15 +1337,7 @@ func (p linux) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (bo
 		return false, bosherr.WrapError(err, "Getting real device path")
 	}
 
-	if !p.options.UsePreformattedPersistentDisk {
-		if strings.Contains(realPath, "/dev/mapper/") {
-			realPath = realPath + "-part1"
-		} else {
-			realPath += "1"
-		}
-	}
-
-	return p.diskManager.GetMounter().Unmount(realPath)
+	return p.diskManager.GetMounter().Unmount(p.persistentDiskPartitionPath(realPath))
 }
 
 func (p linux) GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) string {
@@ -1128,32 +1371,15 @@ func (p linux) IsMountPoint(path string) (string, bool, error) {
 func (p linux) MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error) {
 	p.logger.Debug(logTag, "Migrating persistent disk %v to %v", fromMountPoint, toMountPoint)
 
-	err = p.diskManager.GetMounter().RemountAsReadonly(fromMountPoint)
-	if err != nil {
-		err = bosherr.WrapError(err, "Remounting persistent disk as readonly")
-		return
-	}
-
-	// Golang does not implement a file copy that would allow us to preserve dates...
-	// So we have to shell out to tar to perform the copy instead of delegating to the FileSystem
-	tarCopy := fmt.Sprintf("(tar -C %s -cf - .) | (tar -C %s -xpf -)", fromMountPoint, toMountPoint)
-	_, _, _, err = p.cmdRunner.RunCommand("sh", "-c", tarCopy)
-	if err != nil {
-		err = bosherr.WrapError(err, "Copying files from old disk to new disk")
-		return
-	}
+	return p.diskMigrator.Migrate(fromMountPoint, toMountPoint)
+}
 
-	_, err = p.diskManager.GetMounter().Unmount(fromMountPoint)
-	if err != nil {
-		err = bosherr.WrapError(err, "Unmounting old persistent disk")
-		return
-	}
+func (p linux) CancelPersistentDiskMigration() error {
+	return p.diskMigrator.Cancel()
+}
 
-	err = p.diskManager.GetMounter().Remount(toMountPoint, fromMountPoint)
-	if err != nil {
-		err = bosherr.WrapError(err, "Remounting new disk on original mountpoint")
-	}
-	return
+func (p linux) GetPersistentDiskMigrationProgress() (boshdisk.MigrationProgress, bool) {
+	return p.diskMigrator.Progress()
 }
 
 func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (bool, error) {
@@ -1167,15 +1393,123 @@ func (p linux) IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (
 		return false, bosherr.WrapError(err, "Getting real device path")
 	}
 
-	if !p.options.UsePreformattedPersistentDisk {
-		if strings.Contains(realPath, "/dev/mapper/") {
-			realPath = realPath + "-part1"
-		} else {
-			realPath += "1"
+	return p.diskManager.GetMounter().IsMounted(p.persistentDiskPartitionPath(realPath))
+}
+
+func (p linux) persistentDiskPartitionPath(realPath string) string {
+	if p.options.UsePreformattedPersistentDisk {
+		return realPath
+	}
+
+	return boshdisk.PartitionPath(realPath, 1)
+}
+
+func (p linux) GetDiskInfo(diskType boshdisk.DiskType, diskSettings boshsettings.DiskSettings) (boshdisk.DiskInfo, error) {
+	info := boshdisk.DiskInfo{Type: diskType, CID: diskSettings.ID}
+	if info.CID == "" {
+		info.CID = diskSettings.VolumeID
+	}
+
+	mounts, err := p.diskManager.GetMountsSearcher().SearchMounts()
+	if err != nil {
+		return info, bosherr.WrapError(err, "Searching mounts")
+	}
+
+	var mount boshdisk.Mount
+	var mounted bool
+
+	switch diskType {
+	case boshdisk.DiskTypeSystem:
+		mount, mounted = findMount(mounts, func(m boshdisk.Mount) bool { return m.MountPoint == "/" })
+		info.PartitionPath = mount.PartitionPath
+
+		// Root device name is only known for the most common device naming schemes
+		rootDevicePath, _, err := p.findRootDevicePathAndNumber()
+		if err != nil {
+			p.logger.Debug(logTag, "Failed to find root device path: %s", err)
 		}
+		info.DevicePath = rootDevicePath
+
+	default:
+		// Disks are only inspected so there is no point waiting for them to show up
+		realPath, _, err := p.lookupPathResolver.GetRealDevicePath(diskSettings)
+		if err != nil {
+			return info, bosherr.WrapError(err, "Getting real device path")
+		}
+		info.DevicePath = realPath
+
+		switch diskType {
+		case boshdisk.DiskTypeEphemeral:
+			mount, mounted = findMount(mounts, func(m boshdisk.Mount) bool { return m.MountPoint == p.dirProvider.DataDir() })
+			info.PartitionPath = boshdisk.PartitionPath(realPath, 2)
+			if mounted {
+				info.PartitionPath = mount.PartitionPath
+			}
+
+		case boshdisk.DiskTypePersistent:
+			info.PartitionPath = p.persistentDiskPartitionPath(realPath)
+			mount, mounted = findMount(mounts, func(m boshdisk.Mount) bool { return m.PartitionPath == info.PartitionPath })
+		}
+	}
+
+	inspector := p.diskManager.GetBlockDeviceInspector()
+
+	if info.DevicePath != "" {
+		deviceInfo, err := inspector.Inspect(info.DevicePath)
+		if err != nil {
+			return info, bosherr.WrapError(err, "Inspecting device")
+		}
+
+		info.PartitionTableType = deviceInfo.PartitionTableType
+		info.FileSystemType = deviceInfo.FileSystemType
+		info.UUID = deviceInfo.UUID
 	}
 
-	return p.diskManager.GetMounter().IsMounted(realPath)
+	if info.PartitionPath != "" && info.PartitionPath != info.DevicePath {
+		partitionInfo, err := inspector.Inspect(info.PartitionPath)
+		if err != nil {
+			return info, bosherr.WrapError(err, "Inspecting partition")
+		}
+
+		info.FileSystemType = partitionInfo.FileSystemType
+		info.UUID = partitionInfo.UUID
+	}
+
+	if !mounted {
+		return info, nil
+	}
+
+	info.MountPoint = mount.MountPoint
+	info.MountOptions = mount.Options
+
+	diskStats, err := p.collector.GetDiskStats(mount.MountPoint)
+	if err != nil {
+		return info, bosherr.WrapError(err, "Getting disk usage")
+	}
+
+	info.Usage = &boshdisk.DiskInfoUsage{
+		SizeInKB:    diskStats.DiskUsage.Total,
+		UsedInKB:    diskStats.DiskUsage.Used,
+		InodesTotal: diskStats.InodeUsage.Total,
+		InodesUsed:  diskStats.InodeUsage.Used,
+	}
+
+	if diskType == boshdisk.DiskTypePersistent {
+		_, migrating := findMount(mounts, func(m boshdisk.Mount) bool { return m.MountPoint == p.dirProvider.StoreMigrationDir() })
+		info.Migrating = migrating && (mount.MountPoint == p.dirProvider.StoreDir() || mount.MountPoint == p.dirProvider.StoreMigrationDir())
+	}
+
+	return info, nil
+}
+
+func findMount(mounts []boshdisk.Mount, matches func(boshdisk.Mount) bool) (boshdisk.Mount, bool) {
+	for _, mount := range mounts {
+		if matches(mount) {
+			return mount, true
+		}
+	}
+
+	return boshdisk.Mount{}, false
 }
 
 func (p linux) StartMonit() error {
@@ -1279,18 +1613,23 @@ func (p linux) findRootDevicePathAndNumber() (string, int, error) {
 			rootPartition := strings.Trim(stdout, "\n")
 			p.logger.Debug(logTag, "Symlink is: `%s'", rootPartition)
 
-			validRootPartition := regexp.MustCompile(`^/dev/[a-z]+\d$`)
-			if !validRootPartition.MatchString(rootPartition) {
+			// Partitions of nvme and mmc devices are separated with p, e.g. /dev/nvme0n1p1
+			validRootPartition := regexp.MustCompile(`^(/dev/[a-z]+)(\d)$|^(/dev/(?:nvme\d+n\d+|mmcblk\d+))p(\d+)$`)
+			matches := validRootPartition.FindStringSubmatch(rootPartition)
+			if matches == nil {
 				return "", 0, bosherr.Error("Root partition has an invalid name" + rootPartition)
 			}
 
-			devNum, err := strconv.Atoi(rootPartition[len(rootPartition)-1:])
+			devPath, devNumString := matches[1], matches[2]
+			if devPath == "" {
+				devPath, devNumString = matches[3], matches[4]
+			}
+
+			devNum, err := strconv.Atoi(devNumString)
 			if err != nil {
 				return "", 0, bosherr.WrapError(err, "Parsing device number failed")
 			}
 
-			devPath := rootPartition[:len(rootPartition)-1]
-
 			return devPath, devNum, nil
 		}
 	}
@@ -1337,8 +1676,8 @@ func (p linux) createEphemeralPartitionsOnRootDevice() (string, string, error) {
 		return "", "", bosherr.WrapErrorf(err, "Partitioning root device `%s'", rootDevicePath)
 	}
 
-	swapPartitionPath := rootDevicePath + strconv.Itoa(rootDeviceNumber+1)
-	dataPartitionPath := rootDevicePath + strconv.Itoa(rootDeviceNumber+2)
+	swapPartitionPath := boshdisk.PartitionPath(rootDevicePath, rootDeviceNumber+1)
+	dataPartitionPath := boshdisk.PartitionPath(rootDevicePath, rootDeviceNumber+2)
 	return swapPartitionPath, dataPartitionPath, nil
 }
 
@@ -1367,8 +1706,8 @@ func (p linux) partitionEphemeralDisk(realPath string) (string, string, error) {
 		return "", "", bosherr.WrapErrorf(err, "Partitioning ephemeral disk `%s'", realPath)
 	}
 
-	swapPartitionPath := realPath + "1"
-	dataPartitionPath := realPath + "2"
+	swapPartitionPath := boshdisk.PartitionPath(realPath, 1)
+	dataPartitionPath := boshdisk.PartitionPath(realPath, 2)
 	return swapPartitionPath, dataPartitionPath, nil
 }
 
@@ -19,6 +19,7 @@ import (
 	fakedevutil "github.com/cloudfoundry/bosh-agent/platform/deviceutil/fakes"
 	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
 	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	fakeretry "github.com/cloudfoundry/bosh-utils/retrystrategy/fakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -72,7 +73,7 @@ func describeLinuxPlatform() {
 		cdutil = fakedevutil.NewFakeDeviceUtil()
 		compressor = boshcmd.NewTarballCompressor(cmdRunner, fs)
 		copier = boshcmd.NewGenericCpCopier(fs, logger)
-		vitalsService = boshvitals.NewService(collector, dirProvider)
+		vitalsService = boshvitals.NewService(collector, dirProvider, nil, nil)
 		netManager = &fakenet.FakeManager{}
 		certManager = new(fakecert.FakeManager)
 		monitRetryStrategy = fakeretry.NewFakeRetryStrategy()
@@ -112,6 +113,7 @@ func describeLinuxPlatform() {
 			certManager,
 			monitRetryStrategy,
 			devicePathResolver,
+			devicePathResolver,
 			state,
 			options,
 			logger,
@@ -256,7 +258,7 @@ bosh_foobar:...`
 			})
 
 			It("runs growpart and resize2fs for the right root device number", func() {
-				err := platform.SetupEphemeralDiskWithPath("/dev/sda")
+				err := platform.SetupEphemeralDiskWithPath("/dev/sda", nil)
 				Expect(err).NotTo(HaveOccurred())
 
 				mountsSearcher := diskManager.FakeMountsSearcher
@@ -278,6 +280,26 @@ bosh_foobar:...`
 				Expect(cmdRunner.RunCommands[2]).To(Equal([]string{"resize2fs", "-f", "/dev/sda2"}))
 			})
 
+			It("runs growpart and resize2fs for nvme root device", func() {
+				mountsSearcher := diskManager.FakeMountsSearcher
+				mountsSearcher.SearchMountsMounts = []boshdisk.Mount{{
+					PartitionPath: "/dev/nvme0n1p1",
+					MountPoint:    "/",
+				}}
+
+				cmdRunner.AddCmdResult(
+					"readlink -f /dev/nvme0n1p1",
+					fakesys.FakeCmdResult{Error: nil, Stdout: "/dev/nvme0n1p1"},
+				)
+
+				err := platform.SetupRootDisk("/dev/nvme1n1")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(cmdRunner.RunCommands)).To(Equal(3))
+				Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"growpart", "/dev/nvme0n1", "1"}))
+				Expect(cmdRunner.RunCommands[2]).To(Equal([]string{"resize2fs", "-f", "/dev/nvme0n1p1"}))
+			})
+
 			It("returns error if it can't find the root device", func() {
 				cmdRunner.AddCmdResult(
 					"readlink -f /dev/sda1",
@@ -342,6 +364,7 @@ bosh_foobar:...`
 					certManager,
 					monitRetryStrategy,
 					devicePathResolver,
+					devicePathResolver,
 					state,
 					options,
 					logger,
@@ -609,7 +632,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 		Context("when ephemeral disk path is provided", func() {
 			act := func() error {
-				return platform.SetupEphemeralDiskWithPath("/dev/xvda")
+				return platform.SetupEphemeralDiskWithPath("/dev/xvda", nil)
 			}
 
 			itSetsUpEphemeralDisk(act)
@@ -684,6 +707,20 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 				Expect(mounter.SwapOnPartitionPaths[0]).To(Equal("/dev/xvda1"))
 			})
 
+			It("mounts data partition with provided mount options", func() {
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", []string{"noatime", "discard"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mounter.MountMountOptions).To(Equal([][]string{{"-o", "noatime,discard"}}))
+			})
+
+			It("returns error without touching the disk when mount options are not allowed", func() {
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", []string{"noatime", "remount"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Mount option 'remount' is not allowed"))
+				Expect(partitioner.PartitionCalled).To(BeFalse())
+				Expect(mounter.MountCalled).To(BeFalse())
+			})
+
 			It("creates swap the size of the memory and the rest for data when disk is bigger than twice the memory", func() {
 				memSizeInBytes := uint64(1024 * 1024 * 1024)
 				diskSizeInBytes := 2*memSizeInBytes + 64
@@ -717,7 +754,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 
 		Context("when ephemeral disk path is not provided", func() {
 			act := func() error {
-				return platform.SetupEphemeralDiskWithPath("")
+				return platform.SetupEphemeralDiskWithPath("", nil)
 			}
 
 			Context("when agent should partition ephemeral disk on root disk", func() {
@@ -1072,7 +1109,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 			})
 
 			It("does nothing", func() {
-				err := platform.SetupEphemeralDiskWithPath("/dev/xvda")
+				err := platform.SetupEphemeralDiskWithPath("/dev/xvda", nil)
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(partitioner.PartitionCalled).To(BeFalse())
@@ -1089,7 +1126,7 @@ fake-base-path/data/sys/log/*.log fake-base-path/data/sys/log/.*.log fake-base-p
 			})
 
 			act := func() error {
-				return platform.SetupEphemeralDiskWithPath("/dev/xvda")
+				return platform.SetupEphemeralDiskWithPath("/dev/xvda", nil)
 			}
 
 			It("returns err when the data directory cannot be globbed", func() {
@@ -2379,6 +2416,188 @@ Number  Start   End     Size    File system  Name             Flags
 			})
 		})
 
+		Context("when disk settings specify mount options and quotas", func() {
+			var diskSettings boshsettings.DiskSettings
+
+			BeforeEach(func() {
+				devicePathResolver.RealDevicePath = "fake-real-device-path"
+				diskSettings = boshsettings.DiskSettings{
+					ID:           "fake-unique-id",
+					Path:         "fake-volume-id",
+					MountOptions: []string{"noatime", "nodev"},
+				}
+			})
+
+			It("mounts the partition with mount options", func() {
+				err := platform.MountPersistentDisk(diskSettings, "/mnt/point")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mounter.MountMountOptions).To(Equal([][]string{{"-o", "noatime,nodev"}}))
+				Expect(diskManager.FakeDirectoryQuotaManager.SetQuotasCalled).To(BeFalse())
+			})
+
+			It("enables project quotas and sets directory quotas after mounting", func() {
+				diskSettings.Quotas = []boshdisk.DirectoryQuota{{Path: "fake-job", SizeInMB: 100}}
+
+				err := platform.MountPersistentDisk(diskSettings, "/mnt/point")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mounter.MountMountOptions).To(Equal([][]string{{"-o", "noatime,nodev,prjquota"}}))
+
+				quotaManager := diskManager.FakeDirectoryQuotaManager
+				Expect(quotaManager.SetQuotasPartitionPath).To(Equal("fake-real-device-path1"))
+				Expect(quotaManager.SetQuotasMountPoint).To(Equal("/mnt/point"))
+				Expect(quotaManager.SetQuotasQuotas).To(Equal(diskSettings.Quotas))
+				Expect(diskSettings.MountOptions).To(Equal([]string{"noatime", "nodev"}))
+			})
+
+			Context("when persistent disk is bind mounted", func() {
+				BeforeEach(func() {
+					options.BindMountPersistentDisk = true
+				})
+
+				It("skips directory quotas", func() {
+					diskSettings.Quotas = []boshdisk.DirectoryQuota{{Path: "fake-job", SizeInMB: 100}}
+
+					err := platform.MountPersistentDisk(diskSettings, "/mnt/point")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mounter.MountMountOptions).To(Equal([][]string{{"-o", "noatime,nodev"}}))
+					Expect(diskManager.FakeDirectoryQuotaManager.SetQuotasCalled).To(BeFalse())
+				})
+			})
+
+			It("returns error when setting directory quotas fails", func() {
+				diskSettings.Quotas = []boshdisk.DirectoryQuota{{Path: "fake-job", SizeInMB: 100}}
+				diskManager.FakeDirectoryQuotaManager.SetQuotasErr = errors.New("fake-quota-err")
+
+				err := platform.MountPersistentDisk(diskSettings, "/mnt/point")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-quota-err"))
+			})
+
+			It("returns error without touching the disk when mount options are not allowed", func() {
+				diskSettings.MountOptions = []string{"bind"}
+
+				err := platform.MountPersistentDisk(diskSettings, "/mnt/point")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Mount option 'bind' is not allowed"))
+				Expect(partitioner.PartitionCalled).To(BeFalse())
+				Expect(mounter.MountCalled).To(BeFalse())
+			})
+
+			It("returns error without touching the disk when quotas are invalid", func() {
+				diskSettings.Quotas = []boshdisk.DirectoryQuota{{Path: "/etc", SizeInMB: 100}}
+
+				err := platform.MountPersistentDisk(diskSettings, "/mnt/point")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must be relative to disk mount point"))
+				Expect(mounter.MountCalled).To(BeFalse())
+			})
+		})
+
+		Context("when PersistentDiskFSCheckMode is not set", func() {
+			It("does not check the file system", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diskManager.FakeFileSystemChecker.CheckCalled).To(BeFalse())
+				Expect(fs.FileExists("/fake-dir/bosh/persistent_disk_fs_checks.json")).To(BeFalse())
+			})
+		})
+
+		Context("when PersistentDiskFSCheckMode is set", func() {
+			var checker *fakedisk.FakeFileSystemChecker
+
+			BeforeEach(func() {
+				options.PersistentDiskFSCheckMode = "repair"
+				devicePathResolver.RealDevicePath = "fake-real-device-path"
+				checker = diskManager.FakeFileSystemChecker
+			})
+
+			It("checks the partition before mounting it", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(checker.CheckPartitionPaths).To(Equal([]string{"fake-real-device-path1"}))
+				Expect(checker.CheckModes).To(Equal([]boshdisk.FileSystemCheckMode{boshdisk.FileSystemCheckModeRepair}))
+				Expect(mounter.MountPartitionPaths).To(Equal([]string{"fake-real-device-path1"}))
+			})
+
+			It("records the check result for the disk", func() {
+				checker.CheckResult = boshdisk.FileSystemCheckResult{FileSystemType: boshdisk.FileSystemExt4, Repaired: true}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				result, found, err := platform.GetPersistentDiskFileSystemCheck(boshsettings.DiskSettings{ID: "fake-unique-id"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(result).To(Equal(boshdisk.FileSystemCheckResult{
+					PartitionPath:  "fake-real-device-path1",
+					FileSystemType: boshdisk.FileSystemExt4,
+					Mode:           boshdisk.FileSystemCheckModeRepair,
+					Repaired:       true,
+				}))
+
+				_, found, err = platform.GetPersistentDiskFileSystemCheck(boshsettings.DiskSettings{ID: "fake-other-id"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+
+			It("records the check result and does not mount when check fails", func() {
+				checker.CheckErr = errors.New("fake-check-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-check-err"))
+				Expect(mounter.MountCalled).To(BeFalse())
+
+				_, found, err := platform.GetPersistentDiskFileSystemCheck(boshsettings.DiskSettings{ID: "fake-unique-id"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+			})
+
+			It("does not check partition which is already mounted", func() {
+				mounter.IsMountedStub = func(devicePathOrMountPoint string) (bool, error) {
+					return devicePathOrMountPoint == "fake-real-device-path1", nil
+				}
+
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(checker.CheckCalled).To(BeFalse())
+				Expect(mounter.MountPartitionPaths).To(Equal([]string{"fake-real-device-path1"}))
+			})
+
+			It("returns error when it cannot determine whether partition is mounted", func() {
+				mounter.IsMountedErr = errors.New("fake-is-mounted-err")
+
+				err := act()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-is-mounted-err"))
+				Expect(checker.CheckCalled).To(BeFalse())
+			})
+
+			Context("when UsePreformattedPersistentDisk set to true", func() {
+				BeforeEach(func() {
+					options.UsePreformattedPersistentDisk = true
+				})
+
+				It("checks the device itself", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(checker.CheckPartitionPaths).To(Equal([]string{"fake-real-device-path"}))
+				})
+			})
+
+			Context("when mode is off", func() {
+				BeforeEach(func() {
+					options.PersistentDiskFSCheckMode = "off"
+				})
+
+				It("does not check the file system", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(checker.CheckCalled).To(BeFalse())
+				})
+			})
+		})
+
 		Context("when device path is not successfully resolved", func() {
 			It("return an error", func() {
 				devicePathResolver.GetRealDevicePathErr = errors.New("fake-get-real-device-path-err")
@@ -2624,20 +2843,188 @@ Number  Start   End     Size    File system  Name             Flags
 		var mounter *fakedisk.FakeMounter
 		BeforeEach(func() {
 			mounter = diskManager.FakeMounter
+			diskManager.FakeMountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+				{PartitionPath: "/dev/sdb1", MountPoint: "/from/path", Options: []string{"ro"}},
+				{PartitionPath: "/dev/sdc1", MountPoint: "/to/path", Options: []string{"rw", "noatime", "attr2", "prjquota"}},
+			}
+			cmdRunner.AddCmdResult("find /from/path -mindepth 1 -maxdepth 1 -printf %f\\n", fakesys.FakeCmdResult{Stdout: "fake-dir\n"})
+			countResult := fakesys.FakeCmdResult{Stdout: "2 1024\n"}
+			cmdRunner.AddCmdResult(`sh -c find /from/path -xdev -type f -printf '%s\n' | awk '{n++; s+=$1} END {printf "%d %.0f\n", n, s}'`, countResult)
+			cmdRunner.AddCmdResult(`sh -c find /to/path -xdev -type f -printf '%s\n' | awk '{n++; s+=$1} END {printf "%d %.0f\n", n, s}'`, countResult)
 		})
 
-		It("migrate persistent disk", func() {
+		It("copies, verifies and moves new disk on original mount point", func() {
 			err := platform.MigratePersistentDisk("/from/path", "/to/path")
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(mounter.RemountAsReadonlyPath).To(Equal("/from/path"))
-
-			Expect(len(cmdRunner.RunCommands)).To(Equal(1))
-			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"sh", "-c", "(tar -C /from/path -cf - .) | (tar -C /to/path -xpf -)"}))
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"sh", "-c", "(tar -C /from/path -cf - './fake-dir') | (tar -C /to/path -xpf -)"}))
 
 			Expect(mounter.UnmountPartitionPathOrMountPoint).To(Equal("/from/path"))
 			Expect(mounter.RemountFromMountPoint).To(Equal("/to/path"))
 			Expect(mounter.RemountToMountPoint).To(Equal("/from/path"))
+			Expect(mounter.RemountMountOptions).To(Equal([]string{"-o", "noatime,prjquota"}))
+
+			Expect(fs.FileExists("/fake-dir/bosh/persistent_disk_migration.json")).To(BeFalse())
+		})
+
+		It("returns error when mounts cannot be searched", func() {
+			diskManager.FakeMountsSearcher.SearchMountsErr = errors.New("fake-search-mounts-err")
+
+			err := platform.MigratePersistentDisk("/from/path", "/to/path")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-search-mounts-err"))
+		})
+	})
+
+	Describe("CancelPersistentDiskMigration", func() {
+		It("succeeds when no migration is in progress", func() {
+			Expect(platform.CancelPersistentDiskMigration()).To(Succeed())
+
+			_, found := platform.GetPersistentDiskMigrationProgress()
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("GetDiskInfo", func() {
+		var inspector *fakedisk.FakeBlockDeviceInspector
+
+		BeforeEach(func() {
+			inspector = diskManager.FakeBlockDeviceInspector
+			inspector.InspectResults = map[string]boshdisk.BlockDeviceInfo{
+				"/dev/sdc":  {PartitionTableType: "dos"},
+				"/dev/sdc1": {FileSystemType: boshdisk.FileSystemExt4, UUID: "fake-uuid"},
+			}
+			collector.DiskStats = map[string]boshstats.DiskStats{
+				"/fake-dir/store": {
+					DiskUsage:  boshstats.Usage{Used: 10, Total: 100},
+					InodeUsage: boshstats.Usage{Used: 2, Total: 50},
+				},
+			}
+			devicePathResolver.RealDevicePath = "/dev/sdc"
+		})
+
+		Context("for persistent disk", func() {
+			diskSettings := boshsettings.DiskSettings{ID: "fake-disk-cid", Path: "/dev/sdc"}
+
+			It("returns device, file system and usage details of mounted disk", func() {
+				diskManager.FakeMountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/sdc1", MountPoint: "/fake-dir/store", Options: []string{"rw", "noatime"}},
+				}
+
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypePersistent, diskSettings)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info).To(Equal(boshdisk.DiskInfo{
+					Type:               boshdisk.DiskTypePersistent,
+					CID:                "fake-disk-cid",
+					DevicePath:         "/dev/sdc",
+					PartitionTableType: "dos",
+					PartitionPath:      "/dev/sdc1",
+					FileSystemType:     boshdisk.FileSystemExt4,
+					UUID:               "fake-uuid",
+					MountPoint:         "/fake-dir/store",
+					MountOptions:       []string{"rw", "noatime"},
+					Usage: &boshdisk.DiskInfoUsage{
+						SizeInKB:    100,
+						UsedInKB:    10,
+						InodesTotal: 50,
+						InodesUsed:  2,
+					},
+				}))
+				Expect(devicePathResolver.GetRealDevicePathDiskSettings).To(Equal(diskSettings))
+			})
+
+			It("reports that disk is mid-migration when store migration directory is mounted", func() {
+				diskManager.FakeMountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/sdb1", MountPoint: "/fake-dir/store"},
+					{PartitionPath: "/dev/sdc1", MountPoint: "/fake-dir/store_migration_target"},
+				}
+				collector.DiskStats["/fake-dir/store_migration_target"] = boshstats.DiskStats{}
+
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypePersistent, diskSettings)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.MountPoint).To(Equal("/fake-dir/store_migration_target"))
+				Expect(info.Migrating).To(BeTrue())
+			})
+
+			It("returns device details of disk that is not mounted", func() {
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypePersistent, diskSettings)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.PartitionPath).To(Equal("/dev/sdc1"))
+				Expect(info.FileSystemType).To(Equal(boshdisk.FileSystemExt4))
+				Expect(info.MountPoint).To(BeEmpty())
+				Expect(info.Usage).To(BeNil())
+				Expect(info.Migrating).To(BeFalse())
+			})
+
+			It("returns error when device path cannot be resolved", func() {
+				devicePathResolver.GetRealDevicePathErr = errors.New("fake-get-real-device-path-err")
+
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypePersistent, diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-real-device-path-err"))
+				Expect(info.CID).To(Equal("fake-disk-cid"))
+			})
+
+			It("returns error when device cannot be inspected", func() {
+				inspector.InspectErr = errors.New("fake-inspect-err")
+
+				_, err := platform.GetDiskInfo(boshdisk.DiskTypePersistent, diskSettings)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-inspect-err"))
+			})
+		})
+
+		Context("for ephemeral disk", func() {
+			It("returns details of partition mounted on data directory", func() {
+				diskManager.FakeMountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/sdc2", MountPoint: "/fake-dir/data"},
+				}
+				collector.DiskStats["/fake-dir/data"] = boshstats.DiskStats{}
+
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypeEphemeral, boshsettings.DiskSettings{VolumeID: "fake-volume-id"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.CID).To(Equal("fake-volume-id"))
+				Expect(info.DevicePath).To(Equal("/dev/sdc"))
+				Expect(info.PartitionPath).To(Equal("/dev/sdc2"))
+				Expect(info.MountPoint).To(Equal("/fake-dir/data"))
+				Expect(inspector.InspectDevicePaths).To(Equal([]string{"/dev/sdc", "/dev/sdc2"}))
+			})
+
+			It("names partition of unmounted nvme disk the way kernel does", func() {
+				devicePathResolver.RealDevicePath = "/dev/nvme1n1"
+
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypeEphemeral, boshsettings.DiskSettings{VolumeID: "fake-volume-id"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.PartitionPath).To(Equal("/dev/nvme1n1p2"))
+				Expect(info.MountPoint).To(BeEmpty())
+			})
+		})
+
+		Context("for raw ephemeral disk", func() {
+			It("only inspects the device", func() {
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypeRawEphemeral, boshsettings.DiskSettings{Path: "/dev/sdc"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.PartitionTableType).To(Equal("dos"))
+				Expect(info.PartitionPath).To(BeEmpty())
+				Expect(inspector.InspectDevicePaths).To(Equal([]string{"/dev/sdc"}))
+			})
+		})
+
+		Context("for system disk", func() {
+			It("returns details of root partition", func() {
+				diskManager.FakeMountsSearcher.SearchMountsMounts = []boshdisk.Mount{
+					{PartitionPath: "/dev/sda1", MountPoint: "/"},
+				}
+				cmdRunner.AddCmdResult("readlink -f /dev/sda1", fakesys.FakeCmdResult{Stdout: "/dev/sda1"})
+				collector.DiskStats["/"] = boshstats.DiskStats{}
+
+				info, err := platform.GetDiskInfo(boshdisk.DiskTypeSystem, boshsettings.DiskSettings{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.DevicePath).To(Equal("/dev/sda"))
+				Expect(info.PartitionPath).To(Equal("/dev/sda1"))
+				Expect(info.MountPoint).To(Equal("/"))
+				Expect(devicePathResolver.GetRealDevicePathDiskSettings).To(Equal(boshsettings.DiskSettings{}))
+			})
 		})
 	})
 
@@ -2943,6 +3330,41 @@ unit: sectors
 
 			Expect(netManager.SetupNetworkingNetworks).To(Equal(networks))
 		})
+
+		Context("when local DNS server is enabled", func() {
+			BeforeEach(func() {
+				options.LocalDNSAddress = "169.254.0.2"
+			})
+
+			It("uses local DNS server as the first DNS server of networks", func() {
+				networks := boshsettings.Networks{
+					"net1": boshsettings.Network{IP: "10.0.0.2", DNS: []string{"8.8.8.8"}},
+					"net2": boshsettings.Network{IP: "10.0.1.2", Netmask: "255.255.255.0"},
+				}
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(netManager.SetupNetworkingNetworks).To(Equal(boshsettings.Networks{
+					"net1": boshsettings.Network{IP: "10.0.0.2", DNS: []string{"169.254.0.2", "8.8.8.8"}},
+					"net2": boshsettings.Network{IP: "10.0.1.2", Netmask: "255.255.255.0"},
+				}))
+				Expect(networks["net1"].DNS).To(Equal([]string{"8.8.8.8"}))
+			})
+
+			It("uses local DNS server as DNS server of DHCP networks without DNS servers", func() {
+				networks := boshsettings.Networks{
+					"net1": boshsettings.Network{Type: "dynamic"},
+				}
+
+				err := platform.SetupNetworking(networks)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(netManager.SetupNetworkingNetworks).To(Equal(boshsettings.Networks{
+					"net1": boshsettings.Network{Type: "dynamic", DNS: []string{"169.254.0.2"}},
+				}))
+			})
+		})
 	})
 
 	Describe("GetConfiguredNetworkInterfaces", func() {
@@ -3093,5 +3515,41 @@ unit: sectors
 			Expect(hostsFileContents).Should(MatchRegexp("fake-ip0\\s+fake-name0\\n"))
 			Expect(hostsFileContents).Should(MatchRegexp("fake-ip1\\s+fake-name1\\n"))
 		})
+
+		Context("when local DNS server is enabled", func() {
+			var recordsPath string
+
+			BeforeEach(func() {
+				options.LocalDNSAddress = "169.254.0.2"
+				recordsPath = filepath.Join(dirProvider.InstanceDir(), "dns", "records.json")
+			})
+
+			It("writes DNS records for local DNS server instead of '/etc/hosts'", func() {
+				err := platform.SaveDNSRecords(dnsRecords, "fake-hostname")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.FileExists("/etc/hosts")).To(BeFalse())
+
+				recordsJSON, err := fs.ReadFileString(recordsPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(recordsJSON).To(MatchJSON(`{"Version":0,"records":[["fake-ip0","fake-name0"],["fake-ip1","fake-name1"]]}`))
+			})
+
+			It("renames intermediary records file atomically", func() {
+				err := platform.SaveDNSRecords(dnsRecords, "fake-hostname")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.RenameOldPaths).To(Equal([]string{recordsPath + "-fake-uuid-0"}))
+				Expect(fs.RenameNewPaths).To(Equal([]string{recordsPath}))
+			})
+
+			It("returns error when records cannot be written", func() {
+				fs.WriteFileErrors[recordsPath+"-fake-uuid-0"] = errors.New("fake-write-err")
+
+				err := platform.SaveDNSRecords(dnsRecords, "fake-hostname")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			})
+		})
 	})
 }
@@ -1,6 +1,7 @@
 package arp
 
 import (
+	gonet "net"
 	"path"
 	"sync"
 	"time"
@@ -86,6 +87,21 @@ func (a arping) broadcastMACAddress(address boship.InterfaceAddress) {
 
 	ifaceName := address.GetInterfaceName()
 
+	// IPv6 does not have ARP; ndsend sends unsolicited neighbor advertisement
+	// with override flag so that neighbors replace cached MAC address
+	if parsedIP := gonet.ParseIP(ip); parsedIP != nil && parsedIP.To4() == nil {
+		if !a.cmdRunner.CommandExists("ndsend") {
+			a.logger.Warn(arpingLogTag, "Cannot advertise %s on %s to IPv6 neighbors: ndsend is not installed", ip, ifaceName)
+			return
+		}
+
+		_, _, _, err = a.cmdRunner.RunCommand("ndsend", ip, ifaceName)
+		if err != nil {
+			a.logger.Warn(arpingLogTag, "Failed to advertise %s on %s to IPv6 neighbors: %s", ip, ifaceName, err.Error())
+		}
+		return
+	}
+
 	_, _, _, err = a.cmdRunner.RunCommand("arping", "-c", "1", "-U", "-I", ifaceName, ip)
 	if err != nil {
 		a.logger.Info(arpingLogTag, "Ignoring arping failure: %s", err.Error())
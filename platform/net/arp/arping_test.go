@@ -73,6 +73,31 @@ var _ = Describe("arping", func() {
 			Expect(countB).To(Equal(arpingIterations))
 		})
 
+		It("sends unsolicited neighbor advertisements for IPv6 addresses", func() {
+			cmdRunner.AvailableCommands = map[string]bool{"ndsend": true}
+
+			addresses := []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "2001:db8::5"),
+			}
+
+			arping.BroadcastMACAddresses(addresses)
+
+			Expect(len(cmdRunner.RunCommands)).To(Equal(arpingIterations))
+			for _, cmd := range cmdRunner.RunCommands {
+				Expect(cmd).To(Equal([]string{"ndsend", "2001:db8::5", "eth0"}))
+			}
+		})
+
+		It("does not advertise IPv6 addresses when ndsend is not installed", func() {
+			addresses := []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "2001:db8::5"),
+			}
+
+			arping.BroadcastMACAddresses(addresses)
+
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
 		It("does not run arping command if failed to get interface IP address", func() {
 			addresses := []boship.InterfaceAddress{failingInterfaceAddress{}}
 
@@ -2,6 +2,7 @@ package net
 
 import (
 	"bytes"
+	"fmt"
 	"path"
 	"strings"
 	"text/template"
@@ -24,6 +25,7 @@ type centosNetManager struct {
 	interfaceConfigurationCreator InterfaceConfigurationCreator
 	interfaceAddressesValidator   boship.InterfaceAddressesValidator
 	dnsValidator                  DNSValidator
+	routesValidator               RoutesValidator
 	addressBroadcaster            bosharp.AddressBroadcaster
 	logger                        boshlog.Logger
 }
@@ -35,6 +37,7 @@ func NewCentosNetManager(
 	interfaceConfigurationCreator InterfaceConfigurationCreator,
 	interfaceAddressesValidator boship.InterfaceAddressesValidator,
 	dnsValidator DNSValidator,
+	routesValidator RoutesValidator,
 	addressBroadcaster bosharp.AddressBroadcaster,
 	logger boshlog.Logger,
 ) Manager {
@@ -45,6 +48,7 @@ func NewCentosNetManager(
 		interfaceConfigurationCreator: interfaceConfigurationCreator,
 		interfaceAddressesValidator:   interfaceAddressesValidator,
 		dnsValidator:                  dnsValidator,
+		routesValidator:               routesValidator,
 		addressBroadcaster:            addressBroadcaster,
 		logger:                        logger,
 	}
@@ -91,6 +95,16 @@ func (net centosNetManager) SetupNetworking(networks boshsettings.Networks, errC
 		return bosherr.WrapError(err, "Validating static network configuration")
 	}
 
+	err = net.interfaceAddressesValidator.ValidateMTUs(interfaceMTUs(staticInterfaceConfigurations, dhcpInterfaceConfigurations))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating MTU")
+	}
+
+	err = net.routesValidator.Validate(interfaceRoutes(staticInterfaceConfigurations, dhcpInterfaceConfigurations))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating routes")
+	}
+
 	err = net.dnsValidator.Validate(dnsServers)
 	if err != nil {
 		return bosherr.WrapError(err, "Validating dns configuration")
@@ -101,10 +115,79 @@ func (net centosNetManager) SetupNetworking(networks boshsettings.Networks, errC
 	return nil
 }
 
+func (net centosNetManager) PlanNetworking(networks boshsettings.Networks) (NetworkPlan, error) {
+	plan := newNetworkPlan()
+
+	fs := newPlanningFileSystem(net.fs)
+	planner := net
+	planner.fs = fs
+	planner.cmdRunner = planningCmdRunner{}
+
+	nonVipNetworks := boshsettings.Networks{}
+	for networkName, networkSettings := range networks {
+		if networkSettings.IsVIP() {
+			continue
+		}
+		nonVipNetworks[networkName] = networkSettings
+	}
+
+	staticInterfaceConfigurations, dhcpInterfaceConfigurations, err := planner.buildInterfaces(nonVipNetworks)
+	if err != nil {
+		plan.Errors = append(plan.Errors, err.Error())
+		return plan, nil
+	}
+
+	plan.Errors = append(plan.Errors, overlappingSubnetErrors(staticInterfaceConfigurations)...)
+
+	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
+	dnsServers := dnsNetwork.DNS
+
+	interfacesChanged, err := planner.writeNetworkInterfaces(dhcpInterfaceConfigurations, staticInterfaceConfigurations, dnsServers)
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Planning network configuration")
+	}
+
+	dhcpChanged := false
+	if len(dhcpInterfaceConfigurations) > 0 {
+		dhcpChanged, err = planner.writeDHCPConfiguration(dnsServers, dhcpInterfaceConfigurations)
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	// Network service restarts all interfaces
+	if interfacesChanged || dhcpChanged {
+		plan.RestartedInterfaces = net.ifaceNames(dhcpInterfaceConfigurations, staticInterfaceConfigurations)
+	}
+
+	plan.Files = fs.PlannedFiles()
+
+	return plan, nil
+}
+
+func (net centosNetManager) ifaceNames(dhcpConfigs []DHCPInterfaceConfiguration, staticConfigs []StaticInterfaceConfiguration) []string {
+	ifaceNames := newLinkTopology(staticConfigs, dhcpConfigs).LowerInterfaces()
+	for _, config := range dhcpConfigs {
+		ifaceNames = append(ifaceNames, config.Name)
+	}
+	for _, config := range staticConfigs {
+		ifaceNames = append(ifaceNames, config.Name)
+	}
+	return ifaceNames
+}
+
+func (net centosNetManager) configurationFiles() []string {
+	files := []string{"/etc/dhcp/dhclient.conf", "/etc/dhcp/dhclient-*.conf"}
+	for _, prefix := range []string{"ifcfg", "route", "route6", "rule", "rule6"} {
+		files = append(files, path.Join("/etc/sysconfig/network-scripts", prefix+"-*"))
+	}
+	return files
+}
+
 func (net centosNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 	interfaces := []string{}
 
-	interfacesByMacAddress, err := net.detectMacAddresses()
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
 	if err != nil {
 		return interfaces, bosherr.WrapError(err, "Getting network interfaces")
 	}
@@ -119,25 +202,141 @@ func (net centosNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 }
 
 const centosDHCPIfcfgTemplate = `DEVICE={{ .Name }}
-BOOTPROTO=dhcp
+BOOTPROTO=dhcp{{ range .IPv6Lines }}
+{{ . }}{{ end }}{{ range .LinkLines }}
+{{ . }}{{ end }}{{ if .MTU }}
+MTU={{ .MTU }}{{ end }}{{ if .Offloads }}
+ETHTOOL_OPTS="{{ ethtoolOffloadOpts .Name .Offloads }}"{{ end }}
 ONBOOT=yes
-PEERDNS=yes
+PEERDNS=yes{{ range .DNSServers }}
+DNS{{ .Index }}={{ .Address }}{{ end }}
 `
 
-const centosStaticIfcfgTemplate = `DEVICE={{ .Name }}
+const centosStaticIfcfgTemplate = `DEVICE={{ .Name }}{{ if .Address }}
 BOOTPROTO=static
 IPADDR={{ .Address }}
 NETMASK={{ .Netmask }}
 BROADCAST={{ .Broadcast }}{{if .IsDefaultForGateway}}
-GATEWAY={{ .Gateway }}{{end}}
+GATEWAY={{ .Gateway }}{{end}}{{ else }}
+BOOTPROTO=none{{ end }}{{ range $i, $alias := .Aliases }}
+IPADDR{{ inc $i }}={{ .Address }}
+PREFIX{{ inc $i }}={{ .PrefixLength }}{{ end }}{{ range .IPv6Lines }}
+{{ . }}{{ end }}{{ range .LinkLines }}
+{{ . }}{{ end }}{{ if .MTU }}
+MTU={{ .MTU }}{{ end }}{{ if .Offloads }}
+ETHTOOL_OPTS="{{ ethtoolOffloadOpts .Name .Offloads }}"{{ end }}
 ONBOOT=yes
 PEERDNS=no{{ range .DNSServers }}
 DNS{{ .Index }}={{ .Address }}{{ end }}
 `
 
+// Interfaces without addresses that bonds, VLANs or bridges are built on
+const centosLowerIfcfgTemplate = `DEVICE={{ .Name }}
+BOOTPROTO=none{{ range .LinkLines }}
+{{ . }}{{ end }}{{ if .MTU }}
+MTU={{ .MTU }}{{ end }}
+ONBOOT=yes
+`
+
+var centosIfcfgTemplateFuncs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+	"ethtoolOffloadOpts": func(ifaceName string, offloads []OffloadConfiguration) string {
+		return strings.Join(ethtoolOffloadArgs(ifaceName, offloads), " ")
+	},
+}
+
+// MTU overrides configured MTU of the interface
+// since it might be raised by interfaces built on top of it
 type centosStaticIfcfg struct {
 	*StaticInterfaceConfiguration
 	DNSServers []dnsConfig
+	IPv6Lines  []string
+	LinkLines  []string
+	MTU        int
+}
+
+type centosDHCPIfcfg struct {
+	*DHCPInterfaceConfiguration
+	DNSServers []dnsConfig
+	IPv6Lines  []string
+	LinkLines  []string
+	MTU        int
+}
+
+type centosLowerIfcfg struct {
+	Name      string
+	LinkLines []string
+	MTU       int
+}
+
+// centosLinkLines returns lines creating bond, VLAN or bridge
+// and enslaving interface to a bond or bridge
+func centosLinkLines(topology linkTopology, ifaceName string) []string {
+	var lines []string
+
+	if link, found := topology.Link(ifaceName); found {
+		switch link.Kind {
+		case LinkKindBond:
+			lines = append(lines, "TYPE=Bond", "BONDING_MASTER=yes")
+
+			var options []string
+			if link.BondMode != "" {
+				options = append(options, "mode="+link.BondMode)
+			}
+			if link.BondMIIMon > 0 {
+				options = append(options, fmt.Sprintf("miimon=%d", link.BondMIIMon))
+			}
+			if len(options) > 0 {
+				lines = append(lines, fmt.Sprintf("BONDING_OPTS=\"%s\"", strings.Join(options, " ")))
+			}
+		case LinkKindVLAN:
+			lines = append(lines, "VLAN=yes", "PHYSDEV="+link.Lower[0])
+		case LinkKindBridge:
+			lines = append(lines, "TYPE=Bridge")
+		}
+	}
+
+	if master, found := topology.Master(ifaceName); found {
+		if master.Kind == LinkKindBond {
+			lines = append(lines, "MASTER="+master.Name, "SLAVE=yes")
+		} else {
+			lines = append(lines, "BRIDGE="+master.Name)
+		}
+	}
+
+	return lines
+}
+
+func centosStaticIPv6Lines(config StaticInterfaceConfiguration) []string {
+	if len(config.IPv6Addresses) == 0 {
+		return centosDynamicIPv6Lines(config.IPv6Mode)
+	}
+
+	lines := []string{"IPV6INIT=yes", "IPV6_AUTOCONF=no", "IPV6ADDR=" + config.IPv6Addresses[0].String()}
+
+	if len(config.IPv6Addresses) > 1 {
+		secondaries := []string{}
+		for _, address := range config.IPv6Addresses[1:] {
+			secondaries = append(secondaries, address.String())
+		}
+		lines = append(lines, fmt.Sprintf("IPV6ADDR_SECONDARIES=\"%s\"", strings.Join(secondaries, " ")))
+	}
+
+	if config.IsDefaultForGateway && config.IPv6Gateway != "" {
+		lines = append(lines, "IPV6_DEFAULTGW="+config.IPv6Gateway)
+	}
+
+	return lines
+}
+
+func centosDynamicIPv6Lines(mode boshsettings.NetworkIPv6Mode) []string {
+	switch mode {
+	case boshsettings.NetworkIPv6ModeSLAAC:
+		return []string{"IPV6INIT=yes", "IPV6_AUTOCONF=yes"}
+	case boshsettings.NetworkIPv6ModeDHCPv6:
+		return []string{"IPV6INIT=yes", "IPV6_AUTOCONF=no", "DHCPV6C=yes"}
+	}
+	return nil
 }
 
 type dnsConfig struct {
@@ -177,12 +376,30 @@ func (net centosNetManager) writeIfcfgFile(name string, t *template.Template, co
 func (net centosNetManager) writeNetworkInterfaces(dhcpInterfaceConfigurations []DHCPInterfaceConfiguration, staticInterfaceConfigurations []StaticInterfaceConfiguration, dnsServers []string) (bool, error) {
 	anyInterfaceChanged := false
 
+	topology := newLinkTopology(staticInterfaceConfigurations, dhcpInterfaceConfigurations)
+	mtus := interfaceMTUs(staticInterfaceConfigurations, dhcpInterfaceConfigurations)
+	lowerTemplate := template.Must(template.New("ifcfg").Parse(centosLowerIfcfgTemplate))
+
+	for _, name := range topology.LowerInterfaces() {
+		lowerConfig := centosLowerIfcfg{Name: name, LinkLines: centosLinkLines(topology, name), MTU: mtus[name]}
+
+		changed, err := net.writeIfcfgFile(name, lowerTemplate, lowerConfig)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Writing link config")
+		}
+
+		anyInterfaceChanged = anyInterfaceChanged || changed
+	}
+
 	staticConfig := centosStaticIfcfg{}
 	staticConfig.DNSServers = newDNSConfigs(dnsServers)
-	staticTemplate := template.Must(template.New("ifcfg").Parse(centosStaticIfcfgTemplate))
+	staticTemplate := template.Must(template.New("ifcfg").Funcs(centosIfcfgTemplateFuncs).Parse(centosStaticIfcfgTemplate))
 
 	for i := range staticInterfaceConfigurations {
 		staticConfig.StaticInterfaceConfiguration = &staticInterfaceConfigurations[i]
+		staticConfig.IPv6Lines = centosStaticIPv6Lines(staticInterfaceConfigurations[i])
+		staticConfig.LinkLines = centosLinkLines(topology, staticConfig.Name)
+		staticConfig.MTU = mtus[staticConfig.Name]
 
 		changed, err := net.writeIfcfgFile(staticConfig.StaticInterfaceConfiguration.Name, staticTemplate, staticConfig)
 		if err != nil {
@@ -190,26 +407,99 @@ func (net centosNetManager) writeNetworkInterfaces(dhcpInterfaceConfigurations [
 		}
 
 		anyInterfaceChanged = anyInterfaceChanged || changed
+
+		changed, err = net.writeRouteFiles(staticConfig.Name, staticConfig.Routes, staticConfig.PolicyRules)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Writing static routes")
+		}
+
+		anyInterfaceChanged = anyInterfaceChanged || changed
 	}
 
-	dhcpTemplate := template.Must(template.New("ifcfg").Parse(centosDHCPIfcfgTemplate))
+	dhcpConfig := centosDHCPIfcfg{}
+	// IPv4 DNS servers are prepended by dhclient
+	dhcpConfig.DNSServers = newDNSConfigs(ipv6DNSServers(dnsServers))
+	dhcpTemplate := template.Must(template.New("ifcfg").Funcs(centosIfcfgTemplateFuncs).Parse(centosDHCPIfcfgTemplate))
 
 	for i := range dhcpInterfaceConfigurations {
-		config := &dhcpInterfaceConfigurations[i]
+		dhcpConfig.DHCPInterfaceConfiguration = &dhcpInterfaceConfigurations[i]
+		dhcpConfig.IPv6Lines = centosDynamicIPv6Lines(dhcpInterfaceConfigurations[i].IPv6Mode)
+		dhcpConfig.LinkLines = centosLinkLines(topology, dhcpConfig.Name)
+		dhcpConfig.MTU = mtus[dhcpConfig.Name]
 
-		changed, err := net.writeIfcfgFile(config.Name, dhcpTemplate, config)
+		changed, err := net.writeIfcfgFile(dhcpConfig.Name, dhcpTemplate, dhcpConfig)
 		if err != nil {
 			return false, bosherr.WrapError(err, "Writing dhcp config")
 		}
 
 		anyInterfaceChanged = anyInterfaceChanged || changed
+
+		changed, err = net.writeRouteFiles(dhcpConfig.Name, dhcpConfig.Routes, nil)
+		if err != nil {
+			return false, bosherr.WrapError(err, "Writing dhcp routes")
+		}
+
+		anyInterfaceChanged = anyInterfaceChanged || changed
 	}
 
 	return anyInterfaceChanged, nil
 }
 
+// writeRouteFiles writes route-<iface> and rule-<iface> files read by initscripts;
+// IPv6 routes and rules go to route6-<iface> and rule6-<iface> files
+func (net centosNetManager) writeRouteFiles(name string, routes []RouteConfiguration, rules []PolicyRuleConfiguration) (bool, error) {
+	linesByFileName := map[string][]string{
+		"route-" + name:  nil,
+		"route6-" + name: nil,
+		"rule-" + name:   nil,
+		"rule6-" + name:  nil,
+	}
+
+	for _, route := range routes {
+		fileName := "route-" + name
+		if route.IsIPv6() {
+			fileName = "route6-" + name
+		}
+		linesByFileName[fileName] = append(linesByFileName[fileName], route.IPRouteSpec(name))
+	}
+
+	for _, rule := range rules {
+		fileName := "rule-" + name
+		if rule.IsIPv6() {
+			fileName = "rule6-" + name
+		}
+		linesByFileName[fileName] = append(linesByFileName[fileName], rule.IPRuleSpec())
+	}
+
+	anyFileChanged := false
+
+	for fileName, lines := range linesByFileName {
+		filePath := path.Join("/etc/sysconfig/network-scripts", fileName)
+
+		if len(lines) == 0 {
+			if net.fs.FileExists(filePath) {
+				err := net.fs.RemoveAll(filePath)
+				if err != nil {
+					return false, bosherr.WrapErrorf(err, "Removing '%s'", filePath)
+				}
+				anyFileChanged = true
+			}
+			continue
+		}
+
+		changed, err := net.fs.ConvergeFileContents(filePath, []byte(strings.Join(lines, "\n")+"\n"))
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Writing config to '%s'", filePath)
+		}
+
+		anyFileChanged = anyFileChanged || changed
+	}
+
+	return anyFileChanged, nil
+}
+
 func (net centosNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
-	interfacesByMacAddress, err := net.detectMacAddresses()
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
 	if err != nil {
 		return nil, nil, bosherr.WrapError(err, "Getting network interfaces")
 	}
@@ -262,7 +552,7 @@ func (net centosNetManager) writeDHCPConfiguration(dnsServers []string, dhcpInte
 
 	// Keep DNS servers in the order specified by the network
 	// because they are added by a *single* DHCP's prepend command
-	dnsServersList := strings.Join(dnsServers, ", ")
+	dnsServersList := strings.Join(ipv4DNSServers(dnsServers), ", ")
 	err := t.Execute(buffer, dnsServersList)
 	if err != nil {
 		return false, bosherr.WrapError(err, "Generating config from template")
@@ -286,38 +576,12 @@ func (net centosNetManager) writeDHCPConfiguration(dnsServers []string, dhcpInte
 	return changed, nil
 }
 
-func (net centosNetManager) detectMacAddresses() (map[string]string, error) {
-	addresses := map[string]string{}
-
-	filePaths, err := net.fs.Glob("/sys/class/net/*")
-	if err != nil {
-		return addresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
-	}
-
-	var macAddress string
-	for _, filePath := range filePaths {
-		isPhysicalDevice := net.fs.FileExists(path.Join(filePath, "device"))
-
-		if isPhysicalDevice {
-			macAddress, err = net.fs.ReadFileString(path.Join(filePath, "address"))
-			if err != nil {
-				return addresses, bosherr.WrapError(err, "Reading mac address from file")
-			}
-
-			macAddress = strings.Trim(macAddress, "\n")
-
-			interfaceName := path.Base(filePath)
-			addresses[macAddress] = interfaceName
-		}
-	}
-
-	return addresses, nil
-}
-
 func (net centosNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
 	staticAddresses := []boship.InterfaceAddress{}
 	for _, iface := range staticConfigs {
-		staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, iface.Address))
+		for _, address := range iface.IPAddresses() {
+			staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, address))
+		}
 	}
 	dynamicAddresses := []boship.InterfaceAddress{}
 	for _, iface := range dhcpConfigs {
@@ -9,6 +9,7 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/platform/net"
 	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
 	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -25,6 +26,7 @@ func describeCentosNetManager() {
 		ipResolver                    *fakeip.FakeResolver
 		interfaceAddrsProvider        *fakeip.FakeInterfaceAddressesProvider
 		addressBroadcaster            *fakearp.FakeAddressBroadcaster
+		routesSearcher                *fakenet.FakeRoutesSearcher
 		netManager                    Manager
 		interfaceConfigurationCreator InterfaceConfigurationCreator
 	)
@@ -38,6 +40,7 @@ func describeCentosNetManager() {
 		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
 		interfaceAddrsValidator := boship.NewInterfaceAddressesValidator(interfaceAddrsProvider)
 		dnsValidator := NewDNSValidator(fs)
+		routesSearcher = &fakenet.FakeRoutesSearcher{}
 		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
 		netManager = NewCentosNetManager(
 			fs,
@@ -46,6 +49,7 @@ func describeCentosNetManager() {
 			interfaceConfigurationCreator,
 			interfaceAddrsValidator,
 			dnsValidator,
+			NewRoutesValidator(routesSearcher),
 			addressBroadcaster,
 			logger,
 		)
@@ -584,6 +588,349 @@ DNS1=8.8.8.8
 
 		})
 
+		It("writes IPv6 addresses and additional IPv4 addresses of dual-stack networks", func() {
+			dualStackNetwork := boshsettings.Network{
+				Type:    "manual",
+				Default: []string{"gateway", "dns"},
+				DNS:     []string{"8.8.8.8", "2001:4860:4860::8888"},
+				Mac:     "fake-static-mac-address",
+				Addresses: []boshsettings.NetworkAddress{
+					{IP: "1.2.3.4", Prefix: 24, Gateway: "1.2.3.1"},
+					{IP: "1.2.4.4", Prefix: 24},
+					{IP: "2001:db8::4", Prefix: 64, Gateway: "2001:db8::1"},
+					{IP: "2001:db8:1::4", Prefix: 64},
+				},
+			}
+			dhcpNetwork.Default = nil
+			dhcpNetwork.IPv6Mode = boshsettings.NetworkIPv6ModeDHCPv6
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": dualStackNetwork,
+			})
+
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.4.4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "2001:db8::4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "2001:db8:1::4"),
+			}
+			fs.WriteFileString("/etc/resolv.conf", `
+nameserver 8.8.8.8
+nameserver 2001:4860:4860::8888
+`)
+
+			err := netManager.SetupNetworking(boshsettings.Networks{
+				"dhcp-network":   dhcpNetwork,
+				"static-network": dualStackNetwork,
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-ethstatic")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`DEVICE=ethstatic
+BOOTPROTO=static
+IPADDR=1.2.3.4
+NETMASK=255.255.255.0
+BROADCAST=1.2.3.255
+GATEWAY=1.2.3.1
+IPADDR1=1.2.4.4
+PREFIX1=24
+IPV6INIT=yes
+IPV6_AUTOCONF=no
+IPV6ADDR=2001:db8::4/64
+IPV6ADDR_SECONDARIES="2001:db8:1::4/64"
+IPV6_DEFAULTGW=2001:db8::1
+ONBOOT=yes
+PEERDNS=no
+DNS1=8.8.8.8
+DNS2=2001:4860:4860::8888
+`))
+
+			dhcpConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-ethdhcp")
+			Expect(dhcpConfig).ToNot(BeNil())
+			Expect(dhcpConfig.StringContents()).To(Equal(`DEVICE=ethdhcp
+BOOTPROTO=dhcp
+IPV6INIT=yes
+IPV6_AUTOCONF=no
+DHCPV6C=yes
+ONBOOT=yes
+PEERDNS=yes
+DNS1=2001:4860:4860::8888
+`))
+
+			dhclientConfig := fs.GetFileTestStat("/etc/dhcp/dhclient.conf")
+			Expect(dhclientConfig).ToNot(BeNil())
+			Expect(dhclientConfig.StringContents()).To(ContainSubstring("prepend domain-name-servers 8.8.8.8;"))
+		})
+
+		It("writes route and rule files for static routes and policy routing", func() {
+			staticNetwork.Routes = []boshsettings.NetworkRoute{
+				{Destination: "192.168.0.0/16", Gateway: "1.2.3.7", Metric: 10},
+				{Destination: "2001:db8:5::/48", Gateway: "2001:db8::2"},
+			}
+			staticNetwork.RoutingTable = 100
+
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": staticNetwork})
+			routesSearcher.SearchRoutesRoutes = []Route{
+				{Destination: "192.168.0.0", Netmask: "255.255.0.0", InterfaceName: "ethstatic"},
+				{Destination: "2001:db8:5::/48", InterfaceName: "ethstatic"},
+			}
+			fs.WriteFileString("/etc/sysconfig/network-scripts/rule6-ethstatic", "fake-old-rule")
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			routeConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/route-ethstatic")
+			Expect(routeConfig).ToNot(BeNil())
+			Expect(routeConfig.StringContents()).To(Equal(`192.168.0.0/16 via 1.2.3.7 dev ethstatic metric 10
+1.2.3.0/24 dev ethstatic table 100
+0.0.0.0/0 via 3.4.5.6 dev ethstatic table 100
+`))
+
+			route6Config := fs.GetFileTestStat("/etc/sysconfig/network-scripts/route6-ethstatic")
+			Expect(route6Config).ToNot(BeNil())
+			Expect(route6Config.StringContents()).To(Equal("2001:db8:5::/48 via 2001:db8::2 dev ethstatic\n"))
+
+			ruleConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/rule-ethstatic")
+			Expect(ruleConfig).ToNot(BeNil())
+			Expect(ruleConfig.StringContents()).To(Equal("from 1.2.3.4 table 100\n"))
+
+			Expect(fs.FileExists("/etc/sysconfig/network-scripts/rule6-ethstatic")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(Equal([][]string{{"service", "network", "restart"}}))
+		})
+
+		It("writes network scripts of bonds, VLANs and bridges and interfaces they are built on", func() {
+			staticNetwork.Mac = ""
+			staticNetwork.Bond = &boshsettings.NetworkBond{
+				Name:    "bond0",
+				Mode:    "802.3ad",
+				MIIMon:  100,
+				Members: []string{"fake-mac-1", "fake-mac-2"},
+			}
+			dhcpNetwork.Mac = "fake-mac-3"
+			dhcpNetwork.VLAN = 200
+			dhcpNetwork.Bridge = "br0"
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "fake-mac-1"},
+				"eth1": {Mac: "fake-mac-2"},
+				"eth2": {Mac: "fake-mac-3"},
+			})
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0", "1.2.3.4"),
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, member := range []string{"eth0", "eth1"} {
+				memberConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-" + member)
+				Expect(memberConfig).ToNot(BeNil())
+				Expect(memberConfig.StringContents()).To(Equal(`DEVICE=` + member + `
+BOOTPROTO=none
+MASTER=bond0
+SLAVE=yes
+ONBOOT=yes
+`))
+			}
+
+			bondConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-bond0")
+			Expect(bondConfig).ToNot(BeNil())
+			Expect(bondConfig.StringContents()).To(Equal(`DEVICE=bond0
+BOOTPROTO=static
+IPADDR=1.2.3.4
+NETMASK=255.255.255.0
+BROADCAST=1.2.3.255
+TYPE=Bond
+BONDING_MASTER=yes
+BONDING_OPTS="mode=802.3ad miimon=100"
+ONBOOT=yes
+PEERDNS=no
+DNS1=8.8.8.8
+DNS2=9.9.9.9
+`))
+
+			parentConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-eth2")
+			Expect(parentConfig).ToNot(BeNil())
+			Expect(parentConfig.StringContents()).To(Equal(`DEVICE=eth2
+BOOTPROTO=none
+ONBOOT=yes
+`))
+
+			vlanConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-eth2.200")
+			Expect(vlanConfig).ToNot(BeNil())
+			Expect(vlanConfig.StringContents()).To(Equal(`DEVICE=eth2.200
+BOOTPROTO=none
+VLAN=yes
+PHYSDEV=eth2
+BRIDGE=br0
+ONBOOT=yes
+`))
+
+			bridgeConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-br0")
+			Expect(bridgeConfig).ToNot(BeNil())
+			Expect(bridgeConfig.StringContents()).To(Equal(`DEVICE=br0
+BOOTPROTO=dhcp
+TYPE=Bridge
+ONBOOT=yes
+PEERDNS=yes
+`))
+		})
+
+		It("raises MTU of VLAN parents to MTU of VLANs built on them", func() {
+			dhcpNetwork.Mac = "fake-mac-3"
+			dhcpNetwork.VLAN = 200
+			dhcpNetwork.MTU = 9000
+
+			stubInterfaces(map[string]boshsettings.Network{"eth2": {Mac: "fake-mac-3"}})
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"eth2": 9000, "eth2.200": 9000}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			parentConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-eth2")
+			Expect(parentConfig).ToNot(BeNil())
+			Expect(parentConfig.StringContents()).To(Equal(`DEVICE=eth2
+BOOTPROTO=none
+MTU=9000
+ONBOOT=yes
+`))
+
+			vlanConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-eth2.200")
+			Expect(vlanConfig).ToNot(BeNil())
+			Expect(vlanConfig.StringContents()).To(ContainSubstring("MTU=9000\n"))
+		})
+
+		It("writes MTU and offload features to network scripts", func() {
+			staticNetwork.MTU = 9000
+			staticNetwork.Offload = map[string]bool{"tso": false, "gro": true}
+			dhcpNetwork.MTU = 1450
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"ethstatic": 9000, "ethdhcp": 1450}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-ethstatic")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(ContainSubstring(`
+MTU=9000
+ETHTOOL_OPTS="-K ethstatic gro on tso off"
+ONBOOT=yes
+`))
+
+			dhcpConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-ethdhcp")
+			Expect(dhcpConfig).ToNot(BeNil())
+			Expect(dhcpConfig.StringContents()).To(Equal(`DEVICE=ethdhcp
+BOOTPROTO=dhcp
+MTU=1450
+ONBOOT=yes
+PEERDNS=yes
+`))
+		})
+
+		It("writes IPv6 only static network without IPv4 boot protocol", func() {
+			ipv6Network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "2001:db8::4",
+				Netmask: "64",
+				Mac:     "fake-static-mac-address",
+			}
+
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": ipv6Network})
+
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "2001:db8::4"),
+			}
+			fs.WriteFileString("/etc/resolv.conf", "")
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": ipv6Network}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/sysconfig/network-scripts/ifcfg-ethstatic")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`DEVICE=ethstatic
+BOOTPROTO=none
+IPV6INIT=yes
+IPV6_AUTOCONF=no
+IPV6ADDR=2001:db8::4/64
+ONBOOT=yes
+PEERDNS=no
+`))
+		})
+
+	})
+
+	Describe("PlanNetworking", func() {
+		var (
+			dhcpNetwork   boshsettings.Network
+			staticNetwork boshsettings.Network
+		)
+
+		BeforeEach(func() {
+			dhcpNetwork = boshsettings.Network{
+				Type:    "dynamic",
+				Default: []string{"dns"},
+				DNS:     []string{"8.8.8.8"},
+				Mac:     "fake-dhcp-mac-address",
+			}
+			staticNetwork = boshsettings.Network{
+				Type:    "manual",
+				IP:      "1.2.3.4",
+				Netmask: "255.255.255.0",
+				Gateway: "3.4.5.6",
+				Mac:     "fake-static-mac-address",
+			}
+
+			fs.SetGlob("/sys/class/net/*", []string{
+				writeNetworkDevice("ethdhcp", dhcpNetwork.Mac, true),
+				writeNetworkDevice("ethstatic", staticNetwork.Mac, true),
+			})
+		})
+
+		It("returns rendered files and interfaces to restart without changing anything", func() {
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(BeEmpty())
+			Expect(plan.RestartedInterfaces).To(Equal([]string{"ethdhcp", "ethstatic"}))
+
+			var paths []string
+			for _, file := range plan.Files {
+				paths = append(paths, file.Path)
+				Expect(file.Changed).To(BeTrue())
+			}
+			Expect(paths).To(Equal([]string{
+				"/etc/dhcp/dhclient-ethdhcp.conf",
+				"/etc/dhcp/dhclient.conf",
+				"/etc/sysconfig/network-scripts/ifcfg-ethdhcp",
+				"/etc/sysconfig/network-scripts/ifcfg-ethstatic",
+			}))
+			Expect(plan.Files[0].SymlinkTarget).To(Equal("/etc/dhcp/dhclient.conf"))
+			Expect(plan.Files[3].Content).To(ContainSubstring("IPADDR=1.2.3.4\n"))
+
+			Expect(fs.FileExists("/etc/sysconfig/network-scripts/ifcfg-ethstatic")).To(BeFalse())
+			Expect(fs.FileExists("/etc/dhcp/dhclient.conf")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("reports overlapping subnets of different interfaces", func() {
+			dhcpNetwork.Type = "manual"
+			dhcpNetwork.IP = "1.2.3.5"
+			dhcpNetwork.Netmask = "255.255.255.0"
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"second-network": dhcpNetwork, "static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(Equal([]string{
+				"Subnet '1.2.3.0/24' of interface 'ethdhcp' overlaps subnet '1.2.3.0/24' of interface 'ethstatic'",
+			}))
+		})
 	})
 
 	Describe("GetConfiguredNetworkInterfaces", func() {
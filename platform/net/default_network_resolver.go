@@ -2,6 +2,7 @@ package net
 
 import (
 	gonet "net"
+	"strconv"
 
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -35,8 +36,9 @@ func (r defaultNetworkResolver) GetDefaultNetwork() (boshsettings.Network, error
 		return network, bosherr.Error("No routes found")
 	}
 
+	// IPv4 default route is preferred on dual-stack networks
 	for _, route := range routes {
-		if !route.IsDefault() {
+		if !route.IsDefault() || route.IsIPv6() {
 			continue
 		}
 
@@ -53,5 +55,24 @@ func (r defaultNetworkResolver) GetDefaultNetwork() (boshsettings.Network, error
 
 	}
 
+	for _, route := range routes {
+		if !route.IsDefault() || !route.IsIPv6() {
+			continue
+		}
+
+		ip, err := r.ipResolver.GetPrimaryIPv6(route.InterfaceName)
+		if err != nil {
+			return network, bosherr.WrapErrorf(err, "Getting primary IPv6 for interface '%s'", route.InterfaceName)
+		}
+
+		prefixLength, _ := ip.Mask.Size()
+
+		return boshsettings.Network{
+			IP:      ip.IP.String(),
+			Netmask: strconv.Itoa(prefixLength),
+			Gateway: route.Gateway,
+		}, nil
+	}
+
 	return network, bosherr.Error("Failed to find default route")
 }
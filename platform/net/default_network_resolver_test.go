@@ -76,6 +76,52 @@ var _ = Describe("defaultNetworkResolver", func() {
 			})
 		})
 
+		Context("when only IPv6 default route is found", func() {
+			BeforeEach(func() {
+				routesSearcher.SearchRoutesRoutes = []Route{
+					Route{Destination: "2001:db8::/64", Gateway: "::", InterfaceName: "eth0"},
+					Route{Destination: "::/0", Gateway: "2001:db8::1", InterfaceName: "eth0"},
+				}
+				ipResolver.GetPrimaryIPv6IPNet = &gonet.IPNet{
+					IP:   gonet.ParseIP("2001:db8::5"),
+					Mask: gonet.CIDRMask(64, 128),
+				}
+			})
+
+			It("returns network with primary IPv6 address from associated interface", func() {
+				network, err := resolver.GetDefaultNetwork()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ipResolver.GetPrimaryIPv6InterfaceName).To(Equal("eth0"))
+				Expect(network).To(Equal(boshsettings.Network{
+					IP:      "2001:db8::5",
+					Netmask: "64",
+					Gateway: "2001:db8::1",
+				}))
+			})
+
+			It("prefers IPv4 default route", func() {
+				routesSearcher.SearchRoutesRoutes = append(routesSearcher.SearchRoutesRoutes, Route{
+					Destination: "0.0.0.0", Gateway: "fake-gateway", InterfaceName: "eth1",
+				})
+				ipResolver.GetPrimaryIPv4IPNet = &gonet.IPNet{
+					IP:   gonet.ParseIP("127.0.0.1"),
+					Mask: gonet.CIDRMask(16, 32),
+				}
+
+				network, err := resolver.GetDefaultNetwork()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(network.IP).To(Equal("127.0.0.1"))
+			})
+
+			It("returns error when primary IPv6 does not exist", func() {
+				ipResolver.GetPrimaryIPv6Err = errors.New("fake-get-primary-ipv6-err")
+
+				_, err := resolver.GetDefaultNetwork()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-primary-ipv6-err"))
+			})
+		})
+
 		Context("when default route is not found", func() {
 			BeforeEach(func() {
 				routesSearcher.SearchRoutesRoutes = []Route{
@@ -0,0 +1,324 @@
+package net
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/pivotal-golang/clock"
+)
+
+const (
+	// resolv.conf generated by resolvconf on Ubuntu from base written by net manager
+	resolvconfResolvConfPath = "/run/resolvconf/resolv.conf"
+	resolvconfBasePath       = "/etc/resolvconf/resolv.conf.d/base"
+)
+
+type dnsHealthChecker struct {
+	fs              boshsys.FileSystem
+	cmdRunner       boshsys.CmdRunner
+	prober          DNSProber
+	resolvConfPath  string
+	localDNSAddress string
+	timeService     clock.Clock
+
+	lastResults     []DNSServerHealth
+	lastResultsLock sync.RWMutex
+
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewDNSHealthChecker returns checker that moves unhealthy nameservers
+// to the end of resolv.conf so that resolution does not wait for them;
+// unhealthy nameservers are kept so that they are checked again.
+// Nameservers are reordered in configuration resolv.conf is generated from
+// (resolvconf base, systemd-resolved DNS=) when resolv.conf links to it.
+// Local DNS address (if any) is always kept first and is not probed
+func NewDNSHealthChecker(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	prober DNSProber,
+	resolvConfPath string,
+	localDNSAddress string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) DNSHealthChecker {
+	return &dnsHealthChecker{
+		fs:              fs,
+		cmdRunner:       cmdRunner,
+		prober:          prober,
+		resolvConfPath:  resolvConfPath,
+		localDNSAddress: localDNSAddress,
+		timeService:     timeService,
+		logger:          logger,
+		logTag:          "dnsHealthChecker",
+	}
+}
+
+func (c *dnsHealthChecker) Start(interval time.Duration) {
+	ticker := c.timeService.NewTicker(interval)
+
+	go func() {
+		for range ticker.C() {
+			c.CheckAll()
+		}
+	}()
+}
+
+func (c *dnsHealthChecker) CheckAll() []DNSServerHealth {
+	resolvConfPath := c.resolvConfPath
+
+	// Symlinked resolv.conf is generated from configuration of net manager
+	if targetPath, err := c.fs.Readlink(c.resolvConfPath); err == nil {
+		resolvConfPath = targetPath
+	}
+
+	resolvConf, err := c.fs.ReadFileString(resolvConfPath)
+	if err != nil {
+		c.logger.Error(c.logTag, "Failed to read `%s': %s", resolvConfPath, err)
+		return nil
+	}
+
+	configuredServers := resolvConfNameservers(resolvConf)
+
+	// Servers are checked in the order they were configured in
+	// and not in the order of previous reordering
+	servers := c.configuredServers(c.probedServers(configuredServers))
+
+	var results []DNSServerHealth
+
+	for _, server := range servers {
+		result := DNSServerHealth{Server: server, Healthy: true}
+
+		err := c.prober.Probe(server)
+		result.CheckedAt = c.timeService.Now()
+
+		if err != nil {
+			c.logger.Warn(c.logTag, "DNS server `%s' is unhealthy: %s", server, err)
+			result.Healthy = false
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	err = c.reorderNameservers(resolvConfPath, c.orderedServers(configuredServers, results))
+	if err != nil {
+		c.logger.Error(c.logTag, "Failed to reorder nameservers of `%s': %s", resolvConfPath, err)
+	}
+
+	c.lastResultsLock.Lock()
+	c.lastResults = results
+	c.lastResultsLock.Unlock()
+
+	return results
+}
+
+func (c *dnsHealthChecker) LastResults() []DNSServerHealth {
+	c.lastResultsLock.RLock()
+	defer c.lastResultsLock.RUnlock()
+
+	return append([]DNSServerHealth(nil), c.lastResults...)
+}
+
+// configuredServers restores order of servers reordered by previous check
+// unless resolv.conf was rewritten with other servers since then
+func (c *dnsHealthChecker) configuredServers(servers []string) []string {
+	c.lastResultsLock.RLock()
+	defer c.lastResultsLock.RUnlock()
+
+	if len(c.lastResults) != len(servers) {
+		return servers
+	}
+
+	var previousServers []string
+
+	for _, result := range c.lastResults {
+		if !stringsContain(servers, result.Server) {
+			return servers
+		}
+		previousServers = append(previousServers, result.Server)
+	}
+
+	return previousServers
+}
+
+// probedServers excludes local DNS server which is managed by the agent
+func (c *dnsHealthChecker) probedServers(servers []string) []string {
+	var probedServers []string
+
+	for _, server := range servers {
+		if server != c.localDNSAddress {
+			probedServers = append(probedServers, server)
+		}
+	}
+
+	return probedServers
+}
+
+// orderedServers puts healthy nameservers in front of unhealthy ones
+// keeping relative order within each group; local DNS server stays first
+func (c *dnsHealthChecker) orderedServers(configuredServers []string, results []DNSServerHealth) []string {
+	var ordered, unhealthy []string
+
+	if c.localDNSAddress != "" && stringsContain(configuredServers, c.localDNSAddress) {
+		ordered = append(ordered, c.localDNSAddress)
+	}
+
+	for _, result := range results {
+		if result.Healthy {
+			ordered = append(ordered, result.Server)
+		} else {
+			unhealthy = append(unhealthy, result.Server)
+		}
+	}
+
+	return append(ordered, unhealthy...)
+}
+
+// reorderNameservers applies order in configuration that resolv.conf
+// is generated from since changes to generated resolv.conf are overwritten
+func (c *dnsHealthChecker) reorderNameservers(resolvConfPath string, ordered []string) error {
+	switch resolvConfPath {
+	case resolvconfResolvConfPath:
+		changed, err := c.reorderFile(resolvconfBasePath, ordered, reorderNameserverLines)
+		if err != nil || !changed {
+			return err
+		}
+
+		_, _, _, err = c.cmdRunner.RunCommand("resolvconf", "-u")
+		if err != nil {
+			return bosherr.WrapError(err, "Updating resolvconf")
+		}
+
+	case networkdResolvConfPath:
+		changed, err := c.reorderFile(networkdResolvedConfPath, ordered, reorderResolvedDNS)
+		if err != nil || !changed {
+			return err
+		}
+
+		_, _, _, err = c.cmdRunner.RunCommand("systemctl", "restart", "systemd-resolved")
+		if err != nil {
+			return bosherr.WrapError(err, "Restarting systemd-resolved")
+		}
+
+	default:
+		_, err := c.reorderFile(resolvConfPath, ordered, reorderNameserverLines)
+		return err
+	}
+
+	return nil
+}
+
+func (c *dnsHealthChecker) reorderFile(filePath string, ordered []string, reorder func(string, []string) string) (bool, error) {
+	contents, err := c.fs.ReadFileString(filePath)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Reading `%s'", filePath)
+	}
+
+	reordered := reorder(contents, ordered)
+	if reordered == contents {
+		return false, nil
+	}
+
+	c.logger.Info(c.logTag, "Reordering nameservers in `%s': %s", filePath, strings.Join(ordered, ", "))
+
+	err = c.fs.WriteFileString(filePath, reordered)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Writing `%s'", filePath)
+	}
+
+	return true, nil
+}
+
+// reorderNameserverLines rewrites nameserver lines in given order
+// keeping all other lines and nameservers which are not ordered
+func reorderNameserverLines(contents string, ordered []string) string {
+	ordered = presentServers(ordered, resolvConfNameservers(contents))
+
+	var lines []string
+	i := 0
+
+	for _, line := range strings.Split(contents, "\n") {
+		if server, ok := nameserverLine(line); ok && i < len(ordered) && stringsContain(ordered, server) {
+			line = "nameserver " + ordered[i]
+			i++
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// reorderResolvedDNS rewrites DNS= line of systemd-resolved configuration
+// in given order keeping servers which are not ordered
+func reorderResolvedDNS(contents string, ordered []string) string {
+	lines := strings.Split(contents, "\n")
+
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "DNS=") {
+			continue
+		}
+
+		servers := strings.Fields(strings.TrimPrefix(line, "DNS="))
+		serversOrdered := presentServers(ordered, servers)
+
+		j := 0
+		for k, server := range servers {
+			if j < len(serversOrdered) && stringsContain(serversOrdered, server) {
+				servers[k] = serversOrdered[j]
+				j++
+			}
+		}
+
+		lines[i] = "DNS=" + strings.Join(servers, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// presentServers returns ordered servers which are also in servers
+func presentServers(ordered []string, servers []string) []string {
+	var present []string
+
+	for _, server := range ordered {
+		if stringsContain(servers, server) {
+			present = append(present, server)
+		}
+	}
+
+	return present
+}
+
+func resolvConfNameservers(resolvConf string) []string {
+	var servers []string
+
+	for _, line := range strings.Split(resolvConf, "\n") {
+		if server, ok := nameserverLine(line); ok {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers
+}
+
+func nameserverLine(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "nameserver" {
+		return "", false
+	}
+	return fields[1], true
+}
+
+func stringsContain(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package net
+
+import (
+	"time"
+)
+
+type DNSServerHealth struct {
+	Server    string    `json:"server"`
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type DNSProber interface {
+	// Probe returns error when DNS server does not answer queries
+	Probe(server string) error
+}
+
+type DNSHealthChecker interface {
+	// Start periodically checks DNS servers in the background
+	Start(interval time.Duration)
+
+	CheckAll() []DNSServerHealth
+
+	// LastResults returns results of the most recent check
+	// in the order DNS servers were configured in
+	LastResults() []DNSServerHealth
+}
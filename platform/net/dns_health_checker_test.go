@@ -0,0 +1,195 @@
+package net_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("DNSHealthChecker", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		cmdRunner *fakesys.FakeCmdRunner
+		prober    *fakenet.FakeDNSProber
+		clock     *fakeclock.FakeClock
+		checker   DNSHealthChecker
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		prober = &fakenet.FakeDNSProber{ProbeErrs: map[string]error{}}
+		clock = fakeclock.NewFakeClock(time.Now())
+		checker = NewDNSHealthChecker(fs, cmdRunner, prober, "/etc/resolv.conf", "", clock, boshlog.NewLogger(boshlog.LevelNone))
+
+		err := fs.WriteFileString("/etc/resolv.conf", "# Generated by bosh-agent\nnameserver 10.0.0.1\nnameserver 10.0.0.2\nnameserver 10.0.0.3\nsearch example.com\n")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("CheckAll", func() {
+		It("probes each nameserver", func() {
+			results := checker.CheckAll()
+
+			Expect(prober.ProbeServers).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}))
+			Expect(results).To(Equal([]DNSServerHealth{
+				{Server: "10.0.0.1", Healthy: true, CheckedAt: clock.Now()},
+				{Server: "10.0.0.2", Healthy: true, CheckedAt: clock.Now()},
+				{Server: "10.0.0.3", Healthy: true, CheckedAt: clock.Now()},
+			}))
+			Expect(checker.LastResults()).To(Equal(results))
+		})
+
+		It("does not rewrite resolv.conf when all nameservers are healthy", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			results := checker.CheckAll()
+			Expect(results).To(HaveLen(3))
+		})
+
+		It("moves unhealthy nameservers to the end of resolv.conf", func() {
+			prober.ProbeErrs["10.0.0.1"] = errors.New("fake-probe-err")
+
+			results := checker.CheckAll()
+			Expect(results[0]).To(Equal(DNSServerHealth{
+				Server:    "10.0.0.1",
+				Healthy:   false,
+				CheckedAt: clock.Now(),
+				Error:     "fake-probe-err",
+			}))
+
+			resolvConf, err := fs.ReadFileString("/etc/resolv.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resolvConf).To(Equal("# Generated by bosh-agent\nnameserver 10.0.0.2\nnameserver 10.0.0.3\nnameserver 10.0.0.1\nsearch example.com\n"))
+		})
+
+		It("restores configured order when nameservers recover", func() {
+			prober.ProbeErrs["10.0.0.1"] = errors.New("fake-probe-err")
+			checker.CheckAll()
+
+			delete(prober.ProbeErrs, "10.0.0.1")
+			results := checker.CheckAll()
+
+			Expect(results[0].Server).To(Equal("10.0.0.1"))
+
+			resolvConf, err := fs.ReadFileString("/etc/resolv.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resolvConf).To(Equal("# Generated by bosh-agent\nnameserver 10.0.0.1\nnameserver 10.0.0.2\nnameserver 10.0.0.3\nsearch example.com\n"))
+		})
+
+		It("uses order of resolv.conf when it was rewritten with other nameservers", func() {
+			prober.ProbeErrs["10.0.0.1"] = errors.New("fake-probe-err")
+			checker.CheckAll()
+
+			err := fs.WriteFileString("/etc/resolv.conf", "nameserver 10.0.0.4\nnameserver 10.0.0.1\n")
+			Expect(err).ToNot(HaveOccurred())
+
+			results := checker.CheckAll()
+			Expect(results[0].Server).To(Equal("10.0.0.4"))
+			Expect(results[1].Server).To(Equal("10.0.0.1"))
+		})
+
+		Context("when resolv.conf is generated by resolvconf", func() {
+			BeforeEach(func() {
+				err := fs.Symlink("/run/resolvconf/resolv.conf", "/etc/resolv.conf")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = fs.WriteFileString("/run/resolvconf/resolv.conf", "nameserver 10.0.0.1\nnameserver 10.0.0.2\nnameserver 10.0.0.5\n")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = fs.WriteFileString("/etc/resolvconf/resolv.conf.d/base", "# Generated by bosh-agent\nnameserver 10.0.0.1\nnameserver 10.0.0.2\n")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("reorders nameservers in resolvconf base and updates resolv.conf", func() {
+				prober.ProbeErrs["10.0.0.1"] = errors.New("fake-probe-err")
+
+				results := checker.CheckAll()
+				Expect(results).To(HaveLen(3))
+
+				base, err := fs.ReadFileString("/etc/resolvconf/resolv.conf.d/base")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(base).To(Equal("# Generated by bosh-agent\nnameserver 10.0.0.2\nnameserver 10.0.0.1\n"))
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{{"resolvconf", "-u"}}))
+			})
+
+			It("does not update resolv.conf when order of base does not change", func() {
+				prober.ProbeErrs["10.0.0.5"] = errors.New("fake-probe-err")
+
+				checker.CheckAll()
+				Expect(cmdRunner.RunCommands).To(BeEmpty())
+			})
+		})
+
+		Context("when resolv.conf is generated by systemd-resolved", func() {
+			BeforeEach(func() {
+				err := fs.Symlink("/run/systemd/resolve/resolv.conf", "/etc/resolv.conf")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = fs.WriteFileString("/run/systemd/resolve/resolv.conf", "nameserver 10.0.0.1\nnameserver 10.0.0.2\n")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = fs.WriteFileString("/etc/systemd/resolved.conf.d/bosh.conf", "# Generated by bosh-agent\n[Resolve]\nDNS=10.0.0.1 10.0.0.2\n")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("reorders DNS servers of systemd-resolved and restarts it", func() {
+				prober.ProbeErrs["10.0.0.1"] = errors.New("fake-probe-err")
+
+				checker.CheckAll()
+
+				resolvedConf, err := fs.ReadFileString("/etc/systemd/resolved.conf.d/bosh.conf")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resolvedConf).To(Equal("# Generated by bosh-agent\n[Resolve]\nDNS=10.0.0.2 10.0.0.1\n"))
+
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "systemd-resolved"}}))
+			})
+		})
+
+		Context("when local DNS address is configured", func() {
+			BeforeEach(func() {
+				checker = NewDNSHealthChecker(fs, cmdRunner, prober, "/etc/resolv.conf", "127.0.0.53", clock, boshlog.NewLogger(boshlog.LevelNone))
+
+				err := fs.WriteFileString("/etc/resolv.conf", "nameserver 127.0.0.53\nnameserver 10.0.0.1\nnameserver 10.0.0.2\n")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("does not probe local DNS server and keeps it first", func() {
+				prober.ProbeErrs["10.0.0.1"] = errors.New("fake-probe-err")
+
+				results := checker.CheckAll()
+				Expect(prober.ProbeServers).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+				Expect(results).To(HaveLen(2))
+
+				resolvConf, err := fs.ReadFileString("/etc/resolv.conf")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resolvConf).To(Equal("nameserver 127.0.0.53\nnameserver 10.0.0.2\nnameserver 10.0.0.1\n"))
+			})
+		})
+
+		It("returns no results when resolv.conf cannot be read", func() {
+			fs.RegisterReadFileError("/etc/resolv.conf", errors.New("fake-read-err"))
+
+			Expect(checker.CheckAll()).To(BeEmpty())
+			Expect(prober.ProbeServers).To(BeEmpty())
+		})
+	})
+
+	Describe("Start", func() {
+		It("checks nameservers periodically", func() {
+			checker.Start(time.Minute)
+
+			clock.WaitForWatcherAndIncrement(time.Minute)
+
+			Eventually(func() int { return len(checker.LastResults()) }).Should(Equal(3))
+		})
+	})
+})
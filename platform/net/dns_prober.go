@@ -0,0 +1,43 @@
+package net
+
+import (
+	gonet "net"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/localdns"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Queried name does not exist so that answers are never cached
+// and servers answer without recursing to other servers
+const dnsProbeName = "bosh-agent-health-check.invalid."
+
+type queryDNSProber struct {
+	timeout time.Duration
+}
+
+// NewQueryDNSProber returns prober that sends queries directly to DNS servers
+// instead of resolving through resolv.conf
+func NewQueryDNSProber(timeout time.Duration) DNSProber {
+	return queryDNSProber{timeout: timeout}
+}
+
+func (p queryDNSProber) Probe(server string) error {
+	query, err := localdns.BuildQuery(dnsProbeName, localdns.TypeA)
+	if err != nil {
+		return err
+	}
+
+	response, err := localdns.Exchange("udp", gonet.JoinHostPort(server, "53"), query, p.timeout)
+	if err != nil {
+		return err
+	}
+
+	// Server which answers that name does not exist is healthy
+	switch rcode := localdns.Rcode(response); rcode {
+	case localdns.RcodeSuccess, localdns.RcodeNameError:
+		return nil
+	default:
+		return bosherr.Errorf("DNS server responded with error code %d", rcode)
+	}
+}
@@ -0,0 +1,36 @@
+package net
+
+import (
+	gonet "net"
+)
+
+// ipv4DNSServers filters out IPv6 DNS servers
+// since dhclient only accepts IPv4 addresses for domain-name-servers
+func ipv4DNSServers(dnsServers []string) []string {
+	servers := []string{}
+
+	for _, dnsServer := range dnsServers {
+		if !isIPv6Address(dnsServer) {
+			servers = append(servers, dnsServer)
+		}
+	}
+
+	return servers
+}
+
+func ipv6DNSServers(dnsServers []string) []string {
+	servers := []string{}
+
+	for _, dnsServer := range dnsServers {
+		if isIPv6Address(dnsServer) {
+			servers = append(servers, dnsServer)
+		}
+	}
+
+	return servers
+}
+
+func isIPv6Address(address string) bool {
+	ip := gonet.ParseIP(address)
+	return ip != nil && ip.To4() == nil
+}
@@ -1,6 +1,7 @@
 package net
 
 import (
+	gonet "net"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -35,7 +36,30 @@ func (d *dnsValidator) Validate(dnsServers []string) error {
 		if strings.Contains(resolvConfContents, dnsServer) {
 			return nil
 		}
+
+		if isIPv6Address(dnsServer) && d.hasIPv6Nameserver(resolvConfContents, dnsServer) {
+			return nil
+		}
 	}
 
 	return bosherr.WrapError(err, "None of the DNS servers that were specified in the manifest were found in /etc/resolv.conf.")
 }
+
+// hasIPv6Nameserver compares parsed addresses since IPv6 addresses
+// have multiple textual representations (e.g. 2001:db8::1 and 2001:db8:0::1)
+func (d *dnsValidator) hasIPv6Nameserver(resolvConfContents, dnsServer string) bool {
+	dnsServerIP := gonet.ParseIP(dnsServer)
+
+	for _, line := range strings.Split(resolvConfContents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		if dnsServerIP.Equal(gonet.ParseIP(fields[1])) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -33,6 +33,18 @@ var _ = Describe("DNSValidator", func() {
 		})
 	})
 
+	Context("when /etc/resolv.conf contains IPv6 dns server written differently", func() {
+		BeforeEach(func() {
+			fs.WriteFileString("/etc/resolv.conf", `
+				nameserver 2001:4860:4860:0:0:0:0:8888`)
+		})
+
+		It("returns nil", func() {
+			err := dnsValidator.Validate([]string{"2001:4860:4860::8888"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	Context("when reading /etc/resolv.conf failed", func() {
 		It("returns error", func() {
 			err := dnsValidator.Validate([]string{"8.8.8.8", "9.9.9.9"})
@@ -0,0 +1,37 @@
+package fakes
+
+import (
+	"time"
+
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+)
+
+type FakeDNSProber struct {
+	ProbeServers []string
+	ProbeErrs    map[string]error
+}
+
+func (p *FakeDNSProber) Probe(server string) error {
+	p.ProbeServers = append(p.ProbeServers, server)
+	return p.ProbeErrs[server]
+}
+
+type FakeDNSHealthChecker struct {
+	StartInterval time.Duration
+
+	CheckAllResults []boshnet.DNSServerHealth
+
+	LastResultsResults []boshnet.DNSServerHealth
+}
+
+func (c *FakeDNSHealthChecker) Start(interval time.Duration) {
+	c.StartInterval = interval
+}
+
+func (c *FakeDNSHealthChecker) CheckAll() []boshnet.DNSServerHealth {
+	return c.CheckAllResults
+}
+
+func (c *FakeDNSHealthChecker) LastResults() []boshnet.DNSServerHealth {
+	return c.LastResultsResults
+}
@@ -1,6 +1,7 @@
 package fakes
 
 import (
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 )
 
@@ -9,6 +10,12 @@ type FakeManager struct {
 
 	SetupNetworkingNetworks boshsettings.Networks
 	SetupNetworkingErr      error
+	SetupNetworkingStub     func(boshsettings.Networks) error
+	SetupNetworkingHistory  []boshsettings.Networks
+
+	PlanNetworkingNetworks boshsettings.Networks
+	PlanNetworkingPlan     boshnet.NetworkPlan
+	PlanNetworkingErr      error
 
 	GetConfiguredNetworkInterfacesInterfaces []string
 	GetConfiguredNetworkInterfacesErr        error
@@ -19,9 +26,18 @@ type FakeManager struct {
 
 func (net *FakeManager) SetupNetworking(networks boshsettings.Networks, errCh chan error) error {
 	net.SetupNetworkingNetworks = networks
+	net.SetupNetworkingHistory = append(net.SetupNetworkingHistory, networks)
+	if net.SetupNetworkingStub != nil {
+		return net.SetupNetworkingStub(networks)
+	}
 	return net.SetupNetworkingErr
 }
 
+func (net *FakeManager) PlanNetworking(networks boshsettings.Networks) (boshnet.NetworkPlan, error) {
+	net.PlanNetworkingNetworks = networks
+	return net.PlanNetworkingPlan, net.PlanNetworkingErr
+}
+
 func (net *FakeManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 	return net.GetConfiguredNetworkInterfacesInterfaces, net.GetConfiguredNetworkInterfacesErr
 }
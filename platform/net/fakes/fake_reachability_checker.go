@@ -0,0 +1,27 @@
+package fakes
+
+type FakeReachabilityChecker struct {
+	CheckTargets []string
+
+	// Errors are returned in order; the last one is returned for all further checks
+	CheckErrs map[string][]error
+}
+
+func NewFakeReachabilityChecker() *FakeReachabilityChecker {
+	return &FakeReachabilityChecker{CheckErrs: map[string][]error{}}
+}
+
+func (c *FakeReachabilityChecker) Check(target string) error {
+	c.CheckTargets = append(c.CheckTargets, target)
+
+	errs := c.CheckErrs[target]
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if len(errs) > 1 {
+		c.CheckErrs[target] = errs[1:]
+	}
+
+	return errs[0]
+}
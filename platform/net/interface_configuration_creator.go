@@ -1,12 +1,127 @@
 package net
 
 import (
+	"fmt"
+	gonet "net"
+	"sort"
+	"strconv"
+	"strings"
+
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+// AddressConfiguration is an address configured on an interface
+// in addition to its primary IPv4 address
+type AddressConfiguration struct {
+	Address      string
+	PrefixLength int
+}
+
+func (c AddressConfiguration) String() string {
+	return fmt.Sprintf("%s/%d", c.Address, c.PrefixLength)
+}
+
+// Netmask returns dotted netmask of an IPv4 address
+func (c AddressConfiguration) Netmask() string {
+	return gonet.IP(gonet.CIDRMask(c.PrefixLength, 32)).String()
+}
+
+// RouteConfiguration is a route via an interface;
+// Table is 0 for routes in the main routing table
+type RouteConfiguration struct {
+	Destination string // CIDR
+	Gateway     string // empty for directly connected destinations
+	Metric      int
+	Table       int
+}
+
+func (c RouteConfiguration) IsIPv6() bool {
+	return strings.Contains(c.Destination, ":")
+}
+
+// IPRouteSpec returns route in 'ip route' command format
+func (c RouteConfiguration) IPRouteSpec(ifaceName string) string {
+	spec := c.Destination
+
+	if c.Gateway != "" {
+		spec += " via " + c.Gateway
+	}
+
+	spec += " dev " + ifaceName
+
+	if c.Metric > 0 {
+		spec += fmt.Sprintf(" metric %d", c.Metric)
+	}
+
+	if c.Table > 0 {
+		spec += fmt.Sprintf(" table %d", c.Table)
+	}
+
+	return spec
+}
+
+// PolicyRuleConfiguration selects routing table
+// for packets sent from given address
+type PolicyRuleConfiguration struct {
+	From  string
+	Table int
+}
+
+func (c PolicyRuleConfiguration) IsIPv6() bool {
+	return strings.Contains(c.From, ":")
+}
+
+// IPRuleSpec returns rule in 'ip rule' command format
+func (c PolicyRuleConfiguration) IPRuleSpec() string {
+	return fmt.Sprintf("from %s table %d", c.From, c.Table)
+}
+
+type LinkKind string
+
+const (
+	LinkKindBond   LinkKind = "bond"
+	LinkKindVLAN   LinkKind = "vlan"
+	LinkKindBridge LinkKind = "bridge"
+)
+
+// LinkConfiguration is a virtual interface built on top of Lower interfaces:
+// interfaces aggregated by a bond, parent interface of a VLAN
+// or ports of a bridge
+type LinkConfiguration struct {
+	Name  string
+	Kind  LinkKind
+	Lower []string
+
+	BondMode   string
+	BondMIIMon int
+
+	VLANID int
+}
+
+// OffloadConfiguration enables or disables
+// offload feature known by its ethtool name
+type OffloadConfiguration struct {
+	Feature string
+	Enabled bool
+}
+
+// State returns feature state in ethtool format
+func (c OffloadConfiguration) State() string {
+	if c.Enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// offloadFeatures are ethtool names of offload features that can be toggled
+var offloadFeatures = map[string]bool{
+	"rx": true, "tx": true, "sg": true, "tso": true, "ufo": true, "gso": true,
+	"gro": true, "lro": true, "rxvlan": true, "txvlan": true, "ntuple": true, "rxhash": true,
+}
+
 type StaticInterfaceConfiguration struct {
 	Name                string
 	Address             string
@@ -16,6 +131,50 @@ type StaticInterfaceConfiguration struct {
 	IsDefaultForGateway bool
 	Mac                 string
 	Gateway             string
+
+	// Additional IPv4 addresses
+	Aliases []AddressConfiguration
+
+	IPv6Addresses []AddressConfiguration
+	IPv6Gateway   string
+
+	// Used only when there are no static IPv6 addresses
+	IPv6Mode boshsettings.NetworkIPv6Mode
+
+	Routes      []RouteConfiguration
+	PolicyRules []PolicyRuleConfiguration
+
+	// Links the interface is built on in the order they have to be created;
+	// last link is the interface itself
+	Links []LinkConfiguration
+
+	MTU      int
+	Offloads []OffloadConfiguration
+}
+
+// PrefixLength returns prefix length of primary IPv4 address
+func (c StaticInterfaceConfiguration) PrefixLength() int {
+	prefixLength, _ := gonet.IPMask(gonet.ParseIP(c.Netmask).To4()).Size()
+	return prefixLength
+}
+
+// IPAddresses returns primary IPv4 address, aliases and IPv6 addresses
+func (c StaticInterfaceConfiguration) IPAddresses() []string {
+	var addresses []string
+
+	if c.Address != "" {
+		addresses = append(addresses, c.Address)
+	}
+
+	for _, alias := range c.Aliases {
+		addresses = append(addresses, alias.Address)
+	}
+
+	for _, address := range c.IPv6Addresses {
+		addresses = append(addresses, address.Address)
+	}
+
+	return addresses
 }
 
 type StaticInterfaceConfigurations []StaticInterfaceConfiguration
@@ -33,7 +192,12 @@ func (configs StaticInterfaceConfigurations) Swap(i, j int) {
 }
 
 type DHCPInterfaceConfiguration struct {
-	Name string
+	Name     string
+	IPv6Mode boshsettings.NetworkIPv6Mode
+	Routes   []RouteConfiguration
+	Links    []LinkConfiguration
+	MTU      int
+	Offloads []OffloadConfiguration
 }
 
 type DHCPInterfaceConfigurations []DHCPInterfaceConfiguration
@@ -66,36 +230,300 @@ func NewInterfaceConfigurationCreator(logger boshlog.Logger) InterfaceConfigurat
 	}
 }
 
-func (creator interfaceConfigurationCreator) createInterfaceConfiguration(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration, ifaceName string, networkSettings boshsettings.Network) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+func (creator interfaceConfigurationCreator) createInterfaceConfiguration(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration, ifaceName string, networkSettings boshsettings.Network, links []LinkConfiguration) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
 	creator.logger.Debug(creator.logTag, "Creating network configuration with settings: %s", networkSettings)
 
+	offloads, err := creator.createOffloads(networkSettings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = validateMTU(networkSettings)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if networkSettings.IsDHCP() || networkSettings.Mac == "" {
 		creator.logger.Debug(creator.logTag, "Using dhcp networking")
+
+		routes, err := creator.createRoutes(networkSettings)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if networkSettings.RoutingTable > 0 {
+			creator.logger.Warn(creator.logTag, "Ignoring routing table of interface '%s' since its addresses are obtained via DHCP", ifaceName)
+		}
+
 		dhcpConfigs = append(dhcpConfigs, DHCPInterfaceConfiguration{
-			Name: ifaceName,
+			Name:     ifaceName,
+			IPv6Mode: networkSettings.IPv6Mode,
+			Routes:   routes,
+			Links:    links,
+			MTU:      networkSettings.MTU,
+			Offloads: offloads,
 		})
 	} else {
 		creator.logger.Debug(creator.logTag, "Using static networking")
-		networkAddress, broadcastAddress, err := boshsys.CalculateNetworkAndBroadcast(networkSettings.IP, networkSettings.Netmask)
+		staticConfig, err := creator.createStaticInterfaceConfiguration(ifaceName, networkSettings)
 		if err != nil {
-			return nil, nil, bosherr.WrapError(err, "Calculating Network and Broadcast")
-		}
-
-		staticConfigs = append(staticConfigs, StaticInterfaceConfiguration{
-			Name:                ifaceName,
-			Address:             networkSettings.IP,
-			Netmask:             networkSettings.Netmask,
-			Network:             networkAddress,
-			IsDefaultForGateway: networkSettings.IsDefaultFor("gateway"),
-			Broadcast:           broadcastAddress,
-			Mac:                 networkSettings.Mac,
-			Gateway:             networkSettings.Gateway,
-		})
+			return nil, nil, err
+		}
+
+		staticConfig.Links = links
+		staticConfig.MTU = networkSettings.MTU
+		staticConfig.Offloads = offloads
+		staticConfigs = append(staticConfigs, staticConfig)
 	}
 	return staticConfigs, dhcpConfigs, nil
 }
 
+// Minimum MTUs every link has to support for IPv4 (RFC 791) and IPv6 (RFC 8200)
+const (
+	minIPv4MTU = 68
+	minIPv6MTU = 1280
+)
+
+func validateMTU(networkSettings boshsettings.Network) error {
+	if networkSettings.MTU == 0 {
+		return nil
+	}
+
+	minMTU := minIPv4MTU
+	if usesIPv6(networkSettings) {
+		minMTU = minIPv6MTU
+	}
+
+	if networkSettings.MTU < minMTU {
+		return bosherr.Errorf("Invalid MTU '%d', must be at least %d", networkSettings.MTU, minMTU)
+	}
+
+	return nil
+}
+
+func usesIPv6(networkSettings boshsettings.Network) bool {
+	if networkSettings.IsIPv6() || networkSettings.IPv6Mode != "" {
+		return true
+	}
+
+	for _, address := range networkSettings.Addresses {
+		if address.IsIPv6() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (creator interfaceConfigurationCreator) createStaticInterfaceConfiguration(ifaceName string, networkSettings boshsettings.Network) (StaticInterfaceConfiguration, error) {
+	staticConfig := StaticInterfaceConfiguration{
+		Name:                ifaceName,
+		IsDefaultForGateway: networkSettings.IsDefaultFor("gateway"),
+		Mac:                 networkSettings.Mac,
+		IPv6Mode:            networkSettings.IPv6Mode,
+	}
+
+	addresses := networkSettings.Addresses
+
+	if networkSettings.IP != "" {
+		if networkSettings.IsIPv6() {
+			prefixLength, err := ipv6PrefixLength(networkSettings.Netmask)
+			if err != nil {
+				return staticConfig, bosherr.WrapErrorf(err, "Parsing netmask of '%s'", networkSettings.IP)
+			}
+
+			addresses = append([]boshsettings.NetworkAddress{{
+				IP:      networkSettings.IP,
+				Prefix:  prefixLength,
+				Gateway: networkSettings.Gateway,
+			}}, addresses...)
+		} else {
+			networkAddress, broadcastAddress, err := boshsys.CalculateNetworkAndBroadcast(networkSettings.IP, networkSettings.Netmask)
+			if err != nil {
+				return staticConfig, bosherr.WrapError(err, "Calculating Network and Broadcast")
+			}
+
+			staticConfig.Address = networkSettings.IP
+			staticConfig.Netmask = networkSettings.Netmask
+			staticConfig.Network = networkAddress
+			staticConfig.Broadcast = broadcastAddress
+			staticConfig.Gateway = networkSettings.Gateway
+		}
+	}
+
+	for _, address := range addresses {
+		ip := gonet.ParseIP(address.IP)
+		if ip == nil {
+			return staticConfig, bosherr.Errorf("Invalid network address '%s'", address.IP)
+		}
+
+		if address.IsIPv6() {
+			if address.Prefix < 1 || address.Prefix > 128 {
+				return staticConfig, bosherr.Errorf("Invalid prefix length '%d' of network address '%s'", address.Prefix, address.IP)
+			}
+
+			staticConfig.IPv6Addresses = append(staticConfig.IPv6Addresses, AddressConfiguration{address.IP, address.Prefix})
+			if staticConfig.IPv6Gateway == "" {
+				staticConfig.IPv6Gateway = address.Gateway
+			}
+			continue
+		}
+
+		if address.Prefix < 1 || address.Prefix > 32 {
+			return staticConfig, bosherr.Errorf("Invalid prefix length '%d' of network address '%s'", address.Prefix, address.IP)
+		}
+
+		// First IPv4 address becomes primary address of the interface
+		if staticConfig.Address == "" {
+			netmask := AddressConfiguration{address.IP, address.Prefix}.Netmask()
+
+			networkAddress, broadcastAddress, err := boshsys.CalculateNetworkAndBroadcast(address.IP, netmask)
+			if err != nil {
+				return staticConfig, bosherr.WrapError(err, "Calculating Network and Broadcast")
+			}
+
+			staticConfig.Address = address.IP
+			staticConfig.Netmask = netmask
+			staticConfig.Network = networkAddress
+			staticConfig.Broadcast = broadcastAddress
+			staticConfig.Gateway = address.Gateway
+			continue
+		}
+
+		staticConfig.Aliases = append(staticConfig.Aliases, AddressConfiguration{address.IP, address.Prefix})
+	}
+
+	routes, err := creator.createRoutes(networkSettings)
+	if err != nil {
+		return staticConfig, err
+	}
+
+	staticConfig.Routes = routes
+
+	if networkSettings.RoutingTable > 0 {
+		err = creator.addPolicyRouting(&staticConfig, networkSettings.RoutingTable)
+		if err != nil {
+			return staticConfig, err
+		}
+	}
+
+	return staticConfig, nil
+}
+
+func (creator interfaceConfigurationCreator) createRoutes(networkSettings boshsettings.Network) ([]RouteConfiguration, error) {
+	var routes []RouteConfiguration
+
+	for _, route := range networkSettings.Routes {
+		_, destination, err := gonet.ParseCIDR(route.Destination)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing destination of route '%s'", route.Destination)
+		}
+
+		if route.Gateway != "" && gonet.ParseIP(route.Gateway) == nil {
+			return nil, bosherr.Errorf("Invalid gateway '%s' of route '%s'", route.Gateway, route.Destination)
+		}
+
+		routes = append(routes, RouteConfiguration{
+			Destination: destination.String(),
+			Gateway:     route.Gateway,
+			Metric:      route.Metric,
+		})
+	}
+
+	return routes, nil
+}
+
+// createOffloads returns offload features ordered by name
+func (creator interfaceConfigurationCreator) createOffloads(networkSettings boshsettings.Network) ([]OffloadConfiguration, error) {
+	var features []string
+	for feature := range networkSettings.Offload {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	var offloads []OffloadConfiguration
+
+	for _, feature := range features {
+		if !offloadFeatures[feature] {
+			return nil, bosherr.Errorf("Unknown offload feature '%s'", feature)
+		}
+
+		offloads = append(offloads, OffloadConfiguration{Feature: feature, Enabled: networkSettings.Offload[feature]})
+	}
+
+	return offloads, nil
+}
+
+// addPolicyRouting routes packets sent from interface addresses
+// via interface gateway using separate routing table
+func (creator interfaceConfigurationCreator) addPolicyRouting(staticConfig *StaticInterfaceConfiguration, table int) error {
+	if staticConfig.Address != "" && staticConfig.Gateway != "" {
+		addresses := append([]AddressConfiguration{{staticConfig.Address, staticConfig.PrefixLength()}}, staticConfig.Aliases...)
+		creator.addPolicyRoutes(staticConfig, addresses, "0.0.0.0/0", staticConfig.Gateway, table)
+	}
+
+	if len(staticConfig.IPv6Addresses) > 0 && staticConfig.IPv6Gateway != "" {
+		creator.addPolicyRoutes(staticConfig, staticConfig.IPv6Addresses, "::/0", staticConfig.IPv6Gateway, table)
+	}
+
+	if len(staticConfig.PolicyRules) == 0 {
+		return bosherr.Errorf("Routing table '%d' of interface '%s' requires a gateway", table, staticConfig.Name)
+	}
+
+	return nil
+}
+
+func (creator interfaceConfigurationCreator) addPolicyRoutes(staticConfig *StaticInterfaceConfiguration, addresses []AddressConfiguration, defaultDestination, gateway string, table int) {
+	for _, address := range addresses {
+		_, network, _ := gonet.ParseCIDR(address.String())
+
+		staticConfig.Routes = append(staticConfig.Routes, RouteConfiguration{Destination: network.String(), Table: table})
+		staticConfig.PolicyRules = append(staticConfig.PolicyRules, PolicyRuleConfiguration{From: address.Address, Table: table})
+	}
+
+	staticConfig.Routes = append(staticConfig.Routes, RouteConfiguration{Destination: defaultDestination, Gateway: gateway, Table: table})
+}
+
+// ipv6PrefixLength accepts either prefix length (e.g. 64)
+// or IPv6 netmask (e.g. ffff:ffff:ffff:ffff::)
+func ipv6PrefixLength(netmask string) (int, error) {
+	prefixLength, err := strconv.Atoi(netmask)
+	if err == nil {
+		if prefixLength < 1 || prefixLength > 128 {
+			return 0, bosherr.Errorf("Invalid prefix length '%d'", prefixLength)
+		}
+		return prefixLength, nil
+	}
+
+	ip := gonet.ParseIP(netmask)
+	if ip == nil {
+		return 0, bosherr.Errorf("Invalid netmask '%s'", netmask)
+	}
+
+	prefixLength, bits := gonet.IPMask(ip.To16()).Size()
+	if bits == 0 {
+		return 0, bosherr.Errorf("Netmask '%s' is not canonical", netmask)
+	}
+
+	return prefixLength, nil
+}
+
 func (creator interfaceConfigurationCreator) CreateInterfaceConfigurations(networks boshsettings.Networks, interfacesByMAC map[string]string) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+	physicalNetworks := boshsettings.Networks{}
+	linkedNetworks := boshsettings.Networks{}
+
+	for name, network := range networks {
+		if network.HasLinks() {
+			linkedNetworks[name] = network
+		} else {
+			physicalNetworks[name] = network
+		}
+	}
+
+	if len(linkedNetworks) > 0 {
+		return creator.createLinkedInterfaceConfigurations(physicalNetworks, linkedNetworks, interfacesByMAC)
+	}
+
 	// In cases where we only have one network and it has no MAC address (either because the IAAS doesn't give us one or
 	// it's an old CPI), if we only have one interface, we should map them
 	if len(networks) == 1 && len(interfacesByMAC) == 1 {
@@ -103,7 +531,7 @@ func (creator interfaceConfigurationCreator) CreateInterfaceConfigurations(netwo
 		if networkSettings.Mac == "" {
 			var ifaceName string
 			networkSettings.Mac, ifaceName = creator.getFirstInterface(interfacesByMAC)
-			return creator.createInterfaceConfiguration([]StaticInterfaceConfiguration{}, []DHCPInterfaceConfiguration{}, ifaceName, networkSettings)
+			return creator.createInterfaceConfiguration([]StaticInterfaceConfiguration{}, []DHCPInterfaceConfiguration{}, ifaceName, networkSettings, nil)
 		}
 	}
 
@@ -132,7 +560,7 @@ func (creator interfaceConfigurationCreator) createMultipleInterfaceConfiguratio
 
 	for mac, ifaceName := range interfacesByMAC {
 		networkSettings, _ = networks.NetworkForMac(mac)
-		staticConfigs, dhcpConfigs, err = creator.createInterfaceConfiguration(staticConfigs, dhcpConfigs, ifaceName, networkSettings)
+		staticConfigs, dhcpConfigs, err = creator.createInterfaceConfiguration(staticConfigs, dhcpConfigs, ifaceName, networkSettings, nil)
 		if err != nil {
 			return nil, nil, bosherr.WrapError(err, "Creating interface configuration")
 		}
@@ -141,6 +569,132 @@ func (creator interfaceConfigurationCreator) createMultipleInterfaceConfiguratio
 	return staticConfigs, dhcpConfigs, nil
 }
 
+// createLinkedInterfaceConfigurations configures networks on bonds, VLANs and bridges;
+// interfaces they are built on are not configured as DHCP interfaces
+// unless they match MAC address of another network
+func (creator interfaceConfigurationCreator) createLinkedInterfaceConfigurations(physicalNetworks, linkedNetworks boshsettings.Networks, interfacesByMAC map[string]string) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+	staticConfigs := []StaticInterfaceConfiguration{}
+	dhcpConfigs := []DHCPInterfaceConfiguration{}
+	lowerMACs := map[string]bool{}
+
+	networkNames := []string{}
+	for name := range linkedNetworks {
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+
+	for _, name := range networkNames {
+		networkSettings := linkedNetworks[name]
+
+		links, ifaceName, macs, err := creator.createLinks(networkSettings, interfacesByMAC)
+		if err != nil {
+			return nil, nil, bosherr.WrapErrorf(err, "Creating links of network '%s'", name)
+		}
+
+		for _, mac := range macs {
+			lowerMACs[mac] = true
+		}
+
+		// Bond takes MAC address of its first member
+		if networkSettings.Mac == "" {
+			networkSettings.Mac = macs[0]
+		}
+
+		staticConfigs, dhcpConfigs, err = creator.createInterfaceConfiguration(staticConfigs, dhcpConfigs, ifaceName, networkSettings, links)
+		if err != nil {
+			return nil, nil, bosherr.WrapError(err, "Creating interface configuration")
+		}
+	}
+
+	remainingInterfacesByMAC := map[string]string{}
+	for mac, ifaceName := range interfacesByMAC {
+		if _, found := physicalNetworks.NetworkForMac(mac); found || !lowerMACs[mac] {
+			remainingInterfacesByMAC[mac] = ifaceName
+		}
+	}
+
+	physicalStaticConfigs, physicalDHCPConfigs, err := creator.createMultipleInterfaceConfigurations(physicalNetworks, remainingInterfacesByMAC)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(staticConfigs, physicalStaticConfigs...), append(dhcpConfigs, physicalDHCPConfigs...), nil
+}
+
+// createLinks returns links of a network in the order they have to be created,
+// name of the interface network addresses are configured on
+// and MAC addresses of physical interfaces links are built on
+func (creator interfaceConfigurationCreator) createLinks(networkSettings boshsettings.Network, interfacesByMAC map[string]string) ([]LinkConfiguration, string, []string, error) {
+	var links []LinkConfiguration
+	var ifaceName string
+	var macs []string
+
+	if bond := networkSettings.Bond; bond != nil {
+		if bond.Name == "" {
+			return nil, "", nil, bosherr.Error("Bond name must be specified")
+		}
+
+		if len(bond.Members) == 0 {
+			return nil, "", nil, bosherr.Errorf("Bond '%s' must have members", bond.Name)
+		}
+
+		var members []string
+		for _, mac := range bond.Members {
+			member, found := interfacesByMAC[mac]
+			if !found {
+				return nil, "", nil, bosherr.Errorf("No device found for bond '%s' member with MAC address '%s'", bond.Name, mac)
+			}
+
+			members = append(members, member)
+			macs = append(macs, mac)
+		}
+
+		links = append(links, LinkConfiguration{
+			Name:       bond.Name,
+			Kind:       LinkKindBond,
+			Lower:      members,
+			BondMode:   bond.Mode,
+			BondMIIMon: bond.MIIMon,
+		})
+		ifaceName = bond.Name
+	} else {
+		var found bool
+		ifaceName, found = interfacesByMAC[networkSettings.Mac]
+		if !found {
+			return nil, "", nil, bosherr.Errorf("No device found with MAC address '%s'", networkSettings.Mac)
+		}
+
+		macs = append(macs, networkSettings.Mac)
+	}
+
+	if networkSettings.VLAN > 0 {
+		if networkSettings.VLAN > 4094 {
+			return nil, "", nil, bosherr.Errorf("Invalid VLAN ID '%d'", networkSettings.VLAN)
+		}
+
+		vlanName := fmt.Sprintf("%s.%d", ifaceName, networkSettings.VLAN)
+
+		links = append(links, LinkConfiguration{
+			Name:   vlanName,
+			Kind:   LinkKindVLAN,
+			Lower:  []string{ifaceName},
+			VLANID: networkSettings.VLAN,
+		})
+		ifaceName = vlanName
+	}
+
+	if networkSettings.Bridge != "" {
+		links = append(links, LinkConfiguration{
+			Name:  networkSettings.Bridge,
+			Kind:  LinkKindBridge,
+			Lower: []string{ifaceName},
+		})
+		ifaceName = networkSettings.Bridge
+	}
+
+	return links, ifaceName, macs, nil
+}
+
 func (creator interfaceConfigurationCreator) getFirstNetwork(networks boshsettings.Networks) boshsettings.Network {
 	for networkName := range networks {
 		return networks[networkName]
@@ -258,6 +258,391 @@ func describeInterfaceConfigurationCreator() {
 		})
 	})
 
+	Describe("IPv6", func() {
+		var interfacesByMAC map[string]string
+
+		BeforeEach(func() {
+			interfacesByMAC = map[string]string{"fake-mac": "eth0"}
+		})
+
+		It("creates dual-stack configuration with aliases from addresses", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				Default: []string{"gateway"},
+				Mac:     "fake-mac",
+				Addresses: []boshsettings.NetworkAddress{
+					{IP: "10.0.0.5", Prefix: 24, Gateway: "10.0.0.1"},
+					{IP: "2001:db8::5", Prefix: 64, Gateway: "2001:db8::1"},
+					{IP: "10.0.1.5", Prefix: 16},
+					{IP: "2001:db8:1::5", Prefix: 48},
+				},
+				IPv6Mode: boshsettings.NetworkIPv6ModeSLAAC,
+			}
+
+			staticConfigs, dhcpConfigs, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dhcpConfigs).To(BeEmpty())
+			Expect(staticConfigs).To(Equal([]StaticInterfaceConfiguration{
+				{
+					Name:                "eth0",
+					Address:             "10.0.0.5",
+					Netmask:             "255.255.255.0",
+					Network:             "10.0.0.0",
+					Broadcast:           "10.0.0.255",
+					IsDefaultForGateway: true,
+					Mac:                 "fake-mac",
+					Gateway:             "10.0.0.1",
+					Aliases:             []AddressConfiguration{{"10.0.1.5", 16}},
+					IPv6Addresses: []AddressConfiguration{
+						{"2001:db8::5", 64},
+						{"2001:db8:1::5", 48},
+					},
+					IPv6Gateway: "2001:db8::1",
+					IPv6Mode:    boshsettings.NetworkIPv6ModeSLAAC,
+				},
+			}))
+		})
+
+		It("creates IPv6 only configuration when ip is IPv6 address", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "2001:db8::5",
+				Netmask: "ffff:ffff:ffff:ffff::",
+				Gateway: "2001:db8::1",
+				Mac:     "fake-mac",
+			}
+
+			staticConfigs, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staticConfigs).To(Equal([]StaticInterfaceConfiguration{
+				{
+					Name:          "eth0",
+					Mac:           "fake-mac",
+					IPv6Addresses: []AddressConfiguration{{"2001:db8::5", 64}},
+					IPv6Gateway:   "2001:db8::1",
+				},
+			}))
+		})
+
+		It("accepts prefix length as netmask of IPv6 address", func() {
+			network := boshsettings.Network{Type: "manual", IP: "2001:db8::5", Netmask: "96", Mac: "fake-mac"}
+
+			staticConfigs, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staticConfigs[0].IPv6Addresses).To(Equal([]AddressConfiguration{{"2001:db8::5", 96}}))
+		})
+
+		It("keeps IPv6 mode of dynamic networks", func() {
+			network := boshsettings.Network{Type: "dynamic", Mac: "fake-mac", IPv6Mode: boshsettings.NetworkIPv6ModeDHCPv6}
+
+			_, dhcpConfigs, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dhcpConfigs).To(Equal([]DHCPInterfaceConfiguration{
+				{Name: "eth0", IPv6Mode: boshsettings.NetworkIPv6ModeDHCPv6},
+			}))
+		})
+
+		It("returns error when prefix length is invalid", func() {
+			network := boshsettings.Network{
+				Type:      "manual",
+				Mac:       "fake-mac",
+				Addresses: []boshsettings.NetworkAddress{{IP: "2001:db8::5", Prefix: 129}},
+			}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid prefix length '129' of network address '2001:db8::5'"))
+		})
+	})
+
+	Describe("routes", func() {
+		var interfacesByMAC map[string]string
+
+		BeforeEach(func() {
+			interfacesByMAC = map[string]string{"fake-mac": "eth0"}
+		})
+
+		It("adds static routes in main routing table", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Gateway: "10.0.0.1",
+				Mac:     "fake-mac",
+				Routes: []boshsettings.NetworkRoute{
+					{Destination: "192.168.1.7/16", Gateway: "10.0.0.2", Metric: 10},
+					{Destination: "2001:db8:5::/48", Gateway: "2001:db8::2"},
+				},
+			}
+
+			staticConfigs, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staticConfigs[0].Routes).To(Equal([]RouteConfiguration{
+				{Destination: "192.168.0.0/16", Gateway: "10.0.0.2", Metric: 10},
+				{Destination: "2001:db8:5::/48", Gateway: "2001:db8::2"},
+			}))
+			Expect(staticConfigs[0].PolicyRules).To(BeEmpty())
+		})
+
+		It("adds static routes of dynamic networks", func() {
+			network := boshsettings.Network{
+				Type:   "dynamic",
+				Mac:    "fake-mac",
+				Routes: []boshsettings.NetworkRoute{{Destination: "192.168.0.0/16", Gateway: "10.0.0.2"}},
+			}
+
+			_, dhcpConfigs, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dhcpConfigs[0].Routes).To(Equal([]RouteConfiguration{
+				{Destination: "192.168.0.0/16", Gateway: "10.0.0.2"},
+			}))
+		})
+
+		It("adds routes and rules in separate routing table for policy routing", func() {
+			network := boshsettings.Network{
+				Type: "manual",
+				Mac:  "fake-mac",
+				Addresses: []boshsettings.NetworkAddress{
+					{IP: "10.0.0.5", Prefix: 24, Gateway: "10.0.0.1"},
+					{IP: "2001:db8::5", Prefix: 64, Gateway: "2001:db8::1"},
+				},
+				RoutingTable: 100,
+			}
+
+			staticConfigs, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staticConfigs[0].Routes).To(Equal([]RouteConfiguration{
+				{Destination: "10.0.0.0/24", Table: 100},
+				{Destination: "0.0.0.0/0", Gateway: "10.0.0.1", Table: 100},
+				{Destination: "2001:db8::/64", Table: 100},
+				{Destination: "::/0", Gateway: "2001:db8::1", Table: 100},
+			}))
+			Expect(staticConfigs[0].PolicyRules).To(Equal([]PolicyRuleConfiguration{
+				{From: "10.0.0.5", Table: 100},
+				{From: "2001:db8::5", Table: 100},
+			}))
+		})
+
+		It("returns error when network with routing table has no gateway", func() {
+			network := boshsettings.Network{
+				Type:         "manual",
+				IP:           "10.0.0.5",
+				Netmask:      "255.255.255.0",
+				Mac:          "fake-mac",
+				RoutingTable: 100,
+			}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Routing table '100' of interface 'eth0' requires a gateway"))
+		})
+
+		It("returns error when route destination is invalid", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Mac:     "fake-mac",
+				Routes:  []boshsettings.NetworkRoute{{Destination: "192.168.0.0"}},
+			}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing destination of route '192.168.0.0'"))
+		})
+
+		It("returns error when route gateway is invalid", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Mac:     "fake-mac",
+				Routes:  []boshsettings.NetworkRoute{{Destination: "192.168.0.0/16", Gateway: "not-ip"}},
+			}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid gateway 'not-ip' of route '192.168.0.0/16'"))
+		})
+	})
+
+	Describe("links", func() {
+		var interfacesByMAC map[string]string
+
+		BeforeEach(func() {
+			interfacesByMAC = map[string]string{
+				"fake-mac-1": "eth0",
+				"fake-mac-2": "eth1",
+				"fake-mac-3": "eth2",
+			}
+		})
+
+		It("configures network on a bond of interfaces and does not configure bond members", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Gateway: "10.0.0.1",
+				Bond: &boshsettings.NetworkBond{
+					Name:    "bond0",
+					Mode:    "802.3ad",
+					MIIMon:  100,
+					Members: []string{"fake-mac-1", "fake-mac-2"},
+				},
+			}
+
+			staticConfigs, dhcpConfigs, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staticConfigs).To(HaveLen(1))
+			Expect(staticConfigs[0].Name).To(Equal("bond0"))
+			Expect(staticConfigs[0].Mac).To(Equal("fake-mac-1"))
+			Expect(staticConfigs[0].Address).To(Equal("10.0.0.5"))
+			Expect(staticConfigs[0].Links).To(Equal([]LinkConfiguration{
+				{Name: "bond0", Kind: LinkKindBond, Lower: []string{"eth0", "eth1"}, BondMode: "802.3ad", BondMIIMon: 100},
+			}))
+
+			Expect(dhcpConfigs).To(Equal([]DHCPInterfaceConfiguration{{Name: "eth2"}}))
+		})
+
+		It("configures networks on VLANs and bridge in dependency order", func() {
+			networks := boshsettings.Networks{
+				"untagged": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.0.0.5",
+					Netmask: "255.255.255.0",
+					Mac:     "fake-mac-1",
+				},
+				"tagged": boshsettings.Network{
+					Type:    "manual",
+					IP:      "10.0.100.5",
+					Netmask: "255.255.255.0",
+					Mac:     "fake-mac-1",
+					VLAN:    100,
+				},
+				"bridged": boshsettings.Network{
+					Type:   "dynamic",
+					Mac:    "fake-mac-2",
+					VLAN:   200,
+					Bridge: "br0",
+				},
+			}
+
+			staticConfigs, dhcpConfigs, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(staticConfigs).To(HaveLen(2))
+			Expect(staticConfigs[0].Name).To(Equal("eth0.100"))
+			Expect(staticConfigs[0].Links).To(Equal([]LinkConfiguration{
+				{Name: "eth0.100", Kind: LinkKindVLAN, Lower: []string{"eth0"}, VLANID: 100},
+			}))
+			Expect(staticConfigs[1].Name).To(Equal("eth0"))
+			Expect(staticConfigs[1].Links).To(BeEmpty())
+
+			Expect(dhcpConfigs).To(ConsistOf(
+				DHCPInterfaceConfiguration{
+					Name: "br0",
+					Links: []LinkConfiguration{
+						{Name: "eth1.200", Kind: LinkKindVLAN, Lower: []string{"eth1"}, VLANID: 200},
+						{Name: "br0", Kind: LinkKindBridge, Lower: []string{"eth1.200"}},
+					},
+				},
+				DHCPInterfaceConfiguration{Name: "eth2"},
+			))
+		})
+
+		It("returns error when bond member is not found", func() {
+			network := boshsettings.Network{
+				Type: "dynamic",
+				Bond: &boshsettings.NetworkBond{Name: "bond0", Members: []string{"fake-mac-1", "fake-unknown-mac"}},
+			}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No device found for bond 'bond0' member with MAC address 'fake-unknown-mac'"))
+		})
+
+		It("returns error when bond has no members", func() {
+			network := boshsettings.Network{
+				Type: "dynamic",
+				Bond: &boshsettings.NetworkBond{Name: "bond0"},
+			}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Bond 'bond0' must have members"))
+		})
+
+		It("returns error when VLAN ID is invalid", func() {
+			network := boshsettings.Network{Type: "dynamic", Mac: "fake-mac-1", VLAN: 4095}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": network}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid VLAN ID '4095'"))
+		})
+	})
+
+	Describe("link settings", func() {
+		var interfacesByMAC map[string]string
+
+		BeforeEach(func() {
+			interfacesByMAC = map[string]string{"fake-static-mac-address": "eth0", "fake-dhcp-mac-address": "eth1"}
+		})
+
+		It("adds MTU and offload features ordered by name", func() {
+			staticNetwork.MTU = 9000
+			staticNetwork.Offload = map[string]bool{"tso": false, "gro": true}
+			dhcpNetwork.MTU = 1450
+
+			staticConfigs, dhcpConfigs, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": staticNetwork, "bar": dhcpNetwork}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(staticConfigs[0].MTU).To(Equal(9000))
+			Expect(staticConfigs[0].Offloads).To(Equal([]OffloadConfiguration{
+				{Feature: "gro", Enabled: true},
+				{Feature: "tso", Enabled: false},
+			}))
+			Expect(dhcpConfigs[0].MTU).To(Equal(1450))
+			Expect(dhcpConfigs[0].Offloads).To(BeEmpty())
+		})
+
+		It("returns error when offload feature is unknown", func() {
+			staticNetwork.Offload = map[string]bool{"fake-feature": false}
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"foo": staticNetwork}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown offload feature 'fake-feature'"))
+		})
+
+		It("returns error when MTU is negative", func() {
+			dhcpNetwork.MTU = -1
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"bar": dhcpNetwork}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid MTU '-1'"))
+		})
+
+		It("returns error when MTU is below minimum MTU of IPv4", func() {
+			dhcpNetwork.MTU = 67
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"bar": dhcpNetwork}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid MTU '67', must be at least 68"))
+		})
+
+		It("returns error when MTU is below minimum MTU of IPv6 on networks with IPv6", func() {
+			dhcpNetwork.MTU = 1279
+			dhcpNetwork.IPv6Mode = boshsettings.NetworkIPv6ModeSLAAC
+
+			_, _, err := interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"bar": dhcpNetwork}, interfacesByMAC)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid MTU '1279', must be at least 1280"))
+
+			dhcpNetwork.IPv6Mode = ""
+
+			_, _, err = interfaceConfigurationCreator.CreateInterfaceConfigurations(boshsettings.Networks{"bar": dhcpNetwork}, interfacesByMAC)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	It("wraps errors calculating Network and Broadcast addresses", func() {
 		invalidNetwork := boshsettings.Network{
 			Type:    "manual",
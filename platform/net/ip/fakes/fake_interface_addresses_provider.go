@@ -7,8 +7,15 @@ import (
 type FakeInterfaceAddressesProvider struct {
 	GetInterfaceAddresses []boship.InterfaceAddress
 	GetErr                error
+
+	GetMTUsMTUs map[string]int
+	GetMTUsErr  error
 }
 
 func (f *FakeInterfaceAddressesProvider) Get() ([]boship.InterfaceAddress, error) {
 	return f.GetInterfaceAddresses, f.GetErr
 }
+
+func (f *FakeInterfaceAddressesProvider) GetMTUs() (map[string]int, error) {
+	return f.GetMTUsMTUs, f.GetMTUsErr
+}
@@ -8,9 +8,18 @@ type FakeResolver struct {
 	GetPrimaryIPv4InterfaceName string
 	GetPrimaryIPv4IPNet         *gonet.IPNet
 	GetPrimaryIPv4Err           error
+
+	GetPrimaryIPv6InterfaceName string
+	GetPrimaryIPv6IPNet         *gonet.IPNet
+	GetPrimaryIPv6Err           error
 }
 
 func (r *FakeResolver) GetPrimaryIPv4(interfaceName string) (*gonet.IPNet, error) {
 	r.GetPrimaryIPv4InterfaceName = interfaceName
 	return r.GetPrimaryIPv4IPNet, r.GetPrimaryIPv4Err
 }
+
+func (r *FakeResolver) GetPrimaryIPv6(interfaceName string) (*gonet.IPNet, error) {
+	r.GetPrimaryIPv6InterfaceName = interfaceName
+	return r.GetPrimaryIPv6IPNet, r.GetPrimaryIPv6Err
+}
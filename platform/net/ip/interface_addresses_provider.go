@@ -8,6 +8,9 @@ import (
 
 type InterfaceAddressesProvider interface {
 	Get() ([]InterfaceAddress, error)
+
+	// GetMTUs returns MTUs of network interfaces by interface name
+	GetMTUs() (map[string]int, error)
 }
 
 type systemInterfaceAddrs struct{}
@@ -38,6 +41,12 @@ func (s *systemInterfaceAddrs) Get() ([]InterfaceAddress, error) {
 
 			if ipv4 := ip.To4(); ipv4 != nil {
 				interfaceAddrs = append(interfaceAddrs, NewSimpleInterfaceAddress(iface.Name, ipv4.String()))
+				continue
+			}
+
+			// Link-local IPv6 addresses are assigned automatically
+			if !ip.IsLinkLocalUnicast() {
+				interfaceAddrs = append(interfaceAddrs, NewSimpleInterfaceAddress(iface.Name, ip.String()))
 			}
 		}
 
@@ -45,3 +54,18 @@ func (s *systemInterfaceAddrs) Get() ([]InterfaceAddress, error) {
 
 	return interfaceAddrs, nil
 }
+
+func (s *systemInterfaceAddrs) GetMTUs() (map[string]int, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	mtus := map[string]int{}
+
+	for _, iface := range ifaces {
+		mtus[iface.Name] = iface.MTU
+	}
+
+	return mtus, nil
+}
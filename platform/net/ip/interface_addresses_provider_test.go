@@ -34,4 +34,14 @@ var _ = Describe("InterfaceAddressesProvider", func() {
 		// lo is on linux, lo0 on mac, Loopback Pseudo-Interface 1 windows
 		Expect([]string{"lo", "lo0", "Loopback Pseudo-Interface 1"}).To(ContainElement(loopBackInterface.GetInterfaceName()))
 	})
+
+	It("returns current system interfaces MTUs", func() {
+		mtus, err := interfaceAddressesProvider.GetMTUs()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mtus).ToNot(BeEmpty())
+
+		for _, mtu := range mtus {
+			Expect(mtu).To(BeNumerically(">", 0))
+		}
+	})
 })
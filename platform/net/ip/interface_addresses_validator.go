@@ -1,11 +1,17 @@
 package ip
 
 import (
+	"net"
+	"strings"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type InterfaceAddressesValidator interface {
 	Validate(desiredInterfaceAddresses []InterfaceAddress) error
+
+	// ValidateMTUs checks MTUs of interfaces by interface name
+	ValidateMTUs(desiredMTUs map[string]int) error
 }
 
 type interfaceAddressesValidator struct {
@@ -26,26 +32,72 @@ func (i *interfaceAddressesValidator) Validate(desiredInterfaceAddresses []Inter
 
 	for _, desiredInterfaceAddress := range desiredInterfaceAddresses {
 		ifaceName := desiredInterfaceAddress.GetInterfaceName()
-		iface, found := i.findInterfaceByName(ifaceName, systemInterfaceAddresses)
-		if !found {
+		actualIPs := i.findInterfaceIPs(ifaceName, systemInterfaceAddresses)
+		if len(actualIPs) == 0 {
 			return bosherr.WrapErrorf(err, "Validating network interface '%s' IP addresses, no interface configured with that name", ifaceName)
 		}
 		desiredIP, _ := desiredInterfaceAddress.GetIP()
-		actualIP, _ := iface.GetIP()
-		if desiredIP != actualIP {
-			return bosherr.WrapErrorf(err, "Validating network interface '%s' IP addresses, expected: '%s', actual: '%s'", ifaceName, desiredIP, actualIP)
+		if !containsIP(actualIPs, desiredIP) {
+			return bosherr.WrapErrorf(err, "Validating network interface '%s' IP addresses, expected: '%s', actual: '%s'", ifaceName, desiredIP, strings.Join(actualIPs, "', '"))
+		}
+	}
+
+	return nil
+}
+
+func (i *interfaceAddressesValidator) ValidateMTUs(desiredMTUs map[string]int) error {
+	if len(desiredMTUs) == 0 {
+		return nil
+	}
+
+	actualMTUs, err := i.interfaceAddrsProvider.GetMTUs()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting network interface MTUs")
+	}
+
+	for ifaceName, desiredMTU := range desiredMTUs {
+		actualMTU, found := actualMTUs[ifaceName]
+		if !found {
+			return bosherr.Errorf("Validating network interface '%s' MTU, no interface configured with that name", ifaceName)
+		}
+
+		if actualMTU != desiredMTU {
+			return bosherr.Errorf("Validating network interface '%s' MTU, expected: '%d', actual: '%d'", ifaceName, desiredMTU, actualMTU)
 		}
 	}
 
 	return nil
 }
 
-func (i *interfaceAddressesValidator) findInterfaceByName(ifaceName string, ifaces []InterfaceAddress) (InterfaceAddress, bool) {
+// findInterfaceIPs returns all addresses since interface
+// could have multiple IPv4 and IPv6 addresses
+func (i *interfaceAddressesValidator) findInterfaceIPs(ifaceName string, ifaces []InterfaceAddress) []string {
+	var ips []string
+
 	for _, iface := range ifaces {
 		if iface.GetInterfaceName() == ifaceName {
-			return iface, true
+			ip, _ := iface.GetIP()
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+// containsIP compares parsed addresses since IPv6 addresses
+// have multiple textual representations
+func containsIP(ips []string, ip string) bool {
+	parsedIP := net.ParseIP(ip)
+
+	for _, candidate := range ips {
+		if candidate == ip {
+			return true
+		}
+
+		if parsedIP != nil && parsedIP.Equal(net.ParseIP(candidate)) {
+			return true
 		}
 	}
 
-	return nil, false
+	return false
 }
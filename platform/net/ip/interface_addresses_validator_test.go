@@ -54,6 +54,32 @@ var _ = Describe("InterfaceAddressesValidator", func() {
 		})
 	})
 
+	Context("when interface has multiple IPv4 and IPv6 addresses", func() {
+		BeforeEach(func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.4.4"),
+				boship.NewSimpleInterfaceAddress("eth0", "2001:db8::4"),
+			}
+		})
+
+		It("returns nil when all desired addresses are found", func() {
+			err := interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "1.2.4.4"),
+				boship.NewSimpleInterfaceAddress("eth0", "2001:db8:0:0::4"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("fails listing all actual addresses", func() {
+			err := interfaceAddrsValidator.Validate([]boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "2001:db8::5"),
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expected: '2001:db8::5', actual: '1.2.3.4', '1.2.4.4', '2001:db8::4'"))
+		})
+	})
+
 	Context("when validating manual networks fails", func() {
 		BeforeEach(func() {
 			interfaceAddrsProvider.GetErr = errors.New("interface-error")
@@ -84,6 +110,44 @@ var _ = Describe("InterfaceAddressesValidator", func() {
 		})
 	})
 
+	Describe("ValidateMTUs", func() {
+		BeforeEach(func() {
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"eth0": 9000, "eth1": 1500}
+		})
+
+		It("returns nil when MTUs match", func() {
+			err := interfaceAddrsValidator.ValidateMTUs(map[string]int{"eth0": 9000})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not get MTUs when there are no desired MTUs", func() {
+			interfaceAddrsProvider.GetMTUsErr = errors.New("fake-get-mtus-err")
+
+			err := interfaceAddrsValidator.ValidateMTUs(map[string]int{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("fails when MTU does not match", func() {
+			err := interfaceAddrsValidator.ValidateMTUs(map[string]int{"eth1": 9000})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating network interface 'eth1' MTU, expected: '9000', actual: '1500'"))
+		})
+
+		It("fails when interface is not found", func() {
+			err := interfaceAddrsValidator.ValidateMTUs(map[string]int{"eth2": 9000})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating network interface 'eth2' MTU, no interface configured with that name"))
+		})
+
+		It("fails when getting MTUs fails", func() {
+			interfaceAddrsProvider.GetMTUsErr = errors.New("fake-get-mtus-err")
+
+			err := interfaceAddrsValidator.ValidateMTUs(map[string]int{"eth0": 9000})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-mtus-err"))
+		})
+	})
+
 	Context("when resolv.conf has valid dns configurations", func() {
 		It("fails", func() {
 
@@ -20,6 +20,9 @@ func NetworkInterfaceToAddrsFunc(interfaceName string) ([]gonet.Addr, error) {
 type Resolver interface {
 	// GetPrimaryIPv4 always returns error unless IPNet is found for given interface
 	GetPrimaryIPv4(interfaceName string) (*gonet.IPNet, error)
+
+	// GetPrimaryIPv6 ignores link-local addresses
+	GetPrimaryIPv6(interfaceName string) (*gonet.IPNet, error)
 }
 
 type ipResolver struct {
@@ -56,3 +59,25 @@ func (r ipResolver) GetPrimaryIPv4(interfaceName string) (*gonet.IPNet, error) {
 
 	return nil, bosherr.Errorf("Failed to find primary IPv4 address for interface '%s'", interfaceName)
 }
+
+func (r ipResolver) GetPrimaryIPv6(interfaceName string) (*gonet.IPNet, error) {
+	addrs, err := r.ifaceToAddrsFunc(interfaceName)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Looking up addresses for interface '%s'", interfaceName)
+	}
+
+	for _, addr := range addrs {
+		ip, ok := addr.(*gonet.IPNet)
+		if !ok {
+			continue
+		}
+
+		if ip.IP.To4() != nil || ip.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		return ip, nil
+	}
+
+	return nil, bosherr.Errorf("Failed to find primary IPv6 address for interface '%s'", interfaceName)
+}
@@ -0,0 +1,49 @@
+package net
+
+// interfaceMTUs returns MTUs of interfaces that have MTU configured
+// and of interfaces they are built on; bond slaves and VLAN parents
+// need MTU at least as large as the largest MTU of interfaces on top of them
+func interfaceMTUs(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) map[string]int {
+	mtusByInterface := map[string]int{}
+
+	for _, config := range staticConfigs {
+		if config.MTU > 0 {
+			mtusByInterface[config.Name] = config.MTU
+		}
+	}
+
+	for _, config := range dhcpConfigs {
+		if config.MTU > 0 {
+			mtusByInterface[config.Name] = config.MTU
+		}
+	}
+
+	// Links are ordered so that each link comes after links it is built on
+	links := newLinkTopology(staticConfigs, dhcpConfigs).Links()
+
+	for i := len(links) - 1; i >= 0; i-- {
+		mtu := mtusByInterface[links[i].Name]
+		if mtu == 0 {
+			continue
+		}
+
+		for _, lower := range links[i].Lower {
+			if mtusByInterface[lower] < mtu {
+				mtusByInterface[lower] = mtu
+			}
+		}
+	}
+
+	return mtusByInterface
+}
+
+// ethtoolOffloadArgs returns ethtool arguments toggling offload features of an interface
+func ethtoolOffloadArgs(ifaceName string, offloads []OffloadConfiguration) []string {
+	args := []string{"-K", ifaceName}
+
+	for _, offload := range offloads {
+		args = append(args, offload.Feature, offload.State())
+	}
+
+	return args
+}
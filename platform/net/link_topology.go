@@ -0,0 +1,108 @@
+package net
+
+// linkTopology describes how bonds, VLANs and bridges
+// of configured interfaces are built on each other
+type linkTopology struct {
+	links      []LinkConfiguration
+	configured map[string]bool
+}
+
+func newLinkTopology(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) linkTopology {
+	topology := linkTopology{configured: map[string]bool{}}
+
+	var configLinks [][]LinkConfiguration
+
+	for _, config := range dhcpConfigs {
+		topology.configured[config.Name] = true
+		configLinks = append(configLinks, config.Links)
+	}
+
+	for _, config := range staticConfigs {
+		topology.configured[config.Name] = true
+		configLinks = append(configLinks, config.Links)
+	}
+
+	// Several networks may share a bond or parent interface of VLANs
+	seen := map[string]bool{}
+
+	for _, links := range configLinks {
+		for _, link := range links {
+			if !seen[link.Name] {
+				seen[link.Name] = true
+				topology.links = append(topology.links, link)
+			}
+		}
+	}
+
+	return topology
+}
+
+// Links returns all links in the order they have to be created
+func (t linkTopology) Links() []LinkConfiguration {
+	return t.links
+}
+
+func (t linkTopology) Link(name string) (LinkConfiguration, bool) {
+	for _, link := range t.links {
+		if link.Name == name {
+			return link, true
+		}
+	}
+
+	return LinkConfiguration{}, false
+}
+
+// Master returns bond or bridge enslaving given interface
+func (t linkTopology) Master(name string) (LinkConfiguration, bool) {
+	for _, link := range t.links {
+		if link.Kind == LinkKindVLAN {
+			continue
+		}
+
+		for _, lower := range link.Lower {
+			if lower == name {
+				return link, true
+			}
+		}
+	}
+
+	return LinkConfiguration{}, false
+}
+
+// VLANs returns VLAN sub-interfaces of given interface
+func (t linkTopology) VLANs(name string) []LinkConfiguration {
+	var vlans []LinkConfiguration
+
+	for _, link := range t.links {
+		if link.Kind == LinkKindVLAN && link.Lower[0] == name {
+			vlans = append(vlans, link)
+		}
+	}
+
+	return vlans
+}
+
+// LowerInterfaces returns interfaces without addresses of their own
+// that links are built on; interfaces are ordered so that
+// each interface comes after interfaces it is built on
+func (t linkTopology) LowerInterfaces() []string {
+	var names []string
+	seen := map[string]bool{}
+
+	add := func(name string) {
+		if !seen[name] && !t.configured[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, link := range t.links {
+		for _, lower := range link.Lower {
+			add(lower)
+		}
+
+		add(link.Name)
+	}
+
+	return names
+}
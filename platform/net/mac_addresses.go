@@ -0,0 +1,44 @@
+package net
+
+import (
+	"path"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// detectMacAddresses maps MAC addresses of physical devices to interface names
+func detectMacAddresses(fs boshsys.FileSystem) (map[string]string, error) {
+	addresses := map[string]string{}
+
+	filePaths, err := fs.Glob("/sys/class/net/*")
+	if err != nil {
+		return addresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
+	}
+
+	var macAddress string
+	for _, filePath := range filePaths {
+		isPhysicalDevice := fs.FileExists(path.Join(filePath, "device"))
+
+		if isPhysicalDevice {
+			// Bonded interfaces report MAC address of the bond
+			addressPath := path.Join(filePath, "address")
+			if permAddressPath := path.Join(filePath, "bonding_slave", "perm_hwaddr"); fs.FileExists(permAddressPath) {
+				addressPath = permAddressPath
+			}
+
+			macAddress, err = fs.ReadFileString(addressPath)
+			if err != nil {
+				return addresses, bosherr.WrapError(err, "Reading mac address from file")
+			}
+
+			macAddress = strings.Trim(macAddress, "\n")
+
+			interfaceName := path.Base(filePath)
+			addresses[macAddress] = interfaceName
+		}
+	}
+
+	return addresses, nil
+}
@@ -10,6 +10,11 @@ type Manager interface {
 	// upon completion of background network reconfiguration (e.g. arping).
 	SetupNetworking(networks boshsettings.Networks, errCh chan error) error
 
+	// PlanNetworking returns configuration files SetupNetworking would write
+	// and interfaces it would restart without changing anything;
+	// invalid network settings are reported in the plan
+	PlanNetworking(networks boshsettings.Networks) (NetworkPlan, error)
+
 	// Returns the list of interfaces that have configurations for them present
 	GetConfiguredNetworkInterfaces() ([]string, error)
 }
@@ -0,0 +1,283 @@
+package net
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const networkGuardLogTag = "NetworkGuard"
+
+// NetworkVerificationInterval is time between reachability checks
+// of targets after networking was set up
+const NetworkVerificationInterval = 5 * time.Second
+
+// configurationFilesManager is implemented by managers that configure
+// interfaces with files; paths may contain glob patterns
+type configurationFilesManager interface {
+	configurationFiles() []string
+}
+
+// NetworkRollbackError is returned when networking was set up
+// with previously applied networks since new networks were broken
+type NetworkRollbackError struct {
+	SetupError         string   `json:"setup_error,omitempty"`
+	UnreachableTargets []string `json:"unreachable_targets,omitempty"`
+	RestoreError       string   `json:"restore_error,omitempty"`
+}
+
+func (e NetworkRollbackError) Error() string {
+	reason := fmt.Sprintf("Targets '%s' were not reachable", strings.Join(e.UnreachableTargets, "', '"))
+	if e.SetupError != "" {
+		reason = e.SetupError
+	}
+
+	msg := "Rolled back network configuration: " + reason
+	if e.RestoreError != "" {
+		msg += "; restoring previous network configuration failed: " + e.RestoreError
+	}
+
+	return msg
+}
+
+// NetworkGuard sets up networking and restores previously applied
+// networks when targets are not reachable with the new ones
+type NetworkGuard struct {
+	manager             Manager
+	fs                  boshsys.FileSystem
+	checker             ReachabilityChecker
+	appliedNetworksPath string
+	timeout             time.Duration
+	clock               clock.Clock
+	logger              boshlog.Logger
+}
+
+func NewNetworkGuard(
+	manager Manager,
+	fs boshsys.FileSystem,
+	checker ReachabilityChecker,
+	appliedNetworksPath string,
+	timeout time.Duration,
+	clock clock.Clock,
+	logger boshlog.Logger,
+) NetworkGuard {
+	return NetworkGuard{
+		manager:             manager,
+		fs:                  fs,
+		checker:             checker,
+		appliedNetworksPath: appliedNetworksPath,
+		timeout:             timeout,
+		clock:               clock,
+		logger:              logger,
+	}
+}
+
+// SetupNetworking sets up networks and verifies that targets (e.g. mbus, registry)
+// and default gateway are reachable; targets with a port are dialed and others
+// are pinged. Verification is skipped when there are no previously applied
+// networks to restore
+func (g NetworkGuard) SetupNetworking(networks boshsettings.Networks, targets []string) error {
+	previousNetworks, found, err := g.appliedNetworks()
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := g.snapshotConfigurationFiles()
+	if err != nil {
+		return err
+	}
+
+	rollbackErr := NetworkRollbackError{}
+
+	err = g.manager.SetupNetworking(networks, nil)
+	if err != nil {
+		if !found {
+			return err
+		}
+		rollbackErr.SetupError = err.Error()
+	} else {
+		// Without previously applied networks there is no known good configuration
+		if !found {
+			g.logger.Info(networkGuardLogTag, "Skipping verification of networks since no networks were applied before")
+			return g.saveAppliedNetworks(networks)
+		}
+
+		var allTargets []string
+		allTargets = append(allTargets, targets...)
+		allTargets = append(allTargets, g.gatewayTargets(networks)...)
+
+		rollbackErr.UnreachableTargets = g.verify(allTargets)
+		if len(rollbackErr.UnreachableTargets) == 0 {
+			return g.saveAppliedNetworks(networks)
+		}
+	}
+
+	g.logger.Error(networkGuardLogTag, "Rolling back network configuration: %s", rollbackErr.Error())
+
+	err = g.rollback(previousNetworks, snapshot)
+	if err != nil {
+		rollbackErr.RestoreError = err.Error()
+	}
+
+	return rollbackErr
+}
+
+func (g NetworkGuard) verify(targets []string) []string {
+	deadline := g.clock.Now().Add(g.timeout)
+
+	for {
+		var unreachable []string
+
+		for _, target := range targets {
+			err := g.checker.Check(target)
+			if err != nil {
+				g.logger.Debug(networkGuardLogTag, "Target '%s' is not reachable: %s", target, err.Error())
+				unreachable = append(unreachable, target)
+			}
+		}
+
+		if len(unreachable) == 0 || !g.clock.Now().Before(deadline) {
+			return unreachable
+		}
+
+		// Interfaces may take a while to come up after restart
+		targets = unreachable
+		g.clock.Sleep(NetworkVerificationInterval)
+	}
+}
+
+// gatewayTargets returns gateway of default network; gateways
+// of dynamic networks are not known before DHCP answers
+func (g NetworkGuard) gatewayTargets(networks boshsettings.Networks) []string {
+	network, found := networks.DefaultNetworkFor("gateway")
+	if !found || network.IsDHCP() || network.Gateway == "" {
+		return nil
+	}
+
+	return []string{network.Gateway}
+}
+
+func (g NetworkGuard) rollback(previousNetworks boshsettings.Networks, snapshot map[string][]byte) error {
+	// Removing current files makes manager restart interfaces
+	// even if previous configuration matches files on disk
+	currentFilePaths, err := g.configurationFilePaths()
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range currentFilePaths {
+		err = g.fs.RemoveAll(filePath)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Removing '%s'", filePath)
+		}
+	}
+
+	setupErr := g.manager.SetupNetworking(previousNetworks, nil)
+
+	// Files not generated for previous networks are restored as they were
+	for filePath, content := range snapshot {
+		if g.fs.FileExists(filePath) {
+			continue
+		}
+
+		err = g.fs.WriteFile(filePath, content)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Restoring '%s'", filePath)
+		}
+	}
+
+	if setupErr != nil {
+		return bosherr.WrapError(setupErr, "Setting up previous networks")
+	}
+
+	return nil
+}
+
+func (g NetworkGuard) snapshotConfigurationFiles() (map[string][]byte, error) {
+	snapshot := map[string][]byte{}
+
+	filePaths, err := g.configurationFilePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filePath := range filePaths {
+		content, err := g.fs.ReadFile(filePath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading '%s'", filePath)
+		}
+
+		snapshot[filePath] = content
+	}
+
+	return snapshot, nil
+}
+
+func (g NetworkGuard) configurationFilePaths() ([]string, error) {
+	manager, ok := g.manager.(configurationFilesManager)
+	if !ok {
+		return nil, nil
+	}
+
+	var filePaths []string
+
+	for _, pattern := range manager.configurationFiles() {
+		if !strings.Contains(pattern, "*") {
+			if g.fs.FileExists(pattern) {
+				filePaths = append(filePaths, pattern)
+			}
+			continue
+		}
+
+		matches, err := g.fs.Glob(pattern)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Listing '%s'", pattern)
+		}
+
+		filePaths = append(filePaths, matches...)
+	}
+
+	return filePaths, nil
+}
+
+func (g NetworkGuard) appliedNetworks() (boshsettings.Networks, bool, error) {
+	if !g.fs.FileExists(g.appliedNetworksPath) {
+		return nil, false, nil
+	}
+
+	contents, err := g.fs.ReadFile(g.appliedNetworksPath)
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Reading applied networks")
+	}
+
+	var networks boshsettings.Networks
+
+	err = json.Unmarshal(contents, &networks)
+	if err != nil {
+		return nil, false, bosherr.WrapError(err, "Unmarshalling applied networks")
+	}
+
+	return networks, true, nil
+}
+
+func (g NetworkGuard) saveAppliedNetworks(networks boshsettings.Networks) error {
+	contents, err := json.Marshal(networks)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling applied networks")
+	}
+
+	err = g.fs.WriteFile(g.appliedNetworksPath, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing applied networks")
+	}
+
+	return nil
+}
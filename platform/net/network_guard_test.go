@@ -0,0 +1,261 @@
+package net_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("NetworkGuard", func() {
+	const appliedNetworksPath = "/var/vcap/bosh/applied_networks.json"
+
+	var (
+		fs               *fakesys.FakeFileSystem
+		clock            *fakeclock.FakeClock
+		checker          *fakenet.FakeReachabilityChecker
+		logger           boshlog.Logger
+		previousNetworks boshsettings.Networks
+		networks         boshsettings.Networks
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		clock = fakeclock.NewFakeClock(time.Now())
+		checker = fakenet.NewFakeReachabilityChecker()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		previousNetworks = boshsettings.Networks{
+			"net": boshsettings.Network{
+				Type:    "manual",
+				IP:      "10.0.0.5",
+				Netmask: "255.255.255.0",
+				Gateway: "10.0.0.1",
+				Default: []string{"dns", "gateway"},
+				DNS:     []string{"8.8.8.8"},
+				Mac:     "fake-mac-address",
+			},
+		}
+
+		networks = boshsettings.Networks{
+			"net": boshsettings.Network{
+				Type:    "manual",
+				IP:      "10.0.0.6",
+				Netmask: "255.255.255.0",
+				Gateway: "10.0.0.2",
+				Default: []string{"dns", "gateway"},
+				DNS:     []string{"8.8.8.8"},
+				Mac:     "fake-mac-address",
+			},
+		}
+	})
+
+	writeAppliedNetworks := func(networks boshsettings.Networks) {
+		contents, err := json.Marshal(networks)
+		Expect(err).ToNot(HaveOccurred())
+		fs.WriteFile(appliedNetworksPath, contents)
+	}
+
+	readAppliedNetworks := func() boshsettings.Networks {
+		contents, err := fs.ReadFile(appliedNetworksPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		var networks boshsettings.Networks
+		Expect(json.Unmarshal(contents, &networks)).To(Succeed())
+		return networks
+	}
+
+	Context("with fake manager", func() {
+		var (
+			manager *fakenet.FakeManager
+			guard   NetworkGuard
+		)
+
+		BeforeEach(func() {
+			manager = &fakenet.FakeManager{}
+			guard = NewNetworkGuard(manager, fs, checker, appliedNetworksPath, 10*time.Second, clock, logger)
+		})
+
+		Context("when networks were applied before", func() {
+			BeforeEach(func() {
+				writeAppliedNetworks(previousNetworks)
+			})
+
+			It("sets up networks and records them as applied when targets and gateway are reachable", func() {
+				err := guard.SetupNetworking(networks, []string{"10.0.0.10:4222"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(manager.SetupNetworkingHistory).To(Equal([]boshsettings.Networks{networks}))
+				Expect(checker.CheckTargets).To(Equal([]string{"10.0.0.10:4222", "10.0.0.2"}))
+				Expect(readAppliedNetworks()).To(Equal(networks))
+			})
+
+			It("checks unreachable targets again until deadline", func() {
+				checker.CheckErrs["10.0.0.2"] = []error{errors.New("fake-ping-err"), nil}
+
+				errCh := make(chan error)
+				go func() { errCh <- guard.SetupNetworking(networks, []string{"10.0.0.10:4222"}) }()
+
+				clock.WaitForWatcherAndIncrement(NetworkVerificationInterval)
+
+				Expect(<-errCh).ToNot(HaveOccurred())
+				Expect(checker.CheckTargets).To(Equal([]string{"10.0.0.10:4222", "10.0.0.2", "10.0.0.2"}))
+				Expect(manager.SetupNetworkingHistory).To(HaveLen(1))
+			})
+
+			It("sets up previous networks when targets are not reachable before deadline", func() {
+				checker.CheckErrs["10.0.0.10:4222"] = []error{errors.New("fake-dial-err")}
+
+				errCh := make(chan error)
+				go func() { errCh <- guard.SetupNetworking(networks, []string{"10.0.0.10:4222"}) }()
+
+				clock.WaitForWatcherAndIncrement(NetworkVerificationInterval)
+				clock.WaitForWatcherAndIncrement(NetworkVerificationInterval)
+
+				err := <-errCh
+				Expect(err).To(Equal(NetworkRollbackError{UnreachableTargets: []string{"10.0.0.10:4222"}}))
+				Expect(err.Error()).To(Equal("Rolled back network configuration: Targets '10.0.0.10:4222' were not reachable"))
+
+				Expect(manager.SetupNetworkingHistory).To(Equal([]boshsettings.Networks{networks, previousNetworks}))
+				Expect(readAppliedNetworks()).To(Equal(previousNetworks))
+			})
+
+			It("sets up previous networks when gateway is not reachable before deadline", func() {
+				checker.CheckErrs["10.0.0.2"] = []error{errors.New("fake-ping-err")}
+
+				errCh := make(chan error)
+				go func() { errCh <- guard.SetupNetworking(networks, nil) }()
+
+				clock.WaitForWatcherAndIncrement(NetworkVerificationInterval)
+				clock.WaitForWatcherAndIncrement(NetworkVerificationInterval)
+
+				Expect(<-errCh).To(Equal(NetworkRollbackError{UnreachableTargets: []string{"10.0.0.2"}}))
+				Expect(manager.SetupNetworkingHistory).To(Equal([]boshsettings.Networks{networks, previousNetworks}))
+			})
+
+			It("does not check gateway of dynamic networks", func() {
+				networks = boshsettings.Networks{"net": boshsettings.Network{Type: "dynamic", Default: []string{"gateway"}}}
+
+				err := guard.SetupNetworking(networks, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(checker.CheckTargets).To(BeEmpty())
+			})
+
+			It("sets up previous networks when setting up networks fails", func() {
+				manager.SetupNetworkingStub = func(n boshsettings.Networks) error {
+					if n["net"].IP == "10.0.0.6" {
+						return errors.New("fake-setup-err")
+					}
+					return nil
+				}
+
+				err := guard.SetupNetworking(networks, nil)
+				Expect(err).To(Equal(NetworkRollbackError{SetupError: "fake-setup-err"}))
+
+				Expect(manager.SetupNetworkingHistory).To(Equal([]boshsettings.Networks{networks, previousNetworks}))
+				Expect(checker.CheckTargets).To(BeEmpty())
+			})
+
+			It("reports failure to set up previous networks", func() {
+				manager.SetupNetworkingErr = errors.New("fake-setup-err")
+
+				err := guard.SetupNetworking(networks, nil)
+				Expect(err).To(Equal(NetworkRollbackError{
+					SetupError:   "fake-setup-err",
+					RestoreError: "Setting up previous networks: fake-setup-err",
+				}))
+			})
+		})
+
+		Context("when no networks were applied before", func() {
+			It("returns error when setting up networks fails", func() {
+				manager.SetupNetworkingErr = errors.New("fake-setup-err")
+
+				err := guard.SetupNetworking(networks, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("fake-setup-err"))
+				Expect(manager.SetupNetworkingHistory).To(HaveLen(1))
+			})
+
+			It("records networks as applied without verifying targets since there is nothing to roll back to", func() {
+				err := guard.SetupNetworking(networks, []string{"10.0.0.10:4222"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(checker.CheckTargets).To(BeEmpty())
+				Expect(manager.SetupNetworkingHistory).To(HaveLen(1))
+				Expect(readAppliedNetworks()).To(Equal(networks))
+			})
+		})
+	})
+
+	Context("with manager configuring interfaces with files", func() {
+		var (
+			cmdRunner              *fakesys.FakeCmdRunner
+			interfaceAddrsProvider *fakeip.FakeInterfaceAddressesProvider
+			guard                  NetworkGuard
+		)
+
+		BeforeEach(func() {
+			cmdRunner = fakesys.NewFakeCmdRunner()
+			interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
+
+			manager := NewUbuntuNetManager(
+				fs,
+				cmdRunner,
+				&fakeip.FakeResolver{},
+				NewInterfaceConfigurationCreator(logger),
+				boship.NewInterfaceAddressesValidator(interfaceAddrsProvider),
+				NewDNSValidator(fs),
+				NewRoutesValidator(&fakenet.FakeRoutesSearcher{}),
+				&fakearp.FakeAddressBroadcaster{},
+				logger,
+			)
+			guard = NewNetworkGuard(manager, fs, checker, appliedNetworksPath, 10*time.Second, clock, logger)
+
+			fs.WriteFile("/sys/class/net/eth0", []byte{})
+			fs.WriteFile("/sys/class/net/eth0/device", []byte{})
+			fs.WriteFileString("/sys/class/net/eth0/address", "fake-mac-address\n")
+			fs.SetGlob("/sys/class/net/*", []string{"/sys/class/net/eth0"})
+			fs.WriteFileString("/etc/resolv.conf", "nameserver 8.8.8.8\n")
+
+			// Interface keeps previous address since new one could not be configured
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("eth0", "10.0.0.5"),
+			}
+
+			writeAppliedNetworks(previousNetworks)
+		})
+
+		It("restarts interfaces with previous configuration and restores other files", func() {
+			fs.WriteFileString("/etc/network/interfaces", "fake-previous-interfaces")
+			fs.WriteFileString("/etc/dhcp/dhclient.conf", "fake-previous-dhclient")
+
+			err := guard.SetupNetworking(networks, nil)
+			Expect(err).To(BeAssignableToTypeOf(NetworkRollbackError{}))
+			Expect(err.Error()).To(ContainSubstring("Validating network interface 'eth0' IP addresses"))
+
+			contents, err := fs.ReadFileString("/etc/network/interfaces")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(ContainSubstring("address 10.0.0.5"))
+
+			contents, err = fs.ReadFileString("/etc/dhcp/dhclient.conf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("fake-previous-dhclient"))
+
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"ifup", "--force", "eth0"}))
+		})
+	})
+})
@@ -0,0 +1,278 @@
+package net
+
+import (
+	"fmt"
+	gonet "net"
+	"os"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// NetworkPlan describes changes SetupNetworking would make
+// without making them
+type NetworkPlan struct {
+	Files               []PlannedFile `json:"files"`
+	RestartedInterfaces []string      `json:"restarted_interfaces"`
+	Errors              []string      `json:"errors"`
+}
+
+// PlannedFile is a configuration file that would be written or removed;
+// Diff lists removed lines with '-' and added lines with '+'
+type PlannedFile struct {
+	Path          string `json:"path"`
+	Content       string `json:"content,omitempty"`
+	Diff          string `json:"diff,omitempty"`
+	Changed       bool   `json:"changed"`
+	Removed       bool   `json:"removed,omitempty"`
+	SymlinkTarget string `json:"symlink_target,omitempty"`
+}
+
+func newNetworkPlan() NetworkPlan {
+	return NetworkPlan{
+		Files:               []PlannedFile{},
+		RestartedInterfaces: []string{},
+		Errors:              []string{},
+	}
+}
+
+// planningFileSystem records writes instead of making them;
+// reads of planned files return planned content
+type planningFileSystem struct {
+	boshsys.FileSystem
+
+	files map[string]*PlannedFile
+}
+
+func newPlanningFileSystem(fs boshsys.FileSystem) *planningFileSystem {
+	return &planningFileSystem{FileSystem: fs, files: map[string]*PlannedFile{}}
+}
+
+func (fs *planningFileSystem) ConvergeFileContents(path string, content []byte) (bool, error) {
+	return fs.plan(path, string(content)), nil
+}
+
+func (fs *planningFileSystem) WriteFile(path string, content []byte) error {
+	fs.plan(path, string(content))
+	return nil
+}
+
+func (fs *planningFileSystem) WriteFileString(path, content string) error {
+	fs.plan(path, content)
+	return nil
+}
+
+func (fs *planningFileSystem) ReadFile(path string) ([]byte, error) {
+	if file, found := fs.files[path]; found && !file.Removed && file.SymlinkTarget == "" {
+		return []byte(file.Content), nil
+	}
+	return fs.FileSystem.ReadFile(path)
+}
+
+func (fs *planningFileSystem) ReadFileString(path string) (string, error) {
+	content, err := fs.ReadFile(path)
+	return string(content), err
+}
+
+func (fs *planningFileSystem) FileExists(path string) bool {
+	if file, found := fs.files[path]; found {
+		return !file.Removed
+	}
+	return fs.FileSystem.FileExists(path)
+}
+
+func (fs *planningFileSystem) RemoveAll(path string) error {
+	if !fs.FileSystem.FileExists(path) {
+		delete(fs.files, path)
+		return nil
+	}
+
+	currentContent, _ := fs.FileSystem.ReadFileString(path)
+	fs.files[path] = &PlannedFile{Path: path, Diff: diffLines(currentContent, ""), Changed: true, Removed: true}
+
+	return nil
+}
+
+func (fs *planningFileSystem) Symlink(oldPath, newPath string) error {
+	currentTarget, err := fs.FileSystem.Readlink(newPath)
+	changed := err != nil || currentTarget != oldPath
+
+	fs.files[newPath] = &PlannedFile{Path: newPath, SymlinkTarget: oldPath, Changed: changed}
+
+	return nil
+}
+
+func (fs *planningFileSystem) MkdirAll(path string, perm os.FileMode) error { return nil }
+
+func (fs *planningFileSystem) Chmod(path string, perm os.FileMode) error { return nil }
+
+func (fs *planningFileSystem) Chown(path, username string) error { return nil }
+
+func (fs *planningFileSystem) plan(path, content string) bool {
+	currentContent, err := fs.FileSystem.ReadFileString(path)
+	if err != nil {
+		currentContent = ""
+	}
+
+	changed := err != nil || currentContent != content
+
+	fs.files[path] = &PlannedFile{Path: path, Content: content, Changed: changed}
+	if changed {
+		fs.files[path].Diff = diffLines(currentContent, content)
+	}
+
+	return changed
+}
+
+// PlannedFiles returns planned files ordered by path
+func (fs *planningFileSystem) PlannedFiles() []PlannedFile {
+	files := []PlannedFile{}
+
+	for _, file := range fs.files {
+		files = append(files, *file)
+	}
+
+	sort.Sort(plannedFilesByPath(files))
+
+	return files
+}
+
+type plannedFilesByPath []PlannedFile
+
+func (f plannedFilesByPath) Len() int           { return len(f) }
+func (f plannedFilesByPath) Less(i, j int) bool { return f[i].Path < f[j].Path }
+func (f plannedFilesByPath) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// planningCmdRunner does not run commands while network changes are planned
+type planningCmdRunner struct{}
+
+func (r planningCmdRunner) RunComplexCommand(cmd boshsys.Command) (string, string, int, error) {
+	return "", "", 0, nil
+}
+
+func (r planningCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Process, error) {
+	return nil, bosherr.Error("Running commands asynchronously is not supported while planning")
+}
+
+func (r planningCmdRunner) RunCommand(cmdName string, args ...string) (string, string, int, error) {
+	return "", "", 0, nil
+}
+
+func (r planningCmdRunner) RunCommandWithInput(input, cmdName string, args ...string) (string, string, int, error) {
+	return "", "", 0, nil
+}
+
+func (r planningCmdRunner) CommandExists(cmdName string) bool {
+	return true
+}
+
+// diffLines returns line based diff of two contents
+// computed from their longest common subsequence
+func diffLines(oldContent, newContent string) string {
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+
+	// lengths[i][j] is length of common subsequence of oldLines[i:] and newLines[j:]
+	lengths := make([][]int, len(oldLines)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(newLines)+1)
+	}
+
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			diff = append(diff, " "+oldLines[i])
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lengths[i+1][j] >= lengths[i][j+1]):
+			diff = append(diff, "-"+oldLines[i])
+			i++
+		default:
+			diff = append(diff, "+"+newLines[j])
+			j++
+		}
+	}
+
+	if len(diff) == 0 {
+		return ""
+	}
+
+	return strings.Join(diff, "\n") + "\n"
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// overlappingSubnetErrors reports subnets of different interfaces that overlap
+// since traffic to them would leave via an arbitrary interface
+func overlappingSubnetErrors(staticConfigs []StaticInterfaceConfiguration) []string {
+	type interfaceSubnet struct {
+		ifaceName string
+		subnet    *gonet.IPNet
+	}
+
+	var subnets []interfaceSubnet
+
+	// Errors are reported in the same order regardless of network settings order
+	staticConfigs = append([]StaticInterfaceConfiguration{}, staticConfigs...)
+	sort.Stable(StaticInterfaceConfigurations(staticConfigs))
+
+	for _, config := range staticConfigs {
+		var addresses []AddressConfiguration
+
+		if config.Address != "" {
+			addresses = append(addresses, AddressConfiguration{config.Address, config.PrefixLength()})
+		}
+
+		addresses = append(addresses, config.Aliases...)
+		addresses = append(addresses, config.IPv6Addresses...)
+
+		for _, address := range addresses {
+			_, subnet, err := gonet.ParseCIDR(address.String())
+			if err == nil {
+				subnets = append(subnets, interfaceSubnet{config.Name, subnet})
+			}
+		}
+	}
+
+	errs := []string{}
+
+	for i := range subnets {
+		for j := i + 1; j < len(subnets); j++ {
+			a, b := subnets[i], subnets[j]
+			if a.ifaceName == b.ifaceName {
+				continue
+			}
+
+			if a.subnet.Contains(b.subnet.IP) || b.subnet.Contains(a.subnet.IP) {
+				errs = append(errs, fmt.Sprintf(
+					"Subnet '%s' of interface '%s' overlaps subnet '%s' of interface '%s'",
+					a.subnet, a.ifaceName, b.subnet, b.ifaceName,
+				))
+			}
+		}
+	}
+
+	return errs
+}
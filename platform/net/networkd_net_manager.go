@@ -0,0 +1,562 @@
+package net
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"text/template"
+
+	bosharp "github.com/cloudfoundry/bosh-agent/platform/net/arp"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const networkdNetManagerLogTag = "networkdNetManager"
+
+const (
+	networkdConfigDir        = "/etc/systemd/network"
+	networkdResolvedConfPath = "/etc/systemd/resolved.conf.d/bosh.conf"
+
+	// Non-stub resolv.conf lists actual DNS servers
+	// so that they can be validated
+	networkdResolvConfPath = "/run/systemd/resolve/resolv.conf"
+
+	// Reconfigured links are waited for before their configuration is validated
+	networkdWaitOnlineTimeoutInSeconds = 60
+)
+
+// networkdNetManager configures interfaces with systemd-networkd
+// on stemcells that do not ship ifupdown
+type networkdNetManager struct {
+	fs                            boshsys.FileSystem
+	cmdRunner                     boshsys.CmdRunner
+	ipResolver                    boship.Resolver
+	interfaceConfigurationCreator InterfaceConfigurationCreator
+	interfaceAddressesValidator   boship.InterfaceAddressesValidator
+	dnsValidator                  DNSValidator
+	routesValidator               RoutesValidator
+	addressBroadcaster            bosharp.AddressBroadcaster
+	logger                        boshlog.Logger
+}
+
+func NewNetworkdNetManager(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	ipResolver boship.Resolver,
+	interfaceConfigurationCreator InterfaceConfigurationCreator,
+	interfaceAddressesValidator boship.InterfaceAddressesValidator,
+	dnsValidator DNSValidator,
+	routesValidator RoutesValidator,
+	addressBroadcaster bosharp.AddressBroadcaster,
+	logger boshlog.Logger,
+) Manager {
+	return networkdNetManager{
+		fs:                            fs,
+		cmdRunner:                     cmdRunner,
+		ipResolver:                    ipResolver,
+		interfaceConfigurationCreator: interfaceConfigurationCreator,
+		interfaceAddressesValidator:   interfaceAddressesValidator,
+		dnsValidator:                  dnsValidator,
+		routesValidator:               routesValidator,
+		addressBroadcaster:            addressBroadcaster,
+		logger:                        logger,
+	}
+}
+
+func (net networkdNetManager) SetupNetworking(networks boshsettings.Networks, errCh chan error) error {
+	nonVipNetworks := boshsettings.Networks{}
+	for networkName, networkSettings := range networks {
+		if networkSettings.IsVIP() {
+			continue
+		}
+		nonVipNetworks[networkName] = networkSettings
+	}
+
+	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
+	dnsServers := dnsNetwork.DNS
+
+	if networks.IsPreconfigured() {
+		// Note in this case IPs are not broadcasted
+		return net.writeResolvedConfiguration(dnsServers)
+	}
+
+	staticConfigs, dhcpConfigs, err := net.buildInterfaces(nonVipNetworks)
+	if err != nil {
+		return err
+	}
+
+	interfacesChanged, err := net.writeNetworkFiles(dhcpConfigs, staticConfigs)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing network configuration")
+	}
+
+	if interfacesChanged {
+		net.reloadNetworkingInterfaces(net.ifaceNames(dhcpConfigs, staticConfigs))
+		net.setOffloads(dhcpConfigs, staticConfigs)
+	}
+
+	err = net.writeResolvedConfiguration(dnsServers)
+	if err != nil {
+		return err
+	}
+
+	staticAddresses, dynamicAddresses := net.ifaceAddresses(staticConfigs, dhcpConfigs)
+
+	err = net.interfaceAddressesValidator.Validate(staticAddresses)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating static network configuration")
+	}
+
+	err = net.interfaceAddressesValidator.ValidateMTUs(interfaceMTUs(staticConfigs, dhcpConfigs))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating MTU")
+	}
+
+	err = net.routesValidator.Validate(interfaceRoutes(staticConfigs, dhcpConfigs))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating routes")
+	}
+
+	err = net.dnsValidator.Validate(dnsServers)
+	if err != nil {
+		return bosherr.WrapError(err, "Validating dns configuration")
+	}
+
+	net.broadcastIps(append(staticAddresses, dynamicAddresses...), errCh)
+
+	return nil
+}
+
+func (net networkdNetManager) PlanNetworking(networks boshsettings.Networks) (NetworkPlan, error) {
+	plan := newNetworkPlan()
+
+	fs := newPlanningFileSystem(net.fs)
+	planner := net
+	planner.fs = fs
+	planner.cmdRunner = planningCmdRunner{}
+
+	nonVipNetworks := boshsettings.Networks{}
+	for networkName, networkSettings := range networks {
+		if networkSettings.IsVIP() {
+			continue
+		}
+		nonVipNetworks[networkName] = networkSettings
+	}
+
+	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
+	dnsServers := dnsNetwork.DNS
+
+	if !networks.IsPreconfigured() {
+		staticConfigs, dhcpConfigs, err := planner.buildInterfaces(nonVipNetworks)
+		if err != nil {
+			plan.Errors = append(plan.Errors, err.Error())
+			return plan, nil
+		}
+
+		plan.Errors = append(plan.Errors, overlappingSubnetErrors(staticConfigs)...)
+
+		interfacesChanged, err := planner.writeNetworkFiles(dhcpConfigs, staticConfigs)
+		if err != nil {
+			return plan, bosherr.WrapError(err, "Planning network configuration")
+		}
+
+		if interfacesChanged {
+			plan.RestartedInterfaces = net.ifaceNames(dhcpConfigs, staticConfigs)
+		}
+	}
+
+	err := planner.writeResolvedConfiguration(dnsServers)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Files = fs.PlannedFiles()
+
+	return plan, nil
+}
+
+func (net networkdNetManager) configurationFiles() []string {
+	return []string{networkdNetdevFilePath("*"), networkdNetworkFilePath("*"), networkdResolvedConfPath}
+}
+
+func (net networkdNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
+	interfaces := []string{}
+
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
+	if err != nil {
+		return interfaces, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	for _, iface := range interfacesByMacAddress {
+		if net.fs.FileExists(networkdNetworkFilePath(iface)) {
+			interfaces = append(interfaces, iface)
+		}
+	}
+
+	return interfaces, nil
+}
+
+const networkdNetworkTemplate = `# Generated by bosh-agent
+[Match]
+Name={{ .Name }}
+{{ if .MTU }}
+[Link]
+MTUBytes={{ .MTU }}
+{{ end }}
+[Network]{{ if .DHCP }}
+DHCP={{ .DHCP }}{{ end }}{{ if .IPv6AcceptRA }}
+IPv6AcceptRA=yes{{ end }}{{ range .Addresses }}
+Address={{ . }}{{ end }}{{ range .Gateways }}
+Gateway={{ . }}{{ end }}{{ range .LinkLines }}
+{{ . }}{{ end }}
+{{ range .Routes }}
+[Route]
+Destination={{ .Destination }}{{ if .Gateway }}
+Gateway={{ .Gateway }}{{ end }}{{ if .Metric }}
+Metric={{ .Metric }}{{ end }}{{ if .Table }}
+Table={{ .Table }}{{ end }}
+{{ end }}{{ range .PolicyRules }}
+[RoutingPolicyRule]
+From={{ .From }}
+Table={{ .Table }}
+{{ end }}`
+
+type networkdNetworkConfig struct {
+	Name         string
+	DHCP         string
+	IPv6AcceptRA bool
+	Addresses    []string
+	Gateways     []string
+	Routes       []RouteConfiguration
+	PolicyRules  []PolicyRuleConfiguration
+	LinkLines    []string
+	MTU          int
+}
+
+const networkdNetdevTemplate = `# Generated by bosh-agent
+[NetDev]
+Name={{ .Name }}
+Kind={{ .Kind }}
+{{ if eq .Kind "bond" }}
+[Bond]{{ if .BondMode }}
+Mode={{ .BondMode }}{{ end }}{{ if .BondMIIMon }}
+MIIMonitorSec={{ .BondMIIMon }}ms{{ end }}
+{{ end }}{{ if eq .Kind "vlan" }}
+[VLAN]
+Id={{ .VLANID }}
+{{ end }}`
+
+// networkdLinkLines returns lines enslaving interface to a bond or bridge
+// and creating VLANs on top of it
+func networkdLinkLines(topology linkTopology, ifaceName string) []string {
+	var lines []string
+
+	if master, found := topology.Master(ifaceName); found {
+		if master.Kind == LinkKindBond {
+			lines = append(lines, "Bond="+master.Name)
+		} else {
+			lines = append(lines, "Bridge="+master.Name)
+		}
+	}
+
+	for _, vlan := range topology.VLANs(ifaceName) {
+		lines = append(lines, "VLAN="+vlan.Name)
+	}
+
+	return lines
+}
+
+func newNetworkdStaticNetworkConfig(config StaticInterfaceConfiguration) networkdNetworkConfig {
+	networkConfig := networkdNetworkConfig{
+		Name:        config.Name,
+		Routes:      config.Routes,
+		PolicyRules: config.PolicyRules,
+		MTU:         config.MTU,
+	}
+
+	if config.Address != "" {
+		networkConfig.Addresses = append(networkConfig.Addresses, fmt.Sprintf("%s/%d", config.Address, config.PrefixLength()))
+
+		if config.IsDefaultForGateway && config.Gateway != "" {
+			networkConfig.Gateways = append(networkConfig.Gateways, config.Gateway)
+		}
+	}
+
+	for _, alias := range config.Aliases {
+		networkConfig.Addresses = append(networkConfig.Addresses, alias.String())
+	}
+
+	for _, address := range config.IPv6Addresses {
+		networkConfig.Addresses = append(networkConfig.Addresses, address.String())
+	}
+
+	if len(config.IPv6Addresses) > 0 {
+		if config.IsDefaultForGateway && config.IPv6Gateway != "" {
+			networkConfig.Gateways = append(networkConfig.Gateways, config.IPv6Gateway)
+		}
+	} else {
+		switch config.IPv6Mode {
+		case boshsettings.NetworkIPv6ModeSLAAC:
+			networkConfig.IPv6AcceptRA = true
+		case boshsettings.NetworkIPv6ModeDHCPv6:
+			networkConfig.DHCP = "ipv6"
+		}
+	}
+
+	return networkConfig
+}
+
+func newNetworkdDHCPNetworkConfig(config DHCPInterfaceConfiguration) networkdNetworkConfig {
+	networkConfig := networkdNetworkConfig{Name: config.Name, DHCP: "ipv4", Routes: config.Routes, MTU: config.MTU}
+
+	switch config.IPv6Mode {
+	case boshsettings.NetworkIPv6ModeSLAAC:
+		networkConfig.IPv6AcceptRA = true
+	case boshsettings.NetworkIPv6ModeDHCPv6:
+		networkConfig.DHCP = "yes"
+	}
+
+	return networkConfig
+}
+
+func networkdNetworkFilePath(name string) string {
+	return path.Join(networkdConfigDir, "10-bosh-"+name+".network")
+}
+
+func networkdNetdevFilePath(name string) string {
+	return path.Join(networkdConfigDir, "10-bosh-"+name+".netdev")
+}
+
+func (net networkdNetManager) writeNetworkFiles(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations) (bool, error) {
+	sort.Stable(dhcpConfigs)
+	sort.Stable(staticConfigs)
+
+	topology := newLinkTopology(staticConfigs, dhcpConfigs)
+	mtus := interfaceMTUs(staticConfigs, dhcpConfigs)
+	networkConfigs := []networkdNetworkConfig{}
+
+	for _, name := range topology.LowerInterfaces() {
+		networkConfigs = append(networkConfigs, networkdNetworkConfig{Name: name})
+	}
+
+	for _, config := range dhcpConfigs {
+		networkConfigs = append(networkConfigs, newNetworkdDHCPNetworkConfig(config))
+	}
+
+	for _, config := range staticConfigs {
+		networkConfigs = append(networkConfigs, newNetworkdStaticNetworkConfig(config))
+	}
+
+	netdevTemplate := template.Must(template.New("networkd-netdev").Parse(networkdNetdevTemplate))
+	networkTemplate := template.Must(template.New("networkd-network").Parse(networkdNetworkTemplate))
+
+	anyFileChanged := false
+	desiredFilePaths := map[string]bool{}
+
+	for _, link := range topology.Links() {
+		filePath := networkdNetdevFilePath(link.Name)
+		desiredFilePaths[filePath] = true
+
+		changed, err := net.writeFile(filePath, netdevTemplate, link)
+		if err != nil {
+			return false, err
+		}
+
+		anyFileChanged = anyFileChanged || changed
+	}
+
+	for _, networkConfig := range networkConfigs {
+		networkConfig.LinkLines = networkdLinkLines(topology, networkConfig.Name)
+		networkConfig.MTU = mtus[networkConfig.Name]
+
+		filePath := networkdNetworkFilePath(networkConfig.Name)
+		desiredFilePaths[filePath] = true
+
+		changed, err := net.writeFile(filePath, networkTemplate, networkConfig)
+		if err != nil {
+			return false, err
+		}
+
+		anyFileChanged = anyFileChanged || changed
+	}
+
+	// Interfaces and links that are no longer used by any network should not keep old configuration
+	for _, pattern := range []string{networkdNetdevFilePath("*"), networkdNetworkFilePath("*")} {
+		existingFilePaths, err := net.fs.Glob(pattern)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Listing files in %s", networkdConfigDir)
+		}
+
+		for _, filePath := range existingFilePaths {
+			if desiredFilePaths[filePath] {
+				continue
+			}
+
+			err = net.fs.RemoveAll(filePath)
+			if err != nil {
+				return false, bosherr.WrapErrorf(err, "Removing %s", filePath)
+			}
+
+			anyFileChanged = true
+		}
+	}
+
+	return anyFileChanged, nil
+}
+
+func (net networkdNetManager) writeFile(filePath string, t *template.Template, config interface{}) (bool, error) {
+	buffer := bytes.NewBuffer([]byte{})
+
+	err := t.Execute(buffer, config)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Generating config from template")
+	}
+
+	changed, err := net.fs.ConvergeFileContents(filePath, buffer.Bytes())
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Writing to %s", filePath)
+	}
+
+	return changed, nil
+}
+
+const networkdResolvedConfTemplate = `# Generated by bosh-agent
+[Resolve]
+DNS={{ range $i, $server := . }}{{ if $i }} {{ end }}{{ $server }}{{ end }}
+`
+
+// writeResolvedConfiguration configures DNS servers globally with systemd-resolved;
+// they take precedence over DNS servers obtained via DHCP
+func (net networkdNetManager) writeResolvedConfiguration(dnsServers []string) error {
+	buffer := bytes.NewBuffer([]byte{})
+	t := template.Must(template.New("resolved-conf").Parse(networkdResolvedConfTemplate))
+
+	err := t.Execute(buffer, dnsServers)
+	if err != nil {
+		return bosherr.WrapError(err, "Generating DNS config from template")
+	}
+
+	changed, err := net.fs.ConvergeFileContents(networkdResolvedConfPath, buffer.Bytes())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing to %s", networkdResolvedConfPath)
+	}
+
+	err = net.fs.Symlink(networkdResolvConfPath, "/etc/resolv.conf")
+	if err != nil {
+		return bosherr.WrapError(err, "Setting up /etc/resolv.conf symlink")
+	}
+
+	if changed {
+		_, _, _, err = net.cmdRunner.RunCommand("systemctl", "restart", "systemd-resolved")
+		if err != nil {
+			return bosherr.WrapError(err, "Restarting systemd-resolved")
+		}
+	}
+
+	return nil
+}
+
+func (net networkdNetManager) reloadNetworkingInterfaces(ifaceNames []string) {
+	net.logger.Debug(networkdNetManagerLogTag, "Reloading network interfaces")
+
+	_, _, _, err := net.cmdRunner.RunCommand("networkctl", "reload")
+	if err != nil {
+		net.logger.Error(networkdNetManagerLogTag, "Ignoring networkctl reload failure: %s", err.Error())
+	}
+
+	// Reload alone does not reapply configuration to links that are already configured
+	_, _, _, err = net.cmdRunner.RunCommand("networkctl", append([]string{"reconfigure"}, ifaceNames...)...)
+	if err != nil {
+		net.logger.Error(networkdNetManagerLogTag, "Ignoring networkctl reconfigure failure: %s", err.Error())
+	}
+
+	// networkctl returns before networkd applies configuration to links
+	waitOnlineArgs := []string{fmt.Sprintf("--timeout=%d", networkdWaitOnlineTimeoutInSeconds)}
+	for _, ifaceName := range ifaceNames {
+		waitOnlineArgs = append(waitOnlineArgs, "--interface="+ifaceName)
+	}
+
+	_, _, _, err = net.cmdRunner.RunCommand("systemd-networkd-wait-online", waitOnlineArgs...)
+	if err != nil {
+		net.logger.Error(networkdNetManagerLogTag, "Ignoring systemd-networkd-wait-online failure: %s", err.Error())
+	}
+}
+
+// setOffloads toggles offload features with ethtool
+// since networkd only configures them in .link files applied by udev
+func (net networkdNetManager) setOffloads(dhcpConfigs []DHCPInterfaceConfiguration, staticConfigs []StaticInterfaceConfiguration) {
+	offloadsByInterface := map[string][]OffloadConfiguration{}
+
+	for _, config := range dhcpConfigs {
+		offloadsByInterface[config.Name] = config.Offloads
+	}
+
+	for _, config := range staticConfigs {
+		offloadsByInterface[config.Name] = config.Offloads
+	}
+
+	for _, ifaceName := range net.ifaceNames(dhcpConfigs, staticConfigs) {
+		offloads := offloadsByInterface[ifaceName]
+		if len(offloads) == 0 {
+			continue
+		}
+
+		_, _, _, err := net.cmdRunner.RunCommand("ethtool", ethtoolOffloadArgs(ifaceName, offloads)...)
+		if err != nil {
+			net.logger.Error(networkdNetManagerLogTag, "Ignoring ethtool failure for interface '%s': %s", ifaceName, err.Error())
+		}
+	}
+}
+
+func (net networkdNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Getting network interfaces")
+	}
+
+	staticConfigs, dhcpConfigs, err := net.interfaceConfigurationCreator.CreateInterfaceConfigurations(networks, interfacesByMacAddress)
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Creating interface configurations")
+	}
+
+	return staticConfigs, dhcpConfigs, nil
+}
+
+func (net networkdNetManager) ifaceNames(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations) []string {
+	ifaceNames := newLinkTopology(staticConfigs, dhcpConfigs).LowerInterfaces()
+	for _, config := range dhcpConfigs {
+		ifaceNames = append(ifaceNames, config.Name)
+	}
+	for _, config := range staticConfigs {
+		ifaceNames = append(ifaceNames, config.Name)
+	}
+	return ifaceNames
+}
+
+func (net networkdNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
+	staticAddresses := []boship.InterfaceAddress{}
+	for _, iface := range staticConfigs {
+		for _, address := range iface.IPAddresses() {
+			staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, address))
+		}
+	}
+	dynamicAddresses := []boship.InterfaceAddress{}
+	for _, iface := range dhcpConfigs {
+		dynamicAddresses = append(dynamicAddresses, boship.NewResolvingInterfaceAddress(iface.Name, net.ipResolver))
+	}
+
+	return staticAddresses, dynamicAddresses
+}
+
+func (net networkdNetManager) broadcastIps(addresses []boship.InterfaceAddress, errCh chan error) {
+	go func() {
+		net.addressBroadcaster.BroadcastMACAddresses(addresses)
+		if errCh != nil {
+			errCh <- nil
+		}
+	}()
+}
@@ -0,0 +1,544 @@
+package net_test
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("networkdNetManager", func() {
+	var (
+		fs                     *fakesys.FakeFileSystem
+		cmdRunner              *fakesys.FakeCmdRunner
+		interfaceAddrsProvider *fakeip.FakeInterfaceAddressesProvider
+		addressBroadcaster     *fakearp.FakeAddressBroadcaster
+		routesSearcher         *fakenet.FakeRoutesSearcher
+		netManager             Manager
+
+		dhcpNetwork   boshsettings.Network
+		staticNetwork boshsettings.Network
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
+		addressBroadcaster = &fakearp.FakeAddressBroadcaster{}
+		routesSearcher = &fakenet.FakeRoutesSearcher{}
+		netManager = NewNetworkdNetManager(
+			fs,
+			cmdRunner,
+			&fakeip.FakeResolver{},
+			NewInterfaceConfigurationCreator(logger),
+			boship.NewInterfaceAddressesValidator(interfaceAddrsProvider),
+			NewDNSValidator(fs),
+			NewRoutesValidator(routesSearcher),
+			addressBroadcaster,
+			logger,
+		)
+
+		dhcpNetwork = boshsettings.Network{
+			Type:    "dynamic",
+			Default: []string{"dns"},
+			DNS:     []string{"8.8.8.8", "9.9.9.9"},
+			Mac:     "fake-dhcp-mac-address",
+		}
+		staticNetwork = boshsettings.Network{
+			Type:    "manual",
+			IP:      "1.2.3.4",
+			Default: []string{"gateway"},
+			Netmask: "255.255.255.0",
+			Gateway: "3.4.5.6",
+			Mac:     "fake-static-mac-address",
+		}
+
+		interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+			boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+		}
+		fs.WriteFileString("/etc/resolv.conf", `
+nameserver 8.8.8.8
+nameserver 9.9.9.9
+`)
+	})
+
+	stubInterfaces := func(physicalInterfaces map[string]boshsettings.Network) {
+		interfacePaths := []string{}
+
+		for iface, networkSettings := range physicalInterfaces {
+			interfacePath := fmt.Sprintf("/sys/class/net/%s", iface)
+			fs.WriteFile(interfacePath, []byte{})
+			fs.WriteFile(fmt.Sprintf("/sys/class/net/%s/device", iface), []byte{})
+			fs.WriteFileString(fmt.Sprintf("/sys/class/net/%s/address", iface), fmt.Sprintf("%s\n", networkSettings.Mac))
+			interfacePaths = append(interfacePaths, interfacePath)
+		}
+
+		fs.SetGlob("/sys/class/net/*", interfacePaths)
+	}
+
+	Describe("SetupNetworking", func() {
+		BeforeEach(func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+		})
+
+		It("writes networkd configuration for static and dynamic interfaces", func() {
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethstatic
+
+[Network]
+Address=1.2.3.4/24
+Gateway=3.4.5.6
+`))
+
+			dhcpConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethdhcp.network")
+			Expect(dhcpConfig).ToNot(BeNil())
+			Expect(dhcpConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethdhcp
+
+[Network]
+DHCP=ipv4
+`))
+		})
+
+		It("writes dns servers for systemd-resolved", func() {
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			resolvedConfig := fs.GetFileTestStat("/etc/systemd/resolved.conf.d/bosh.conf")
+			Expect(resolvedConfig).ToNot(BeNil())
+			Expect(resolvedConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Resolve]
+DNS=8.8.8.8 9.9.9.9
+`))
+
+			resolvConf := fs.GetFileTestStat("/etc/resolv.conf")
+			Expect(resolvConf.SymlinkTarget).To(Equal("/run/systemd/resolve/resolv.conf"))
+
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"systemctl", "restart", "systemd-resolved"}))
+		})
+
+		It("writes IPv6 and additional IPv4 addresses of dual-stack networks", func() {
+			dualStackNetwork := boshsettings.Network{
+				Type:    "manual",
+				Default: []string{"gateway"},
+				Mac:     "fake-static-mac-address",
+				Addresses: []boshsettings.NetworkAddress{
+					{IP: "1.2.3.4", Prefix: 24, Gateway: "1.2.3.1"},
+					{IP: "1.2.4.4", Prefix: 16},
+					{IP: "2001:db8::4", Prefix: 64, Gateway: "2001:db8::1"},
+				},
+			}
+			dhcpNetwork.IPv6Mode = boshsettings.NetworkIPv6ModeSLAAC
+
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.4.4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "2001:db8::4"),
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": dualStackNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(staticConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethstatic
+
+[Network]
+Address=1.2.3.4/24
+Address=1.2.4.4/16
+Address=2001:db8::4/64
+Gateway=1.2.3.1
+Gateway=2001:db8::1
+`))
+
+			dhcpConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethdhcp.network")
+			Expect(dhcpConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethdhcp
+
+[Network]
+DHCP=ipv4
+IPv6AcceptRA=yes
+`))
+		})
+
+		It("writes static routes and policy routing rules", func() {
+			staticNetwork.Routes = []boshsettings.NetworkRoute{{Destination: "192.168.0.0/16", Gateway: "1.2.3.7", Metric: 10}}
+			staticNetwork.RoutingTable = 100
+			routesSearcher.SearchRoutesRoutes = []Route{{Destination: "192.168.0.0", Netmask: "255.255.0.0", InterfaceName: "ethstatic"}}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(staticConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethstatic
+
+[Network]
+Address=1.2.3.4/24
+Gateway=3.4.5.6
+
+[Route]
+Destination=192.168.0.0/16
+Gateway=1.2.3.7
+Metric=10
+
+[Route]
+Destination=1.2.3.0/24
+Table=100
+
+[Route]
+Destination=0.0.0.0/0
+Gateway=3.4.5.6
+Table=100
+
+[RoutingPolicyRule]
+From=1.2.3.4
+Table=100
+`))
+		})
+
+		It("reloads networkd and reconfigures interfaces when configuration changes", func() {
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"networkctl", "reload"}))
+			Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"networkctl", "reconfigure", "ethdhcp", "ethstatic"}))
+			Expect(cmdRunner.RunCommands[2]).To(Equal([]string{
+				"systemd-networkd-wait-online", "--timeout=60", "--interface=ethdhcp", "--interface=ethstatic",
+			}))
+		})
+
+		It("waits for reconfigured interfaces before validating their configuration", func() {
+			interfaceAddresses := interfaceAddrsProvider.GetInterfaceAddresses
+			interfaceAddrsProvider.GetInterfaceAddresses = nil
+
+			cmdRunner.SetCmdCallback("systemd-networkd-wait-online --timeout=60 --interface=ethdhcp --interface=ethstatic", func() {
+				interfaceAddrsProvider.GetInterfaceAddresses = interfaceAddresses
+			})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not reload networkd when configuration does not change", func() {
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			cmdRunner.RunCommands = [][]string{}
+
+			err = netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("removes configuration of interfaces that are no longer used", func() {
+			fs.WriteFileString("/etc/systemd/network/10-bosh-ethold.network", "fake-old-config")
+			fs.SetGlob("/etc/systemd/network/10-bosh-*.network", []string{
+				"/etc/systemd/network/10-bosh-ethold.network",
+				"/etc/systemd/network/10-bosh-ethstatic.network",
+			})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethold.network")).To(BeFalse())
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethstatic.network")).To(BeTrue())
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"networkctl", "reload"}))
+		})
+
+		It("writes netdev files of bonds and VLANs and enslaves interfaces they are built on", func() {
+			staticNetwork.Mac = ""
+			staticNetwork.Bond = &boshsettings.NetworkBond{
+				Name:    "bond0",
+				Mode:    "active-backup",
+				MIIMon:  100,
+				Members: []string{"fake-static-mac-address", "fake-dhcp-mac-address"},
+			}
+			staticNetwork.VLAN = 100
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0.100", "1.2.3.4"),
+			}
+			fs.WriteFileString("/etc/systemd/network/10-bosh-bond1.netdev", "fake-old-config")
+			fs.SetGlob("/etc/systemd/network/10-bosh-*.netdev", []string{"/etc/systemd/network/10-bosh-bond1.netdev"})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			bondNetdev := fs.GetFileTestStat("/etc/systemd/network/10-bosh-bond0.netdev")
+			Expect(bondNetdev).ToNot(BeNil())
+			Expect(bondNetdev.StringContents()).To(Equal(`# Generated by bosh-agent
+[NetDev]
+Name=bond0
+Kind=bond
+
+[Bond]
+Mode=active-backup
+MIIMonitorSec=100ms
+`))
+
+			vlanNetdev := fs.GetFileTestStat("/etc/systemd/network/10-bosh-bond0.100.netdev")
+			Expect(vlanNetdev).ToNot(BeNil())
+			Expect(vlanNetdev.StringContents()).To(Equal(`# Generated by bosh-agent
+[NetDev]
+Name=bond0.100
+Kind=vlan
+
+[VLAN]
+Id=100
+`))
+
+			memberConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(memberConfig).ToNot(BeNil())
+			Expect(memberConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethstatic
+
+[Network]
+Bond=bond0
+`))
+
+			bondConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-bond0.network")
+			Expect(bondConfig).ToNot(BeNil())
+			Expect(bondConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=bond0
+
+[Network]
+VLAN=bond0.100
+`))
+
+			vlanConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-bond0.100.network")
+			Expect(vlanConfig).ToNot(BeNil())
+			Expect(vlanConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=bond0.100
+
+[Network]
+Address=1.2.3.4/24
+Gateway=3.4.5.6
+`))
+
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-bond1.netdev")).To(BeFalse())
+			Expect(cmdRunner.RunCommands[1]).To(Equal([]string{"networkctl", "reconfigure", "ethstatic", "ethdhcp", "bond0", "bond0.100"}))
+		})
+
+		It("raises MTU of bond slaves and VLAN parents to MTU of interfaces built on them", func() {
+			staticNetwork.Mac = ""
+			staticNetwork.Bond = &boshsettings.NetworkBond{
+				Name:    "bond0",
+				Members: []string{"fake-static-mac-address"},
+			}
+			staticNetwork.VLAN = 100
+			staticNetwork.MTU = 9000
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0.100", "1.2.3.4"),
+			}
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"ethstatic": 9000, "bond0": 9000, "bond0.100": 9000}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			memberConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(memberConfig).ToNot(BeNil())
+			Expect(memberConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethstatic
+
+[Link]
+MTUBytes=9000
+
+[Network]
+Bond=bond0
+`))
+
+			bondConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-bond0.network")
+			Expect(bondConfig).ToNot(BeNil())
+			Expect(bondConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=bond0
+
+[Link]
+MTUBytes=9000
+
+[Network]
+VLAN=bond0.100
+`))
+		})
+
+		It("writes MTU and sets offload features with ethtool", func() {
+			staticNetwork.MTU = 9000
+			staticNetwork.Offload = map[string]bool{"tso": false}
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"ethstatic": 9000}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			staticConfig := fs.GetFileTestStat("/etc/systemd/network/10-bosh-ethstatic.network")
+			Expect(staticConfig).ToNot(BeNil())
+			Expect(staticConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+[Match]
+Name=ethstatic
+
+[Link]
+MTUBytes=9000
+
+[Network]
+Address=1.2.3.4/24
+Gateway=3.4.5.6
+`))
+
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"ethtool", "-K", "ethstatic", "tso", "off"}))
+		})
+
+		It("skips vip networks", func() {
+			vipNetwork := boshsettings.Network{
+				Type:    "vip",
+				Default: []string{"dns"},
+				DNS:     []string{"8.8.8.8"},
+				Mac:     "fake-vip-mac-address",
+				IP:      "9.8.7.6",
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"vip-network": vipNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethstatic.network")).To(BeTrue())
+		})
+
+		It("broadcasts MAC addresses for all interfaces", func() {
+			errCh := make(chan error)
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, errCh)
+			Expect(err).ToNot(HaveOccurred())
+
+			<-errCh
+
+			Expect(addressBroadcaster.BroadcastMACAddressesAddresses).To(Equal([]boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+				boship.NewResolvingInterfaceAddress("ethdhcp", &fakeip.FakeResolver{}),
+			}))
+		})
+
+		It("returns error when static addresses are not configured", func() {
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.5"),
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating static network configuration"))
+		})
+
+		It("returns error when writing configuration fails", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+		})
+
+		Context("when networks are preconfigured", func() {
+			BeforeEach(func() {
+				dhcpNetwork.Preconfigured = true
+				staticNetwork.Preconfigured = true
+			})
+
+			It("only writes dns servers", func() {
+				err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethstatic.network")).To(BeFalse())
+				Expect(fs.FileExists("/etc/systemd/resolved.conf.d/bosh.conf")).To(BeTrue())
+				Expect(cmdRunner.RunCommands).To(Equal([][]string{{"systemctl", "restart", "systemd-resolved"}}))
+			})
+		})
+	})
+
+	Describe("PlanNetworking", func() {
+		BeforeEach(func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+		})
+
+		It("returns rendered files and interfaces to restart without changing anything", func() {
+			fs.WriteFileString("/etc/systemd/network/10-bosh-ethold.network", "fake-old-config")
+			fs.SetGlob("/etc/systemd/network/10-bosh-*.network", []string{"/etc/systemd/network/10-bosh-ethold.network"})
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(BeEmpty())
+			Expect(plan.RestartedInterfaces).To(Equal([]string{"ethdhcp", "ethstatic"}))
+
+			filesByPath := map[string]PlannedFile{}
+			for _, file := range plan.Files {
+				filesByPath[file.Path] = file
+			}
+
+			Expect(filesByPath).To(HaveKey("/etc/systemd/network/10-bosh-ethdhcp.network"))
+			Expect(filesByPath).To(HaveKey("/etc/systemd/resolved.conf.d/bosh.conf"))
+			Expect(filesByPath["/etc/systemd/network/10-bosh-ethstatic.network"].Content).To(ContainSubstring("Address=1.2.3.4/24\n"))
+			Expect(filesByPath["/etc/systemd/network/10-bosh-ethold.network"]).To(Equal(PlannedFile{
+				Path:    "/etc/systemd/network/10-bosh-ethold.network",
+				Diff:    "-fake-old-config\n",
+				Changed: true,
+				Removed: true,
+			}))
+			Expect(filesByPath["/etc/resolv.conf"].SymlinkTarget).To(Equal("/run/systemd/resolve/resolv.conf"))
+
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethold.network")).To(BeTrue())
+			Expect(fs.FileExists("/etc/systemd/network/10-bosh-ethstatic.network")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("reports networks without matching interfaces", func() {
+			staticNetwork.Mac = "fake-missing-mac-address"
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(HaveLen(1))
+			Expect(plan.Errors[0]).To(ContainSubstring("with MAC address 'fake-missing-mac-address'"))
+			Expect(plan.Files).To(BeEmpty())
+		})
+	})
+
+	Describe("GetConfiguredNetworkInterfaces", func() {
+		It("returns interfaces that have networkd configuration", func() {
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "fake-mac-1"},
+				"eth1": {Mac: "fake-mac-2"},
+			})
+			fs.WriteFileString("/etc/systemd/network/10-bosh-eth1.network", "fake-config")
+
+			interfaces, err := netManager.GetConfiguredNetworkInterfaces()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(interfaces).To(Equal([]string{"eth1"}))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package net
+
+import (
+	gonet "net"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const reachabilityDialTimeout = 5 * time.Second
+
+type ReachabilityChecker interface {
+	// Check dials targets in host:port form and pings other targets
+	Check(target string) error
+}
+
+type reachabilityChecker struct {
+	cmdRunner boshsys.CmdRunner
+}
+
+func NewReachabilityChecker(cmdRunner boshsys.CmdRunner) ReachabilityChecker {
+	return reachabilityChecker{cmdRunner: cmdRunner}
+}
+
+func (c reachabilityChecker) Check(target string) error {
+	if _, _, err := gonet.SplitHostPort(target); err == nil {
+		conn, err := gonet.DialTimeout("tcp", target, reachabilityDialTimeout)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Connecting to '%s'", target)
+		}
+
+		return conn.Close()
+	}
+
+	_, _, _, err := c.cmdRunner.RunCommand("ping", pingArgs(target)...)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Pinging '%s'", target)
+	}
+
+	return nil
+}
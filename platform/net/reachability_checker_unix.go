@@ -0,0 +1,7 @@
+// +build !windows
+
+package net
+
+func pingArgs(host string) []string {
+	return []string{"-c", "1", "-W", "2", host}
+}
@@ -0,0 +1,5 @@
+package net
+
+func pingArgs(host string) []string {
+	return []string{"-n", "1", "-w", "2000", host}
+}
@@ -1,8 +1,13 @@
 package net
 
+import (
+	"strings"
+)
+
 type Route struct {
 	Destination   string
 	Gateway       string
+	Netmask       string // empty when destination includes prefix length
 	InterfaceName string
 }
 
@@ -11,5 +16,9 @@ type RoutesSearcher interface {
 }
 
 func (r Route) IsDefault() bool {
-	return r.Destination == "0.0.0.0"
+	return r.Destination == "0.0.0.0" || r.Destination == "::/0"
+}
+
+func (r Route) IsIPv6() bool {
+	return strings.Contains(r.Destination, ":")
 }
@@ -41,9 +41,41 @@ func (s cmdRoutesSearcher) SearchRoutes() ([]Route, error) {
 		routes = append(routes, Route{
 			Destination:   routeFields[0],
 			Gateway:       routeFields[1],
+			Netmask:       routeFields[2],
 			InterfaceName: routeFields[7],
 		})
 	}
 
-	return routes, nil
+	return append(routes, s.searchIPv6Routes()...), nil
+}
+
+// searchIPv6Routes ignores failures since IPv6 might be disabled in the kernel
+func (s cmdRoutesSearcher) searchIPv6Routes() []Route {
+	var routes []Route
+
+	stdout, _, _, err := s.runner.RunCommand("route", "-n", "-A", "inet6")
+	if err != nil {
+		return routes
+	}
+
+	for i, routeEntry := range strings.Split(stdout, "\n") {
+		if i < 2 { // first two lines are informational
+			continue
+		}
+
+		routeFields := strings.Fields(routeEntry)
+
+		// Skip empty lines and local & unreachable routes on loopback interface
+		if len(routeFields) < 7 || routeFields[6] == "lo" {
+			continue
+		}
+
+		routes = append(routes, Route{
+			Destination:   routeFields[0],
+			Gateway:       routeFields[1],
+			InterfaceName: routeFields[6],
+		})
+	}
+
+	return routes
 }
@@ -38,9 +38,54 @@ Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
 				routes, err := searcher.SearchRoutes()
 				Expect(err).ToNot(HaveOccurred())
 				Expect(routes).To(Equal([]Route{
-					Route{Destination: "172.16.79.0", Gateway: "0.0.0.0", InterfaceName: "eth0"},
-					Route{Destination: "169.254.0.0", Gateway: "0.0.0.0", InterfaceName: "eth0"},
-					Route{Destination: "0.0.0.0", Gateway: "172.16.79.1", InterfaceName: "eth0"},
+					Route{Destination: "172.16.79.0", Gateway: "0.0.0.0", Netmask: "255.255.255.0", InterfaceName: "eth0"},
+					Route{Destination: "169.254.0.0", Gateway: "0.0.0.0", Netmask: "255.255.0.0", InterfaceName: "eth0"},
+					Route{Destination: "0.0.0.0", Gateway: "172.16.79.1", Netmask: "0.0.0.0", InterfaceName: "eth0"},
+				}))
+			})
+
+			It("includes IPv6 routes except ones on loopback interface", func() {
+				runner.AddCmdResult("route -n", fakesys.FakeCmdResult{
+					Stdout: `Kernel IP routing table
+Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
+0.0.0.0         172.16.79.1     0.0.0.0         UG    0      0        0 eth0
+`,
+				})
+				runner.AddCmdResult("route -n -A inet6", fakesys.FakeCmdResult{
+					Stdout: `Kernel IPv6 routing table
+Destination                    Next Hop                   Flag Met Ref Use If
+2001:db8::/64                  ::                         U    256 0     0 eth0
+::/0                           2001:db8::1                UG   1024 0    0 eth0
+::1/128                        ::                         Un   0   2     0 lo
+`,
+				})
+
+				routes, err := searcher.SearchRoutes()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(routes).To(Equal([]Route{
+					Route{Destination: "0.0.0.0", Gateway: "172.16.79.1", Netmask: "0.0.0.0", InterfaceName: "eth0"},
+					Route{Destination: "2001:db8::/64", Gateway: "::", InterfaceName: "eth0"},
+					Route{Destination: "::/0", Gateway: "2001:db8::1", InterfaceName: "eth0"},
+				}))
+				Expect(routes[2].IsDefault()).To(BeTrue())
+				Expect(routes[2].IsIPv6()).To(BeTrue())
+			})
+
+			It("ignores failures listing IPv6 routes", func() {
+				runner.AddCmdResult("route -n", fakesys.FakeCmdResult{
+					Stdout: `Kernel IP routing table
+Destination     Gateway         Genmask         Flags Metric Ref    Use Iface
+0.0.0.0         172.16.79.1     0.0.0.0         UG    0      0        0 eth0
+`,
+				})
+				runner.AddCmdResult("route -n -A inet6", fakesys.FakeCmdResult{
+					Error: errors.New("fake-run-err"),
+				})
+
+				routes, err := searcher.SearchRoutes()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(routes).To(Equal([]Route{
+					Route{Destination: "0.0.0.0", Gateway: "172.16.79.1", Netmask: "0.0.0.0", InterfaceName: "eth0"},
 				}))
 			})
 
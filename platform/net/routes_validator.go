@@ -0,0 +1,120 @@
+package net
+
+import (
+	gonet "net"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type RoutesValidator interface {
+	// Validate checks that routes in the main routing table
+	// are present via their interfaces; routes in other tables are skipped
+	Validate(routesByInterface map[string][]RouteConfiguration) error
+}
+
+type routesValidator struct {
+	routesSearcher RoutesSearcher
+}
+
+func NewRoutesValidator(routesSearcher RoutesSearcher) RoutesValidator {
+	return &routesValidator{
+		routesSearcher: routesSearcher,
+	}
+}
+
+func (v *routesValidator) Validate(routesByInterface map[string][]RouteConfiguration) error {
+	var desiredRoutes []RouteConfiguration
+	var desiredIfaceNames []string
+
+	for ifaceName, routes := range routesByInterface {
+		for _, route := range routes {
+			if route.Table == 0 {
+				desiredRoutes = append(desiredRoutes, route)
+				desiredIfaceNames = append(desiredIfaceNames, ifaceName)
+			}
+		}
+	}
+
+	if len(desiredRoutes) == 0 {
+		return nil
+	}
+
+	actualRoutes, err := v.routesSearcher.SearchRoutes()
+	if err != nil {
+		return bosherr.WrapError(err, "Searching routes")
+	}
+
+	for i, desiredRoute := range desiredRoutes {
+		_, desiredDestination, err := gonet.ParseCIDR(desiredRoute.Destination)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Parsing destination of route '%s'", desiredRoute.Destination)
+		}
+
+		if !v.hasRoute(actualRoutes, desiredDestination, desiredIfaceNames[i]) {
+			return bosherr.Errorf("Route to '%s' via interface '%s' was not found", desiredRoute.Destination, desiredIfaceNames[i])
+		}
+	}
+
+	return nil
+}
+
+func (v *routesValidator) hasRoute(actualRoutes []Route, desiredDestination *gonet.IPNet, ifaceName string) bool {
+	desiredOnes, desiredBits := desiredDestination.Mask.Size()
+
+	for _, actualRoute := range actualRoutes {
+		if actualRoute.InterfaceName != ifaceName {
+			continue
+		}
+
+		actualDestination := routeDestination(actualRoute)
+		if actualDestination == nil || !actualDestination.IP.Equal(desiredDestination.IP) {
+			continue
+		}
+
+		actualOnes, actualBits := actualDestination.Mask.Size()
+		if actualOnes == desiredOnes && actualBits == desiredBits {
+			return true
+		}
+	}
+
+	return false
+}
+
+// routeDestination combines destination and netmask of IPv4 routes
+// since route searcher reports prefix length only for IPv6 destinations
+func routeDestination(route Route) *gonet.IPNet {
+	if strings.Contains(route.Destination, "/") {
+		_, destination, err := gonet.ParseCIDR(route.Destination)
+		if err != nil {
+			return nil
+		}
+		return destination
+	}
+
+	ip := gonet.ParseIP(route.Destination).To4()
+	mask := gonet.ParseIP(route.Netmask).To4()
+	if ip == nil || mask == nil {
+		return nil
+	}
+
+	return &gonet.IPNet{IP: ip, Mask: gonet.IPMask(mask)}
+}
+
+func interfaceRoutes(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) map[string][]RouteConfiguration {
+	routesByInterface := map[string][]RouteConfiguration{}
+
+	for _, config := range staticConfigs {
+		if len(config.Routes) > 0 {
+			routesByInterface[config.Name] = config.Routes
+		}
+	}
+
+	for _, config := range dhcpConfigs {
+		if len(config.Routes) > 0 {
+			routesByInterface[config.Name] = config.Routes
+		}
+	}
+
+	return routesByInterface
+}
@@ -0,0 +1,82 @@
+package net_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
+)
+
+var _ = Describe("RoutesValidator", func() {
+	var (
+		routesSearcher  *fakenet.FakeRoutesSearcher
+		routesValidator RoutesValidator
+	)
+
+	BeforeEach(func() {
+		routesSearcher = &fakenet.FakeRoutesSearcher{
+			SearchRoutesRoutes: []Route{
+				{Destination: "192.168.0.0", Gateway: "10.0.0.2", Netmask: "255.255.0.0", InterfaceName: "eth0"},
+				{Destination: "2001:db8:5::/48", Gateway: "2001:db8::2", InterfaceName: "eth1"},
+			},
+		}
+		routesValidator = NewRoutesValidator(routesSearcher)
+	})
+
+	It("returns nil when all routes are found via their interfaces", func() {
+		err := routesValidator.Validate(map[string][]RouteConfiguration{
+			"eth0": {{Destination: "192.168.0.0/16", Gateway: "10.0.0.2"}},
+			"eth1": {{Destination: "2001:db8:5::/48", Gateway: "2001:db8::2"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("skips routes in other routing tables", func() {
+		err := routesValidator.Validate(map[string][]RouteConfiguration{
+			"eth0": {{Destination: "0.0.0.0/0", Gateway: "10.0.0.1", Table: 100}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("does not search routes when there are no routes to validate", func() {
+		routesSearcher.SearchRoutesErr = errors.New("fake-search-err")
+
+		err := routesValidator.Validate(map[string][]RouteConfiguration{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns error when route is found via different interface", func() {
+		err := routesValidator.Validate(map[string][]RouteConfiguration{
+			"eth1": {{Destination: "192.168.0.0/16", Gateway: "10.0.0.2"}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Route to '192.168.0.0/16' via interface 'eth1' was not found"))
+	})
+
+	It("returns error when route is found with different prefix length", func() {
+		err := routesValidator.Validate(map[string][]RouteConfiguration{
+			"eth0": {{Destination: "192.168.0.0/24", Gateway: "10.0.0.2"}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Route to '192.168.0.0/24' via interface 'eth0' was not found"))
+
+		err = routesValidator.Validate(map[string][]RouteConfiguration{
+			"eth1": {{Destination: "2001:db8:5::/64", Gateway: "2001:db8::2"}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Route to '2001:db8:5::/64' via interface 'eth1' was not found"))
+	})
+
+	It("returns error when searching routes fails", func() {
+		routesSearcher.SearchRoutesErr = errors.New("fake-search-err")
+
+		err := routesValidator.Validate(map[string][]RouteConfiguration{
+			"eth0": {{Destination: "192.168.0.0/16"}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-search-err"))
+	})
+})
@@ -2,7 +2,7 @@ package net
 
 import (
 	"bytes"
-	"path"
+	"fmt"
 	"sort"
 	"strings"
 	"text/template"
@@ -24,6 +24,7 @@ type UbuntuNetManager struct {
 	interfaceConfigurationCreator InterfaceConfigurationCreator
 	interfaceAddressesValidator   boship.InterfaceAddressesValidator
 	dnsValidator                  DNSValidator
+	routesValidator               RoutesValidator
 	addressBroadcaster            bosharp.AddressBroadcaster
 	logger                        boshlog.Logger
 }
@@ -35,6 +36,7 @@ func NewUbuntuNetManager(
 	interfaceConfigurationCreator InterfaceConfigurationCreator,
 	interfaceAddressesValidator boship.InterfaceAddressesValidator,
 	dnsValidator DNSValidator,
+	routesValidator RoutesValidator,
 	addressBroadcaster bosharp.AddressBroadcaster,
 	logger boshlog.Logger,
 ) Manager {
@@ -45,6 +47,7 @@ func NewUbuntuNetManager(
 		interfaceConfigurationCreator: interfaceConfigurationCreator,
 		interfaceAddressesValidator:   interfaceAddressesValidator,
 		dnsValidator:                  dnsValidator,
+		routesValidator:               routesValidator,
 		addressBroadcaster:            addressBroadcaster,
 		logger:                        logger,
 	}
@@ -125,6 +128,16 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errC
 		return bosherr.WrapError(err, "Validating static network configuration")
 	}
 
+	err = net.interfaceAddressesValidator.ValidateMTUs(interfaceMTUs(staticConfigs, dhcpConfigs))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating MTU")
+	}
+
+	err = net.routesValidator.Validate(interfaceRoutes(staticConfigs, dhcpConfigs))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating routes")
+	}
+
 	err = net.dnsValidator.Validate(dnsServers)
 	if err != nil {
 		return bosherr.WrapError(err, "Validating dns configuration")
@@ -135,10 +148,62 @@ func (net UbuntuNetManager) SetupNetworking(networks boshsettings.Networks, errC
 	return nil
 }
 
+func (net UbuntuNetManager) PlanNetworking(networks boshsettings.Networks) (NetworkPlan, error) {
+	plan := newNetworkPlan()
+
+	fs := newPlanningFileSystem(net.fs)
+	planner := net
+	planner.fs = fs
+	planner.cmdRunner = planningCmdRunner{}
+
+	if networks.IsPreconfigured() {
+		err := planner.writeResolvConf(networks)
+		if err != nil {
+			return plan, err
+		}
+
+		plan.Files = fs.PlannedFiles()
+		return plan, nil
+	}
+
+	staticConfigs, dhcpConfigs, dnsServers, err := planner.ComputeNetworkConfig(networks)
+	if err != nil {
+		plan.Errors = append(plan.Errors, err.Error())
+		return plan, nil
+	}
+
+	plan.Errors = append(plan.Errors, overlappingSubnetErrors(staticConfigs)...)
+
+	interfacesChanged, err := planner.writeNetworkInterfaces(dhcpConfigs, staticConfigs, dnsServers)
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Planning network configuration")
+	}
+
+	dhcpChanged := false
+	if len(dhcpConfigs) > 0 {
+		dhcpChanged, err = planner.writeDHCPConfiguration(dnsServers)
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	if interfacesChanged || dhcpChanged {
+		plan.RestartedInterfaces = net.ifaceNames(dhcpConfigs, staticConfigs)
+	}
+
+	plan.Files = fs.PlannedFiles()
+
+	return plan, nil
+}
+
+func (net UbuntuNetManager) configurationFiles() []string {
+	return []string{"/etc/network/interfaces", "/etc/dhcp/dhclient.conf"}
+}
+
 func (net UbuntuNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 	interfaces := []string{}
 
-	interfacesByMacAddress, err := net.detectMacAddresses()
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
 	if err != nil {
 		return interfaces, bosherr.WrapError(err, "Getting network interfaces")
 	}
@@ -166,7 +231,7 @@ func (net UbuntuNetManager) removeDhcpDNSConfiguration() error {
 		net.logger.Error(UbuntuNetManagerLogTag, "Ignoring failure calling 'pkill dhclient': %s", err)
 	}
 
-	interfacesByMacAddress, err := net.detectMacAddresses()
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
 	if err != nil {
 		return err
 	}
@@ -185,7 +250,7 @@ func (net UbuntuNetManager) removeDhcpDNSConfiguration() error {
 }
 
 func (net UbuntuNetManager) buildInterfaces(networks boshsettings.Networks) ([]StaticInterfaceConfiguration, []DHCPInterfaceConfiguration, error) {
-	interfacesByMacAddress, err := net.detectMacAddresses()
+	interfacesByMacAddress, err := detectMacAddresses(net.fs)
 	if err != nil {
 		return nil, nil, bosherr.WrapError(err, "Getting network interfaces")
 	}
@@ -205,7 +270,9 @@ func (net UbuntuNetManager) buildInterfaces(networks boshsettings.Networks) ([]S
 func (net UbuntuNetManager) ifaceAddresses(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) ([]boship.InterfaceAddress, []boship.InterfaceAddress) {
 	staticAddresses := []boship.InterfaceAddress{}
 	for _, iface := range staticConfigs {
-		staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, iface.Address))
+		for _, address := range iface.IPAddresses() {
+			staticAddresses = append(staticAddresses, boship.NewSimpleInterfaceAddress(iface.Name, address))
+		}
 	}
 	dynamicAddresses := []boship.InterfaceAddress{}
 	for _, iface := range dhcpConfigs {
@@ -227,7 +294,13 @@ func (net UbuntuNetManager) broadcastIps(addresses []boship.InterfaceAddress, er
 func (net UbuntuNetManager) restartNetworkingInterfaces(ifaceNames []string) {
 	net.logger.Debug(UbuntuNetManagerLogTag, "Restarting network interfaces")
 
-	_, _, _, err := net.cmdRunner.RunCommand("ifdown", append([]string{"--force"}, ifaceNames...)...)
+	// Interfaces are brought down before interfaces they are built on
+	reversedIfaceNames := make([]string, len(ifaceNames))
+	for i, name := range ifaceNames {
+		reversedIfaceNames[len(ifaceNames)-1-i] = name
+	}
+
+	_, _, _, err := net.cmdRunner.RunCommand("ifdown", append([]string{"--force"}, reversedIfaceNames...)...)
 	if err != nil {
 		net.logger.Error(UbuntuNetManagerLogTag, "Ignoring ifdown failure: %s", err.Error())
 	}
@@ -244,7 +317,7 @@ func (net UbuntuNetManager) writeDHCPConfiguration(dnsServers []string) (bool, e
 
 	// Keep DNS servers in the order specified by the network
 	// because they are added by a *single* DHCP's prepend command
-	dnsServersList := strings.Join(dnsServers, ", ")
+	dnsServersList := strings.Join(ipv4DNSServers(dnsServers), ", ")
 	err := t.Execute(buffer, dnsServersList)
 	if err != nil {
 		return false, bosherr.WrapError(err, "Generating config from template")
@@ -264,6 +337,18 @@ type networkInterfaceConfig struct {
 	StaticConfigs     []StaticInterfaceConfiguration
 	DHCPConfigs       []DHCPInterfaceConfiguration
 	HasDNSNameServers bool
+
+	LowerInterfaces []lowerInterfaceConfig
+
+	// Options added to the first stanza of configured interfaces
+	InterfaceOptions map[string][]string
+}
+
+// lowerInterfaceConfig is an interface without addresses
+// that bonds, VLANs or bridges are built on
+type lowerInterfaceConfig struct {
+	Name    string
+	Options []string
 }
 
 func (net UbuntuNetManager) writeNetworkInterfaces(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations, dnsServers []string) (bool, error) {
@@ -275,11 +360,49 @@ func (net UbuntuNetManager) writeNetworkInterfaces(dhcpConfigs DHCPInterfaceConf
 		StaticConfigs:     staticConfigs,
 		HasDNSNameServers: true,
 		DNSServers:        dnsServers,
+		InterfaceOptions:  map[string][]string{},
+	}
+
+	topology := newLinkTopology(staticConfigs, dhcpConfigs)
+	mtus := interfaceMTUs(staticConfigs, dhcpConfigs)
+
+	for _, name := range topology.LowerInterfaces() {
+		options := ubuntuLinkOptions(topology, name)
+
+		if mtus[name] > 0 {
+			options = append(options, fmt.Sprintf("mtu %d", mtus[name]))
+		}
+
+		networkInterfaceValues.LowerInterfaces = append(networkInterfaceValues.LowerInterfaces, lowerInterfaceConfig{
+			Name:    name,
+			Options: options,
+		})
+	}
+
+	for _, config := range dhcpConfigs {
+		options := ubuntuLinkOptions(topology, config.Name)
+
+		// MTU received via DHCP is overridden once interface is up
+		if mtus[config.Name] > 0 {
+			options = append(options, fmt.Sprintf("post-up ip link set dev %s mtu %d", config.Name, mtus[config.Name]))
+		}
+
+		networkInterfaceValues.InterfaceOptions[config.Name] = append(options, ubuntuOffloadCommands(config.Name, config.Offloads)...)
+	}
+
+	for _, config := range staticConfigs {
+		options := ubuntuLinkOptions(topology, config.Name)
+
+		if mtus[config.Name] > 0 {
+			options = append(options, fmt.Sprintf("mtu %d", mtus[config.Name]))
+		}
+
+		networkInterfaceValues.InterfaceOptions[config.Name] = append(options, ubuntuOffloadCommands(config.Name, config.Offloads)...)
 	}
 
 	buffer := bytes.NewBuffer([]byte{})
 
-	t := template.Must(template.New("network-interfaces").Parse(networkInterfacesTemplate))
+	t := template.Must(template.New("network-interfaces").Funcs(networkInterfacesTemplateFuncs).Parse(networkInterfacesTemplate))
 
 	err := t.Execute(buffer, networkInterfaceValues)
 	if err != nil {
@@ -294,53 +417,133 @@ func (net UbuntuNetManager) writeNetworkInterfaces(dhcpConfigs DHCPInterfaceConf
 	return changed, nil
 }
 
+var networkInterfacesTemplateFuncs = template.FuncMap{
+	// ifupdown names SLAAC method 'auto' and DHCPv6 method 'dhcp'
+	"inet6Method": func(mode boshsettings.NetworkIPv6Mode) string {
+		if mode == boshsettings.NetworkIPv6ModeDHCPv6 {
+			return "dhcp"
+		}
+		return "auto"
+	},
+	"routeCommands": ubuntuRouteCommands,
+	"ruleCommands":  ubuntuRuleCommands,
+}
+
+// ubuntuLinkOptions returns options creating bond, VLAN or bridge
+// and enslaving interface to a bond; they are added to the first stanza
+// of the interface and require ifenslave, vlan and bridge-utils packages
+func ubuntuLinkOptions(topology linkTopology, ifaceName string) []string {
+	options := []string{}
+
+	if link, found := topology.Link(ifaceName); found {
+		switch link.Kind {
+		case LinkKindBond:
+			options = append(options, "bond-slaves none")
+			if link.BondMode != "" {
+				options = append(options, "bond-mode "+link.BondMode)
+			}
+			if link.BondMIIMon > 0 {
+				options = append(options, fmt.Sprintf("bond-miimon %d", link.BondMIIMon))
+			}
+		case LinkKindVLAN:
+			options = append(options, "vlan-raw-device "+link.Lower[0])
+		case LinkKindBridge:
+			options = append(options, "bridge_ports "+strings.Join(link.Lower, " "))
+		}
+	}
+
+	if master, found := topology.Master(ifaceName); found && master.Kind == LinkKindBond {
+		options = append(options, "bond-master "+master.Name)
+	}
+
+	return options
+}
+
+// ubuntuOffloadCommands returns commands toggling offload features;
+// they require ethtool package
+func ubuntuOffloadCommands(ifaceName string, offloads []OffloadConfiguration) []string {
+	if len(offloads) == 0 {
+		return nil
+	}
+
+	return []string{"post-up ethtool " + strings.Join(ethtoolOffloadArgs(ifaceName, offloads), " ")}
+}
+
+// ubuntuRouteCommands returns commands added to the last stanza of the interface;
+// routes are removed by the kernel once interface goes down
+func ubuntuRouteCommands(ifaceName string, routes []RouteConfiguration) []string {
+	commands := []string{}
+
+	for _, route := range routes {
+		commands = append(commands, fmt.Sprintf("post-up %s route add %s", ipCommand(route.IsIPv6()), route.IPRouteSpec(ifaceName)))
+	}
+
+	return commands
+}
+
+// ubuntuRuleCommands returns commands added to the last stanza of the interface;
+// unlike routes, rules have to be removed explicitly
+func ubuntuRuleCommands(rules []PolicyRuleConfiguration) []string {
+	commands := []string{}
+
+	for _, rule := range rules {
+		commands = append(commands, fmt.Sprintf("post-up %s rule add %s", ipCommand(rule.IsIPv6()), rule.IPRuleSpec()))
+	}
+
+	for _, rule := range rules {
+		commands = append(commands, fmt.Sprintf("pre-down %s rule del %s", ipCommand(rule.IsIPv6()), rule.IPRuleSpec()))
+	}
+
+	return commands
+}
+
+func ipCommand(isIPv6 bool) string {
+	if isIPv6 {
+		return "ip -6"
+	}
+	return "ip"
+}
+
 const networkInterfacesTemplate = `# Generated by bosh-agent
 auto lo
 iface lo inet loopback
-{{ range .DHCPConfigs }}
+{{ range .LowerInterfaces }}
 auto {{ .Name }}
-iface {{ .Name }} inet dhcp
-{{ end }}{{ range .StaticConfigs }}
+iface {{ .Name }} inet manual{{ range .Options }}
+    {{ . }}{{ end }}
+{{ end }}{{ range .DHCPConfigs }}
 auto {{ .Name }}
+iface {{ .Name }} inet dhcp
+{{ range index $.InterfaceOptions .Name }}    {{ . }}
+{{ end }}{{ if .IPv6Mode }}iface {{ .Name }} inet6 {{ inet6Method .IPv6Mode }}
+{{ end }}{{ range routeCommands .Name .Routes }}    {{ . }}
+{{ end }}{{ end }}{{ range $config := .StaticConfigs }}
+auto {{ .Name }}{{ if .Address }}
 iface {{ .Name }} inet static
     address {{ .Address }}
     network {{ .Network }}
     netmask {{ .Netmask }}
 {{ if .IsDefaultForGateway }}    broadcast {{ .Broadcast }}
-    gateway {{ .Gateway }}{{ end }}{{ end }}
+    gateway {{ .Gateway }}{{ end }}{{ range index $.InterfaceOptions .Name }}
+    {{ . }}{{ end }}{{ end }}{{ range .Aliases }}
+iface {{ $config.Name }} inet static
+    address {{ .Address }}
+    netmask {{ .Netmask }}{{ end }}{{ range $i, $address := .IPv6Addresses }}
+iface {{ $config.Name }} inet6 static
+    address {{ .Address }}
+    netmask {{ .PrefixLength }}{{ if and (eq $i 0) $config.IsDefaultForGateway $config.IPv6Gateway }}
+    gateway {{ $config.IPv6Gateway }}{{ end }}{{ if and (eq $i 0) (not $config.Address) }}{{ range index $.InterfaceOptions $config.Name }}
+    {{ . }}{{ end }}{{ end }}{{ end }}{{ if and .IPv6Mode (not .IPv6Addresses) }}
+iface {{ .Name }} inet6 {{ inet6Method .IPv6Mode }}{{ end }}{{ range routeCommands .Name .Routes }}
+    {{ . }}{{ end }}{{ range ruleCommands .PolicyRules }}
+    {{ . }}{{ end }}{{ end }}
 {{ if .DNSServers }}
 dns-nameservers{{ range .DNSServers }} {{ . }}{{ end }}{{ end }}`
 
-func (net UbuntuNetManager) detectMacAddresses() (map[string]string, error) {
-	addresses := map[string]string{}
-
-	filePaths, err := net.fs.Glob("/sys/class/net/*")
-	if err != nil {
-		return addresses, bosherr.WrapError(err, "Getting file list from /sys/class/net")
-	}
-
-	var macAddress string
-	for _, filePath := range filePaths {
-		isPhysicalDevice := net.fs.FileExists(path.Join(filePath, "device"))
-
-		if isPhysicalDevice {
-			macAddress, err = net.fs.ReadFileString(path.Join(filePath, "address"))
-			if err != nil {
-				return addresses, bosherr.WrapError(err, "Reading mac address from file")
-			}
-
-			macAddress = strings.Trim(macAddress, "\n")
-
-			interfaceName := path.Base(filePath)
-			addresses[macAddress] = interfaceName
-		}
-	}
-
-	return addresses, nil
-}
-
+// ifaceNames returns interfaces that links are built on
+// before interfaces with addresses so that they are brought up first
 func (net UbuntuNetManager) ifaceNames(dhcpConfigs DHCPInterfaceConfigurations, staticConfigs StaticInterfaceConfigurations) []string {
-	ifaceNames := []string{}
+	ifaceNames := newLinkTopology(staticConfigs, dhcpConfigs).LowerInterfaces()
 	for _, config := range dhcpConfigs {
 		ifaceNames = append(ifaceNames, config.Name)
 	}
@@ -3,6 +3,7 @@ package net_test
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -10,6 +11,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/factory"
 	. "github.com/cloudfoundry/bosh-agent/platform/net"
 	fakearp "github.com/cloudfoundry/bosh-agent/platform/net/arp/fakes"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
 	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
 	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
@@ -25,6 +27,7 @@ func describeUbuntuNetManager() {
 		cmdRunner                     *fakesys.FakeCmdRunner
 		ipResolver                    *fakeip.FakeResolver
 		addressBroadcaster            *fakearp.FakeAddressBroadcaster
+		routesSearcher                *fakenet.FakeRoutesSearcher
 		interfaceAddrsProvider        *fakeip.FakeInterfaceAddressesProvider
 		netManager                    UbuntuNetManager
 		interfaceConfigurationCreator InterfaceConfigurationCreator
@@ -69,6 +72,7 @@ func describeUbuntuNetManager() {
 		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
 		interfaceAddrsValidator := boship.NewInterfaceAddressesValidator(interfaceAddrsProvider)
 		dnsValidator := NewDNSValidator(fs)
+		routesSearcher = &fakenet.FakeRoutesSearcher{}
 		netManager = NewUbuntuNetManager(
 			fs,
 			cmdRunner,
@@ -76,6 +80,7 @@ func describeUbuntuNetManager() {
 			interfaceConfigurationCreator,
 			interfaceAddrsValidator,
 			dnsValidator,
+			NewRoutesValidator(routesSearcher),
 			addressBroadcaster,
 			logger,
 		).(UbuntuNetManager)
@@ -458,6 +463,316 @@ dns-nameservers 8.8.8.8`))
 
 		})
 
+		It("writes IPv6 addresses and additional IPv4 addresses of dual-stack networks", func() {
+			dualStackNetwork := boshsettings.Network{
+				Type:    "manual",
+				Default: []string{"gateway", "dns"},
+				DNS:     []string{"8.8.8.8", "2001:4860:4860::8888"},
+				Mac:     "fake-static-mac-address",
+				Addresses: []boshsettings.NetworkAddress{
+					{IP: "1.2.3.4", Prefix: 24, Gateway: "1.2.3.1"},
+					{IP: "1.2.4.4", Prefix: 24},
+					{IP: "2001:db8::4", Prefix: 64, Gateway: "2001:db8::1"},
+				},
+			}
+			dhcpNetwork.Default = nil
+			dhcpNetwork.IPv6Mode = boshsettings.NetworkIPv6ModeSLAAC
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": dualStackNetwork,
+			})
+
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.3.4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "1.2.4.4"),
+				boship.NewSimpleInterfaceAddress("ethstatic", "2001:db8::4"),
+			}
+			fs.WriteFileString("/etc/resolv.conf", `
+nameserver 8.8.8.8
+nameserver 2001:4860:4860:0:0:0:0:8888
+`)
+
+			err := netManager.SetupNetworking(boshsettings.Networks{
+				"dhcp-network":   dhcpNetwork,
+				"static-network": dualStackNetwork,
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+auto lo
+iface lo inet loopback
+
+auto ethdhcp
+iface ethdhcp inet dhcp
+iface ethdhcp inet6 auto
+
+auto ethstatic
+iface ethstatic inet static
+    address 1.2.3.4
+    network 1.2.3.0
+    netmask 255.255.255.0
+    broadcast 1.2.3.255
+    gateway 1.2.3.1
+iface ethstatic inet static
+    address 1.2.4.4
+    netmask 255.255.255.0
+iface ethstatic inet6 static
+    address 2001:db8::4
+    netmask 64
+    gateway 2001:db8::1
+
+dns-nameservers 8.8.8.8 2001:4860:4860::8888`))
+
+			dhcpConfig := fs.GetFileTestStat("/etc/dhcp/dhclient.conf")
+			Expect(dhcpConfig).ToNot(BeNil())
+			Expect(dhcpConfig.StringContents()).To(ContainSubstring("prepend domain-name-servers 8.8.8.8;"))
+		})
+
+		It("writes static routes and policy routing rules", func() {
+			staticNetwork.Routes = []boshsettings.NetworkRoute{
+				{Destination: "192.168.0.0/16", Gateway: "1.2.3.7", Metric: 10},
+				{Destination: "2001:db8:5::/48", Gateway: "2001:db8::2"},
+			}
+			staticNetwork.RoutingTable = 100
+			dhcpNetwork.Routes = []boshsettings.NetworkRoute{{Destination: "172.16.0.0/12", Gateway: "172.16.0.1"}}
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+			routesSearcher.SearchRoutesRoutes = []Route{
+				{Destination: "192.168.0.0", Netmask: "255.255.0.0", InterfaceName: "ethstatic"},
+				{Destination: "2001:db8:5::/48", InterfaceName: "ethstatic"},
+				{Destination: "172.16.0.0", Netmask: "255.240.0.0", InterfaceName: "ethdhcp"},
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+auto lo
+iface lo inet loopback
+
+auto ethdhcp
+iface ethdhcp inet dhcp
+    post-up ip route add 172.16.0.0/12 via 172.16.0.1 dev ethdhcp
+
+auto ethstatic
+iface ethstatic inet static
+    address 1.2.3.4
+    network 1.2.3.0
+    netmask 255.255.255.0
+    broadcast 1.2.3.255
+    gateway 3.4.5.6
+    post-up ip route add 192.168.0.0/16 via 1.2.3.7 dev ethstatic metric 10
+    post-up ip -6 route add 2001:db8:5::/48 via 2001:db8::2 dev ethstatic
+    post-up ip route add 1.2.3.0/24 dev ethstatic table 100
+    post-up ip route add 0.0.0.0/0 via 3.4.5.6 dev ethstatic table 100
+    post-up ip rule add from 1.2.3.4 table 100
+    pre-down ip rule del from 1.2.3.4 table 100
+
+dns-nameservers 8.8.8.8 9.9.9.9`))
+		})
+
+		It("writes bonds, VLANs and bridges before interfaces built on them", func() {
+			staticNetwork.Mac = ""
+			staticNetwork.Bond = &boshsettings.NetworkBond{
+				Name:    "bond0",
+				Mode:    "active-backup",
+				MIIMon:  100,
+				Members: []string{"fake-mac-1", "fake-mac-2"},
+			}
+			staticNetwork.VLAN = 100
+			staticNetwork.Bridge = "br0"
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "fake-mac-1"},
+				"eth1": {Mac: "fake-bond-mac"},
+			})
+			// Bonded interface reports MAC address of the bond
+			fs.WriteFileString("/sys/class/net/eth1/bonding_slave/perm_hwaddr", "fake-mac-2\n")
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("br0", "1.2.3.4"),
+			}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+auto lo
+iface lo inet loopback
+
+auto eth0
+iface eth0 inet manual
+    bond-master bond0
+
+auto eth1
+iface eth1 inet manual
+    bond-master bond0
+
+auto bond0
+iface bond0 inet manual
+    bond-slaves none
+    bond-mode active-backup
+    bond-miimon 100
+
+auto bond0.100
+iface bond0.100 inet manual
+    vlan-raw-device bond0
+
+auto br0
+iface br0 inet static
+    address 1.2.3.4
+    network 1.2.3.0
+    netmask 255.255.255.0
+    broadcast 1.2.3.255
+    gateway 3.4.5.6
+    bridge_ports bond0.100
+`))
+
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"ifdown", "--force", "br0", "bond0.100", "bond0", "eth1", "eth0"}))
+			Expect(cmdRunner.RunCommands).To(ContainElement([]string{"ifup", "--force", "eth0", "eth1", "bond0", "bond0.100", "br0"}))
+		})
+
+		It("raises MTU of bond slaves and VLAN parents to MTU of interfaces built on them", func() {
+			staticNetwork.Mac = ""
+			staticNetwork.Bond = &boshsettings.NetworkBond{
+				Name:    "bond0",
+				Members: []string{"fake-mac-1", "fake-mac-2"},
+			}
+			staticNetwork.VLAN = 100
+			staticNetwork.MTU = 9000
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"eth0": {Mac: "fake-mac-1"},
+				"eth1": {Mac: "fake-mac-2"},
+			})
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0.100", "1.2.3.4"),
+			}
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"eth0": 9000, "eth1": 9000, "bond0": 9000, "bond0.100": 9000}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(ContainSubstring(`
+auto eth0
+iface eth0 inet manual
+    bond-master bond0
+    mtu 9000
+
+auto eth1
+iface eth1 inet manual
+    bond-master bond0
+    mtu 9000
+
+auto bond0
+iface bond0 inet manual
+    bond-slaves none
+    mtu 9000
+
+auto bond0.100
+iface bond0.100 inet static
+    address 1.2.3.4
+    network 1.2.3.0
+    netmask 255.255.255.0
+    broadcast 1.2.3.255
+    gateway 3.4.5.6
+    vlan-raw-device bond0
+    mtu 9000
+`))
+		})
+
+		It("fails when MTU of bond slave is not applied after setup", func() {
+			staticNetwork.Mac = ""
+			staticNetwork.Bond = &boshsettings.NetworkBond{
+				Name:    "bond0",
+				Members: []string{"fake-mac-1"},
+			}
+			staticNetwork.MTU = 9000
+
+			stubInterfaces(map[string]boshsettings.Network{"eth0": {Mac: "fake-mac-1"}})
+			interfaceAddrsProvider.GetInterfaceAddresses = []boship.InterfaceAddress{
+				boship.NewSimpleInterfaceAddress("bond0", "1.2.3.4"),
+			}
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"eth0": 1500, "bond0": 9000}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating network interface 'eth0' MTU, expected: '9000', actual: '1500'"))
+		})
+
+		It("writes MTU and offload features and validates MTU", func() {
+			staticNetwork.MTU = 9000
+			staticNetwork.Offload = map[string]bool{"tso": false}
+			dhcpNetwork.MTU = 1450
+			dhcpNetwork.Offload = map[string]bool{"gro": true}
+
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethdhcp":   dhcpNetwork,
+				"ethstatic": staticNetwork,
+			})
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"ethstatic": 9000, "ethdhcp": 1450}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"dhcp-network": dhcpNetwork, "static-network": staticNetwork}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			networkConfig := fs.GetFileTestStat("/etc/network/interfaces")
+			Expect(networkConfig).ToNot(BeNil())
+			Expect(networkConfig.StringContents()).To(Equal(`# Generated by bosh-agent
+auto lo
+iface lo inet loopback
+
+auto ethdhcp
+iface ethdhcp inet dhcp
+    post-up ip link set dev ethdhcp mtu 1450
+    post-up ethtool -K ethdhcp gro on
+
+auto ethstatic
+iface ethstatic inet static
+    address 1.2.3.4
+    network 1.2.3.0
+    netmask 255.255.255.0
+    broadcast 1.2.3.255
+    gateway 3.4.5.6
+    mtu 9000
+    post-up ethtool -K ethstatic tso off
+
+dns-nameservers 8.8.8.8 9.9.9.9`))
+		})
+
+		It("fails when MTU is not applied after setup", func() {
+			staticNetwork.MTU = 9000
+
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": staticNetwork})
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"ethstatic": 1500}
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating MTU"))
+			Expect(err.Error()).To(ContainSubstring("Validating network interface 'ethstatic' MTU, expected: '9000', actual: '1500'"))
+		})
+
+		It("fails when static routes are not present after setup", func() {
+			staticNetwork.Routes = []boshsettings.NetworkRoute{{Destination: "192.168.0.0/16", Gateway: "1.2.3.7"}}
+
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": staticNetwork})
+
+			err := netManager.SetupNetworking(boshsettings.Networks{"static-network": staticNetwork}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating routes"))
+			Expect(err.Error()).To(ContainSubstring("Route to '192.168.0.0/16' via interface 'ethstatic' was not found"))
+		})
+
 		It("writes /etc/network/interfaces without dns-namservers if there are no dns servers", func() {
 			staticNetworkWithoutDNS := boshsettings.Network{
 				Type:    "manual",
@@ -631,7 +946,7 @@ prepend domain-name-servers 8.8.8.8, 9.9.9.9;
 			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"pkill", "dhclient"}))
 			Expect(cmdRunner.RunCommands[1:3]).To(ContainElement([]string{"resolvconf", "-d", "ethdhcp.dhclient"}))
 			Expect(cmdRunner.RunCommands[1:3]).To(ContainElement([]string{"resolvconf", "-d", "ethstatic.dhclient"}))
-			Expect(cmdRunner.RunCommands[3]).To(Equal([]string{"ifdown", "--force", "ethdhcp", "ethstatic"}))
+			Expect(cmdRunner.RunCommands[3]).To(Equal([]string{"ifdown", "--force", "ethstatic", "ethdhcp"}))
 			Expect(cmdRunner.RunCommands[4]).To(Equal([]string{"ifup", "--force", "ethdhcp", "ethstatic"}))
 		})
 
@@ -686,7 +1001,7 @@ prepend domain-name-servers 8.8.8.8, 9.9.9.9;
 			Expect(cmdRunner.RunCommands[0]).To(Equal([]string{"pkill", "dhclient"}))
 			Expect(cmdRunner.RunCommands[1:3]).To(ContainElement([]string{"resolvconf", "-d", "ethdhcp.dhclient"}))
 			Expect(cmdRunner.RunCommands[1:3]).To(ContainElement([]string{"resolvconf", "-d", "ethstatic.dhclient"}))
-			Expect(cmdRunner.RunCommands[3]).To(Equal([]string{"ifdown", "--force", "ethdhcp", "ethstatic"}))
+			Expect(cmdRunner.RunCommands[3]).To(Equal([]string{"ifdown", "--force", "ethstatic", "ethdhcp"}))
 			Expect(cmdRunner.RunCommands[4]).To(Equal([]string{"ifup", "--force", "ethdhcp", "ethstatic"}))
 		})
 
@@ -870,6 +1185,118 @@ iface ethstatic inet static
 		})
 	})
 
+	Describe("PlanNetworking", func() {
+		var (
+			staticNetwork boshsettings.Network
+		)
+
+		BeforeEach(func() {
+			staticNetwork = boshsettings.Network{
+				Type:    "manual",
+				IP:      "1.2.3.4",
+				Netmask: "255.255.255.0",
+				Gateway: "3.4.5.6",
+				Mac:     "fake-static-mac-address",
+				DNS:     []string{"8.8.8.8"},
+				Default: []string{"dns", "gateway"},
+			}
+		})
+
+		It("returns rendered files and interfaces to restart without changing anything", func() {
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": staticNetwork})
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(BeEmpty())
+			Expect(plan.RestartedInterfaces).To(Equal([]string{"ethstatic"}))
+
+			Expect(plan.Files).To(HaveLen(1))
+			Expect(plan.Files[0].Path).To(Equal("/etc/network/interfaces"))
+			Expect(plan.Files[0].Changed).To(BeTrue())
+			Expect(plan.Files[0].Content).To(ContainSubstring("address 1.2.3.4"))
+			Expect(plan.Files[0].Diff).To(ContainSubstring("+    address 1.2.3.4\n"))
+
+			Expect(fs.FileExists("/etc/network/interfaces")).To(BeFalse())
+			Expect(cmdRunner.RunCommands).To(BeEmpty())
+		})
+
+		It("returns diff against configuration on disk", func() {
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": staticNetwork})
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			oldContent := strings.Replace(plan.Files[0].Content, "1.2.3.4", "1.2.3.5", -1)
+			err = fs.WriteFileString("/etc/network/interfaces", oldContent)
+			Expect(err).ToNot(HaveOccurred())
+
+			plan, err = netManager.PlanNetworking(boshsettings.Networks{"static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Files[0].Changed).To(BeTrue())
+			Expect(plan.Files[0].Diff).To(ContainSubstring("-    address 1.2.3.5\n+    address 1.2.3.4\n"))
+			Expect(plan.Files[0].Diff).To(ContainSubstring(" auto lo\n"))
+			Expect(plan.RestartedInterfaces).To(Equal([]string{"ethstatic"}))
+
+			contents, err := fs.ReadFileString("/etc/network/interfaces")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(oldContent))
+		})
+
+		It("does not restart interfaces when configuration on disk is up to date", func() {
+			stubInterfaces(map[string]boshsettings.Network{"ethstatic": staticNetwork})
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/etc/network/interfaces", plan.Files[0].Content)
+			Expect(err).ToNot(HaveOccurred())
+
+			plan, err = netManager.PlanNetworking(boshsettings.Networks{"static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Files[0].Changed).To(BeFalse())
+			Expect(plan.Files[0].Diff).To(BeEmpty())
+			Expect(plan.RestartedInterfaces).To(BeEmpty())
+		})
+
+		It("reports interfaces missing for network MAC addresses", func() {
+			stubInterfaces(map[string]boshsettings.Network{"eth0": {Mac: "fake-other-mac-address"}})
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"static-network": staticNetwork})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(HaveLen(1))
+			Expect(plan.Errors[0]).To(ContainSubstring("with MAC address 'fake-static-mac-address'"))
+			Expect(plan.Files).To(BeEmpty())
+		})
+
+		It("reports overlapping subnets of different interfaces", func() {
+			secondNetwork := boshsettings.Network{
+				Type:    "manual",
+				IP:      "1.2.3.5",
+				Netmask: "255.255.0.0",
+				Mac:     "fake-second-mac-address",
+			}
+			stubInterfaces(map[string]boshsettings.Network{
+				"ethstatic": staticNetwork,
+				"ethsecond": secondNetwork,
+			})
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{
+				"static-network": staticNetwork,
+				"second-network": secondNetwork,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Errors).To(HaveLen(1))
+			Expect(plan.Errors[0]).To(ContainSubstring("overlaps subnet"))
+			Expect(plan.Errors[0]).To(ContainSubstring("1.2.0.0/16"))
+			Expect(plan.Errors[0]).To(ContainSubstring("1.2.3.0/24"))
+		})
+	})
+
 	Describe("GetConfiguredNetworkInterfaces", func() {
 		Context("when there are network devices", func() {
 			BeforeEach(func() {
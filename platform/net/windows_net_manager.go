@@ -3,11 +3,13 @@ package net
 import (
 	"fmt"
 	gonet "net"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pivotal-golang/clock"
 
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -46,6 +48,7 @@ type WindowsNetManager struct {
 	runner                        boshsys.CmdRunner
 	interfaceConfigurationCreator InterfaceConfigurationCreator
 	macAddressDetector            MACAddressDetector
+	interfaceAddressesValidator   boship.InterfaceAddressesValidator
 	logTag                        string
 	logger                        boshlog.Logger
 	clock                         clock.Clock
@@ -55,6 +58,7 @@ func NewWindowsNetManager(
 	runner boshsys.CmdRunner,
 	interfaceConfigurationCreator InterfaceConfigurationCreator,
 	macAddressDetector MACAddressDetector,
+	interfaceAddressesValidator boship.InterfaceAddressesValidator,
 	logger boshlog.Logger,
 	clock clock.Clock,
 ) Manager {
@@ -62,6 +66,7 @@ func NewWindowsNetManager(
 		runner: runner,
 		interfaceConfigurationCreator: interfaceConfigurationCreator,
 		macAddressDetector:            macAddressDetector,
+		interfaceAddressesValidator:   interfaceAddressesValidator,
 		logTag:                        "WindowsNetManager",
 		logger:                        logger,
 		clock:                         clock,
@@ -88,8 +93,30 @@ foreach($interface in $interfaces) {
 $connectionName=(get-wmiobject win32_networkadapter | where-object {$_.MacAddress -eq '%s'}).netconnectionid
 netsh interface ip set address $connectionName static %s %s %s
 `
+
+	SetMTUTemplate = `
+netsh interface ipv4 set subinterface "%[1]s" mtu=%[2]d store=persistent
+netsh interface ipv6 set subinterface "%[1]s" mtu=%[2]d store=persistent
+`
+
+	// Replaces route of an interface; next hop is '0.0.0.0' or '::' for directly connected destinations
+	SetRouteTemplate = `
+Remove-NetRoute -DestinationPrefix "%[1]s" -InterfaceAlias "%[2]s" -Confirm:$false -ErrorAction SilentlyContinue
+New-NetRoute -DestinationPrefix "%[1]s" -InterfaceAlias "%[2]s" -NextHop "%[3]s" -RouteMetric %[4]d
+`
+
+	// Enables or disables offload feature of a network adapter, e.g. Disable-NetAdapterLso -Name "Ethernet"
+	SetOffloadTemplate = `%s-NetAdapter%s -Name "%s"`
 )
 
+// windowsOffloadFeatures maps ethtool names of offload features
+// to names used by NetAdapter cmdlets
+var windowsOffloadFeatures = map[string]string{
+	"tso": "Lso",
+	"gro": "Rsc",
+	"lro": "Rsc",
+}
+
 func (net WindowsNetManager) GetConfiguredNetworkInterfaces() ([]string, error) {
 	panic("Not implemented")
 }
@@ -113,12 +140,43 @@ func (net WindowsNetManager) ComputeNetworkConfig(networks boshsettings.Networks
 		return nil, nil, nil, err
 	}
 
+	err = net.validatePolicyRouting(staticConfigs, dhcpConfigs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	dnsNetwork, _ := nonVipNetworks.DefaultNetworkFor("dns")
 	dnsServers := dnsNetwork.DNS
 	return staticConfigs, dhcpConfigs, dnsServers, nil
 
 }
 
+// PlanNetworking reports interfaces that would be reconfigured;
+// Windows interfaces are configured with commands instead of files
+func (net WindowsNetManager) PlanNetworking(networks boshsettings.Networks) (NetworkPlan, error) {
+	plan := newNetworkPlan()
+
+	staticConfigs, dhcpConfigs, _, err := net.ComputeNetworkConfig(networks)
+	if err != nil {
+		plan.Errors = append(plan.Errors, err.Error())
+		return plan, nil
+	}
+
+	plan.Errors = append(plan.Errors, overlappingSubnetErrors(staticConfigs)...)
+
+	for _, conf := range staticConfigs {
+		plan.RestartedInterfaces = append(plan.RestartedInterfaces, conf.Name)
+	}
+
+	for _, conf := range dhcpConfigs {
+		plan.RestartedInterfaces = append(plan.RestartedInterfaces, conf.Name)
+	}
+
+	sort.Strings(plan.RestartedInterfaces)
+
+	return plan, nil
+}
+
 func (net WindowsNetManager) SetupNetworking(networks boshsettings.Networks, errCh chan error) error {
 	nonVipNetworks := boshsettings.Networks{}
 	for networkName, networkSettings := range networks {
@@ -127,7 +185,7 @@ func (net WindowsNetManager) SetupNetworking(networks boshsettings.Networks, err
 		}
 		nonVipNetworks[networkName] = networkSettings
 	}
-	staticConfigs, _, dnsServers, err := net.ComputeNetworkConfig(networks)
+	staticConfigs, dhcpConfigs, dnsServers, err := net.ComputeNetworkConfig(networks)
 	if err != nil {
 		return bosherr.WrapError(err, "Computing network configuration")
 	}
@@ -136,9 +194,28 @@ func (net WindowsNetManager) SetupNetworking(networks boshsettings.Networks, err
 		return err
 	}
 
+	err = net.setupLinkSettings(staticConfigs, dhcpConfigs)
+	if err != nil {
+		return err
+	}
+
+	err = net.setupRoutes(staticConfigs, dhcpConfigs)
+	if err != nil {
+		return err
+	}
+
 	dns := net.setupDNS(dnsServers)
 	net.clock.Sleep(5 * time.Second)
-	return dns
+	if dns != nil {
+		return dns
+	}
+
+	err = net.interfaceAddressesValidator.ValidateMTUs(interfaceMTUs(staticConfigs, dhcpConfigs))
+	if err != nil {
+		return bosherr.WrapError(err, "Validating MTU")
+	}
+
+	return nil
 }
 
 func (net WindowsNetManager) setupInterfaces(staticConfigs []StaticInterfaceConfiguration) error {
@@ -158,6 +235,87 @@ func (net WindowsNetManager) setupInterfaces(staticConfigs []StaticInterfaceConf
 	return nil
 }
 
+func (net WindowsNetManager) setupLinkSettings(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) error {
+	offloadsByInterface := map[string][]OffloadConfiguration{}
+
+	for _, conf := range staticConfigs {
+		offloadsByInterface[conf.Name] = conf.Offloads
+	}
+
+	for _, conf := range dhcpConfigs {
+		offloadsByInterface[conf.Name] = conf.Offloads
+	}
+
+	for ifaceName, mtu := range interfaceMTUs(staticConfigs, dhcpConfigs) {
+		_, _, _, err := net.runner.RunCommand("-Command", fmt.Sprintf(SetMTUTemplate, ifaceName, mtu))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Setting MTU of interface '%s'", ifaceName)
+		}
+	}
+
+	for ifaceName, offloads := range offloadsByInterface {
+		for _, offload := range offloads {
+			feature, found := windowsOffloadFeatures[offload.Feature]
+			if !found {
+				net.logger.Warn(net.logTag, "Ignoring offload feature '%s' of interface '%s' not supported on Windows", offload.Feature, ifaceName)
+				continue
+			}
+
+			action := "Disable"
+			if offload.Enabled {
+				action = "Enable"
+			}
+
+			_, _, _, err := net.runner.RunCommand("-Command", fmt.Sprintf(SetOffloadTemplate, action, feature, ifaceName))
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Setting offload feature '%s' of interface '%s'", offload.Feature, ifaceName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePolicyRouting rejects routing tables and policy rules
+// since Windows has a single routing table
+func (net WindowsNetManager) validatePolicyRouting(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) error {
+	for _, conf := range staticConfigs {
+		if len(conf.PolicyRules) > 0 {
+			return bosherr.Errorf("Policy routing of interface '%s' is not supported on Windows", conf.Name)
+		}
+	}
+
+	for ifaceName, routes := range interfaceRoutes(staticConfigs, dhcpConfigs) {
+		for _, route := range routes {
+			if route.Table != 0 {
+				return bosherr.Errorf("Route to '%s' in routing table '%d' of interface '%s' is not supported on Windows", route.Destination, route.Table, ifaceName)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (net WindowsNetManager) setupRoutes(staticConfigs []StaticInterfaceConfiguration, dhcpConfigs []DHCPInterfaceConfiguration) error {
+	for ifaceName, routes := range interfaceRoutes(staticConfigs, dhcpConfigs) {
+		for _, route := range routes {
+			nextHop := route.Gateway
+			if nextHop == "" && route.IsIPv6() {
+				nextHop = "::"
+			} else if nextHop == "" {
+				nextHop = "0.0.0.0"
+			}
+
+			_, _, _, err := net.runner.RunCommand("-Command", fmt.Sprintf(SetRouteTemplate, route.Destination, ifaceName, nextHop, route.Metric))
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Adding route to '%s' via interface '%s'", route.Destination, ifaceName)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (net WindowsNetManager) buildInterfaces(networks boshsettings.Networks) (
 	[]StaticInterfaceConfiguration,
 	[]DHCPInterfaceConfiguration,
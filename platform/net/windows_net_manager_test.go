@@ -13,6 +13,8 @@ import (
 
 	"github.com/pivotal-golang/clock/fakeclock"
 
+	boship "github.com/cloudfoundry/bosh-agent/platform/net/ip"
+	fakeip "github.com/cloudfoundry/bosh-agent/platform/net/ip/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -46,6 +48,7 @@ var _ = Describe("WindowsNetManager", func() {
 		runner                        *fakesys.FakeCmdRunner
 		netManager                    Manager
 		interfaceConfigurationCreator InterfaceConfigurationCreator
+		interfaceAddrsProvider        *fakeip.FakeInterfaceAddressesProvider
 	)
 	macAddressDetector := new(fakeMACAddressDetector)
 
@@ -67,7 +70,9 @@ var _ = Describe("WindowsNetManager", func() {
 		clock = fakeclock.NewFakeClock(time.Now())
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		interfaceConfigurationCreator = NewInterfaceConfigurationCreator(logger)
-		netManager = NewWindowsNetManager(runner, interfaceConfigurationCreator, macAddressDetector, logger, clock)
+		interfaceAddrsProvider = &fakeip.FakeInterfaceAddressesProvider{}
+		interfaceAddrsValidator := boship.NewInterfaceAddressesValidator(interfaceAddrsProvider)
+		netManager = NewWindowsNetManager(runner, interfaceConfigurationCreator, macAddressDetector, interfaceAddrsValidator, logger, clock)
 	})
 
 	setupNetworking := func(networks boshsettings.Networks) error {
@@ -131,6 +136,121 @@ var _ = Describe("WindowsNetManager", func() {
 		})
 	})
 
+	Describe("PlanNetworking", func() {
+		It("returns interfaces that would be configured without running commands", func() {
+			network := boshsettings.Network{
+				Type:    "manual",
+				IP:      "192.168.50.50",
+				Netmask: "255.255.255.0",
+				Mac:     "00:0C:29:0B:69:7A",
+			}
+			setupMACs(network)
+
+			plan, err := netManager.PlanNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Files).To(BeEmpty())
+			Expect(plan.Errors).To(BeEmpty())
+			Expect(plan.RestartedInterfaces).To(Equal([]string{"Eth_HW 0"}))
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("Setting MTU and offload features", func() {
+		var network boshsettings.Network
+
+		BeforeEach(func() {
+			network = boshsettings.Network{
+				Type:    "manual",
+				IP:      "192.168.50.50",
+				Gateway: "192.168.50.0",
+				Netmask: "255.255.255.0",
+				Mac:     "00:0C:29:0B:69:7A",
+				MTU:     9000,
+				Offload: map[string]bool{"tso": false, "lro": true, "sg": false},
+			}
+			setupMACs(network)
+		})
+
+		It("sets MTU and supported offload features of the interface and validates MTU", func() {
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"Eth_HW 0": 9000}
+
+			err := setupNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(ContainElement([]string{"-Command", fmt.Sprintf(SetMTUTemplate, "Eth_HW 0", 9000)}))
+			Expect(runner.RunCommands).To(ContainElement([]string{"-Command", `Enable-NetAdapterRsc -Name "Eth_HW 0"`}))
+			Expect(runner.RunCommands).To(ContainElement([]string{"-Command", `Disable-NetAdapterLso -Name "Eth_HW 0"`}))
+			Expect(runner.RunCommands).To(HaveLen(5))
+		})
+
+		It("returns error when MTU is not applied", func() {
+			interfaceAddrsProvider.GetMTUsMTUs = map[string]int{"Eth_HW 0": 1500}
+
+			err := setupNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating MTU"))
+		})
+
+		It("returns error when setting MTU fails", func() {
+			runner.AddCmdResult(
+				"-Command "+fmt.Sprintf(SetMTUTemplate, "Eth_HW 0", 9000),
+				fakesys.FakeCmdResult{Error: errors.New("fake-err")},
+			)
+
+			err := setupNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Setting MTU of interface 'Eth_HW 0': fake-err"))
+		})
+	})
+
+	Describe("Setting routes", func() {
+		var network boshsettings.Network
+
+		BeforeEach(func() {
+			network = boshsettings.Network{
+				Type:    "manual",
+				IP:      "192.168.50.50",
+				Gateway: "192.168.50.1",
+				Netmask: "255.255.255.0",
+				Mac:     "00:0C:29:0B:69:7A",
+				Routes: []boshsettings.NetworkRoute{
+					{Destination: "10.10.0.0/16", Gateway: "192.168.50.2", Metric: 10},
+					{Destination: "172.16.0.0/12"},
+				},
+			}
+			setupMACs(network)
+		})
+
+		It("adds routes of the interface", func() {
+			err := setupNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(ContainElement([]string{"-Command", fmt.Sprintf(SetRouteTemplate, "10.10.0.0/16", "Eth_HW 0", "192.168.50.2", 10)}))
+			Expect(runner.RunCommands).To(ContainElement([]string{"-Command", fmt.Sprintf(SetRouteTemplate, "172.16.0.0/12", "Eth_HW 0", "0.0.0.0", 0)}))
+		})
+
+		It("returns error when adding route fails", func() {
+			runner.AddCmdResult(
+				"-Command "+fmt.Sprintf(SetRouteTemplate, "10.10.0.0/16", "Eth_HW 0", "192.168.50.2", 10),
+				fakesys.FakeCmdResult{Error: errors.New("fake-err")},
+			)
+
+			err := setupNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Adding route to '10.10.0.0/16' via interface 'Eth_HW 0': fake-err"))
+		})
+
+		It("returns error without configuring interfaces when policy routing is requested", func() {
+			network.RoutingTable = 100
+
+			err := setupNetworking(boshsettings.Networks{"net1": network})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Policy routing of interface 'Eth_HW 0' is not supported on Windows"))
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
 	Context("when there is a network marked default for DNS", func() {
 		It("configures DNS with a single DNS server", func() {
 			network := boshsettings.Network{
@@ -4,6 +4,8 @@ import (
 	"github.com/cloudfoundry/bosh-agent/platform/cert"
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -32,9 +34,11 @@ type Platform interface {
 	SetUserPassword(user, encryptedPwd string) (err error)
 	SetupHostname(hostname string) (err error)
 	SetupNetworking(networks boshsettings.Networks) (err error)
+	PlanNetworking(networks boshsettings.Networks) (plan boshnet.NetworkPlan, err error)
+	SetupNetworkingWithRollback(networks boshsettings.Networks, reachableTargets []string) (err error)
 	SetupLogrotate(groupName, basePath, size string) (err error)
 	SetTimeWithNtpServers(servers []string) (err error)
-	SetupEphemeralDiskWithPath(devicePath string) (err error)
+	SetupEphemeralDiskWithPath(devicePath string, mountOptions []string) (err error)
 	SetupRawEphemeralDisks(devices []boshsettings.DiskSettings) (err error)
 	SetupDataDir() (err error)
 	SetupTmpDir() (err error)
@@ -49,12 +53,16 @@ type Platform interface {
 	// Disk management
 	MountPersistentDisk(diskSettings boshsettings.DiskSettings, mountPoint string) error
 	UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error)
+	GetPersistentDiskFileSystemCheck(diskSettings boshsettings.DiskSettings) (result boshdisk.FileSystemCheckResult, found bool, err error)
 	MigratePersistentDisk(fromMountPoint, toMountPoint string) (err error)
+	CancelPersistentDiskMigration() error
+	GetPersistentDiskMigrationProgress() (progress boshdisk.MigrationProgress, found bool)
 	GetEphemeralDiskPath(diskSettings boshsettings.DiskSettings) string
 	IsMountPoint(path string) (partitionPath string, result bool, err error)
 	IsPersistentDiskMounted(diskSettings boshsettings.DiskSettings) (result bool, err error)
 	IsPersistentDiskMountable(diskSettings boshsettings.DiskSettings) (bool, error)
 	AssociateDisk(name string, settings boshsettings.DiskSettings) error
+	GetDiskInfo(diskType boshdisk.DiskType, diskSettings boshsettings.DiskSettings) (boshdisk.DiskInfo, error)
 
 	GetFileContentsFromCDROM(filePath string) (contents []byte, err error)
 	GetFilesContentsFromDisk(diskPath string, fileNames []string) (contents [][]byte, err error)
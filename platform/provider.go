@@ -34,6 +34,16 @@ const (
 	SigarStatsCollectionInterval = 10 * time.Second
 )
 
+// NetworkVerificationTimeout is time given to targets to become reachable
+// after networking was set up before previous networks are restored
+const NetworkVerificationTimeout = 2 * time.Minute
+
+// DNSHealthCheckTimeout is time given to each DNS server to answer health check
+const DNSHealthCheckTimeout = 5 * time.Second
+
+// diskLookupTimeout is time given to disks that are only inspected to show up
+const diskLookupTimeout = 1 * time.Second
+
 type Provider interface {
 	Get(name string) (Platform, error)
 }
@@ -65,7 +75,34 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	// Kick of stats collection as soon as possible
 	statsCollector.StartCollecting(SigarStatsCollectionInterval, nil)
 
-	vitalsService := boshvitals.NewService(statsCollector, dirProvider)
+	// Only agent managed disks are trimmed; root disk is left to the stemcell
+	trimScheduler := boshdisk.NewTrimScheduler(
+		linuxDiskManager.GetTrimmer(),
+		linuxDiskManager.GetMountsSearcher(),
+		[]string{dirProvider.DataDir(), dirProvider.StoreDir()},
+		clock,
+		logger,
+	)
+
+	if options.Linux.DiskTrimIntervalInSeconds > 0 {
+		trimScheduler.Start(time.Duration(options.Linux.DiskTrimIntervalInSeconds) * time.Second)
+	}
+
+	dnsHealthChecker := boshnet.NewDNSHealthChecker(
+		fs,
+		runner,
+		boshnet.NewQueryDNSProber(DNSHealthCheckTimeout),
+		"/etc/resolv.conf",
+		options.Linux.LocalDNSAddress,
+		clock,
+		logger,
+	)
+
+	if options.Linux.DNSHealthCheckIntervalInSeconds > 0 {
+		dnsHealthChecker.Start(time.Duration(options.Linux.DNSHealthCheckIntervalInSeconds) * time.Second)
+	}
+
+	vitalsService := boshvitals.NewService(statsCollector, dirProvider, trimScheduler, dnsHealthChecker)
 
 	ipResolver := boship.NewResolver(boship.NetworkInterfaceToAddrsFunc)
 
@@ -76,36 +113,31 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	interfaceAddressesValidator := boship.NewInterfaceAddressesValidator(interfaceAddressesProvider)
 	dnsValidator := boshnet.NewDNSValidator(fs)
 
-	centosNetManager := boshnet.NewCentosNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, logger)
-	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, arping, logger)
+	routesSearcher := boshnet.NewRoutesSearcher(runner)
+	routesValidator := boshnet.NewRoutesValidator(routesSearcher)
+
+	centosNetManager := boshnet.NewCentosNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, routesValidator, arping, logger)
+	ubuntuNetManager := boshnet.NewUbuntuNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, routesValidator, arping, logger)
+
+	if options.Linux.NetworkManagerType == "networkd" {
+		networkdNetManager := boshnet.NewNetworkdNetManager(fs, runner, ipResolver, interfaceConfigurationCreator, interfaceAddressesValidator, dnsValidator, routesValidator, arping, logger)
+		centosNetManager = networkdNetManager
+		ubuntuNetManager = networkdNetManager
+	}
 
-	windowsNetManager := boshnet.NewWindowsNetManager(runner, interfaceConfigurationCreator, boshnet.NewMACAddressDetector(), logger, clock)
+	windowsNetManager := boshnet.NewWindowsNetManager(runner, interfaceConfigurationCreator, boshnet.NewMACAddressDetector(), interfaceAddressesValidator, logger, clock)
 
 	centosCertManager := boshcert.NewCentOSCertManager(fs, runner, 0, logger)
 	ubuntuCertManager := boshcert.NewUbuntuCertManager(fs, runner, 60, logger)
 	windowsCertManager := boshcert.NewWindowsCertManager(fs, runner, dirProvider, logger)
 
-	routesSearcher := boshnet.NewRoutesSearcher(runner)
 	defaultNetworkResolver := boshnet.NewDefaultNetworkResolver(routesSearcher, ipResolver)
 
 	monitRetryable := NewMonitRetryable(runner)
 	monitRetryStrategy := boshretry.NewAttemptRetryStrategy(10, 1*time.Second, monitRetryable, logger)
 
-	var devicePathResolver devicepathresolver.DevicePathResolver
-	switch options.Linux.DevicePathResolutionType {
-	case "virtio":
-		udev := boshudev.NewConcreteUdevDevice(runner, logger)
-		idDevicePathResolver := devicepathresolver.NewIDDevicePathResolver(500*time.Millisecond, udev, fs)
-		mappedDevicePathResolver := devicepathresolver.NewMappedDevicePathResolver(30000*time.Millisecond, fs)
-		devicePathResolver = devicepathresolver.NewVirtioDevicePathResolver(idDevicePathResolver, mappedDevicePathResolver, logger)
-	case "scsi":
-		scsiIDPathResolver := devicepathresolver.NewSCSIIDDevicePathResolver(50000*time.Millisecond, fs, logger)
-		scsiVolumeIDPathResolver := devicepathresolver.NewSCSIVolumeIDDevicePathResolver(500*time.Millisecond, fs)
-		scsiLunPathResolver := devicepathresolver.NewSCSILunDevicePathResolver(50000*time.Millisecond, fs, logger)
-		devicePathResolver = devicepathresolver.NewScsiDevicePathResolver(scsiVolumeIDPathResolver, scsiIDPathResolver, scsiLunPathResolver)
-	default:
-		devicePathResolver = devicepathresolver.NewIdentityDevicePathResolver()
-	}
+	devicePathResolver := newDevicePathResolver(options.Linux.DevicePathResolutionType, 30000*time.Millisecond, 50000*time.Millisecond, runner, fs, logger)
+	lookupPathResolver := newDevicePathResolver(options.Linux.DevicePathResolutionType, diskLookupTimeout, diskLookupTimeout, runner, fs, logger)
 
 	uuidGenerator := boshuuid.NewGenerator()
 
@@ -123,6 +155,7 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 		centosCertManager,
 		monitRetryStrategy,
 		devicePathResolver,
+		lookupPathResolver,
 		bootstrapState,
 		options.Linux,
 		logger,
@@ -144,6 +177,7 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 		ubuntuCertManager,
 		monitRetryStrategy,
 		devicePathResolver,
+		lookupPathResolver,
 		bootstrapState,
 		options.Linux,
 		logger,
@@ -173,6 +207,30 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.Provider, statsColl
 	}
 }
 
+func newDevicePathResolver(
+	resolutionType string,
+	virtioDiskWaitTimeout time.Duration,
+	scsiDiskWaitTimeout time.Duration,
+	runner boshsys.CmdRunner,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) devicepathresolver.DevicePathResolver {
+	switch resolutionType {
+	case "virtio":
+		udev := boshudev.NewConcreteUdevDevice(runner, logger)
+		idDevicePathResolver := devicepathresolver.NewIDDevicePathResolver(500*time.Millisecond, udev, fs)
+		mappedDevicePathResolver := devicepathresolver.NewMappedDevicePathResolver(virtioDiskWaitTimeout, fs)
+		return devicepathresolver.NewVirtioDevicePathResolver(idDevicePathResolver, mappedDevicePathResolver, logger)
+	case "scsi":
+		scsiIDPathResolver := devicepathresolver.NewSCSIIDDevicePathResolver(scsiDiskWaitTimeout, fs, logger)
+		scsiVolumeIDPathResolver := devicepathresolver.NewSCSIVolumeIDDevicePathResolver(500*time.Millisecond, fs)
+		scsiLunPathResolver := devicepathresolver.NewSCSILunDevicePathResolver(scsiDiskWaitTimeout, fs, logger)
+		return devicepathresolver.NewScsiDevicePathResolver(scsiVolumeIDPathResolver, scsiIDPathResolver, scsiLunPathResolver)
+	default:
+		return devicepathresolver.NewIdentityDevicePathResolver()
+	}
+}
+
 func (p provider) Get(name string) (Platform, error) {
 	plat, found := p.platforms[name]
 	if !found {
@@ -0,0 +1,42 @@
+package platform
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const settingsSubscriberLogTag = "platformSettingsSubscriber"
+
+type settingsSubscriber struct {
+	platform Platform
+	logger   boshlog.Logger
+}
+
+// NewSettingsSubscriber returns subscriber which applies changed
+// NTP servers and trusted certificates to the platform
+func NewSettingsSubscriber(platform Platform, logger boshlog.Logger) boshsettings.Subscriber {
+	return settingsSubscriber{platform: platform, logger: logger}
+}
+
+func (s settingsSubscriber) SettingsChanged(diff boshsettings.Diff) error {
+	if diff.NtpChanged {
+		s.logger.Info(settingsSubscriberLogTag, "Setting time with changed NTP servers")
+
+		err := s.platform.SetTimeWithNtpServers(diff.New.Ntp)
+		if err != nil {
+			return bosherr.WrapError(err, "Setting time with changed NTP servers")
+		}
+	}
+
+	if diff.TrustedCertsChanged {
+		s.logger.Info(settingsSubscriberLogTag, "Updating changed trusted certificates")
+
+		err := s.platform.GetCertManager().UpdateCertificates(diff.New.Env.Bosh.TrustedCerts)
+		if err != nil {
+			return bosherr.WrapError(err, "Updating changed trusted certificates")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package platform_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform"
+	fakecert "github.com/cloudfoundry/bosh-agent/platform/cert/fakes"
+	fakeplatform "github.com/cloudfoundry/bosh-agent/platform/fakes"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("settingsSubscriber", func() {
+	var (
+		platform    *fakeplatform.FakePlatform
+		certManager *fakecert.FakeManager
+		oldSettings boshsettings.Settings
+		newSettings boshsettings.Settings
+	)
+
+	BeforeEach(func() {
+		platform = fakeplatform.NewFakePlatform()
+		certManager = platform.GetCertManager().(*fakecert.FakeManager)
+
+		oldSettings = boshsettings.Settings{
+			Ntp: []string{"fake-old-ntp"},
+			Env: boshsettings.Env{Bosh: boshsettings.BoshEnv{TrustedCerts: "fake-old-certs"}},
+		}
+		newSettings = oldSettings
+	})
+
+	settingsChanged := func() error {
+		subscriber := NewSettingsSubscriber(platform, boshlog.NewLogger(boshlog.LevelNone))
+		return subscriber.SettingsChanged(boshsettings.NewDiff(oldSettings, newSettings))
+	}
+
+	It("sets time with changed NTP servers", func() {
+		newSettings.Ntp = []string{"fake-new-ntp"}
+
+		Expect(settingsChanged()).To(Succeed())
+		Expect(platform.SetTimeWithNtpServersServers).To(Equal([]string{"fake-new-ntp"}))
+		Expect(certManager.UpdateCertificatesCallCount()).To(Equal(0))
+	})
+
+	It("updates changed trusted certificates", func() {
+		newSettings.Env.Bosh.TrustedCerts = "fake-new-certs"
+
+		Expect(settingsChanged()).To(Succeed())
+		Expect(certManager.UpdateCertificatesCallCount()).To(Equal(1))
+		Expect(certManager.UpdateCertificatesArgsForCall(0)).To(Equal("fake-new-certs"))
+		Expect(platform.SetTimeWithNtpServersServers).To(BeNil())
+	})
+
+	It("returns error when trusted certificates cannot be updated", func() {
+		newSettings.Env.Bosh.TrustedCerts = "fake-new-certs"
+		certManager.UpdateCertificatesReturns(errors.New("fake-cert-err"))
+
+		err := settingsChanged()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-cert-err"))
+	})
+})
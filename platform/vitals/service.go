@@ -2,9 +2,12 @@ package vitals
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/cloudfoundry/gosigar"
 
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -15,14 +18,25 @@ type Service interface {
 }
 
 type concreteService struct {
-	statsCollector boshstats.Collector
-	dirProvider    boshdirs.Provider
+	statsCollector   boshstats.Collector
+	dirProvider      boshdirs.Provider
+	trimScheduler    boshdisk.TrimScheduler
+	dnsHealthChecker boshnet.DNSHealthChecker
 }
 
-func NewService(statsCollector boshstats.Collector, dirProvider boshdirs.Provider) Service {
+// NewService accepts nil trimScheduler on platforms that do not trim disks
+// and nil dnsHealthChecker on platforms that do not check DNS servers
+func NewService(
+	statsCollector boshstats.Collector,
+	dirProvider boshdirs.Provider,
+	trimScheduler boshdisk.TrimScheduler,
+	dnsHealthChecker boshnet.DNSHealthChecker,
+) Service {
 	return concreteService{
-		statsCollector: statsCollector,
-		dirProvider:    dirProvider,
+		statsCollector:   statsCollector,
+		dirProvider:      dirProvider,
+		trimScheduler:    trimScheduler,
+		dnsHealthChecker: dnsHealthChecker,
 	}
 }
 
@@ -75,10 +89,30 @@ func (s concreteService) Get() (vitals Vitals, err error) {
 		Mem:  createMemVitals(memStats),
 		Swap: createMemVitals(swapStats),
 		Disk: diskStats,
+		DNS:  s.getDNSVitals(),
 	}
 	return
 }
 
+func (s concreteService) getDNSVitals() []DNSVitals {
+	if s.dnsHealthChecker == nil {
+		return nil
+	}
+
+	var dnsVitals []DNSVitals
+
+	for _, result := range s.dnsHealthChecker.LastResults() {
+		dnsVitals = append(dnsVitals, DNSVitals{
+			Server:        result.Server,
+			Healthy:       result.Healthy,
+			LastCheckedAt: result.CheckedAt.UTC().Format(time.RFC3339),
+			Error:         result.Error,
+		})
+	}
+
+	return dnsVitals
+}
+
 func (s concreteService) getDiskStats() (diskStats DiskVitals, err error) {
 	disks := map[string]string{
 		"/": "system",
@@ -87,11 +121,28 @@ func (s concreteService) getDiskStats() (diskStats DiskVitals, err error) {
 	}
 	diskStats = make(DiskVitals, len(disks))
 
+	trimResults := map[string]boshdisk.TrimResult{}
+	if s.trimScheduler != nil {
+		for _, result := range s.trimScheduler.LastResults() {
+			trimResults[result.MountPoint] = result
+		}
+	}
+
 	for path, name := range disks {
 		diskStats, err = s.addDiskStats(diskStats, path, name)
 		if err != nil {
 			return
 		}
+
+		trimResult, found := trimResults[path]
+		if diskVitals, added := diskStats[name]; added && found {
+			diskVitals.Trim = &DiskTrimVitals{
+				LastTrimmedAt: trimResult.TrimmedAt.UTC().Format(time.RFC3339),
+				TrimmedBytes:  fmt.Sprintf("%d", trimResult.TrimmedBytes),
+				Error:         trimResult.Error,
+			}
+			diskStats[name] = diskVitals
+		}
 	}
 
 	return
@@ -7,6 +7,10 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
+	fakedisk "github.com/cloudfoundry/bosh-agent/platform/disk/fakes"
+	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
+	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	fakestats "github.com/cloudfoundry/bosh-agent/platform/stats/fakes"
 	. "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -17,6 +21,16 @@ import (
 const Windows = runtime.GOOS == "windows"
 
 func buildVitalsService() (statsCollector *fakestats.FakeCollector, service Service) {
+	statsCollector, _, service = buildVitalsServiceWithTrimScheduler()
+	return
+}
+
+func buildVitalsServiceWithTrimScheduler() (statsCollector *fakestats.FakeCollector, trimScheduler *fakedisk.FakeTrimScheduler, service Service) {
+	statsCollector, trimScheduler, _, service = buildVitalsServiceWithCheckers()
+	return
+}
+
+func buildVitalsServiceWithCheckers() (statsCollector *fakestats.FakeCollector, trimScheduler *fakedisk.FakeTrimScheduler, dnsHealthChecker *fakenet.FakeDNSHealthChecker, service Service) {
 	dirProvider := boshdirs.NewProvider("/fake/base/dir")
 	statsCollector = &fakestats.FakeCollector{
 		CPULoad: boshstats.CPULoad{
@@ -54,7 +68,11 @@ func buildVitalsService() (statsCollector *fakestats.FakeCollector, service Serv
 		},
 	}
 
-	service = NewService(statsCollector, dirProvider)
+	trimScheduler = &fakedisk.FakeTrimScheduler{}
+
+	dnsHealthChecker = &fakenet.FakeDNSHealthChecker{}
+
+	service = NewService(statsCollector, dirProvider, trimScheduler, dnsHealthChecker)
 	statsCollector.StartCollecting(1*time.Millisecond, nil)
 	return
 }
@@ -104,6 +122,67 @@ var _ = Describe("Vitals service", func() {
 		boshassert.MatchesJSONMap(GinkgoT(), vitals, expectedVitals)
 	})
 
+	It("includes most recent trim results of disks", func() {
+		_, trimScheduler, service := buildVitalsServiceWithTrimScheduler()
+		trimScheduler.LastResultsResults = []boshdisk.TrimResult{
+			{
+				MountPoint:   "/fake/base/dir/data",
+				TrimmedBytes: 1024,
+				TrimmedAt:    time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC),
+			},
+			{
+				MountPoint: "/fake/base/dir/store",
+				TrimmedAt:  time.Date(2016, time.March, 1, 12, 0, 5, 0, time.UTC),
+				Error:      "fake-trim-err",
+			},
+		}
+
+		vitals, err := service.Get()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vitals.Disk["system"].Trim).To(BeNil())
+		Expect(vitals.Disk["ephemeral"].Trim).To(Equal(&DiskTrimVitals{
+			LastTrimmedAt: "2016-03-01T12:00:00Z",
+			TrimmedBytes:  "1024",
+		}))
+		Expect(vitals.Disk["persistent"].Trim).To(Equal(&DiskTrimVitals{
+			LastTrimmedAt: "2016-03-01T12:00:05Z",
+			TrimmedBytes:  "0",
+			Error:         "fake-trim-err",
+		}))
+	})
+
+	It("includes most recent DNS server health", func() {
+		_, _, dnsHealthChecker, service := buildVitalsServiceWithCheckers()
+		dnsHealthChecker.LastResultsResults = []boshnet.DNSServerHealth{
+			{
+				Server:    "10.0.0.1",
+				Healthy:   true,
+				CheckedAt: time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC),
+			},
+			{
+				Server:    "10.0.0.2",
+				CheckedAt: time.Date(2016, time.March, 1, 12, 0, 1, 0, time.UTC),
+				Error:     "fake-probe-err",
+			},
+		}
+
+		vitals, err := service.Get()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vitals.DNS).To(Equal([]DNSVitals{
+			{Server: "10.0.0.1", Healthy: true, LastCheckedAt: "2016-03-01T12:00:00Z"},
+			{Server: "10.0.0.2", LastCheckedAt: "2016-03-01T12:00:01Z", Error: "fake-probe-err"},
+		}))
+		Expect(vitals.AllDNSUnhealthy()).To(BeFalse())
+
+		dnsHealthChecker.LastResultsResults = dnsHealthChecker.LastResultsResults[1:]
+
+		vitals, err = service.Get()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vitals.AllDNSUnhealthy()).To(BeTrue())
+	})
+
 	It("getting vitals when missing disks", func() {
 
 		statsCollector, service := buildVitalsService()
@@ -3,6 +3,7 @@ package vitals
 type Vitals struct {
 	CPU  CPUVitals    `json:"cpu"`
 	Disk DiskVitals   `json:"disk,omitempty"`
+	DNS  []DNSVitals  `json:"dns,omitempty"`
 	Load []string     `json:"load,omitempty"`
 	Mem  MemoryVitals `json:"mem"`
 	Swap MemoryVitals `json:"swap"`
@@ -17,8 +18,32 @@ type CPUVitals struct {
 type DiskVitals map[string]SpecificDiskVitals
 
 type SpecificDiskVitals struct {
-	InodePercent string `json:"inode_percent,omitempty"`
-	Percent      string `json:"percent,omitempty"`
+	InodePercent string          `json:"inode_percent,omitempty"`
+	Percent      string          `json:"percent,omitempty"`
+	Trim         *DiskTrimVitals `json:"trim,omitempty"`
+}
+
+type DiskTrimVitals struct {
+	LastTrimmedAt string `json:"last_trimmed_at"`
+	TrimmedBytes  string `json:"trimmed_bytes"`
+	Error         string `json:"error,omitempty"`
+}
+
+type DNSVitals struct {
+	Server        string `json:"server"`
+	Healthy       bool   `json:"healthy"`
+	LastCheckedAt string `json:"last_checked_at"`
+	Error         string `json:"error,omitempty"`
+}
+
+// AllDNSUnhealthy returns true when DNS servers were checked and none is healthy
+func (v Vitals) AllDNSUnhealthy() bool {
+	for _, dns := range v.DNS {
+		if dns.Healthy {
+			return false
+		}
+	}
+	return len(v.DNS) > 0
 }
 
 type MemoryVitals struct {
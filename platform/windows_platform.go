@@ -3,10 +3,14 @@ package platform
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/pivotal-golang/clock"
+
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -31,6 +35,7 @@ type WindowsPlatform struct {
 	devicePathResolver     boshdpresolv.DevicePathResolver
 	certManager            boshcert.Manager
 	defaultNetworkResolver boshsettings.DefaultNetworkResolver
+	logger                 boshlog.Logger
 }
 
 func NewWindowsPlatform(
@@ -53,9 +58,10 @@ func NewWindowsPlatform(
 		dirProvider:            dirProvider,
 		netManager:             netManager,
 		devicePathResolver:     devicePathResolver,
-		vitalsService:          boshvitals.NewService(collector, dirProvider),
+		vitalsService:          boshvitals.NewService(collector, dirProvider, nil, nil),
 		certManager:            certManager,
 		defaultNetworkResolver: defaultNetworkResolver,
+		logger:                 logger,
 	}
 }
 
@@ -131,6 +137,24 @@ func (p WindowsPlatform) SetupNetworking(networks boshsettings.Networks) (err er
 	return p.netManager.SetupNetworking(networks, nil)
 }
 
+func (p WindowsPlatform) PlanNetworking(networks boshsettings.Networks) (boshnet.NetworkPlan, error) {
+	return p.netManager.PlanNetworking(networks)
+}
+
+func (p WindowsPlatform) SetupNetworkingWithRollback(networks boshsettings.Networks, reachableTargets []string) error {
+	guard := boshnet.NewNetworkGuard(
+		p.netManager,
+		p.fs,
+		boshnet.NewReachabilityChecker(p.cmdRunner),
+		filepath.Join(p.dirProvider.BoshDir(), "applied_networks.json"),
+		NetworkVerificationTimeout,
+		clock.NewClock(),
+		p.logger,
+	)
+
+	return guard.SetupNetworking(networks, reachableTargets)
+}
+
 func (p WindowsPlatform) GetConfiguredNetworkInterfaces() (interfaces []string, err error) {
 	return
 }
@@ -184,7 +208,7 @@ func (p WindowsPlatform) SetTimeWithNtpServers(servers []string) (err error) {
 	return
 }
 
-func (p WindowsPlatform) SetupEphemeralDiskWithPath(devicePath string) (err error) {
+func (p WindowsPlatform) SetupEphemeralDiskWithPath(devicePath string, mountOptions []string) (err error) {
 	return
 }
 
@@ -220,6 +244,14 @@ func (p WindowsPlatform) MountPersistentDisk(diskSettings boshsettings.DiskSetti
 	return
 }
 
+func (p WindowsPlatform) GetPersistentDiskFileSystemCheck(diskSettings boshsettings.DiskSettings) (boshdisk.FileSystemCheckResult, bool, error) {
+	return boshdisk.FileSystemCheckResult{}, false, nil
+}
+
+func (p WindowsPlatform) GetDiskInfo(diskType boshdisk.DiskType, diskSettings boshsettings.DiskSettings) (boshdisk.DiskInfo, error) {
+	return boshdisk.DiskInfo{Type: diskType, CID: diskSettings.ID}, nil
+}
+
 func (p WindowsPlatform) UnmountPersistentDisk(diskSettings boshsettings.DiskSettings) (didUnmount bool, err error) {
 	return
 }
@@ -240,6 +272,14 @@ func (p WindowsPlatform) MigratePersistentDisk(fromMountPoint, toMountPoint stri
 	return
 }
 
+func (p WindowsPlatform) CancelPersistentDiskMigration() error {
+	return nil
+}
+
+func (p WindowsPlatform) GetPersistentDiskMigrationProgress() (boshdisk.MigrationProgress, bool) {
+	return boshdisk.MigrationProgress{}, false
+}
+
 func (p WindowsPlatform) IsMountPoint(path string) (string, bool, error) {
 	return "", true, nil
 }
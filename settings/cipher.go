@@ -0,0 +1,94 @@
+package settings
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Encrypted settings start with a header so that
+// plaintext settings written by older agents can be recognized
+var encryptedSettingsHeader = []byte("bosh-agent-encrypted-settings:v1\n")
+
+// Cipher encrypts settings persisted on disk
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type aesGCMCipher struct {
+	keyProvider KeyProvider
+}
+
+// NewAESGCMCipher returns cipher which encrypts with AES-256-GCM;
+// key is obtained from key provider for every operation
+func NewAESGCMCipher(keyProvider KeyProvider) Cipher {
+	return aesGCMCipher{keyProvider: keyProvider}
+}
+
+func (c aesGCMCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating nonce")
+	}
+
+	encrypted := append([]byte{}, encryptedSettingsHeader...)
+	encrypted = append(encrypted, nonce...)
+
+	return aead.Seal(encrypted, nonce, plaintext, encryptedSettingsHeader), nil
+}
+
+func (c aesGCMCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !IsEncrypted(ciphertext) {
+		return nil, bosherr.Error("Settings are not encrypted")
+	}
+
+	aead, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext = ciphertext[len(encryptedSettingsHeader):]
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, bosherr.Error("Encrypted settings are truncated")
+	}
+
+	nonce := ciphertext[:aead.NonceSize()]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext[aead.NonceSize():], encryptedSettingsHeader)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Decrypting settings")
+	}
+
+	return plaintext, nil
+}
+
+func (c aesGCMCipher) aead() (cipher.AEAD, error) {
+	key, err := c.keyProvider.Key()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting settings encryption key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating cipher")
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted returns true for settings encrypted by a Cipher
+func IsEncrypted(contents []byte) bool {
+	return bytes.HasPrefix(contents, encryptedSettingsHeader)
+}
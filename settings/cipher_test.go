@@ -0,0 +1,69 @@
+package settings_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+)
+
+var _ = Describe("AESGCMCipher", func() {
+	var (
+		keyProvider *fakesettings.FakeKeyProvider
+		cipher      Cipher
+	)
+
+	BeforeEach(func() {
+		keyProvider = &fakesettings.FakeKeyProvider{KeyKey: []byte("0123456789abcdef0123456789abcdef")}
+		cipher = NewAESGCMCipher(keyProvider)
+	})
+
+	It("decrypts encrypted settings", func() {
+		encrypted, err := cipher.Encrypt([]byte(`{"agent_id":"fake-agent-id"}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(IsEncrypted(encrypted)).To(BeTrue())
+		Expect(string(encrypted)).ToNot(ContainSubstring("fake-agent-id"))
+
+		decrypted, err := cipher.Decrypt(encrypted)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(decrypted)).To(Equal(`{"agent_id":"fake-agent-id"}`))
+	})
+
+	It("returns error when settings are not encrypted", func() {
+		_, err := cipher.Decrypt([]byte(`{}`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Settings are not encrypted"))
+	})
+
+	It("returns error when settings were encrypted with other key", func() {
+		encrypted, err := cipher.Encrypt([]byte(`{}`))
+		Expect(err).ToNot(HaveOccurred())
+
+		keyProvider.KeyKey = []byte("fedcba9876543210fedcba9876543210")
+
+		_, err = cipher.Decrypt(encrypted)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Decrypting settings"))
+	})
+
+	It("returns error when encrypted settings were modified", func() {
+		encrypted, err := cipher.Encrypt([]byte(`{}`))
+		Expect(err).ToNot(HaveOccurred())
+
+		encrypted[len(encrypted)-1] ^= 1
+
+		_, err = cipher.Decrypt(encrypted)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns error when key cannot be obtained", func() {
+		keyProvider.KeyErr = errors.New("fake-key-err")
+
+		_, err := cipher.Encrypt([]byte(`{}`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-key-err"))
+	})
+})
@@ -0,0 +1,75 @@
+package settings
+
+import (
+	"reflect"
+)
+
+// Diff describes which groups of settings changed when settings were refreshed
+type Diff struct {
+	Old Settings
+	New Settings
+
+	AgentIDChanged      bool
+	MbusChanged         bool
+	BlobstoreChanged    bool
+	DisksChanged        bool
+	EnvChanged          bool
+	TrustedCertsChanged bool
+	NetworksChanged     bool
+	NtpChanged          bool
+	VMChanged           bool
+}
+
+func NewDiff(oldSettings, newSettings Settings) Diff {
+	return Diff{
+		Old: oldSettings,
+		New: newSettings,
+
+		AgentIDChanged:      oldSettings.AgentID != newSettings.AgentID,
+		MbusChanged:         oldSettings.Mbus != newSettings.Mbus,
+		BlobstoreChanged:    !reflect.DeepEqual(oldSettings.Blobstore, newSettings.Blobstore),
+		DisksChanged:        !reflect.DeepEqual(oldSettings.Disks, newSettings.Disks),
+		EnvChanged:          !reflect.DeepEqual(oldSettings.Env, newSettings.Env),
+		TrustedCertsChanged: oldSettings.Env.Bosh.TrustedCerts != newSettings.Env.Bosh.TrustedCerts,
+		NetworksChanged:     !reflect.DeepEqual(oldSettings.Networks, newSettings.Networks),
+		NtpChanged:          !reflect.DeepEqual(oldSettings.Ntp, newSettings.Ntp),
+		VMChanged:           oldSettings.VM != newSettings.VM,
+	}
+}
+
+func (d Diff) HasChanges() bool {
+	return len(d.ChangedGroups()) > 0
+}
+
+// ChangedGroups returns JSON names of changed settings, e.g. ntp, blobstore
+func (d Diff) ChangedGroups() []string {
+	groups := []struct {
+		name    string
+		changed bool
+	}{
+		{"agent_id", d.AgentIDChanged},
+		{"mbus", d.MbusChanged},
+		{"blobstore", d.BlobstoreChanged},
+		{"disks", d.DisksChanged},
+		{"env", d.EnvChanged},
+		{"trusted_certs", d.TrustedCertsChanged},
+		{"networks", d.NetworksChanged},
+		{"ntp", d.NtpChanged},
+		{"vm", d.VMChanged},
+	}
+
+	changedGroups := []string{}
+
+	for _, group := range groups {
+		if group.changed {
+			changedGroups = append(changedGroups, group.name)
+		}
+	}
+
+	return changedGroups
+}
+
+// Subscriber is notified after refreshed settings changed
+type Subscriber interface {
+	SettingsChanged(diff Diff) error
+}
@@ -0,0 +1,177 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudfoundry/bosh-agent/platform/disk"
+)
+
+const ephemeralDiskPath = "disks.ephemeral"
+
+// Keys of hash disk settings holding strings
+var diskSettingsStringKeys = []string{"path", "volume_id", "id", "lun", "host_device_id"}
+
+// Normalize parses legacy string and hash formats of disk settings
+// into typed disk settings; settings with values of unexpected types are rejected
+func (s Settings) Normalize() (Settings, error) {
+	errs := s.validateSchemaVersion()
+	normalized := s
+
+	if s.Disks.Ephemeral != nil {
+		diskSettings, diskErrs := parseDiskSettings(ephemeralDiskPath, s.Disks.Ephemeral)
+		errs = append(errs, diskErrs...)
+		normalized.Disks.normalizedEphemeral = &diskSettings
+	}
+
+	if len(s.Disks.Persistent) > 0 {
+		normalized.Disks.normalizedPersistent = map[string]DiskSettings{}
+
+		for _, diskID := range sortedKeys(s.Disks.Persistent) {
+			diskSettings, diskErrs := parseDiskSettings(persistentDiskPath(diskID), s.Disks.Persistent[diskID])
+			errs = append(errs, diskErrs...)
+			normalized.Disks.normalizedPersistent[diskID] = diskSettings
+		}
+	}
+
+	if len(errs) > 0 {
+		return s, errs
+	}
+
+	return normalized, nil
+}
+
+// parseDiskSettings parses disk settings given as string (path or volume id)
+// or as hash; values of unexpected types are skipped and reported
+func parseDiskSettings(path string, value interface{}) (DiskSettings, ValidationErrors) {
+	var diskSettings DiskSettings
+
+	switch typedValue := value.(type) {
+	case string:
+		// Old CPIs return disk path (string) or volume id (string) as disk settings
+		diskSettings.Path = typedValue
+		diskSettings.VolumeID = typedValue
+		return diskSettings, nil
+
+	case map[string]interface{}:
+		var errs ValidationErrors
+
+		stringFields := map[string]*string{
+			"path":           &diskSettings.Path,
+			"volume_id":      &diskSettings.VolumeID,
+			"id":             &diskSettings.DeviceID,
+			"lun":            &diskSettings.Lun,
+			"host_device_id": &diskSettings.HostDeviceID,
+		}
+
+		for _, key := range diskSettingsStringKeys {
+			fieldValue, found := typedValue[key]
+			if !found {
+				continue
+			}
+
+			stringValue, ok := fieldValue.(string)
+			if !ok {
+				errs = append(errs, typeError(path+"."+key, "a string", fieldValue))
+				continue
+			}
+
+			*stringFields[key] = stringValue
+		}
+
+		if mountOptions, found := typedValue["mount_options"]; found {
+			options, optionErrs := parseMountOptions(path+".mount_options", mountOptions)
+			errs = append(errs, optionErrs...)
+			diskSettings.MountOptions = options
+		}
+
+		if quotas, found := typedValue["quotas"]; found {
+			directoryQuotas, quotaErrs := parseDirectoryQuotas(path+".quotas", quotas)
+			errs = append(errs, quotaErrs...)
+			diskSettings.Quotas = directoryQuotas
+		}
+
+		return diskSettings, errs
+
+	default:
+		return diskSettings, ValidationErrors{typeError(path, "a string or a hash", value)}
+	}
+}
+
+func parseMountOptions(path string, value interface{}) ([]string, ValidationErrors) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, ValidationErrors{typeError(path, "an array of strings", value)}
+	}
+
+	var options []string
+	var errs ValidationErrors
+
+	for i, option := range values {
+		stringOption, ok := option.(string)
+		if !ok {
+			errs = append(errs, typeError(indexPath(path, i), "a string", option))
+			continue
+		}
+
+		options = append(options, stringOption)
+	}
+
+	return options, errs
+}
+
+func parseDirectoryQuotas(path string, value interface{}) ([]disk.DirectoryQuota, ValidationErrors) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, ValidationErrors{typeError(path, "an array of hashes", value)}
+	}
+
+	var quotas []disk.DirectoryQuota
+	var errs ValidationErrors
+
+	for i, quota := range values {
+		quotaPath := indexPath(path, i)
+
+		hashQuota, ok := quota.(map[string]interface{})
+		if !ok {
+			errs = append(errs, typeError(quotaPath, "a hash", quota))
+			continue
+		}
+
+		quotaDirPath, ok := hashQuota["path"].(string)
+		if !ok {
+			errs = append(errs, typeError(quotaPath+".path", "a string", hashQuota["path"]))
+			continue
+		}
+
+		sizeInMB, ok := hashQuota["size_in_mb"].(float64)
+		if !ok || sizeInMB < 0 || sizeInMB != float64(uint64(sizeInMB)) {
+			errs = append(errs, typeError(quotaPath+".size_in_mb", "a non-negative whole number", hashQuota["size_in_mb"]))
+			continue
+		}
+
+		quotas = append(quotas, disk.DirectoryQuota{Path: quotaDirPath, SizeInMB: uint64(sizeInMB)})
+	}
+
+	return quotas, errs
+}
+
+func persistentDiskPath(diskID string) string {
+	return fmt.Sprintf("disks.persistent[%q]", diskID)
+}
+
+func indexPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	var keys []string
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,5 @@
+package settings
+
+func HKDFSHA256(secret, salt, info []byte, size int) []byte {
+	return hkdfSHA256(secret, salt, info, size)
+}
@@ -0,0 +1,10 @@
+package fakes
+
+type FakeKeyProvider struct {
+	KeyKey []byte
+	KeyErr error
+}
+
+func (p *FakeKeyProvider) Key() ([]byte, error) {
+	return p.KeyKey, p.KeyErr
+}
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"sync/atomic"
+
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 )
 
@@ -14,6 +16,14 @@ type FakeSettingsService struct {
 	InvalidateSettingsError error
 	SettingsWereInvalidated bool
 
+	RefreshSettingsDiff      boshsettings.Diff
+	RefreshSettingsErr       error
+	refreshSettingsCallCount int32
+
+	Subscribers []boshsettings.Subscriber
+
+	SettingsInfo boshsettings.Info
+
 	Settings boshsettings.Settings
 }
 
@@ -34,3 +44,20 @@ func (service *FakeSettingsService) LoadSettings() error {
 func (service FakeSettingsService) GetSettings() boshsettings.Settings {
 	return service.Settings
 }
+
+func (service *FakeSettingsService) RefreshSettings() (boshsettings.Diff, error) {
+	atomic.AddInt32(&service.refreshSettingsCallCount, 1)
+	return service.RefreshSettingsDiff, service.RefreshSettingsErr
+}
+
+func (service *FakeSettingsService) Subscribe(subscriber boshsettings.Subscriber) {
+	service.Subscribers = append(service.Subscribers, subscriber)
+}
+
+func (service *FakeSettingsService) GetSettingsInfo() boshsettings.Info {
+	return service.SettingsInfo
+}
+
+func (service *FakeSettingsService) RefreshSettingsCallCount() int {
+	return int(atomic.LoadInt32(&service.refreshSettingsCallCount))
+}
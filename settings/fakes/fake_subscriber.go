@@ -0,0 +1,15 @@
+package fakes
+
+import (
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+type FakeSubscriber struct {
+	SettingsChangedDiffs []boshsettings.Diff
+	SettingsChangedErr   error
+}
+
+func (s *FakeSubscriber) SettingsChanged(diff boshsettings.Diff) error {
+	s.SettingsChangedDiffs = append(s.SettingsChangedDiffs, diff)
+	return s.SettingsChangedErr
+}
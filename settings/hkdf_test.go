@@ -0,0 +1,41 @@
+package settings_test
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+var _ = Describe("HKDFSHA256", func() {
+	byteRange := func(from, to int) []byte {
+		var b []byte
+		for i := from; i <= to; i++ {
+			b = append(b, byte(i))
+		}
+		return b
+	}
+
+	// Test vectors from RFC 5869 Appendix A
+	It("derives key of basic test case", func() {
+		key := HKDFSHA256(bytes.Repeat([]byte{0x0b}, 22), byteRange(0x00, 0x0c), byteRange(0xf0, 0xf9), 42)
+		Expect(hex.EncodeToString(key)).To(Equal("3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"))
+	})
+
+	It("derives key of test case with longer inputs and outputs", func() {
+		key := HKDFSHA256(byteRange(0x00, 0x4f), byteRange(0x60, 0xaf), byteRange(0xb0, 0xff), 82)
+		Expect(hex.EncodeToString(key)).To(Equal(
+			"b11e398dc80327a1c8e7f78c596a49344f012eda2d4efad8a050cc4c19afa97c" +
+				"59045a99cac7827271cb41c65e590e09da3275600c2f09b8367793a9aca3db71" +
+				"cc30c58179ec3e87c14c01d5c1f3434f1d87",
+		))
+	})
+
+	It("derives key of test case with zero-length salt and info", func() {
+		key := HKDFSHA256(bytes.Repeat([]byte{0x0b}, 22), nil, nil, 42)
+		Expect(hex.EncodeToString(key)).To(Equal("8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8"))
+	})
+})
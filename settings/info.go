@@ -0,0 +1,56 @@
+package settings
+
+import (
+	"fmt"
+	"time"
+)
+
+// SourceInfo identifies settings source which answered,
+// e.g. type CDROM with endpoint env
+type SourceInfo struct {
+	Type     string `json:"type"`
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// MetadataService which pointed to endpoint, e.g. for registry
+	MetadataService string `json:"metadata_service,omitempty"`
+}
+
+// InfoSource is implemented by sources which can describe
+// where settings returned last came from
+type InfoSource interface {
+	SourceInfo() SourceInfo
+}
+
+// Info records provenance of current settings and outcome of last load
+type Info struct {
+	Source    SourceInfo `json:"source"`
+	FetchedAt time.Time  `json:"fetched_at"`
+
+	// Settings were read from settings file persisted by previous load
+	// because fetching them from source failed
+	FromCachedFile bool `json:"from_cached_file"`
+
+	LastAttemptAt time.Time `json:"last_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// DescribeSource returns info of source;
+// sources which do not describe themselves are identified by type name
+func DescribeSource(source Source) SourceInfo {
+	if infoSource, ok := source.(InfoSource); ok {
+		return infoSource.SourceInfo()
+	}
+
+	return SourceInfo{Type: fmt.Sprintf("%T", source)}
+}
+
+func (i SourceInfo) String() string {
+	description := i.Type
+	if i.Endpoint != "" {
+		description = fmt.Sprintf("%s (%s)", description, i.Endpoint)
+	}
+	if i.MetadataService != "" {
+		description = fmt.Sprintf("%s via %s", description, i.MetadataService)
+	}
+	return description
+}
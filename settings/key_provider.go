@@ -0,0 +1,96 @@
+package settings
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	settingsKeyInfo = "bosh-agent settings encryption"
+	settingsKeySize = 32
+)
+
+// KeyProvider returns key which encrypts settings persisted on disk;
+// the same key must be returned after agent restarts
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+type fileKeyProvider struct {
+	fs   boshsys.FileSystem
+	path string
+}
+
+// NewFileKeyProvider returns provider of key derived from contents
+// of a key file supplied by the infrastructure
+func NewFileKeyProvider(fs boshsys.FileSystem, path string) KeyProvider {
+	return fileKeyProvider{fs: fs, path: path}
+}
+
+func (p fileKeyProvider) Key() ([]byte, error) {
+	secret, err := p.fs.ReadFile(p.path)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading settings key file '%s'", p.path)
+	}
+
+	return deriveSettingsKey(secret)
+}
+
+type machineKeyProvider struct {
+	fs boshsys.FileSystem
+}
+
+// NewMachineKeyProvider returns provider of key derived from
+// an identifier which is unique to the machine. Machine id is readable
+// by any user and can be regenerated, so the key only prevents settings
+// from being read on other machines; configure EncryptionKeyPath
+// with a root-only key file to protect settings on the machine itself
+func NewMachineKeyProvider(fs boshsys.FileSystem) KeyProvider {
+	return machineKeyProvider{fs: fs}
+}
+
+func (p machineKeyProvider) Key() ([]byte, error) {
+	secret, err := p.machineSecret()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading machine secret")
+	}
+
+	return deriveSettingsKey([]byte(secret))
+}
+
+func deriveSettingsKey(secret []byte) ([]byte, error) {
+	if len(strings.TrimSpace(string(secret))) == 0 {
+		return nil, bosherr.Error("Settings key secret is empty")
+	}
+
+	return hkdfSHA256(secret, nil, []byte(settingsKeyInfo), settingsKeySize), nil
+}
+
+// hkdfSHA256 implements HKDF (RFC 5869) with SHA-256;
+// empty salt is replaced with zeros as specified by RFC
+func hkdfSHA256(secret, salt, info []byte, size int) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, sha256.Size)
+	}
+
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	var key, block []byte
+
+	for counter := byte(1); len(key) < size; counter++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(block)
+		expand.Write(info)
+		expand.Write([]byte{counter})
+		block = expand.Sum(nil)
+		key = append(key, block...)
+	}
+
+	return key[:size]
+}
@@ -0,0 +1,75 @@
+// +build !windows
+
+package settings_test
+
+import (
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/settings"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("KeyProvider", func() {
+	var fs *fakesys.FakeFileSystem
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+	})
+
+	Describe("MachineKeyProvider", func() {
+		It("derives key from machine id", func() {
+			fs.WriteFileString("/etc/machine-id", "fake-machine-id\n")
+
+			key, err := NewMachineKeyProvider(fs).Key()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(HaveLen(32))
+
+			fs.WriteFileString("/etc/machine-id", "other-machine-id\n")
+
+			otherKey, err := NewMachineKeyProvider(fs).Key()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherKey).ToNot(Equal(key))
+		})
+
+		It("falls back to D-Bus machine id", func() {
+			fs.WriteFileString("/var/lib/dbus/machine-id", "fake-machine-id\n")
+
+			key, err := NewMachineKeyProvider(fs).Key()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(HaveLen(32))
+		})
+
+		It("returns error when machine id is not found", func() {
+			_, err := NewMachineKeyProvider(fs).Key()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Machine id was not found"))
+		})
+	})
+
+	Describe("FileKeyProvider", func() {
+		It("derives key from key file", func() {
+			fs.WriteFileString("/var/vcap/bosh/settings.key", "fake-key")
+
+			key, err := NewFileKeyProvider(fs, "/var/vcap/bosh/settings.key").Key()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hex.EncodeToString(key)).To(Equal("4e513fe6f6ace9c902758a09cdfe5354bc74fb43f2993bb417f1918ef1abfdc4"))
+		})
+
+		It("returns error when key file cannot be read", func() {
+			_, err := NewFileKeyProvider(fs, "/var/vcap/bosh/settings.key").Key()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading settings key file '/var/vcap/bosh/settings.key'"))
+		})
+
+		It("returns error when key file is empty", func() {
+			fs.WriteFileString("/var/vcap/bosh/settings.key", "\n")
+
+			_, err := NewFileKeyProvider(fs, "/var/vcap/bosh/settings.key").Key()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Settings key secret is empty"))
+		})
+	})
+})
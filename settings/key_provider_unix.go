@@ -0,0 +1,33 @@
+// +build !windows
+
+package settings
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Older distributions only have D-Bus machine id
+var machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// machineSecret returns machine id; it is world-readable and is regenerated
+// when it is removed, so it does not protect settings from local users
+func (p machineKeyProvider) machineSecret() (string, error) {
+	for _, path := range machineIDPaths {
+		if !p.fs.FileExists(path) {
+			continue
+		}
+
+		machineID, err := p.fs.ReadFileString(path)
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Reading '%s'", path)
+		}
+
+		if machineID = strings.TrimSpace(machineID); machineID != "" {
+			return machineID, nil
+		}
+	}
+
+	return "", bosherr.Errorf("Machine id was not found in %s", strings.Join(machineIDPaths, ", "))
+}
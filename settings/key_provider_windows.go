@@ -0,0 +1,23 @@
+package settings
+
+import (
+	"golang.org/x/sys/windows/registry"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+func (p machineKeyProvider) machineSecret() (string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Cryptography`, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening cryptography registry key")
+	}
+
+	defer key.Close()
+
+	machineGUID, _, err := key.GetStringValue("MachineGuid")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading MachineGuid")
+	}
+
+	return machineGUID, nil
+}
@@ -0,0 +1,80 @@
+package settings
+
+import (
+	"net/url"
+	"strings"
+)
+
+const redactedValue = "xxxxx"
+
+// Blobstore options with one of these words in their name hold secrets
+var secretOptionWords = []string{"password", "secret", "token", "credential", "key"}
+
+// Redacted returns copy of settings with secrets replaced
+// so that settings can be logged
+func (s Settings) Redacted() Settings {
+	redacted := s
+
+	redacted.Mbus = RedactURL(s.Mbus)
+	redacted.Blobstore.Options = redactOptions(s.Blobstore.Options)
+
+	if s.Env.Bosh.Password != "" {
+		redacted.Env.Bosh.Password = redactedValue
+	}
+
+	return redacted
+}
+
+// RedactURL replaces password of URL user info
+func RedactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.User == nil {
+		return rawURL
+	}
+
+	if _, hasPassword := parsedURL.User.Password(); !hasPassword {
+		return rawURL
+	}
+
+	parsedURL.User = url.UserPassword(parsedURL.User.Username(), redactedValue)
+
+	return parsedURL.String()
+}
+
+func redactOptions(options map[string]interface{}) map[string]interface{} {
+	if options == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(options))
+
+	for name, value := range options {
+		switch {
+		case isSecretOption(name):
+			redacted[name] = redactedValue
+		case isMap(value):
+			redacted[name] = redactOptions(value.(map[string]interface{}))
+		default:
+			redacted[name] = value
+		}
+	}
+
+	return redacted
+}
+
+func isSecretOption(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, word := range secretOptionWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isMap(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
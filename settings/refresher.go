@@ -0,0 +1,45 @@
+package settings
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/pivotal-golang/clock"
+)
+
+const refresherLogTag = "settingsRefresher"
+
+type Refresher interface {
+	// Start periodically refreshes settings so that changes
+	// are applied without restarting the agent
+	Start(interval time.Duration)
+}
+
+type refresher struct {
+	service     Service
+	timeService clock.Clock
+	logger      boshlog.Logger
+}
+
+func NewRefresher(service Service, timeService clock.Clock, logger boshlog.Logger) Refresher {
+	return refresher{
+		service:     service,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (r refresher) Start(interval time.Duration) {
+	ticker := r.timeService.NewTicker(interval)
+
+	go func() {
+		defer r.logger.HandlePanic("Settings refresher")
+
+		for range ticker.C() {
+			_, err := r.service.RefreshSettings()
+			if err != nil {
+				r.logger.Error(refresherLogTag, "Failed refreshing settings: %s", err.Error())
+			}
+		}
+	}()
+}
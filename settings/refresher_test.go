@@ -0,0 +1,49 @@
+package settings_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("refresher", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+		refresher       Refresher
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		refresher = NewRefresher(settingsService, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("refreshes settings on every interval", func() {
+		refresher.Start(time.Minute)
+
+		timeService.WaitForWatcherAndIncrement(time.Minute)
+		Eventually(settingsService.RefreshSettingsCallCount).Should(Equal(1))
+
+		timeService.Increment(time.Minute)
+		Eventually(settingsService.RefreshSettingsCallCount).Should(Equal(2))
+	})
+
+	It("keeps refreshing after refresh fails", func() {
+		settingsService.RefreshSettingsErr = errors.New("fake-refresh-err")
+		refresher.Start(time.Minute)
+
+		timeService.WaitForWatcherAndIncrement(time.Minute)
+		Eventually(settingsService.RefreshSettingsCallCount).Should(Equal(1))
+
+		timeService.Increment(time.Minute)
+		Eventually(settingsService.RefreshSettingsCallCount).Should(Equal(2))
+	})
+})
@@ -2,6 +2,11 @@ package settings
 
 import (
 	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pivotal-golang/clock"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -17,6 +22,14 @@ type Service interface {
 	PublicSSHKeyForUsername(string) (string, error)
 
 	InvalidateSettings() error
+
+	// RefreshSettings loads settings and notifies subscribers when settings changed
+	RefreshSettings() (Diff, error)
+
+	Subscribe(subscriber Subscriber)
+
+	// GetSettingsInfo returns provenance of current settings
+	GetSettingsInfo() Info
 }
 
 const settingsServiceLogTag = "settingsService"
@@ -27,7 +40,21 @@ type settingsService struct {
 	settings               Settings
 	settingsSource         Source
 	defaultNetworkResolver DefaultNetworkResolver
+	cipher                 Cipher
+	timeService            clock.Clock
 	logger                 boshlog.Logger
+
+	// Settings are refreshed while other goroutines read them;
+	// settingsLock is only held to swap settings so that readers
+	// are not blocked while settings are fetched
+	settingsLock sync.Mutex
+	info         Info
+
+	// loadLock serializes loads so that settings file has one writer
+	loadLock sync.Mutex
+
+	subscribers     []Subscriber
+	subscribersLock sync.Mutex
 }
 
 type DefaultNetworkResolver interface {
@@ -41,6 +68,8 @@ func NewService(
 	settingsPath string,
 	settingsSource Source,
 	defaultNetworkResolver DefaultNetworkResolver,
+	cipher Cipher,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) (service Service) {
 	return &settingsService{
@@ -49,6 +78,8 @@ func NewService(
 		settings:               Settings{},
 		settingsSource:         settingsSource,
 		defaultNetworkResolver: defaultNetworkResolver,
+		cipher:                 cipher,
+		timeService:            timeService,
 		logger:                 logger,
 	}
 }
@@ -58,48 +89,259 @@ func (s *settingsService) PublicSSHKeyForUsername(username string) (string, erro
 }
 
 func (s *settingsService) LoadSettings() error {
+	s.loadLock.Lock()
+	defer s.loadLock.Unlock()
+
+	return s.loadSettings()
+}
+
+func (s *settingsService) RefreshSettings() (Diff, error) {
+	s.loadLock.Lock()
+
+	oldSettings := s.GetSettings()
+
+	err := s.loadSettings()
+	if err != nil {
+		s.loadLock.Unlock()
+		return Diff{}, bosherr.WrapError(err, "Loading settings")
+	}
+
+	diff := NewDiff(oldSettings, s.GetSettings())
+
+	// Subscribers may refresh settings while handling changes
+	s.loadLock.Unlock()
+
+	if !diff.HasChanges() {
+		s.logger.Debug(settingsServiceLogTag, "Refreshed settings did not change")
+		return diff, nil
+	}
+
+	s.logger.Info(settingsServiceLogTag, "Refreshed settings changed: %s", strings.Join(diff.ChangedGroups(), ", "))
+
+	s.subscribersLock.Lock()
+	subscribers := s.subscribers
+	s.subscribersLock.Unlock()
+
+	var errs []error
+
+	for _, subscriber := range subscribers {
+		err := subscriber.SettingsChanged(diff)
+		if err != nil {
+			s.logger.Error(settingsServiceLogTag, "Failed applying changed settings: %s", err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return diff, bosherr.WrapError(bosherr.NewMultiError(errs...), "Applying changed settings")
+	}
+
+	return diff, nil
+}
+
+func (s *settingsService) Subscribe(subscriber Subscriber) {
+	s.subscribersLock.Lock()
+	defer s.subscribersLock.Unlock()
+
+	s.subscribers = append(s.subscribers, subscriber)
+}
+
+func (s *settingsService) GetSettingsInfo() Info {
+	s.settingsLock.Lock()
+	defer s.settingsLock.Unlock()
+
+	return s.info
+}
+
+// loadSettings records outcome of every attempt in settings info;
+// provenance is kept from the last successful load when attempt fails
+func (s *settingsService) loadSettings() error {
+	attemptedAt := s.timeService.Now()
+
+	settings, info, err := s.fetchSettings()
+
+	s.settingsLock.Lock()
+	defer s.settingsLock.Unlock()
+
+	s.info.LastAttemptAt = attemptedAt
+	s.info.LastError = ""
+
+	if err != nil {
+		s.info.LastError = err.Error()
+		return err
+	}
+
+	if info.FromCachedFile {
+		s.info.LastError = info.LastError
+	}
+
+	s.settings = settings
+
+	s.info.Source = info.Source
+	s.info.FetchedAt = attemptedAt
+	s.info.FromCachedFile = info.FromCachedFile
+
+	s.logger.Info(settingsServiceLogTag, "Loaded settings from %s", info.Source)
+
+	return nil
+}
+
+func (s *settingsService) fetchSettings() (Settings, Info, error) {
 	s.logger.Debug(settingsServiceLogTag, "Loading settings from fetcher")
 
 	newSettings, fetchErr := s.settingsSource.Settings()
 	if fetchErr != nil {
 		s.logger.Error(settingsServiceLogTag, "Failed loading settings via fetcher: %v", fetchErr)
 
-		existingSettingsJSON, readError := s.fs.ReadFile(s.settingsPath)
+		existingSettings, readError := s.fs.ReadFile(s.settingsPath)
 		if readError != nil {
 			s.logger.Error(settingsServiceLogTag, "Failed reading settings from file %s", readError.Error())
-			return bosherr.WrapError(fetchErr, "Invoking settings fetcher")
+			return Settings{}, Info{}, bosherr.WrapError(fetchErr, "Invoking settings fetcher")
 		}
 
 		s.logger.Debug(settingsServiceLogTag, "Successfully read settings from file")
 
-		err := json.Unmarshal(existingSettingsJSON, &s.settings)
+		existingSettingsJSON := existingSettings
+
+		if IsEncrypted(existingSettings) {
+			var err error
+
+			existingSettingsJSON, err = s.cipher.Decrypt(existingSettings)
+			if err != nil {
+				s.logger.Error(settingsServiceLogTag, "Failed decrypting settings from file %s", err.Error())
+				return Settings{}, Info{}, bosherr.WrapError(fetchErr, "Invoking settings fetcher")
+			}
+		}
+
+		var existingSettingsStruct Settings
+
+		err := json.Unmarshal(existingSettingsJSON, &existingSettingsStruct)
 		if err != nil {
 			s.logger.Error(settingsServiceLogTag, "Failed unmarshalling settings from file %s", err.Error())
-			return bosherr.WrapError(fetchErr, "Invoking settings fetcher")
+			return Settings{}, Info{}, bosherr.WrapError(fetchErr, "Invoking settings fetcher")
+		}
+
+		existingSettingsStruct, err = s.normalizeSettings(existingSettingsStruct)
+		if err != nil {
+			s.logger.Error(settingsServiceLogTag, "Failed normalizing settings from file %s", err.Error())
+			return Settings{}, Info{}, bosherr.WrapError(fetchErr, "Invoking settings fetcher")
+		}
+
+		s.logSettings(existingSettingsStruct)
+
+		s.logger.Warn(settingsServiceLogTag, "Using cached settings file %s because fetching settings failed", s.settingsPath)
+
+		// Settings written by older agents are migrated to encrypted settings
+		if !IsEncrypted(existingSettings) {
+			s.logger.Info(settingsServiceLogTag, "Encrypting plaintext settings file")
+
+			err = s.writeSettings(existingSettingsJSON)
+			if err != nil {
+				s.logger.Error(settingsServiceLogTag, "Failed encrypting plaintext settings file %s", err.Error())
+			}
 		}
 
-		return nil
+		cachedFileInfo := Info{
+			Source:         SourceInfo{Type: "File", Endpoint: s.settingsPath},
+			FromCachedFile: true,
+			LastError:      fetchErr.Error(),
+		}
+
+		return existingSettingsStruct, cachedFileInfo, nil
 	}
 
 	s.logger.Debug(settingsServiceLogTag, "Successfully received settings from fetcher")
-	s.settings = newSettings
+
+	newSettings, err := s.normalizeSettings(newSettings)
+	if err != nil {
+		return Settings{}, Info{}, bosherr.WrapError(err, "Normalizing settings")
+	}
+
+	s.logSettings(newSettings)
 
 	newSettingsJSON, err := json.Marshal(newSettings)
 	if err != nil {
-		return bosherr.WrapError(err, "Marshalling settings json")
+		return Settings{}, Info{}, bosherr.WrapError(err, "Marshalling settings json")
 	}
 
-	err = s.fs.WriteFile(s.settingsPath, newSettingsJSON)
+	err = s.writeSettings(newSettingsJSON)
 	if err != nil {
-		return bosherr.WrapError(err, "Writing setting json")
+		return Settings{}, Info{}, bosherr.WrapError(err, "Writing setting json")
 	}
 
-	return nil
+	return newSettings, Info{Source: DescribeSource(s.settingsSource)}, nil
+}
+
+// normalizeSettings rejects settings which cannot be used;
+// other problems are logged so that settings accepted by older agents still load
+func (s *settingsService) normalizeSettings(settings Settings) (Settings, error) {
+	normalizedSettings, err := settings.Normalize()
+	if err != nil {
+		return Settings{}, err
+	}
+
+	err = settings.Validate()
+	if err != nil {
+		s.logger.Warn(settingsServiceLogTag, "Settings have problems: %s", err.Error())
+	}
+
+	return normalizedSettings, nil
+}
+
+// writeSettings persists encrypted settings; when encryption key is not
+// available (e.g. machine has no machine id) settings are persisted
+// unencrypted so that agent still starts. File is replaced atomically
+// so that plaintext is never partially overwritten
+func (s *settingsService) writeSettings(settingsJSON []byte) error {
+	settingsContents, err := s.cipher.Encrypt(settingsJSON)
+	if err != nil {
+		s.logger.Warn(settingsServiceLogTag, "Persisting settings unencrypted since they could not be encrypted: %s", err.Error())
+		settingsContents = settingsJSON
+	}
+
+	tmpPath := s.settingsPath + ".tmp"
+
+	err = s.fs.WriteFile(tmpPath, settingsContents)
+	if err != nil {
+		return err
+	}
+
+	err = s.fs.Chmod(tmpPath, os.FileMode(0600))
+	if err != nil {
+		return err
+	}
+
+	return s.fs.Rename(tmpPath, s.settingsPath)
+}
+
+func (s *settingsService) logSettings(settings Settings) {
+	redactedJSON, err := json.Marshal(settings.Redacted())
+	if err != nil {
+		return
+	}
+
+	s.logger.Debug(settingsServiceLogTag, "Loaded settings %s", redactedJSON)
 }
 
 // GetSettings returns setting even if it fails to resolve IPs for dynamic networks.
+// Networks are resolved on a copy of settings without holding settings lock.
 func (s *settingsService) GetSettings() Settings {
-	for networkName, network := range s.settings.Networks {
+	s.settingsLock.Lock()
+	settings := s.settings
+	s.settingsLock.Unlock()
+
+	if settings.Networks == nil {
+		return settings
+	}
+
+	networks := Networks{}
+	for networkName, network := range settings.Networks {
+		networks[networkName] = network
+	}
+
+	settings.Networks = networks
+
+	for networkName, network := range settings.Networks {
 		if !network.IsDHCP() {
 			continue
 		}
@@ -109,10 +351,10 @@ func (s *settingsService) GetSettings() Settings {
 			break
 		}
 
-		s.settings.Networks[networkName] = resolvedNetwork
+		settings.Networks[networkName] = resolvedNetwork
 	}
 
-	return s.settings
+	return settings
 }
 
 func (s *settingsService) InvalidateSettings() error {
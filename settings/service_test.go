@@ -3,6 +3,8 @@ package settings_test
 import (
 	"encoding/json"
 	"errors"
+	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -10,8 +12,10 @@ import (
 	"github.com/cloudfoundry/bosh-agent/infrastructure/fakes"
 	fakenet "github.com/cloudfoundry/bosh-agent/platform/net/fakes"
 	. "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 func init() {
@@ -20,20 +24,111 @@ func init() {
 			fs                         *fakesys.FakeFileSystem
 			fakeDefaultNetworkResolver *fakenet.FakeDefaultNetworkResolver
 			fakeSettingsSource         *fakes.FakeSettingsSource
+			keyProvider                *fakesettings.FakeKeyProvider
+			cipher                     Cipher
+			timeService                *fakeclock.FakeClock
 		)
 
 		BeforeEach(func() {
 			fs = fakesys.NewFakeFileSystem()
 			fakeDefaultNetworkResolver = &fakenet.FakeDefaultNetworkResolver{}
 			fakeSettingsSource = &fakes.FakeSettingsSource{}
+			keyProvider = &fakesettings.FakeKeyProvider{KeyKey: []byte("0123456789abcdef0123456789abcdef")}
+			cipher = NewAESGCMCipher(keyProvider)
+			timeService = fakeclock.NewFakeClock(time.Date(2016, time.March, 1, 10, 0, 0, 0, time.UTC))
 		})
 
 		buildService := func() (Service, *fakesys.FakeFileSystem) {
 			logger := boshlog.NewLogger(boshlog.LevelNone)
-			service := NewService(fs, "/setting/path.json", fakeSettingsSource, fakeDefaultNetworkResolver, logger)
+			service := NewService(fs, "/setting/path.json", fakeSettingsSource, fakeDefaultNetworkResolver, cipher, timeService, logger)
 			return service, fs
 		}
 
+		readSettingsFile := func() []byte {
+			fileContent, err := fs.ReadFile("/setting/path.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IsEncrypted(fileContent)).To(BeTrue())
+
+			settingsJSON, err := cipher.Decrypt(fileContent)
+			Expect(err).NotTo(HaveOccurred())
+
+			return settingsJSON
+		}
+
+		Describe("GetSettingsInfo", func() {
+			var (
+				service Service
+			)
+
+			BeforeEach(func() {
+				service, fs = buildService()
+			})
+
+			It("records source which answered and fetch time", func() {
+				fakeSettingsSource.SettingsValue = Settings{AgentID: "fake-agent-id"}
+
+				err := service.LoadSettings()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(service.GetSettingsInfo()).To(Equal(Info{
+					Source:        SourceInfo{Type: "*fakes.FakeSettingsSource"},
+					FetchedAt:     timeService.Now(),
+					LastAttemptAt: timeService.Now(),
+				}))
+			})
+
+			It("records that cached settings file was used and why", func() {
+				fs.WriteFile("/setting/path.json", []byte(`{"agent_id":"fake-agent-id"}`))
+				fakeSettingsSource.SettingsErr = errors.New("fake-fetch-error")
+
+				err := service.LoadSettings()
+				Expect(err).NotTo(HaveOccurred())
+
+				info := service.GetSettingsInfo()
+				Expect(info.Source).To(Equal(SourceInfo{Type: "File", Endpoint: "/setting/path.json"}))
+				Expect(info.FromCachedFile).To(BeTrue())
+				Expect(info.FetchedAt).To(Equal(timeService.Now()))
+				Expect(info.LastError).To(ContainSubstring("fake-fetch-error"))
+			})
+
+			It("keeps provenance of current settings when loading fails", func() {
+				fakeSettingsSource.SettingsValue = Settings{AgentID: "fake-agent-id"}
+
+				err := service.LoadSettings()
+				Expect(err).NotTo(HaveOccurred())
+
+				fetchedAt := timeService.Now()
+				timeService.Increment(time.Minute)
+
+				fs.RemoveAll("/setting/path.json")
+				fakeSettingsSource.SettingsErr = errors.New("fake-fetch-error")
+
+				err = service.LoadSettings()
+				Expect(err).To(HaveOccurred())
+
+				info := service.GetSettingsInfo()
+				Expect(info.Source).To(Equal(SourceInfo{Type: "*fakes.FakeSettingsSource"}))
+				Expect(info.FetchedAt).To(Equal(fetchedAt))
+				Expect(info.FromCachedFile).To(BeFalse())
+				Expect(info.LastAttemptAt).To(Equal(timeService.Now()))
+				Expect(info.LastError).To(ContainSubstring("fake-fetch-error"))
+			})
+
+			It("clears last error when loading succeeds", func() {
+				fakeSettingsSource.SettingsErr = errors.New("fake-fetch-error")
+
+				err := service.LoadSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(service.GetSettingsInfo().LastError).To(ContainSubstring("fake-fetch-error"))
+
+				fakeSettingsSource.SettingsErr = nil
+
+				err = service.LoadSettings()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(service.GetSettingsInfo().LastError).To(BeEmpty())
+			})
+		})
+
 		Describe("LoadSettings", func() {
 			var (
 				fetchedSettings Settings
@@ -70,7 +165,23 @@ func init() {
 						Expect(service.GetSettings().AgentID).To(Equal("some-new-agent-id"))
 					})
 
-					It("persists settings to the settings file", func() {
+					It("persists encrypted settings to the settings file", func() {
+						err := service.LoadSettings()
+						Expect(err).NotTo(HaveOccurred())
+
+						json, err := json.Marshal(fetchedSettings)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(readSettingsFile()).To(Equal(json))
+
+						stat, err := fs.Stat("/setting/path.json")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0600)))
+					})
+
+					It("persists plaintext settings when settings cannot be encrypted", func() {
+						keyProvider.KeyErr = errors.New("fake-key-err")
+
 						err := service.LoadSettings()
 						Expect(err).NotTo(HaveOccurred())
 
@@ -80,6 +191,10 @@ func init() {
 						fileContent, err := fs.ReadFile("/setting/path.json")
 						Expect(err).NotTo(HaveOccurred())
 						Expect(fileContent).To(Equal(json))
+
+						stat, err := fs.Stat("/setting/path.json")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0600)))
 					})
 
 					It("returns any error from writing to the setting file", func() {
@@ -90,6 +205,58 @@ func init() {
 						Expect(err.Error()).To(ContainSubstring("fs-write-file-error"))
 					})
 				})
+
+				Context("when settings have malformed disk settings", func() {
+					BeforeEach(func() {
+						fetchedSettings.Disks = Disks{
+							Persistent: map[string]interface{}{
+								"fake-disk-id": map[string]interface{}{"mount_options": "noatime"},
+							},
+						}
+					})
+
+					It("returns error without updating or persisting settings", func() {
+						err := service.LoadSettings()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring(`disks.persistent["fake-disk-id"].mount_options`))
+
+						Expect(service.GetSettings().AgentID).To(BeEmpty())
+						Expect(fs.FileExists("/setting/path.json")).To(BeFalse())
+					})
+				})
+			})
+
+			Context("when fetched settings have disk settings of unexpected types", func() {
+				BeforeEach(func() {
+					fetchedSettings = Settings{
+						AgentID: "some-new-agent-id",
+						Disks:   Disks{Ephemeral: float64(1)},
+					}
+				})
+
+				It("returns error and does not persist settings", func() {
+					err := service.LoadSettings()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("disks.ephemeral: must be a string or a hash, got number 1"))
+
+					Expect(fs.FileExists("/setting/path.json")).To(BeFalse())
+				})
+			})
+
+			Context("when fetched settings have disk settings", func() {
+				BeforeEach(func() {
+					fetchedSettings = Settings{
+						AgentID: "some-new-agent-id",
+						Disks:   Disks{Ephemeral: map[string]interface{}{"path": "/dev/sdb"}},
+					}
+				})
+
+				It("normalizes disk settings", func() {
+					err := service.LoadSettings()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(service.GetSettings().EphemeralDiskSettings()).To(Equal(DiskSettings{Path: "/dev/sdb"}))
+					Expect(readSettingsFile()).To(MatchJSON(`{"agent_id":"some-new-agent-id","blobstore":{"provider":"","options":null},"disks":{"system":"","ephemeral":{"path":"/dev/sdb"},"persistent":null,"raw_ephemeral":null},"env":{"bosh":{"password":"","keep_root_password":false,"remove_dev_tools":false,"authorized_keys":null},"persistent_disk_fs":"","persistent_disk_mount_options":null,"persistent_disk_quotas":null,"ephemeral_disk_mount_options":null},"networks":null,"ntp":null,"mbus":"","vm":{"name":""}}`))
+				})
 			})
 
 			Context("when settings fetcher fails fetching settings", func() {
@@ -131,6 +298,66 @@ func init() {
 					})
 				})
 
+				Context("when an encrypted settings file exists", func() {
+					BeforeEach(func() {
+						encrypted, err := cipher.Encrypt([]byte(`{"agent_id":"some-agent-id"}`))
+						Expect(err).NotTo(HaveOccurred())
+						fs.WriteFile("/setting/path.json", encrypted)
+					})
+
+					It("returns settings from the decrypted settings file", func() {
+						err := service.LoadSettings()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(service.GetSettings()).To(Equal(Settings{AgentID: "some-agent-id"}))
+					})
+
+					It("returns any error from the fetcher when settings cannot be decrypted", func() {
+						keyProvider.KeyKey = []byte("fedcba9876543210fedcba9876543210")
+
+						err := service.LoadSettings()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-fetch-error"))
+
+						Expect(service.GetSettings()).To(Equal(Settings{}))
+					})
+				})
+
+				Context("when settings file has disk settings of unexpected types", func() {
+					BeforeEach(func() {
+						fs.WriteFile("/setting/path.json", []byte(`{"agent_id":"some-agent-id","disks":{"persistent":{"fake-disk":5}}}`))
+					})
+
+					It("returns any error from the fetcher", func() {
+						err := service.LoadSettings()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-fetch-error"))
+
+						Expect(service.GetSettings()).To(Equal(Settings{}))
+					})
+				})
+
+				Context("when a plaintext settings file exists", func() {
+					BeforeEach(func() {
+						fs.WriteFile("/setting/path.json", []byte(`{"agent_id":"some-agent-id"}`))
+					})
+
+					It("encrypts the settings file", func() {
+						err := service.LoadSettings()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(service.GetSettings()).To(Equal(Settings{AgentID: "some-agent-id"}))
+
+						Expect(readSettingsFile()).To(MatchJSON(`{"agent_id":"some-agent-id"}`))
+					})
+
+					It("returns settings when settings file cannot be encrypted", func() {
+						fs.WriteFileError = errors.New("fake-write-err")
+
+						err := service.LoadSettings()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(service.GetSettings()).To(Equal(Settings{AgentID: "some-agent-id"}))
+					})
+				})
+
 				Context("when non-unmarshallable settings file exists", func() {
 					It("returns any error from the fetcher", func() {
 						fs.WriteFile("/setting/path.json", []byte(`$%^&*(`))
@@ -155,6 +382,96 @@ func init() {
 			})
 		})
 
+		Describe("RefreshSettings", func() {
+			var (
+				service     Service
+				subscriber1 *fakesettings.FakeSubscriber
+				subscriber2 *fakesettings.FakeSubscriber
+			)
+
+			BeforeEach(func() {
+				fakeSettingsSource.SettingsValue = Settings{AgentID: "fake-agent-id", Ntp: []string{"fake-old-ntp"}}
+				service, fs = buildService()
+
+				err := service.LoadSettings()
+				Expect(err).NotTo(HaveOccurred())
+
+				subscriber1 = &fakesettings.FakeSubscriber{}
+				subscriber2 = &fakesettings.FakeSubscriber{}
+				service.Subscribe(subscriber1)
+				service.Subscribe(subscriber2)
+			})
+
+			It("notifies subscribers with diff of changed settings", func() {
+				fakeSettingsSource.SettingsValue = Settings{AgentID: "fake-agent-id", Ntp: []string{"fake-new-ntp"}}
+
+				diff, err := service.RefreshSettings()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.ChangedGroups()).To(Equal([]string{"ntp"}))
+				Expect(diff.Old.Ntp).To(Equal([]string{"fake-old-ntp"}))
+				Expect(diff.New.Ntp).To(Equal([]string{"fake-new-ntp"}))
+
+				Expect(subscriber1.SettingsChangedDiffs).To(Equal([]Diff{diff}))
+				Expect(subscriber2.SettingsChangedDiffs).To(Equal([]Diff{diff}))
+
+				Expect(service.GetSettings().Ntp).To(Equal([]string{"fake-new-ntp"}))
+			})
+
+			It("does not notify subscribers when settings did not change", func() {
+				diff, err := service.RefreshSettings()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.HasChanges()).To(BeFalse())
+
+				Expect(subscriber1.SettingsChangedDiffs).To(BeEmpty())
+			})
+
+			It("notifies every subscriber and returns their errors", func() {
+				fakeSettingsSource.SettingsValue = Settings{AgentID: "fake-agent-id", Ntp: []string{"fake-new-ntp"}}
+				subscriber1.SettingsChangedErr = errors.New("fake-subscriber-err")
+
+				_, err := service.RefreshSettings()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-subscriber-err"))
+
+				Expect(subscriber2.SettingsChangedDiffs).To(HaveLen(1))
+			})
+
+			It("returns current settings while refreshed settings are fetched", func() {
+				fetching := make(chan struct{})
+				fetched := make(chan struct{})
+
+				fakeSettingsSource.SettingsStub = func() (Settings, error) {
+					close(fetching)
+					<-fetched
+					return Settings{AgentID: "fake-agent-id", Ntp: []string{"fake-new-ntp"}}, nil
+				}
+
+				refreshed := make(chan error)
+				go func() {
+					_, err := service.RefreshSettings()
+					refreshed <- err
+				}()
+
+				<-fetching
+				Expect(service.GetSettings().Ntp).To(Equal([]string{"fake-old-ntp"}))
+				Expect(service.GetSettingsInfo().Source).To(Equal(SourceInfo{Type: "*fakes.FakeSettingsSource"}))
+
+				close(fetched)
+				Expect(<-refreshed).ToNot(HaveOccurred())
+				Expect(service.GetSettings().Ntp).To(Equal([]string{"fake-new-ntp"}))
+			})
+
+			It("returns error and keeps settings when settings cannot be loaded", func() {
+				fakeSettingsSource.SettingsValue = Settings{AgentID: "fake-agent-id", Disks: Disks{Ephemeral: float64(1)}}
+
+				_, err := service.RefreshSettings()
+				Expect(err).To(HaveOccurred())
+
+				Expect(subscriber1.SettingsChangedDiffs).To(BeEmpty())
+				Expect(service.GetSettings().Ntp).To(Equal([]string{"fake-old-ntp"}))
+			})
+		})
+
 		Describe("InvalidateSettings", func() {
 			It("removes the settings file", func() {
 				fakeSettingsSource.SettingsValue = Settings{}
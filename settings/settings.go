@@ -2,6 +2,7 @@ package settings
 
 import (
 	"fmt"
+	gonet "net"
 
 	"github.com/cloudfoundry/bosh-agent/platform/disk"
 )
@@ -25,6 +26,9 @@ const (
 )
 
 type Settings struct {
+	// Version of settings format; settings without version use the first version
+	SchemaVersion int `json:"schema_version,omitempty"`
+
 	AgentID   string    `json:"agent_id"`
 	Blobstore Blobstore `json:"blobstore"`
 	Disks     Disks     `json:"disks"`
@@ -71,6 +75,10 @@ type Disks struct {
 	Persistent map[string]interface{} `json:"persistent"`
 
 	RawEphemeral []DiskSettings `json:"raw_ephemeral"`
+
+	// Ephemeral and persistent disk settings parsed by Normalize
+	normalizedEphemeral  *DiskSettings
+	normalizedPersistent map[string]DiskSettings
 }
 
 type DiskSettings struct {
@@ -81,6 +89,8 @@ type DiskSettings struct {
 	HostDeviceID   string
 	Path           string
 	FileSystemType disk.FileSystemType
+	MountOptions   []string
+	Quotas         []disk.DirectoryQuota
 }
 
 type VM struct {
@@ -88,72 +98,56 @@ type VM struct {
 }
 
 func (s Settings) PersistentDiskSettings(diskID string) (DiskSettings, bool) {
-	diskSettings := DiskSettings{}
-
-	for key, settings := range s.Disks.Persistent {
-		if key == diskID {
-			diskSettings.ID = diskID
-
-			if hashSettings, ok := settings.(map[string]interface{}); ok {
-				if path, ok := hashSettings["path"]; ok {
-					diskSettings.Path = path.(string)
-				}
-				if volumeID, ok := hashSettings["volume_id"]; ok {
-					diskSettings.VolumeID = volumeID.(string)
-				}
-				if deviceID, ok := hashSettings["id"]; ok {
-					diskSettings.DeviceID = deviceID.(string)
-				}
-				if lun, ok := hashSettings["lun"]; ok {
-					diskSettings.Lun = lun.(string)
-				}
-				if hostDeviceID, ok := hashSettings["host_device_id"]; ok {
-					diskSettings.HostDeviceID = hostDeviceID.(string)
-				}
-			} else {
-				// Old CPIs return disk path (string) or volume id (string) as disk settings
-				diskSettings.Path = settings.(string)
-				diskSettings.VolumeID = settings.(string)
-			}
+	rawSettings, found := s.Disks.Persistent[diskID]
+	if !found {
+		return DiskSettings{}, false
+	}
 
-			diskSettings.FileSystemType = s.Env.PersistentDiskFS
-			return diskSettings, true
-		}
+	diskSettings, normalized := s.Disks.normalizedPersistent[diskID]
+	if !normalized {
+		// Values of unexpected types are ignored; Validate reports them
+		diskSettings, _ = parseDiskSettings(persistentDiskPath(diskID), rawSettings)
 	}
 
-	return diskSettings, false
+	diskSettings.ID = diskID
+	diskSettings.FileSystemType = s.Env.PersistentDiskFS
+	diskSettings.MountOptions = mergeMountOptions(s.Env.PersistentDiskMountOptions, diskSettings.MountOptions)
+	diskSettings.Quotas = append(append([]disk.DirectoryQuota(nil), s.Env.PersistentDiskQuotas...), diskSettings.Quotas...)
+
+	return diskSettings, true
 }
 
 func (s Settings) EphemeralDiskSettings() DiskSettings {
 	diskSettings := DiskSettings{}
 
-	if s.Disks.Ephemeral != nil {
-		if hashSettings, ok := s.Disks.Ephemeral.(map[string]interface{}); ok {
-			if path, ok := hashSettings["path"]; ok {
-				diskSettings.Path = path.(string)
-			}
-			if volumeID, ok := hashSettings["volume_id"]; ok {
-				diskSettings.VolumeID = volumeID.(string)
-			}
-			if deviceID, ok := hashSettings["id"]; ok {
-				diskSettings.DeviceID = deviceID.(string)
-			}
-			if lun, ok := hashSettings["lun"]; ok {
-				diskSettings.Lun = lun.(string)
-			}
-			if hostDeviceID, ok := hashSettings["host_device_id"]; ok {
-				diskSettings.HostDeviceID = hostDeviceID.(string)
-			}
-		} else {
-			// Old CPIs return disk path (string) or volume id (string) as disk settings
-			diskSettings.Path = s.Disks.Ephemeral.(string)
-			diskSettings.VolumeID = s.Disks.Ephemeral.(string)
-		}
+	if s.Disks.normalizedEphemeral != nil {
+		diskSettings = *s.Disks.normalizedEphemeral
+	} else if s.Disks.Ephemeral != nil {
+		diskSettings, _ = parseDiskSettings(ephemeralDiskPath, s.Disks.Ephemeral)
 	}
 
+	// Quotas are only supported for persistent disks
+	diskSettings.Quotas = nil
+	diskSettings.MountOptions = mergeMountOptions(s.Env.EphemeralDiskMountOptions, diskSettings.MountOptions)
+
 	return diskSettings
 }
 
+// mergeMountOptions appends disk specific options to options from env skipping duplicates
+func mergeMountOptions(envOptions, diskOptions []string) []string {
+	var options []string
+	seen := map[string]bool{}
+
+	for _, option := range append(append([]string(nil), envOptions...), diskOptions...) {
+		if !seen[option] {
+			seen[option] = true
+			options = append(options, option)
+		}
+	}
+
+	return options
+}
+
 func (s Settings) RawEphemeralDiskSettings() (devices []DiskSettings) {
 	return s.Disks.RawEphemeral
 }
@@ -161,6 +155,11 @@ func (s Settings) RawEphemeralDiskSettings() (devices []DiskSettings) {
 type Env struct {
 	Bosh             BoshEnv             `json:"bosh"`
 	PersistentDiskFS disk.FileSystemType `json:"persistent_disk_fs"`
+
+	// Mount options and quotas applied in addition to the ones from disk settings
+	PersistentDiskMountOptions []string              `json:"persistent_disk_mount_options"`
+	PersistentDiskQuotas       []disk.DirectoryQuota `json:"persistent_disk_quotas"`
+	EphemeralDiskMountOptions  []string              `json:"ephemeral_disk_mount_options"`
 }
 
 func (e Env) GetPassword() string {
@@ -184,11 +183,63 @@ type BoshEnv struct {
 	KeepRootPassword bool     `json:"keep_root_password"`
 	RemoveDevTools   bool     `json:"remove_dev_tools"`
 	AuthorizedKeys   []string `json:"authorized_keys"`
+
+	// PEM encoded CA certificates trusted in addition to system certificates;
+	// applied when refreshed settings change them
+	TrustedCerts string `json:"trusted_certs,omitempty"`
+
+	// PEM encoded public key which signs synced DNS records;
+	// when set, DNS records without a valid signature are rejected
+	DNSRecordsPublicKey string `json:"dns_records_public_key,omitempty"`
 }
 
 type DNSRecords struct {
 	Version uint32      `json:"Version"`
 	Records [][2]string `json:"records"`
+
+	// Delta is set when records only contain changes
+	// against records of a base version
+	Delta *DNSRecordsDelta `json:"delta,omitempty"`
+}
+
+type DNSRecordsDelta struct {
+	BaseVersion uint64      `json:"base_version"`
+	Added       [][2]string `json:"added"`
+	Removed     [][2]string `json:"removed"`
+}
+
+// ApplyDelta returns complete records of the version of delta records
+// based on records of the base version
+func (r DNSRecords) ApplyDelta(delta DNSRecords) DNSRecords {
+	removed := map[[2]string]bool{}
+	if delta.Delta != nil {
+		for _, record := range delta.Delta.Removed {
+			removed[record] = true
+		}
+	}
+
+	applied := DNSRecords{Version: delta.Version, Records: [][2]string{}}
+	present := map[[2]string]bool{}
+
+	for _, record := range r.Records {
+		if removed[record] || present[record] {
+			continue
+		}
+		present[record] = true
+		applied.Records = append(applied.Records, record)
+	}
+
+	if delta.Delta != nil {
+		for _, record := range delta.Delta.Added {
+			if present[record] {
+				continue
+			}
+			present[record] = true
+			applied.Records = append(applied.Records, record)
+		}
+	}
+
+	return applied
 }
 
 type NetworkType string
@@ -198,6 +249,27 @@ const (
 	NetworkTypeVIP     NetworkType = "vip"
 )
 
+// NetworkIPv6Mode selects how IPv6 addresses are obtained
+// when network does not specify static IPv6 addresses
+type NetworkIPv6Mode string
+
+const (
+	NetworkIPv6ModeSLAAC  NetworkIPv6Mode = "slaac"
+	NetworkIPv6ModeDHCPv6 NetworkIPv6Mode = "dhcpv6"
+)
+
+// NetworkAddress is an IPv4 or IPv6 address assigned to a network
+// in addition to its IP; Prefix is the length of the network prefix
+type NetworkAddress struct {
+	IP      string `json:"ip"`
+	Prefix  int    `json:"prefix"`
+	Gateway string `json:"gateway,omitempty"`
+}
+
+func (a NetworkAddress) IsIPv6() bool {
+	return isIPv6(a.IP)
+}
+
 type Network struct {
 	Type NetworkType `json:"type"`
 
@@ -213,6 +285,49 @@ type Network struct {
 	Mac string `json:"mac"`
 
 	Preconfigured bool `json:"preconfigured"`
+
+	Addresses []NetworkAddress `json:"addresses,omitempty"`
+	IPv6Mode  NetworkIPv6Mode  `json:"ipv6_mode,omitempty"`
+
+	Routes []NetworkRoute `json:"routes,omitempty"`
+
+	// When set, replies from network addresses leave via network gateway
+	// using given routing table instead of the default route
+	// (e.g. when VM has multiple NICs)
+	RoutingTable int `json:"routing_table,omitempty"`
+
+	// Network addresses are configured on a bond of interfaces
+	// instead of interface matching Mac
+	Bond *NetworkBond `json:"bond,omitempty"`
+
+	// 802.1Q VLAN ID; network addresses are configured on a VLAN
+	// sub-interface of interface matching Mac (or of the bond)
+	VLAN int `json:"vlan,omitempty"`
+
+	// Name of a bridge enslaving interface (or VLAN sub-interface)
+	// on which network addresses are configured
+	Bridge string `json:"bridge,omitempty"`
+
+	MTU int `json:"mtu,omitempty"`
+
+	// Offload features by ethtool name (e.g. tso, gro, lro) to enable or disable
+	Offload map[string]bool `json:"offload,omitempty"`
+}
+
+// NetworkBond aggregates interfaces into a single logical interface
+type NetworkBond struct {
+	Name    string   `json:"name"`
+	Mode    string   `json:"mode"`             // e.g. active-backup, 802.3ad
+	MIIMon  int      `json:"miimon,omitempty"` // link monitoring interval in milliseconds
+	Members []string `json:"members"`          // MAC addresses of bonded interfaces
+}
+
+// NetworkRoute is an additional route via a network; routes may use
+// different gateways so that network can have multiple gateways
+type NetworkRoute struct {
+	Destination string `json:"destination"` // CIDR, e.g. 10.0.0.0/8
+	Gateway     string `json:"gateway,omitempty"`
+	Metric      int    `json:"metric,omitempty"`
 }
 
 type Networks map[string]Network
@@ -319,10 +434,26 @@ func (n Network) IsDHCP() bool {
 	// If manual network does not have IP and Netmask it cannot be statically
 	// configured. We want to keep track how originally the network was resolved.
 	// Otherwise it will be considered as static on subsequent checks.
-	isStatic := (n.IP != "" && n.Netmask != "")
+	isStatic := (n.IP != "" && n.Netmask != "") || len(n.Addresses) > 0
 	return n.Resolved || !isStatic
 }
 
+// HasLinks returns true when network is configured on a bond,
+// VLAN sub-interface or bridge instead of a physical interface
+func (n Network) HasLinks() bool {
+	return n.Bond != nil || n.VLAN > 0 || n.Bridge != ""
+}
+
+// IsIPv6 returns true when primary IP of the network is an IPv6 address
+func (n Network) IsIPv6() bool {
+	return isIPv6(n.IP)
+}
+
+func isIPv6(ip string) bool {
+	parsedIP := gonet.ParseIP(ip)
+	return parsedIP != nil && parsedIP.To4() == nil
+}
+
 func (n Network) isDynamic() bool {
 	return n.Type == NetworkTypeDynamic
 }
@@ -90,6 +90,27 @@ var _ = Describe("Settings", func() {
 					}))
 				})
 
+				It("merges mount options and quotas from env with the ones from disk settings", func() {
+					settings.Disks.Persistent["fake-disk-id"].(map[string]interface{})["mount_options"] = []interface{}{"noatime", "discard"}
+					settings.Disks.Persistent["fake-disk-id"].(map[string]interface{})["quotas"] = []interface{}{
+						map[string]interface{}{"path": "fake-job", "size_in_mb": float64(1024)},
+					}
+
+					settingsJSON := `{"env": {
+						"persistent_disk_mount_options": ["nodev", "noatime"],
+						"persistent_disk_quotas": [{"path": "other-job", "size_in_mb": 10}]
+					}}`
+
+					err := json.Unmarshal([]byte(settingsJSON), &settings)
+					Expect(err).NotTo(HaveOccurred())
+					diskSettings, _ := settings.PersistentDiskSettings("fake-disk-id")
+					Expect(diskSettings.MountOptions).To(Equal([]string{"nodev", "noatime", "discard"}))
+					Expect(diskSettings.Quotas).To(Equal([]disk.DirectoryQuota{
+						{Path: "other-job", SizeInMB: 10},
+						{Path: "fake-job", SizeInMB: 1024},
+					}))
+				})
+
 				It("does not crash if env has a bad fs", func() {
 					settingsJSON := `{"env": {"persistent_disk_fs": "blahblah"}}`
 
@@ -295,6 +316,29 @@ var _ = Describe("Settings", func() {
 			})
 		})
 
+		Context("when mount options are provided", func() {
+			BeforeEach(func() {
+				settings = Settings{
+					Disks: Disks{
+						Ephemeral: map[string]interface{}{
+							"path":          "fake-disk-path",
+							"mount_options": []interface{}{"discard"},
+						},
+					},
+					Env: Env{
+						EphemeralDiskMountOptions: []string{"noatime"},
+					},
+				}
+			})
+
+			It("merges mount options from env with the ones from disk settings", func() {
+				Expect(settings.EphemeralDiskSettings()).To(Equal(DiskSettings{
+					Path:         "fake-disk-path",
+					MountOptions: []string{"noatime", "discard"},
+				}))
+			})
+		})
+
 		Context("when path is not provided", func() {
 			BeforeEach(func() {
 				settings = Settings{
@@ -314,6 +358,27 @@ var _ = Describe("Settings", func() {
 				}))
 			})
 		})
+		Context("when disk settings have values of unexpected types", func() {
+			It("skips values instead of panicking", func() {
+				settingsWithUnexpectedTypes := Settings{
+					Disks: Disks{
+						Ephemeral: map[string]interface{}{
+							"path": "fake-disk-path",
+							"lun":  float64(0),
+						},
+						Persistent: map[string]interface{}{
+							"fake-disk-id": float64(3),
+						},
+					},
+				}
+
+				Expect(settingsWithUnexpectedTypes.EphemeralDiskSettings()).To(Equal(DiskSettings{Path: "fake-disk-path"}))
+
+				diskSettings, found := settingsWithUnexpectedTypes.PersistentDiskSettings("fake-disk-id")
+				Expect(found).To(BeTrue())
+				Expect(diskSettings).To(Equal(DiskSettings{ID: "fake-disk-id"}))
+			})
+		})
 	})
 
 	Describe("DefaultNetworkFor", func() {
@@ -546,6 +611,16 @@ var _ = Describe("Settings", func() {
 				})
 			})
 
+			Context("when only Addresses are set", func() {
+				BeforeEach(func() {
+					network.Addresses = []NetworkAddress{{IP: "2001:db8::5", Prefix: 64}}
+				})
+
+				It("returns false", func() {
+					Expect(network.IsDHCP()).To(BeFalse())
+				})
+			})
+
 			Context("when network was previously resolved via DHCP", func() {
 				BeforeEach(func() {
 					network.Resolved = true
@@ -568,6 +643,43 @@ var _ = Describe("Settings", func() {
 				})
 			})
 		})
+
+		Describe("IsIPv6", func() {
+			It("returns true when IP is IPv6 address", func() {
+				network.IP = "2001:db8::5"
+				Expect(network.IsIPv6()).To(BeTrue())
+			})
+
+			It("returns false when IP is IPv4 address", func() {
+				network.IP = "127.0.0.5"
+				Expect(network.IsIPv6()).To(BeFalse())
+			})
+
+			It("returns false when IP is not set", func() {
+				Expect(network.IsIPv6()).To(BeFalse())
+			})
+		})
+
+		Describe("HasLinks", func() {
+			It("returns false for network on physical interface", func() {
+				Expect(network.HasLinks()).To(BeFalse())
+			})
+
+			It("returns true when network is configured on a bond", func() {
+				network.Bond = &NetworkBond{Name: "bond0", Members: []string{"aa:bb:cc:dd:ee:ff"}}
+				Expect(network.HasLinks()).To(BeTrue())
+			})
+
+			It("returns true when network is configured on a VLAN", func() {
+				network.VLAN = 100
+				Expect(network.HasLinks()).To(BeTrue())
+			})
+
+			It("returns true when network is configured on a bridge", func() {
+				network.Bridge = "br0"
+				Expect(network.HasLinks()).To(BeTrue())
+			})
+		})
 	})
 
 	Describe("Networks", func() {
@@ -662,3 +774,46 @@ var _ = Describe("Settings", func() {
 		})
 	})
 })
+
+var _ = Describe("DNSRecords", func() {
+	Describe("ApplyDelta", func() {
+		It("removes and adds records of delta keeping order of base records", func() {
+			base := DNSRecords{
+				Version: 1,
+				Records: [][2]string{
+					{"10.0.0.1", "web-0"},
+					{"10.0.0.2", "web-1"},
+					{"10.0.0.3", "db-0"},
+				},
+			}
+
+			delta := DNSRecords{
+				Version: 2,
+				Delta: &DNSRecordsDelta{
+					BaseVersion: 1,
+					Added:       [][2]string{{"10.0.0.4", "web-1"}, {"10.0.0.3", "db-0"}},
+					Removed:     [][2]string{{"10.0.0.2", "web-1"}},
+				},
+			}
+
+			Expect(base.ApplyDelta(delta)).To(Equal(DNSRecords{
+				Version: 2,
+				Records: [][2]string{
+					{"10.0.0.1", "web-0"},
+					{"10.0.0.3", "db-0"},
+					{"10.0.0.4", "web-1"},
+				},
+			}))
+		})
+
+		It("ignores removed records which are not present", func() {
+			base := DNSRecords{Version: 1, Records: [][2]string{{"10.0.0.1", "web-0"}}}
+			delta := DNSRecords{
+				Version: 2,
+				Delta:   &DNSRecordsDelta{BaseVersion: 1, Removed: [][2]string{{"10.0.0.9", "web-9"}}},
+			}
+
+			Expect(base.ApplyDelta(delta).Records).To(Equal([][2]string{{"10.0.0.1", "web-0"}}))
+		})
+	})
+})